@@ -2,41 +2,60 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
-	"github.com/wil-ckaew/gofinance-backend/util"
+	"github.com/wil-ckaew/gofinance-backend/money"
 )
 
+var errAccountAmount = errors.New("amount must be positive")
+
 type createAccountRequest struct {
-	UserID      int32     `json:"user_id" binding:"required"`
-	CategoryID  int32     `json:"category_id" binding:"required"`
-	Title       string    `json:"title" binding:"required"`
-	Type        string    `json:"type" binding:"required"`
-	Description string    `json:"description" binding:"required"`
-	Value       int32     `json:"value" binding:"required"`
-	Date        time.Time `json:"date" binding:"required"`
+	CategoryID  int32        `json:"category_id" binding:"required"`
+	WalletID    int32        `json:"wallet_id" binding:"required"`
+	Title       string       `json:"title" binding:"required"`
+	Type        string       `json:"type" binding:"required,oneof=debit credit"`
+	Description string       `json:"description" binding:"required"`
+	Amount      *money.Money `json:"amount" binding:"required"`
+	Date        time.Time    `json:"date" binding:"required"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req createAccountRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Amount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errAccountAmount))
+		return
 	}
 
 	var categoryId = req.CategoryID
 	var accountType = req.Type
 
-	category, err := server.store.GetCategory(ctx, categoryId)
+	member := ledgerMember(ctx)
+	category, err := server.store.GetCategory(ctx, db.GetCategoryParams{
+		ID:       categoryId,
+		LedgerID: member.LedgerID,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	wallet, ok := server.transactionWallet(ctx, req.WalletID, *req.Amount)
+	if !ok {
+		return
 	}
 
 	var categoryTypeIsDifferentOfAccountType = category.Type != accountType
@@ -44,21 +63,25 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, "Account type is different of Category type")
 	} else {
 		arg := db.CreateAccountParams{
-			UserID:      req.UserID,
-			CategoryID:  categoryId,
+			LedgerID:    member.LedgerID,
+			CreatedBy:   sql.NullInt32{Int32: member.UserID, Valid: true},
+			CategoryID:  sql.NullInt32{Int32: categoryId, Valid: true},
 			Title:       req.Title,
 			Type:        accountType,
 			Description: req.Description,
-			Value:       req.Value,
+			Amount:      req.Amount.Amount,
+			Currency:    req.Amount.Currency,
 			Date:        req.Date,
+			WalletID:    wallet.ID,
 		}
 
-		account, err := server.store.CreateAccount(ctx, arg)
+		account, err := server.store.CreateAccountTx(ctx, arg)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
 		}
 
-		ctx.JSON(http.StatusOK, account)
+		ctx.JSON(http.StatusOK, newAccountResponse(account))
 	}
 }
 
@@ -67,17 +90,17 @@ type getAccountRequest struct {
 }
 
 func (server *Server) getAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, err := server.store.GetAccount(ctx, db.GetAccountParams{
+		ID:       req.ID,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -87,28 +110,24 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type getAccountGraphRequest struct {
-	UserID int32  `uri:"user_id" binding:"required"`
-	Type   string `uri:"type" binding:"required"`
+	Type string `uri:"type" binding:"required"`
 }
 
 func (server *Server) getAccountGraph(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountGraphRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
 	arg := db.GetAccountsGraphParams{
-		UserID: req.UserID,
-		Type:   req.Type,
+		LedgerID: ledgerMember(ctx).LedgerID,
+		Type:     req.Type,
 	}
 
 	countGraph, err := server.store.GetAccountsGraph(ctx, arg)
@@ -121,106 +140,178 @@ func (server *Server) getAccountGraph(ctx *gin.Context) {
 }
 
 type getAccountReportsRequest struct {
-	UserID int32  `uri:"user_id" binding:"required"`
-	Type   string `uri:"type" binding:"required"`
+	Type string `uri:"type" binding:"required"`
 }
 
 func (server *Server) getAccountReports(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountReportsRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query convertReportRequest
+	err = ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
 	arg := db.GetAccountsReportsParams{
-		UserID: req.UserID,
-		Type:   req.Type,
+		LedgerID: ledgerMember(ctx).LedgerID,
+		Type:     req.Type,
+	}
+	if query.Convert {
+		server.getConvertedAccountReports(ctx, db.GetAccountsReportsByDateParams(arg))
+		return
 	}
 
-	sumReports, err := server.store.GetAccountsReports(ctx, arg)
+	sums, err := server.store.GetAccountsReports(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, sumReports)
+	rsp := make([]money.Money, len(sums))
+	for i, sum := range sums {
+		rsp[i] = money.New(sum.Amount, sum.Currency)
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type deleteAccountRequest struct {
 	ID int32 `uri:"id" binding:"required"`
 }
 
+// deleteAccount deletes a transaction; deleting either leg of a transfer
+// deletes the whole transfer.
 func (server *Server) deleteAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req deleteAccountRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	account, err := server.store.GetAccount(ctx, db.GetAccountParams{
+		ID:       req.ID,
+		LedgerID: ledgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.TransferID.Valid {
+		server.removeTransfer(ctx, account.TransferID.Int32)
+		return
 	}
 
-	err = server.store.DeleteAccount(ctx, req.ID)
+	rows, err := server.store.DeleteAccount(ctx, db.DeleteAccountParams{
+		ID:       req.ID,
+		LedgerID: ledgerID,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	server.recordOwnDeletion(ctx, auditEventAccountDeleted, fmt.Sprintf("account %d in ledger %d", req.ID, ledgerID))
 
 	ctx.JSON(http.StatusOK, true)
 }
 
 type updateAccountRequest struct {
-	ID          int32  `json:"id" binding:"required"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Value       int32  `json:"value"`
+	ID          int32        `json:"id" binding:"required"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Amount      *money.Money `json:"amount" binding:"required"`
+	WalletID    int32        `json:"wallet_id"`
 }
 
+// updateAccount changes a transaction and may move it to another wallet;
+// without wallet_id it stays in its wallet. Editing either leg of a
+// transfer updates both.
 func (server *Server) updateAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req updateAccountRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Amount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errAccountAmount))
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	account, err := server.store.GetAccount(ctx, db.GetAccountParams{
+		ID:       req.ID,
+		LedgerID: ledgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.TransferID.Valid {
+		server.updateTransferLeg(ctx, account, req)
+		return
+	}
+	if req.WalletID == 0 {
+		req.WalletID = account.WalletID
+	}
+
+	wallet, ok := server.transactionWallet(ctx, req.WalletID, *req.Amount)
+	if !ok {
+		return
 	}
 
 	arg := db.UpdateAccountParams{
 		ID:          req.ID,
 		Title:       req.Title,
 		Description: req.Description,
-		Value:       req.Value,
+		Amount:      req.Amount.Amount,
+		Currency:    req.Amount.Currency,
+		WalletID:    wallet.ID,
+		LedgerID:    ledgerID,
 	}
 
-	account, err := server.store.UpdateAccount(ctx, arg)
+	account, err = server.store.UpdateAccountTx(ctx, arg)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type getAccountsRequest struct {
-	UserID      int32     `json:"user_id" binding:"required"`
 	Type        string    `json:"type" binding:"required"`
 	CategoryID  int32     `json:"category_id"`
+	WalletID    int32     `json:"wallet_id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	Date        time.Time `json:"date"`
 }
 
 func (server *Server) getAccounts(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountsRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
@@ -229,8 +320,8 @@ func (server *Server) getAccounts(ctx *gin.Context) {
 	}
 
 	arg := db.GetAccountsParams{
-		UserID: req.UserID,
-		Type:   req.Type,
+		LedgerID: ledgerMember(ctx).LedgerID,
+		Type:     req.Type,
 		CategoryID: sql.NullInt32{
 			Int32: req.CategoryID,
 			Valid: req.CategoryID > 0,
@@ -241,6 +332,10 @@ func (server *Server) getAccounts(ctx *gin.Context) {
 			Time:  req.Date,
 			Valid: !req.Date.IsZero(),
 		},
+		WalletID: sql.NullInt32{
+			Int32: req.WalletID,
+			Valid: req.WalletID > 0,
+		},
 	}
 
 	accounts, err := server.store.GetAccounts(ctx, arg)
@@ -249,5 +344,9 @@ func (server *Server) getAccounts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	rsp := make([]accountListItemResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = newAccountListItemResponse(account)
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
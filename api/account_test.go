@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createTestCategory(t *testing.T, store *fakeStore, userID int32) db.Category {
+	ledger, err := store.EnsurePersonalLedgerTx(context.Background(), userID)
+	require.NoError(t, err)
+
+	category, err := store.CreateCategory(context.Background(), db.CreateCategoryParams{
+		LedgerID:    ledger.ID,
+		CreatedBy:   sql.NullInt32{Int32: userID, Valid: true},
+		Title:       util.RandomString(8),
+		Type:        "debit",
+		Description: util.RandomString(12),
+	})
+	require.NoError(t, err)
+	return category
+}
+
+func TestCreateAccountAmounts(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallets := map[string]int32{}
+	for _, currency := range []string{"BRL", "JPY", "USD"} {
+		wallets[currency] = createTestWallet(t, store, category.LedgerID, currency).ID
+	}
+
+	createAccount := func(amount interface{}) *httptest.ResponseRecorder {
+		walletID := wallets["BRL"]
+		if m, ok := amount.(map[string]interface{}); ok {
+			walletID = wallets[m["currency"].(string)]
+		}
+		return serveAs(t, server, user.ID, http.MethodPost, "/account", map[string]interface{}{
+			"category_id": category.ID,
+			"wallet_id":   walletID,
+			"title":       util.RandomString(8),
+			"type":        category.Type,
+			"description": util.RandomString(12),
+			"amount":      amount,
+			"date":        time.Now(),
+		})
+	}
+
+	recorder := createAccount(map[string]interface{}{"amount": "30000000.15", "currency": "BRL"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var account accountResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	require.Equal(t, money.New(3000000015, "BRL"), account.Amount)
+	require.Equal(t, int64(3000000015), store.accounts[account.ID].Amount)
+	require.Equal(t, wallets["BRL"], account.WalletID)
+
+	recorder = createAccount(map[string]interface{}{"amount": 1500, "currency": "JPY"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = createAccount(map[string]interface{}{"amount": "0.85", "currency": "BRL"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, amount := range []interface{}{
+		map[string]string{"amount": "1.005", "currency": "BRL"},
+		map[string]string{"amount": "1", "currency": "XXX"},
+		map[string]string{"amount": "1"},
+		// Amounts must be in the currency of their wallet.
+		map[string]string{"amount": "1", "currency": "USD"},
+		map[string]string{"amount": "0", "currency": "BRL"},
+		map[string]string{"amount": "-1.00", "currency": "BRL"},
+		"1.00",
+		nil,
+	} {
+		recorder = createAccount(amount)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, amount)
+	}
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, "/account/reports/debit", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `[
+		{"amount":"30000001.00","currency":"BRL"},
+		{"amount":"1500","currency":"JPY"}
+	]`, recorder.Body.String())
+}
+
+func TestCreateWithUnknownType(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	// The journal posts debits and credits only.
+	recorder := serveAs(t, server, user.ID, http.MethodPost, "/category", createCategoryRequest{
+		Title:       "Savings",
+		Type:        "transfer",
+		Description: "Money put aside",
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Len(t, store.categories, 1)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPost, "/account", createAccountRequest{
+		CategoryID:  category.ID,
+		WalletID:    wallet.ID,
+		Title:       "Groceries",
+		Type:        "expense",
+		Description: "Weekly shopping",
+		Amount:      &money.Money{Amount: 100, Currency: "BRL"},
+		Date:        time.Now(),
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Empty(t, store.accounts)
+}
+
+func TestUpdateAccountAmount(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+
+	brl := createTestWallet(t, store, category.LedgerID, "BRL")
+	usd := createTestWallet(t, store, category.LedgerID, "USD")
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		LedgerID:    category.LedgerID,
+		CategoryID:  sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:       util.RandomString(8),
+		Type:        category.Type,
+		Description: util.RandomString(12),
+		Amount:      1000,
+		Currency:    "BRL",
+		Date:        time.Now(),
+		WalletID:    brl.ID,
+	})
+	require.NoError(t, err)
+
+	// Without wallet_id the transaction stays in its BRL wallet.
+	recorder := serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", account.ID), updateAccountRequest{
+		ID:     account.ID,
+		Amount: &money.Money{Amount: 2599, Currency: "USD"},
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", account.ID), updateAccountRequest{
+		ID:          account.ID,
+		Title:       account.Title,
+		Description: account.Description,
+		Amount:      &money.Money{Amount: 2599, Currency: "USD"},
+		WalletID:    usd.ID,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"amount":"25.99","currency":"USD"}`, string(jsonField(t, recorder.Body.Bytes(), "amount")))
+	require.Equal(t, usd.ID, store.accounts[account.ID].WalletID)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", account.ID), updateAccountRequest{ID: account.ID})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	for _, amount := range []int64{0, -2599} {
+		recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", account.ID), updateAccountRequest{
+			ID:     account.ID,
+			Amount: &money.Money{Amount: amount, Currency: "USD"},
+		})
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+	require.Equal(t, int64(2599), store.accounts[account.ID].Amount)
+}
+
+func jsonField(t *testing.T, body []byte, name string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &fields))
+	return fields[name]
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+)
+
+// recordAdminEvent audits an action an admin took on a user's account; the
+// admin is recorded as the actor.
+func (server *Server) recordAdminEvent(ctx *gin.Context, eventType string, user db.User, details string) error {
+	return server.recordAuditEvent(ctx, auditEvent{
+		Type:     eventType,
+		Outcome:  db.AuditOutcomeSuccess,
+		UserID:   user.ID,
+		Username: user.Username,
+		Details:  details,
+	})
+}
+
+type listUsersRequest struct {
+	Search   string `form:"search"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+// listUsers pages through every user, optionally filtered by a
+// case-insensitive search on username and email.
+func (server *Server) listUsers(ctx *gin.Context) {
+	var req listUsersRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	users, err := server.store.ListUsers(ctx, db.ListUsersParams{
+		Search:     req.Search,
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]adminUserResponse, len(users))
+	for i, user := range users {
+		rsp[i] = newAdminUserResponse(user)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type adminUserRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getAdminUser(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+// updateUserRole changes a user's role. The user is signed out so that new
+// tokens carry the new role.
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri adminUserRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateUserRoleRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if uri.ID == authClaims(ctx).UserID {
+		ctx.JSON(http.StatusConflict, errorResponse(errCannotChangeOwnAccount))
+		return
+	}
+
+	user, err := server.store.UpdateUserRoleTx(ctx, db.UpdateUserRoleParams{ID: uri.ID, Role: req.Role})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordAdminEvent(ctx, auditEventAdminRoleChanged, user, "role set to "+user.Role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+// disableUser blocks every way of signing in to the account and revokes
+// its sessions. Personal access tokens stop working while it is disabled.
+func (server *Server) disableUser(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.ID == authClaims(ctx).UserID {
+		ctx.JSON(http.StatusConflict, errorResponse(errCannotChangeOwnAccount))
+		return
+	}
+
+	user, err := server.store.DisableUserTx(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordAdminEvent(ctx, auditEventAdminUserDisabled, user, "account disabled")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (server *Server) enableUser(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.EnableUser(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordAdminEvent(ctx, auditEventAdminUserEnabled, user, "account enabled")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+// forcePasswordReset signs the user out everywhere, refuses their current
+// password and emails them a reset link.
+func (server *Server) forcePasswordReset(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.ForcePasswordResetTx(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.sendPasswordResetEmail(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordAdminEvent(ctx, auditEventAdminPasswordReset, user, "password reset forced")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (server *Server) getSystemStats(ctx *gin.Context) {
+	stats, err := server.store.GetSystemStats(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newSystemStatsResponse(stats))
+}
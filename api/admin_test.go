@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+// loginTestAdmin creates a user, promotes it to admin in the store and
+// opens a session carrying the admin role.
+func loginTestAdmin(t *testing.T, server *Server, store *fakeStore) (db.User, sessionResponse) {
+	req, _ := loginTestUser(t, server)
+	admin, err := store.GetUser(context.Background(), req.Username)
+	require.NoError(t, err)
+	admin.Role = roleAdmin
+	store.users[admin.ID] = admin
+
+	return admin, login(t, server, req.Username, req.Password)
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, session := loginTestUser(t, server)
+	_, admin := loginTestAdmin(t, server, store)
+
+	status, _ := serveAsSession(t, server, admin.AccessToken, http.MethodGet, "/admin/stats", nil)
+	require.Equal(t, http.StatusOK, status)
+
+	urls := []string{"/admin/stats", "/admin/users?page_id=1&page_size=5", "/admin/users/1"}
+	for _, url := range urls {
+		status, _ = serveAsSession(t, server, session.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusForbidden, status, url)
+
+		recorder := serveAs(t, server, 0, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code, url)
+	}
+
+	pat := createTestPersonalAccessToken(t, server, admin, createPersonalAccessTokenRequest{
+		Name:   "admin script",
+		Scopes: []string{scopeAccountsRead, scopeAccountsWrite, scopeReportsRead},
+	}).Token
+	status, _ = serveAsSession(t, server, pat, http.MethodGet, "/admin/stats", nil)
+	require.Equal(t, http.StatusForbidden, status)
+}
+
+func TestAdminListUsers(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, admin := loginTestAdmin(t, server, store)
+	user, _ := loginTestUser(t, server)
+	loginTestUser(t, server)
+
+	status, body := serveAsSession(t, server, admin.AccessToken, http.MethodGet, "/admin/users?page_id=1&page_size=5", nil)
+	require.Equal(t, http.StatusOK, status)
+	var users []adminUserResponse
+	require.NoError(t, json.Unmarshal(body, &users))
+	require.Len(t, users, 3)
+	require.NotContains(t, string(body), "password\"")
+
+	status, body = serveAsSession(t, server, admin.AccessToken, http.MethodGet,
+		"/admin/users?page_id=1&page_size=5&search="+user.Email, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &users))
+	require.Len(t, users, 1)
+	require.Equal(t, user.Username, users[0].Username)
+	require.Equal(t, roleUser, users[0].Role)
+
+	status, _ = serveAsSession(t, server, admin.AccessToken, http.MethodGet, "/admin/users?page_id=0&page_size=5", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	admin, adminSession := loginTestAdmin(t, server, store)
+	user, session := loginTestUser(t, server)
+	target, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	pat := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:   "script",
+		Scopes: []string{scopeAccountsRead},
+	}).Token
+
+	url := fmt.Sprintf("/admin/users/%d/disable", target.ID)
+	status, body := serveAsSession(t, server, adminSession.AccessToken, http.MethodPost, url, nil)
+	require.Equal(t, http.StatusOK, status)
+	var disabled adminUserResponse
+	require.NoError(t, json.Unmarshal(body, &disabled))
+	require.NotNil(t, disabled.DisabledAt)
+
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, session.AccessToken, http.MethodPost, "/logout"))
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, pat, http.MethodGet, "/category?type=debit"))
+	status, _ = refresh(t, server, session.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{Username: user.Username, Password: user.Password})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	status, _ = serveAsSession(t, server, adminSession.AccessToken, http.MethodPost, fmt.Sprintf("/admin/users/%d/disable", admin.ID), nil)
+	require.Equal(t, http.StatusConflict, status)
+
+	status, _ = serveAsSession(t, server, adminSession.AccessToken, http.MethodPost, fmt.Sprintf("/admin/users/%d/enable", target.ID), nil)
+	require.Equal(t, http.StatusOK, status)
+	login(t, server, user.Username, user.Password)
+
+	disabledEvents := store.eventsOfType(auditEventAdminUserDisabled)
+	require.Len(t, disabledEvents, 1)
+	require.Equal(t, target.ID, disabledEvents[0].UserID.Int32)
+	require.Equal(t, admin.ID, disabledEvents[0].ActorID.Int32)
+	require.Equal(t, admin.Username, disabledEvents[0].Actor)
+	require.Len(t, store.eventsOfType(auditEventAdminUserEnabled), 1)
+
+	status, _ = serveAsSession(t, server, adminSession.AccessToken, http.MethodPost, "/admin/users/9999/disable", nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAdminForcePasswordReset(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	_, adminSession := loginTestAdmin(t, server, store)
+	user, session := loginTestUser(t, server)
+	target, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	pat := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:   "reporting script",
+		Scopes: []string{scopeReportsRead},
+	})
+	mailbox.Reset()
+
+	url := fmt.Sprintf("/admin/users/%d/password-reset", target.ID)
+	status, _ := serveAsSession(t, server, adminSession.AccessToken, http.MethodPost, url, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, session.AccessToken, http.MethodPost, "/logout"))
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, pat.Token, http.MethodGet, "/account/graph/debit"))
+	require.True(t, store.pats[pat.ID].RevokedAt.Valid)
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{Username: user.Username, Password: user.Password})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	newPassword := util.RandomString(12)
+	recorder = serveAs(t, server, 0, http.MethodPost, "/password/reset", resetPasswordRequest{
+		Token:    resetTokenFromMailbox(t, mailbox),
+		Password: newPassword,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	login(t, server, user.Username, newPassword)
+}
+
+func TestAdminUpdateUserRole(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	admin, adminSession := loginTestAdmin(t, server, store)
+	user, session := loginTestUser(t, server)
+	target, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("/admin/users/%d/role", target.ID)
+	status, _ := serveAsSession(t, server, adminSession.AccessToken, http.MethodPut, url, updateUserRoleRequest{Role: "owner"})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, body := serveAsSession(t, server, adminSession.AccessToken, http.MethodPut, url, updateUserRoleRequest{Role: roleAdmin})
+	require.Equal(t, http.StatusOK, status)
+	var promoted adminUserResponse
+	require.NoError(t, json.Unmarshal(body, &promoted))
+	require.Equal(t, roleAdmin, promoted.Role)
+
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, session.AccessToken, http.MethodPost, "/logout"))
+	session = login(t, server, user.Username, user.Password)
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodGet, "/admin/stats", nil)
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = serveAsSession(t, server, adminSession.AccessToken, http.MethodPut,
+		fmt.Sprintf("/admin/users/%d/role", admin.ID), updateUserRoleRequest{Role: roleUser})
+	require.Equal(t, http.StatusConflict, status)
+}
+
+func TestAdminSystemStats(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, adminSession := loginTestAdmin(t, server, store)
+	loginTestUser(t, server)
+
+	status, body := serveAsSession(t, server, adminSession.AccessToken, http.MethodGet, "/admin/stats", nil)
+	require.Equal(t, http.StatusOK, status)
+	var stats systemStatsResponse
+	require.NoError(t, json.Unmarshal(body, &stats))
+	require.Equal(t, int64(2), stats.Users)
+	require.Equal(t, int64(1), stats.Admins)
+	require.Equal(t, int64(3), stats.ActiveSessions)
+}
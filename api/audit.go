@@ -0,0 +1,194 @@
+package api
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/token"
+)
+
+// Audit event types.
+const (
+	auditEventLogin                = "login"
+	auditEventLoginLockout         = "login_lockout"
+	auditEventReauthentication     = "reauthentication"
+	auditEventLogout               = "logout"
+	auditEventLogoutAll            = "logout_all"
+	auditEventPasswordChanged      = "password_changed"
+	auditEventPasswordReset        = "password_reset"
+	auditEventMfaEnabled           = "mfa_enabled"
+	auditEventMfaDisabled          = "mfa_disabled"
+	auditEventTokenCreated         = "token_created"
+	auditEventTokenRevoked         = "token_revoked"
+	auditEventIdentityLinked       = "identity_linked"
+	auditEventIdentityUnlinked     = "identity_unlinked"
+	auditEventCategoryDeleted      = "category_deleted"
+	auditEventAccountDeleted       = "account_deleted"
+	auditEventProfileUpdated       = "profile_updated"
+	auditEventEmailChangeRequested = "email_change_requested"
+	auditEventEmailChanged         = "email_changed"
+	auditEventProfileDeleted       = "profile_deleted"
+	auditEventPermissionDenied     = "permission_denied"
+	auditEventLedgerCreated        = "ledger_created"
+	auditEventLedgerMemberInvited  = "ledger_member_invited"
+	auditEventLedgerInviteRevoked  = "ledger_invitation_revoked"
+	auditEventLedgerMemberJoined   = "ledger_member_joined"
+	auditEventLedgerMemberRole     = "ledger_member_role_changed"
+	auditEventLedgerMemberRemoved  = "ledger_member_removed"
+	auditEventAdminRoleChanged     = "admin_role_changed"
+	auditEventAdminUserDisabled    = "admin_user_disabled"
+	auditEventAdminUserEnabled     = "admin_user_enabled"
+	auditEventAdminPasswordReset   = "admin_password_reset"
+	auditEventExchangeRatesLoaded  = "exchange_rates_imported"
+	auditEventWalletDeleted        = "wallet_deleted"
+	auditEventTransferDeleted      = "transfer_deleted"
+	auditEventRecurringDeleted     = "recurring_deleted"
+	auditEventInstallmentCancelled = "installment_plan_cancelled"
+	auditEventGoalDeleted          = "goal_deleted"
+)
+
+// auditEvent is an entry for the audit log. UserID is the account the
+// event concerns, 0 when there is none, as for a failed login with an
+// unknown username.
+type auditEvent struct {
+	Type     string
+	Outcome  string
+	UserID   int32
+	Username string
+	Details  string
+}
+
+// recordAuditEvent appends an entry to the audit log with the client's IP
+// and user agent. The actor is the signed in caller; on public routes it is
+// the user the event concerns once they proved who they are, and nobody
+// otherwise.
+func (server *Server) recordAuditEvent(ctx *gin.Context, event auditEvent) error {
+	arg := db.AppendAuditEventTxParams{
+		UserID:    sql.NullInt32{Int32: event.UserID, Valid: event.UserID != 0},
+		Username:  event.Username,
+		Type:      event.Type,
+		Outcome:   event.Outcome,
+		ClientIp:  ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Details:   event.Details,
+	}
+	if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+		claims := payload.(*token.Claims)
+		arg.ActorID = sql.NullInt32{Int32: claims.UserID, Valid: true}
+		arg.Actor = claims.Username
+	} else if event.Outcome == db.AuditOutcomeSuccess {
+		arg.ActorID = arg.UserID
+		arg.Actor = event.Username
+	}
+
+	_, err := server.store.AppendAuditEventTx(ctx, arg)
+	return err
+}
+
+// recordOwnAuditEvent records a successful action signed in users took on
+// their own account.
+func (server *Server) recordOwnAuditEvent(ctx *gin.Context, eventType, details string) error {
+	claims := authClaims(ctx)
+	return server.recordAuditEvent(ctx, auditEvent{
+		Type:     eventType,
+		Outcome:  db.AuditOutcomeSuccess,
+		UserID:   claims.UserID,
+		Username: claims.Username,
+		Details:  details,
+	})
+}
+
+// recordOwnDeletion records that signed in users deleted something. The
+// delete is committed by then, so a failure to record it is only logged
+// rather than turned into an error for a request that succeeded.
+func (server *Server) recordOwnDeletion(ctx *gin.Context, eventType, details string) {
+	err := server.recordOwnAuditEvent(ctx, eventType, details)
+	if err != nil {
+		log.Printf("cannot record %s audit event (%s): %v", eventType, details, err)
+	}
+}
+
+type listAuditEventsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+// listOwnAuditEvents returns the caller's recent activity, newest first:
+// what happened to their account and what they did themselves.
+func (server *Server) listOwnAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	events, err := server.store.ListUserAuditEvents(ctx, db.ListUserAuditEventsParams{
+		UserID:     authClaims(ctx).UserID,
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]auditEventResponse, len(events))
+	for i, event := range events {
+		rsp[i] = newAuditEventResponse(event)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type adminListAuditEventsRequest struct {
+	listAuditEventsRequest
+	UserID  int32  `form:"user_id" binding:"omitempty,min=1"`
+	Type    string `form:"type"`
+	Outcome string `form:"outcome" binding:"omitempty,oneof=success failure"`
+}
+
+// listAuditEvents pages through the whole audit log, newest first,
+// optionally filtered by the user involved, the event type and the
+// outcome.
+func (server *Server) listAuditEvents(ctx *gin.Context) {
+	var req adminListAuditEventsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	events, err := server.store.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		UserID:     sql.NullInt32{Int32: req.UserID, Valid: req.UserID != 0},
+		Type:       sql.NullString{String: req.Type, Valid: req.Type != ""},
+		Outcome:    sql.NullString{String: req.Outcome, Valid: req.Outcome != ""},
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]adminAuditEventResponse, len(events))
+	for i, event := range events {
+		rsp[i] = newAdminAuditEventResponse(event)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// verifyAuditLog checks the hash chain of the whole log. Admins should keep
+// the returned head hash and event count somewhere else: a log cut short
+// at the end still verifies, but no longer reaches that head.
+func (server *Server) verifyAuditLog(ctx *gin.Context) {
+	verification, err := server.store.VerifyAuditLog(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAuditLogVerificationResponse(verification))
+}
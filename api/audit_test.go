@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func TestListOwnAuditEvents(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, session := loginTestUser(t, server)
+	loginTestUser(t, server)
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/password/change", changePasswordRequest{
+		CurrentPassword: "wrong-password",
+		NewPassword:     util.RandomString(12),
+	})
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodGet, "/audit?page_id=1&page_size=5", nil)
+	require.Equal(t, http.StatusOK, status)
+	var events []auditEventResponse
+	require.NoError(t, json.Unmarshal(body, &events))
+	require.Len(t, events, 2)
+	require.NotContains(t, string(body), "hash")
+
+	require.Equal(t, auditEventReauthentication, events[0].Type)
+	require.Equal(t, db.AuditOutcomeFailure, events[0].Outcome)
+	require.Equal(t, user.Username, events[0].Username)
+	require.Equal(t, user.Username, events[0].Actor)
+	require.Equal(t, auditEventLogin, events[1].Type)
+	require.Equal(t, db.AuditOutcomeSuccess, events[1].Outcome)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodGet, "/audit?page_id=0&page_size=5", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestAdminListAuditEvents(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	admin, adminSession := loginTestAdmin(t, server, store)
+	user, _ := loginTestUser(t, server)
+	failLogin(t, server, user.Username)
+
+	status, body := serveAsSession(t, server, adminSession.AccessToken, http.MethodGet, "/admin/audit?page_id=1&page_size=10", nil)
+	require.Equal(t, http.StatusOK, status)
+	var events []adminAuditEventResponse
+	require.NoError(t, json.Unmarshal(body, &events))
+	require.Len(t, events, len(store.events))
+	require.Equal(t, store.events[len(store.events)-1].Hash, events[0].Hash)
+	require.Equal(t, events[1].Hash, events[0].PrevHash)
+
+	status, body = serveAsSession(t, server, adminSession.AccessToken, http.MethodGet,
+		"/admin/audit?page_id=1&page_size=10&type=login&outcome=failure", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &events))
+	require.Len(t, events, 1)
+	require.Equal(t, user.Username, events[0].Username)
+	require.Nil(t, events[0].ActorID)
+	require.Empty(t, events[0].Actor)
+
+	status, body = serveAsSession(t, server, adminSession.AccessToken, http.MethodGet,
+		fmt.Sprintf("/admin/audit?page_id=1&page_size=10&user_id=%d", admin.ID), nil)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &events))
+	require.NotEmpty(t, events)
+	for _, event := range events {
+		require.Equal(t, admin.Username, event.Username)
+	}
+
+	status, _ = serveAsSession(t, server, adminSession.AccessToken, http.MethodGet,
+		"/admin/audit?page_id=1&page_size=10&outcome=maybe", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestAdminAuditRequiresAdmin(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, session := loginTestUser(t, server)
+
+	for _, url := range []string{"/admin/audit?page_id=1&page_size=5", "/admin/audit/verify"} {
+		status, _ := serveAsSession(t, server, session.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusForbidden, status, url)
+	}
+
+	denied := store.eventsOfType(auditEventPermissionDenied)
+	require.Len(t, denied, 2)
+	require.Equal(t, db.AuditOutcomeFailure, denied[0].Outcome)
+	require.Equal(t, user.Username, denied[0].Actor)
+	require.Contains(t, denied[0].Details, permissionViewAudit)
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, adminSession := loginTestAdmin(t, server, store)
+	user, _ := loginTestUser(t, server)
+	failLogin(t, server, user.Username)
+
+	verify := func() auditLogVerificationResponse {
+		status, body := serveAsSession(t, server, adminSession.AccessToken, http.MethodGet, "/admin/audit/verify", nil)
+		require.Equal(t, http.StatusOK, status)
+		var rsp auditLogVerificationResponse
+		require.NoError(t, json.Unmarshal(body, &rsp))
+		return rsp
+	}
+
+	rsp := verify()
+	require.True(t, rsp.Valid)
+	require.Equal(t, int64(len(store.events)), rsp.Events)
+	require.Equal(t, store.events[len(store.events)-1].Hash, rsp.HeadHash)
+	require.Nil(t, rsp.FirstInvalidID)
+
+	// Rewriting an entry breaks the chain from that entry on.
+	tampered := store.events[1].ID
+	store.events[1].Details = "nothing to see here"
+
+	rsp = verify()
+	require.False(t, rsp.Valid)
+	require.NotNil(t, rsp.FirstInvalidID)
+	require.Equal(t, tampered, *rsp.FirstInvalidID)
+}
+
+// auditFailingStore cannot append to the audit log.
+type auditFailingStore struct {
+	*fakeStore
+}
+
+func (s auditFailingStore) AppendAuditEventTx(ctx context.Context, arg db.AppendAuditEventTxParams) (db.AuditEvent, error) {
+	return db.AuditEvent{}, errors.New("audit log unavailable")
+}
+
+func TestDeleteSucceedsWhenAuditFails(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, auditFailingStore{store})
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+
+	// The category is gone, so the request must not answer 500.
+	recorder := serveAs(t, server, user.ID, http.MethodDelete, fmt.Sprintf("/category/%d", category.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, store.categories, category.ID)
+}
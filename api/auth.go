@@ -1,15 +1,13 @@
 package api
 
 import (
-	"bytes"
-	"crypto/sha512"
 	"database/sql"
+	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
 )
 
 type loginRequest struct {
@@ -17,55 +15,122 @@ type loginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-type Claims struct {
-	Username string `json:"username"`
-	jwt.RegisteredClaims
-}
-
+// login checks the password and opens a session. Users with two-factor
+// authentication get an MFA challenge instead, see loginMfa. Unknown
+// usernames and wrong passwords get the same answer, and repeated failures
+// are throttled per username and per client.
 func (server *Server) login(ctx *gin.Context) {
 	var req loginRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	throttleKeys := server.loginThrottleKeys(ctx, req.Username)
+	if !server.checkLoginThrottle(ctx, throttleKeys) {
+		return
 	}
 
 	user, err := server.store.GetUser(ctx, req.Username)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	// Deleted accounts look like unknown usernames until they are purged.
+	found := err == nil && !user.DeletedAt.Valid
+
+	err = server.checkPasswordOrDummy(req.Password, user, found)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		failure := auditEvent{Type: auditEventLogin, Username: req.Username, Details: "wrong username or password"}
+		if found {
+			failure.UserID = user.ID
+		}
+		err = server.recordLoginFailure(ctx, throttleKeys, failure)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	if user.PasswordResetRequired {
+		ctx.JSON(http.StatusForbidden, errorResponse(errPasswordResetRequired))
+		return
+	}
+
+	server.upgradePasswordHash(ctx, user, req.Password)
+	server.completeLogin(ctx, user)
+}
+
+// upgradePasswordHash replaces a legacy or outdated password hash once the
+// password it protects is known to be right. Failures are only logged;
+// the user can still sign in with the old hash.
+func (server *Server) upgradePasswordHash(ctx *gin.Context, user db.User, password string) {
+	if !util.PasswordNeedsRehash(user.Password, server.config.PasswordParams) {
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(password, server.config.PasswordParams)
+	if err != nil {
+		log.Printf("cannot rehash password of user %d: %v", user.ID, err)
 		return
 	}
 
-	hashedInput := sha512.Sum512_256([]byte(req.Password))
-	trimmedHash := bytes.Trim(hashedInput[:], "\x00")
-	preparedPassword := string(trimmedHash)
-	plainTextInBytes := []byte(preparedPassword)
-	hashTextInBytes := []byte(user.Password)
-	err = bcrypt.CompareHashAndPassword(hashTextInBytes, plainTextInBytes)
+	_, err = server.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+		NewPassword: hashedPassword,
+		ID:          user.ID,
+		OldPassword: user.Password,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		log.Printf("cannot rehash password of user %d: %v", user.ID, err)
+	}
+}
+
+// completeLogin finishes a login once the user proved who they are: it
+// turns away disabled and deleted accounts, applies the unverified email
+// policy, asks for a second factor when MFA is on and otherwise opens a
+// session.
+func (server *Server) completeLogin(ctx *gin.Context, user db.User) {
+	if err := checkUserActive(user); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+	if !user.VerifiedAt.Valid && server.config.UnverifiedLoginPolicy == util.UnverifiedLoginDeny {
+		ctx.JSON(http.StatusForbidden, errorResponse(errEmailNotVerified))
 		return
 	}
 
-	expirationTime := time.Now().Add(100 * time.Minute)
+	_, err := server.confirmedMfa(ctx, user.ID)
+	if err == nil {
+		challenge, err := server.mfaChallenge(user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, challenge)
+		return
+	}
+	if err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
-	claims := &Claims{
-		Username: req.Username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-		},
+	err = server.clearLoginFailures(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	generatedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	var jwtSignedKey = []byte("secret_key")
-	generatedTokenToString, err := generatedToken.SignedString(jwtSignedKey)
+	rsp, err := server.startSession(ctx, user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, generatedTokenToString)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) getJWKS(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.tokens.JWKS())
 }
@@ -0,0 +1,377 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+var (
+	errBudgetNotFound = errors.New("budget not found")
+	errBudgetAmount   = errors.New("a budget cannot be negative")
+	errBudgetCopy     = errors.New("budgets are copied to another month")
+)
+
+type setBudgetRequest struct {
+	CategoryID int32        `json:"category_id" binding:"required"`
+	Month      string       `json:"month" binding:"required"`
+	Amount     *money.Money `json:"amount" binding:"required"`
+	// Rollover carries what is left of the budget at the end of the
+	// month over to the next one.
+	Rollover bool `json:"rollover"`
+}
+
+// setBudget sets how much a category may take in a month, replacing the
+// budget it had for that month.
+func (server *Server) setBudget(ctx *gin.Context) {
+	var req setBudgetRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	month, err := time.Parse(monthLayout, req.Month)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Amount.Amount < 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errBudgetAmount))
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	category, err := server.store.GetCategory(ctx, db.GetCategoryParams{
+		ID:       req.CategoryID,
+		LedgerID: ledgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	budget, err := server.store.SetBudget(ctx, db.SetBudgetParams{
+		LedgerID:   ledgerID,
+		CategoryID: category.ID,
+		Month:      month,
+		Amount:     req.Amount.Amount,
+		Currency:   req.Amount.Currency,
+		Rollover:   req.Rollover,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBudgetResponse(budget))
+}
+
+type deleteBudgetRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteBudget(ctx *gin.Context) {
+	var req deleteBudgetRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rows, err := server.store.DeleteBudget(ctx, db.DeleteBudgetParams{
+		ID:       req.ID,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errBudgetNotFound))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type copyBudgetsRequest struct {
+	FromMonth string `json:"from_month" binding:"required"`
+	ToMonth   string `json:"to_month" binding:"required"`
+}
+
+// copyBudgets copies the budgets of one month to another, such as the
+// next one. Categories that already have a budget in to_month keep it;
+// the response lists only the budgets copied.
+func (server *Server) copyBudgets(ctx *gin.Context) {
+	var req copyBudgetsRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	from, err := time.Parse(monthLayout, req.FromMonth)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	to, err := time.Parse(monthLayout, req.ToMonth)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if from.Equal(to) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errBudgetCopy))
+		return
+	}
+
+	budgets, err := server.store.CopyBudgets(ctx, db.CopyBudgetsParams{
+		ToMonth:   to,
+		LedgerID:  ledgerMember(ctx).LedgerID,
+		FromMonth: from,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]budgetResponse, len(budgets))
+	for i, budget := range budgets {
+		rsp[i] = newBudgetResponse(budget)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getBudgetsRequest struct {
+	Month string `form:"month" binding:"required"`
+	Type  string `form:"type" binding:"omitempty,oneof=debit credit"`
+}
+
+// getBudgets compares what every category of a type, debit by default,
+// was budgeted in a month with what its transactions add up to. Amounts
+// in other currencies are converted to the currency of the budget, or
+// to the caller's base currency for categories without one.
+func (server *Server) getBudgets(ctx *gin.Context) {
+	var req getBudgetsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	month, err := time.Parse(monthLayout, req.Month)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Type == "" {
+		req.Type = "debit"
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	categories, err := server.store.GetCategories(ctx, db.GetCategoriesParams{
+		LedgerID: ledgerID,
+		Type:     req.Type,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	budgets, err := server.store.ListBudgetsThrough(ctx, db.ListBudgetsThroughParams{
+		LedgerID: ledgerID,
+		Month:    month,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	base, err := server.baseCurrencyConverter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	byCategory := map[int32][]db.Budget{}
+	for _, budget := range budgets {
+		byCategory[budget.CategoryID] = append(byCategory[budget.CategoryID], budget)
+	}
+	chains := map[int32]budgetChain{}
+	from := month
+	for _, category := range categories {
+		chain := newBudgetChain(byCategory[category.ID], month, base.to)
+		chains[category.ID] = chain
+		if len(chain.previous) > 0 && chain.previous[0].Month.Before(from) {
+			from = chain.previous[0].Month
+		}
+	}
+
+	rows, err := server.store.GetBudgetSpending(ctx, db.GetBudgetSpendingParams{
+		LedgerID: ledgerID,
+		FromDate: from,
+		ToDate:   month.AddDate(0, 1, -1),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	spending := budgetSpending{
+		rows:       map[budgetMonthKey][]db.GetBudgetSpendingRow{},
+		converters: []*rateConverter{base},
+		store:      server.store,
+	}
+	for _, row := range rows {
+		key := budgetMonthKey{categoryID: row.CategoryID, month: startOfMonth(row.Date)}
+		spending.rows[key] = append(spending.rows[key], row)
+	}
+
+	rsp := budgetReportResponse{
+		Month:      month.Format(monthLayout),
+		Categories: make([]budgetProgressResponse, len(categories)),
+	}
+	for i, category := range categories {
+		rsp.Categories[i], err = chains[category.ID].progress(ctx, category, &spending)
+		if err != nil {
+			reportError(ctx, err)
+			return
+		}
+	}
+	rsp.Rates = spending.usedRates()
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func startOfMonth(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// budgetChain is the budget of a category for a month together with the
+// budgets of the months right before it that roll over into it, oldest
+// first. A chain stops at a month without a budget, without rollover or
+// in another currency.
+type budgetChain struct {
+	month    time.Time
+	currency string
+	budget   *db.Budget
+	previous []db.Budget
+}
+
+// newBudgetChain builds the chain for month from the budgets of a
+// category up to month, ordered by month. currency is used when the
+// category has no budget for month or the month before.
+func newBudgetChain(budgets []db.Budget, month time.Time, currency string) budgetChain {
+	chain := budgetChain{month: month, currency: currency}
+	i := len(budgets) - 1
+	if i >= 0 && budgets[i].Month.Equal(month) {
+		chain.budget = &budgets[i]
+		chain.currency = budgets[i].Currency
+		i--
+	} else if i >= 0 && budgets[i].Rollover && budgets[i].Month.Equal(month.AddDate(0, -1, 0)) {
+		chain.currency = budgets[i].Currency
+	}
+
+	previous := month.AddDate(0, -1, 0)
+	for ; i >= 0; i-- {
+		budget := budgets[i]
+		if !budget.Month.Equal(previous) || !budget.Rollover || budget.Currency != chain.currency {
+			break
+		}
+		chain.previous = append([]db.Budget{budget}, chain.previous...)
+		previous = previous.AddDate(0, -1, 0)
+	}
+	return chain
+}
+
+// progress works out what the category has left in the chain's month.
+// What was left of each budget of the chain, never less than nothing,
+// carries over to the month after it.
+func (chain budgetChain) progress(ctx *gin.Context, category db.Category, spending *budgetSpending) (budgetProgressResponse, error) {
+	carried := money.New(0, chain.currency)
+	for _, budget := range chain.previous {
+		spent, err := spending.spent(ctx, category.ID, budget.Month, chain.currency)
+		if err != nil {
+			return budgetProgressResponse{}, err
+		}
+		left := budget.Amount + carried.Amount - spent.Amount
+		if left < 0 {
+			left = 0
+		}
+		carried = money.New(left, chain.currency)
+	}
+
+	spent, err := spending.spent(ctx, category.ID, chain.month, chain.currency)
+	if err != nil {
+		return budgetProgressResponse{}, err
+	}
+	rsp := budgetProgressResponse{
+		CategoryID:    category.ID,
+		CategoryTitle: category.Title,
+		Budgeted:      money.New(0, chain.currency),
+		CarriedOver:   carried,
+		Spent:         spent,
+	}
+	if chain.budget != nil {
+		rsp.BudgetID = &chain.budget.ID
+		rsp.Rollover = chain.budget.Rollover
+		rsp.Budgeted = money.New(chain.budget.Amount, chain.currency)
+	}
+	rsp.Remaining = money.New(rsp.Budgeted.Amount+carried.Amount-spent.Amount, chain.currency)
+	return rsp, nil
+}
+
+type budgetMonthKey struct {
+	categoryID int32
+	month      time.Time
+}
+
+// budgetSpending sums the transactions of a category in a month in any
+// currency, converting each day's amounts with that day's rate.
+type budgetSpending struct {
+	rows       map[budgetMonthKey][]db.GetBudgetSpendingRow
+	converters []*rateConverter
+	store      db.Store
+}
+
+func (s *budgetSpending) converter(currency string) *rateConverter {
+	for _, converter := range s.converters {
+		if converter.to == currency {
+			return converter
+		}
+	}
+	converter := newRateConverter(s.store, currency)
+	s.converters = append(s.converters, converter)
+	return converter
+}
+
+func (s *budgetSpending) spent(ctx *gin.Context, categoryID int32, month time.Time, currency string) (money.Money, error) {
+	converter := s.converter(currency)
+
+	total := money.New(0, currency)
+	for _, row := range s.rows[budgetMonthKey{categoryID: categoryID, month: month}] {
+		converted, err := converter.convert(ctx, money.New(row.Amount, row.Currency), row.Date)
+		if err == nil {
+			total, err = total.Add(converted)
+		}
+		if err != nil {
+			return money.Money{}, fmt.Errorf("category %d: %w", categoryID, err)
+		}
+	}
+	return total, nil
+}
+
+func (s *budgetSpending) usedRates() []exchangeRateResponse {
+	rsp := []exchangeRateResponse{}
+	for _, converter := range s.converters {
+		rsp = append(rsp, converter.usedRates()...)
+	}
+	return rsp
+}
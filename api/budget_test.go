@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+func setTestBudget(t *testing.T, server *Server, userID int32, req setBudgetRequest) budgetResponse {
+	recorder := serveAs(t, server, userID, http.MethodPost, "/budgets", req)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var budget budgetResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &budget))
+	return budget
+}
+
+func TestBudgetProgress(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	groceries := createTestCategory(t, store, user.ID)
+	leisure := createTestCategory(t, store, user.ID)
+	travel := createTestCategory(t, store, user.ID)
+
+	setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: groceries.ID, Month: "2024-01", Amount: &money.Money{Amount: 100000, Currency: "BRL"}})
+	setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: groceries.ID, Month: "2024-02", Amount: &money.Money{Amount: 80000, Currency: "BRL"}, Rollover: true})
+	budget := setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: groceries.ID, Month: "2024-03", Amount: &money.Money{Amount: 80000, Currency: "BRL"}})
+	setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: leisure.ID, Month: "2024-02", Amount: &money.Money{Amount: 10000, Currency: "BRL"}, Rollover: true})
+
+	createTestAccountOn(t, store, groceries, 70000, "BRL", "2024-01-15")
+	createTestAccountOn(t, store, groceries, 50000, "BRL", "2024-02-10")
+	createTestAccountOn(t, store, groceries, 90000, "BRL", "2024-03-05")
+	createTestAccountOn(t, store, groceries, 1000, "USD", "2024-03-20")
+	createTestAccountOn(t, store, groceries, 99999, "BRL", "2024-04-01")
+	createTestAccountOn(t, store, leisure, 30000, "BRL", "2024-02-20")
+	createTestAccountOn(t, store, travel, 10000, "BRL", "2024-03-31")
+	importTestRates(t, store, exchangeRateRequest{FromCurrency: "USD", ToCurrency: "BRL", Date: "2024-03-01", Rate: "5"})
+
+	// What groceries left in February rolls over into March; leisure went
+	// over budget and carries nothing, and travel has no budget at all.
+	recorder := serveAs(t, server, user.ID, http.MethodGet, "/budgets?month=2024-03", nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.JSONEq(t, fmt.Sprintf(`{
+		"month": "2024-03",
+		"categories": [
+			{"category_id":%d,"category_title":%q,"budget_id":%d,"rollover":false,
+			 "budgeted":{"amount":"800.00","currency":"BRL"},
+			 "carried_over":{"amount":"300.00","currency":"BRL"},
+			 "spent":{"amount":"950.00","currency":"BRL"},
+			 "remaining":{"amount":"150.00","currency":"BRL"}},
+			{"category_id":%d,"category_title":%q,"budget_id":null,"rollover":false,
+			 "budgeted":{"amount":"0.00","currency":"BRL"},
+			 "carried_over":{"amount":"0.00","currency":"BRL"},
+			 "spent":{"amount":"0.00","currency":"BRL"},
+			 "remaining":{"amount":"0.00","currency":"BRL"}},
+			{"category_id":%d,"category_title":%q,"budget_id":null,"rollover":false,
+			 "budgeted":{"amount":"0.00","currency":"BRL"},
+			 "carried_over":{"amount":"0.00","currency":"BRL"},
+			 "spent":{"amount":"100.00","currency":"BRL"},
+			 "remaining":{"amount":"-100.00","currency":"BRL"}}
+		],
+		"rates": [
+			{"from_currency":"USD","to_currency":"BRL","date":"2024-03-01","rate":"5"}
+		]
+	}`, groceries.ID, groceries.Title, budget.ID, leisure.ID, leisure.Title, travel.ID, travel.Title), recorder.Body.String())
+
+	for _, url := range []string{"/budgets", "/budgets?month=2024-13", "/budgets?month=2024-03&type=transfer"} {
+		recorder = serveAs(t, server, user.ID, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, url)
+	}
+}
+
+func TestSetCopyAndDeleteBudgets(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	groceries := createTestCategory(t, store, user.ID)
+	leisure := createTestCategory(t, store, user.ID)
+
+	first := setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: groceries.ID, Month: "2024-03", Amount: &money.Money{Amount: 80000, Currency: "BRL"}})
+	replaced := setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: groceries.ID, Month: "2024-03", Amount: &money.Money{Amount: 85000, Currency: "BRL"}, Rollover: true})
+	require.Equal(t, first.ID, replaced.ID)
+	require.Equal(t, money.New(85000, "BRL"), replaced.Amount)
+	require.True(t, replaced.Rollover)
+	setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: leisure.ID, Month: "2024-03", Amount: &money.Money{Amount: 20000, Currency: "BRL"}})
+	kept := setTestBudget(t, server, user.ID, setBudgetRequest{CategoryID: leisure.ID, Month: "2024-04", Amount: &money.Money{Amount: 30000, Currency: "BRL"}})
+
+	for _, req := range []setBudgetRequest{
+		{CategoryID: groceries.ID, Month: "2024-03-01", Amount: &money.Money{Amount: 1, Currency: "BRL"}},
+		{CategoryID: groceries.ID, Month: "2024-03", Amount: &money.Money{Amount: -1, Currency: "BRL"}},
+	} {
+		recorder := serveAs(t, server, user.ID, http.MethodPost, "/budgets", req)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+	recorder := serveAs(t, server, user.ID, http.MethodPost, "/budgets", setBudgetRequest{CategoryID: 9999, Month: "2024-03", Amount: &money.Money{Amount: 1, Currency: "BRL"}})
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Leisure already has a budget for April and keeps it.
+	recorder = serveAs(t, server, user.ID, http.MethodPost, "/budgets/copy", copyBudgetsRequest{FromMonth: "2024-03", ToMonth: "2024-04"})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var copied []budgetResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &copied))
+	require.Len(t, copied, 1)
+	require.Equal(t, groceries.ID, copied[0].CategoryID)
+	require.Equal(t, "2024-04", copied[0].Month)
+	require.Equal(t, money.New(85000, "BRL"), copied[0].Amount)
+	require.True(t, copied[0].Rollover)
+	require.Equal(t, int64(30000), store.budgets[kept.ID].Amount)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPost, "/budgets/copy", copyBudgetsRequest{FromMonth: "2024-04", ToMonth: "2024-04"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	other := createTestLedgerUser(t, store)
+	createTestCategory(t, store, other.ID)
+	url := fmt.Sprintf("/budgets/%d", kept.ID)
+	recorder = serveAs(t, server, other.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, store.budgets, kept.ID)
+}
@@ -2,33 +2,34 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
-	"github.com/wil-ckaew/gofinance-backend/util"
 )
 
+var errCategoryInUse = errors.New("category in use by transactions, recurring transactions or installment plans")
+
 type createCategoryRequest struct {
-	UserID      int32  `json:"user_id" binding:"required"`
 	Title       string `json:"title" binding:"required"`
-	Type        string `json:"type" binding:"required"`
+	Type        string `json:"type" binding:"required,oneof=debit credit"`
 	Description string `json:"description" binding:"required"`
 }
 
 func (server *Server) createCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req createCategoryRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
+	member := ledgerMember(ctx)
 	arg := db.CreateCategoryParams{
-		UserID:      req.UserID,
+		LedgerID:    member.LedgerID,
+		CreatedBy:   sql.NullInt32{Int32: member.UserID, Valid: true},
 		Title:       req.Title,
 		Type:        req.Type,
 		Description: req.Description,
@@ -37,9 +38,10 @@ func (server *Server) createCategory(ctx *gin.Context) {
 	category, err := server.store.CreateCategory(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	ctx.JSON(http.StatusOK, category)
+	ctx.JSON(http.StatusOK, newCategoryResponse(category))
 }
 
 type getCategoryRequest struct {
@@ -47,17 +49,17 @@ type getCategoryRequest struct {
 }
 
 func (server *Server) getCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getCategoryRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	category, err := server.store.GetCategory(ctx, req.ID)
+	category, err := server.store.GetCategory(ctx, db.GetCategoryParams{
+		ID:       req.ID,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -67,29 +69,42 @@ func (server *Server) getCategory(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, category)
+	ctx.JSON(http.StatusOK, newCategoryResponse(category))
 }
 
 type deleteCategoryRequest struct {
 	ID int32 `uri:"id" binding:"required"`
 }
 
+// deleteCategory deletes a category of the ledger. While transactions,
+// recurring transactions or installment plans still use it, answer 409.
 func (server *Server) deleteCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req deleteCategoryRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	err = server.store.DeleteCategories(ctx, req.ID)
+	ledgerID := ledgerMember(ctx).LedgerID
+	rows, err := server.store.DeleteCategories(ctx, db.DeleteCategoriesParams{
+		ID:       req.ID,
+		LedgerID: ledgerID,
+	})
 	if err != nil {
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errCategoryInUse))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	server.recordOwnDeletion(ctx, auditEventCategoryDeleted, fmt.Sprintf("category %d in ledger %d", req.ID, ledgerID))
 
 	ctx.JSON(http.StatusOK, true)
 }
@@ -101,42 +116,40 @@ type updateCategoryRequest struct {
 }
 
 func (server *Server) updateCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req updateCategoryRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
 	arg := db.UpdateCategoriesParams{
 		ID:          req.ID,
 		Title:       req.Title,
 		Description: req.Description,
+		LedgerID:    ledgerMember(ctx).LedgerID,
 	}
 
 	category, err := server.store.UpdateCategories(ctx, arg)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	ctx.JSON(http.StatusOK, category)
+	ctx.JSON(http.StatusOK, newCategoryResponse(category))
 }
 
 type getCategoriesRequest struct {
-	UserID      int32  `json:"user_id" binding:"required"`
 	Type        string `json:"type" binding:"required"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 }
 
 func (server *Server) getCategories(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getCategoriesRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
@@ -145,7 +158,7 @@ func (server *Server) getCategories(ctx *gin.Context) {
 	}
 
 	arg := db.GetCategoriesParams{
-		UserID:      req.UserID,
+		LedgerID:    ledgerMember(ctx).LedgerID,
 		Type:        req.Type,
 		Title:       req.Title,
 		Description: req.Description,
@@ -154,7 +167,12 @@ func (server *Server) getCategories(ctx *gin.Context) {
 	categories, err := server.store.GetCategories(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	ctx.JSON(http.StatusOK, categories)
+	rsp := make([]categoryResponse, len(categories))
+	for i, category := range categories {
+		rsp[i] = newCategoryResponse(category)
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+// maxRateImportSize limits the size of an uploaded CSV file.
+const maxRateImportSize = 1 << 20
+
+var (
+	errSameCurrency   = errors.New("from_currency and to_currency must differ")
+	errInvalidRateCSV = errors.New("CSV needs the columns date, from_currency, to_currency and rate")
+	errNoRates        = errors.New("no exchange rates to import")
+)
+
+type exchangeRateRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required"`
+	ToCurrency   string `json:"to_currency" binding:"required"`
+	Date         string `json:"date" binding:"required"`
+	Rate         string `json:"rate" binding:"required"`
+}
+
+// toParams validates the rate; dates are days in dateLayout.
+func (req exchangeRateRequest) toParams() (db.UpsertExchangeRateParams, error) {
+	for _, currency := range []string{req.FromCurrency, req.ToCurrency} {
+		if !money.IsCurrency(currency) {
+			return db.UpsertExchangeRateParams{}, fmt.Errorf("%w %q", money.ErrUnknownCurrency, currency)
+		}
+	}
+	if req.FromCurrency == req.ToCurrency {
+		return db.UpsertExchangeRateParams{}, errSameCurrency
+	}
+
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		return db.UpsertExchangeRateParams{}, err
+	}
+
+	rate, err := money.ParseRate(req.Rate)
+	if err != nil {
+		return db.UpsertExchangeRateParams{}, err
+	}
+
+	return db.UpsertExchangeRateParams{
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		Date:         date,
+		Rate:         money.FormatRate(rate),
+	}, nil
+}
+
+type putExchangeRatesRequest struct {
+	Rates []exchangeRateRequest `json:"rates" binding:"required,min=1,max=1000,dive"`
+}
+
+// putExchangeRates stores the rates in the request body, replacing rates
+// already stored for the same pair and day.
+func (server *Server) putExchangeRates(ctx *gin.Context) {
+	var req putExchangeRatesRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rates := make([]db.UpsertExchangeRateParams, len(req.Rates))
+	for i, rate := range req.Rates {
+		rates[i], err = rate.toParams()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("rate %d: %w", i+1, err)))
+			return
+		}
+	}
+
+	server.importExchangeRates(ctx, rates)
+}
+
+// uploadExchangeRates imports a CSV file with a header row naming the
+// columns date, from_currency, to_currency and rate, in any order.
+func (server *Server) uploadExchangeRates(ctx *gin.Context) {
+	reader := csv.NewReader(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxRateImportSize))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidRateCSV))
+		return
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"date", "from_currency", "to_currency", "rate"} {
+		if _, ok := columns[name]; !ok {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidRateCSV))
+			return
+		}
+	}
+
+	var rates []db.UpsertExchangeRateParams
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		line, _ := reader.FieldPos(0)
+		rate, err := exchangeRateRequest{
+			FromCurrency: record[columns["from_currency"]],
+			ToCurrency:   record[columns["to_currency"]],
+			Date:         record[columns["date"]],
+			Rate:         record[columns["rate"]],
+		}.toParams()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("line %d: %w", line, err)))
+			return
+		}
+		rates = append(rates, rate)
+	}
+
+	server.importExchangeRates(ctx, rates)
+}
+
+type importExchangeRatesResponse struct {
+	Imported int `json:"imported"`
+}
+
+func (server *Server) importExchangeRates(ctx *gin.Context, rates []db.UpsertExchangeRateParams) {
+	if len(rates) == 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNoRates))
+		return
+	}
+
+	imported, err := server.store.ImportExchangeRatesTx(ctx, rates)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventExchangeRatesLoaded, fmt.Sprintf("%d rates", imported))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, importExchangeRatesResponse{Imported: imported})
+}
+
+type listExchangeRatesRequest struct {
+	Currency string `form:"currency"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+// listExchangeRates pages through the stored rates, newest first,
+// optionally only those of one currency.
+func (server *Server) listExchangeRates(ctx *gin.Context) {
+	var req listExchangeRatesRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rates, err := server.store.ListExchangeRates(ctx, db.ListExchangeRatesParams{
+		Currency:   sql.NullString{String: req.Currency, Valid: req.Currency != ""},
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]exchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		rsp[i] = newExchangeRateResponse(rate)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// uploadTestRates posts a CSV file of exchange rates with accessToken.
+func uploadTestRates(t *testing.T, server *Server, accessToken, csv string) *httptest.ResponseRecorder {
+	request, err := http.NewRequest(http.MethodPost, "/admin/exchange-rates/import", strings.NewReader(csv))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "text/csv")
+	request.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestPutExchangeRates(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, admin := loginTestAdmin(t, server, store)
+	_, user := loginTestUser(t, server)
+
+	rates := putExchangeRatesRequest{Rates: []exchangeRateRequest{
+		{FromCurrency: "USD", ToCurrency: "BRL", Date: "2024-03-01", Rate: "5.0"},
+		{FromCurrency: "EUR", ToCurrency: "BRL", Date: "2024-03-01", Rate: "5.4321"},
+	}}
+
+	status, _ := serveAsSession(t, server, user.AccessToken, http.MethodPut, "/admin/exchange-rates", rates)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Empty(t, store.rates)
+
+	status, body := serveAsSession(t, server, admin.AccessToken, http.MethodPut, "/admin/exchange-rates", rates)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `{"imported":2}`, string(body))
+	require.Len(t, store.eventsOfType(auditEventExchangeRatesLoaded), 1)
+
+	// Storing the same pair and day again replaces the rate.
+	rates.Rates = rates.Rates[:1]
+	rates.Rates[0].Rate = "5.1"
+	status, _ = serveAsSession(t, server, admin.AccessToken, http.MethodPut, "/admin/exchange-rates", rates)
+	require.Equal(t, http.StatusOK, status)
+
+	status, body = serveAsSession(t, server, admin.AccessToken, http.MethodGet, "/admin/exchange-rates?currency=USD&page_id=1&page_size=5", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[{"from_currency":"USD","to_currency":"BRL","date":"2024-03-01","rate":"5.1"}]`, string(body))
+
+	for _, rate := range []exchangeRateRequest{
+		{FromCurrency: "USD", ToCurrency: "USD", Date: "2024-03-01", Rate: "1"},
+		{FromCurrency: "USD", ToCurrency: "XYZ", Date: "2024-03-01", Rate: "1"},
+		{FromCurrency: "USD", ToCurrency: "BRL", Date: "01/03/2024", Rate: "5"},
+		{FromCurrency: "USD", ToCurrency: "BRL", Date: "2024-03-01", Rate: "0"},
+		{FromCurrency: "USD", ToCurrency: "BRL", Date: "2024-03-01", Rate: "5,1"},
+	} {
+		status, _ = serveAsSession(t, server, admin.AccessToken, http.MethodPut, "/admin/exchange-rates", putExchangeRatesRequest{
+			Rates: []exchangeRateRequest{rate},
+		})
+		require.Equal(t, http.StatusBadRequest, status, rate)
+	}
+	status, _ = serveAsSession(t, server, admin.AccessToken, http.MethodPut, "/admin/exchange-rates", putExchangeRatesRequest{})
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestUploadExchangeRates(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, admin := loginTestAdmin(t, server, store)
+	_, user := loginTestUser(t, server)
+
+	csv := "rate,date,from_currency,to_currency\n" +
+		"5.0,2024-03-01,USD,BRL\n" +
+		"5.05,2024-03-02,USD,BRL\n" +
+		"0.034,2024-03-01,JPY,BRL\n"
+
+	recorder := uploadTestRates(t, server, user.AccessToken, csv)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = uploadTestRates(t, server, admin.AccessToken, csv)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"imported":3}`, recorder.Body.String())
+	require.Len(t, store.rates, 3)
+
+	recorder = uploadTestRates(t, server, admin.AccessToken, "date,from_currency,rate\n2024-03-01,USD,5\n")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = uploadTestRates(t, server, admin.AccessToken, "date,from_currency,to_currency,rate\n")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = uploadTestRates(t, server, admin.AccessToken, "date,from_currency,to_currency,rate\n"+
+		"2024-03-03,USD,BRL,5.1\n"+
+		"2024-03-04,USD,BRL,-5\n")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "line 3")
+	require.Len(t, store.rates, 3)
+}
@@ -0,0 +1,464 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/schedule"
+)
+
+var (
+	errGoalNotFound         = errors.New("goal not found")
+	errGoalTarget           = errors.New("target_amount must be positive")
+	errGoalDeadline         = errors.New("deadline must not be before starts_on")
+	errContributionNotFound = errors.New("the transaction does not count toward the goal")
+	errContributionLinked   = errors.New("the transaction already counts toward a goal")
+	errContributionTransfer = errors.New("transfers count toward a goal through its wallet")
+)
+
+type createGoalRequest struct {
+	Title        string       `json:"title" binding:"required"`
+	TargetAmount *money.Money `json:"target_amount" binding:"required"`
+	// WalletID is the wallet the goal is saved in, if any. Transfers in
+	// and out of it count toward the goal.
+	WalletID int32  `json:"wallet_id"`
+	StartsOn string `json:"starts_on"`
+	Deadline string `json:"deadline"`
+}
+
+// createGoal adds something to save toward. It starts today unless
+// starts_on says otherwise.
+func (server *Server) createGoal(ctx *gin.Context) {
+	var req createGoalRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.TargetAmount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errGoalTarget))
+		return
+	}
+
+	startsOn := schedule.Day(time.Now())
+	if req.StartsOn != "" {
+		startsOn, err = time.Parse(dateLayout, req.StartsOn)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	deadline, err := parseGoalDeadline(req.Deadline, startsOn)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	member := ledgerMember(ctx)
+	var walletID sql.NullInt32
+	if req.WalletID != 0 {
+		wallet, ok := server.transactionWallet(ctx, req.WalletID, *req.TargetAmount)
+		if !ok {
+			return
+		}
+		walletID = sql.NullInt32{Int32: wallet.ID, Valid: true}
+	}
+
+	goal, err := server.store.CreateGoal(ctx, db.CreateGoalParams{
+		LedgerID:     member.LedgerID,
+		CreatedBy:    sql.NullInt32{Int32: member.UserID, Valid: true},
+		WalletID:     walletID,
+		Title:        req.Title,
+		TargetAmount: req.TargetAmount.Amount,
+		Currency:     req.TargetAmount.Currency,
+		StartsOn:     startsOn,
+		Deadline:     deadline,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.respondWithGoal(ctx, goal, false)
+}
+
+func parseGoalDeadline(deadline string, startsOn time.Time) (sql.NullTime, error) {
+	if deadline == "" {
+		return sql.NullTime{}, nil
+	}
+	date, err := time.Parse(dateLayout, deadline)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	if date.Before(startsOn) {
+		return sql.NullTime{}, errGoalDeadline
+	}
+	return sql.NullTime{Time: date, Valid: true}, nil
+}
+
+// goalResponse loads what was put toward goal and works out its
+// progress as of today.
+func (server *Server) goalResponse(ctx *gin.Context, goal db.Goal, withContributions bool) (goalResponse, error) {
+	contributions, err := server.store.ListGoalContributions(ctx, db.ListGoalContributionsParams{
+		GoalID:   goal.ID,
+		WalletID: goal.WalletID.Int32,
+		StartsOn: goal.StartsOn,
+	})
+	if err != nil {
+		return goalResponse{}, err
+	}
+
+	rsp := newGoalResponse(goal)
+	rsp.Progress = newGoalProgress(goal, contributions, schedule.Day(time.Now()))
+	if withContributions {
+		rsp.Contributions = make([]goalContributionResponse, len(contributions))
+		for i, contribution := range contributions {
+			rsp.Contributions[i] = newGoalContributionResponse(contribution, goal.Currency)
+		}
+	}
+	return rsp, nil
+}
+
+func (server *Server) respondWithGoal(ctx *gin.Context, goal db.Goal, withContributions bool) {
+	rsp, err := server.goalResponse(ctx, goal, withContributions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// newGoalProgress works out how far goal is on today. The average
+// monthly contribution is what was saved over the months from starts_on
+// through today; the projected completion is when saving at that pace
+// reaches the target.
+func newGoalProgress(goal db.Goal, contributions []db.ListGoalContributionsRow, today time.Time) goalProgressResponse {
+	target := goal.TargetAmount
+	var saved int64
+	var completedOn *string
+	for _, contribution := range contributions {
+		reached := saved >= target
+		saved += contribution.Amount
+		if saved < target {
+			completedOn = nil
+		} else if !reached {
+			date := contribution.Date.Format(dateLayout)
+			completedOn = &date
+		}
+	}
+
+	remaining := target - saved
+	if remaining < 0 {
+		remaining = 0
+	}
+	percent := saved * 100 / target
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+
+	elapsed := monthsBetween(goal.StartsOn, today) + 1
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	average := saved / int64(elapsed)
+
+	rsp := goalProgressResponse{
+		Saved:          money.New(saved, goal.Currency),
+		Remaining:      money.New(remaining, goal.Currency),
+		Percent:        int(percent),
+		AverageMonthly: money.New(average, goal.Currency),
+		CompletedOn:    completedOn,
+	}
+	if remaining == 0 {
+		if goal.Deadline.Valid {
+			onTrack := true
+			rsp.OnTrack = &onTrack
+		}
+		return rsp
+	}
+
+	var projected time.Time
+	if average > 0 {
+		months := (remaining + average - 1) / average
+		projected = today.AddDate(0, int(months), 0)
+		date := projected.Format(dateLayout)
+		rsp.ProjectedCompletion = &date
+	}
+	if goal.Deadline.Valid {
+		left := monthsBetween(today, goal.Deadline.Time) + 1
+		if left < 1 {
+			left = 1
+		}
+		needed := money.New((remaining+int64(left)-1)/int64(left), goal.Currency)
+		rsp.MonthlyNeeded = &needed
+
+		onTrack := average > 0 && !projected.After(goal.Deadline.Time)
+		rsp.OnTrack = &onTrack
+	}
+	return rsp
+}
+
+// monthsBetween counts the calendar months from the month of from to the
+// month of to.
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+func (server *Server) listGoals(ctx *gin.Context) {
+	goals, err := server.store.ListGoals(ctx, ledgerMember(ctx).LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]goalResponse, len(goals))
+	for i, goal := range goals {
+		rsp[i], err = server.goalResponse(ctx, goal, false)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type goalURI struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// ledgerGoal loads a goal of the request's ledger, answering 404 for
+// those of other ledgers.
+func (server *Server) ledgerGoal(ctx *gin.Context, id int32) (db.Goal, bool) {
+	goal, err := server.store.GetGoal(ctx, db.GetGoalParams{
+		ID:       id,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errGoalNotFound))
+			return db.Goal{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Goal{}, false
+	}
+	return goal, true
+}
+
+// getGoal returns a goal with its progress and everything put toward it.
+func (server *Server) getGoal(ctx *gin.Context) {
+	var uri goalURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	goal, ok := server.ledgerGoal(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	server.respondWithGoal(ctx, goal, true)
+}
+
+type updateGoalRequest struct {
+	Title        string       `json:"title" binding:"required"`
+	TargetAmount *money.Money `json:"target_amount" binding:"required"`
+	Deadline     string       `json:"deadline"`
+}
+
+// updateGoal changes the target and deadline of a goal. Its currency,
+// wallet and start stay.
+func (server *Server) updateGoal(ctx *gin.Context) {
+	var uri goalURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateGoalRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.TargetAmount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errGoalTarget))
+		return
+	}
+
+	goal, ok := server.ledgerGoal(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	if !goalCurrency(ctx, goal, req.TargetAmount.Currency) {
+		return
+	}
+	deadline, err := parseGoalDeadline(req.Deadline, goal.StartsOn)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	goal, err = server.store.UpdateGoal(ctx, db.UpdateGoalParams{
+		ID:           goal.ID,
+		Title:        req.Title,
+		TargetAmount: req.TargetAmount.Amount,
+		Deadline:     deadline,
+		LedgerID:     goal.LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errGoalNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.respondWithGoal(ctx, goal, false)
+}
+
+// goalCurrency checks that an amount in currency can count toward goal.
+func goalCurrency(ctx *gin.Context, goal db.Goal, currency string) bool {
+	if currency != goal.Currency {
+		err := fmt.Errorf("%w: goal %d is in %s", money.ErrCurrencyMismatch, goal.ID, goal.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// deleteGoal removes a goal. The transactions and transfers that counted
+// toward it are kept.
+func (server *Server) deleteGoal(ctx *gin.Context) {
+	var uri goalURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	rows, err := server.store.DeleteGoal(ctx, db.DeleteGoalParams{
+		ID:       uri.ID,
+		LedgerID: ledgerID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errGoalNotFound))
+		return
+	}
+
+	server.recordOwnDeletion(ctx, auditEventGoalDeleted, fmt.Sprintf("goal %d in ledger %d", uri.ID, ledgerID))
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type linkContributionRequest struct {
+	AccountID int32 `json:"account_id" binding:"required,min=1"`
+}
+
+// linkContribution counts a transaction, such as a deposit into a
+// savings account kept elsewhere, toward a goal.
+func (server *Server) linkContribution(ctx *gin.Context) {
+	var uri goalURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req linkContributionRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	goal, ok := server.ledgerGoal(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	account, err := server.store.GetAccount(ctx, db.GetAccountParams{
+		ID:       req.AccountID,
+		LedgerID: goal.LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.TransferID.Valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errContributionTransfer))
+		return
+	}
+	if !goalCurrency(ctx, goal, account.Currency) {
+		return
+	}
+
+	_, err = server.store.LinkGoalContribution(ctx, db.LinkGoalContributionParams{
+		GoalID:    goal.ID,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errContributionLinked))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.respondWithGoal(ctx, goal, true)
+}
+
+type contributionURI struct {
+	ID        int32 `uri:"id" binding:"required,min=1"`
+	AccountID int32 `uri:"account_id" binding:"required,min=1"`
+}
+
+// unlinkContribution stops counting a transaction toward a goal. The
+// transaction itself is kept.
+func (server *Server) unlinkContribution(ctx *gin.Context) {
+	var uri contributionURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	goal, ok := server.ledgerGoal(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	rows, err := server.store.UnlinkGoalContribution(ctx, db.UnlinkGoalContributionParams{
+		GoalID:    goal.ID,
+		AccountID: uri.AccountID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errContributionNotFound))
+		return
+	}
+
+	server.respondWithGoal(ctx, goal, true)
+}
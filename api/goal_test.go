@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+func TestGoalProgress(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	contribution := func(date time.Time, amount int64) db.ListGoalContributionsRow {
+		return db.ListGoalContributionsRow{Date: date, Amount: amount}
+	}
+	goal := db.Goal{
+		TargetAmount: 120000,
+		Currency:     "BRL",
+		StartsOn:     day(1, 1),
+		Deadline:     sql.NullTime{Time: day(12, 31), Valid: true},
+	}
+	saved := []db.ListGoalContributionsRow{
+		contribution(day(1, 10), 10000),
+		contribution(day(2, 10), 20000),
+		contribution(day(3, 10), 30000),
+	}
+	today := day(3, 15)
+
+	// 600 in three months is 200 a month, which takes three more months
+	// for the other 600; the deadline leaves ten, at 60 a month.
+	progress := newGoalProgress(goal, saved, today)
+	require.Equal(t, money.New(60000, "BRL"), progress.Saved)
+	require.Equal(t, money.New(60000, "BRL"), progress.Remaining)
+	require.Equal(t, 50, progress.Percent)
+	require.Equal(t, money.New(20000, "BRL"), progress.AverageMonthly)
+	require.Equal(t, "2024-06-15", *progress.ProjectedCompletion)
+	require.Equal(t, money.New(6000, "BRL"), *progress.MonthlyNeeded)
+	require.True(t, *progress.OnTrack)
+	require.Nil(t, progress.CompletedOn)
+
+	goal.Deadline.Time = day(4, 30)
+	progress = newGoalProgress(goal, saved, today)
+	require.Equal(t, money.New(30000, "BRL"), *progress.MonthlyNeeded)
+	require.False(t, *progress.OnTrack)
+
+	// Without a deadline there is nothing to be on track for.
+	progress = newGoalProgress(db.Goal{TargetAmount: 120000, Currency: "BRL", StartsOn: day(1, 1)}, nil, today)
+	require.Nil(t, progress.ProjectedCompletion)
+	require.Nil(t, progress.MonthlyNeeded)
+	require.Nil(t, progress.OnTrack)
+
+	// A goal is complete from the day it last reached the target.
+	saved = append(saved,
+		contribution(day(3, 11), 60000),
+		contribution(day(3, 12), -1000),
+		contribution(day(3, 13), 2000),
+	)
+	progress = newGoalProgress(goal, saved, today)
+	require.Equal(t, 100, progress.Percent)
+	require.Zero(t, progress.Remaining.Amount)
+	require.Equal(t, "2024-03-13", *progress.CompletedOn)
+	require.Nil(t, progress.ProjectedCompletion)
+	require.True(t, *progress.OnTrack)
+}
+
+func TestGoalContributions(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	checking := createTestWallet(t, store, category.LedgerID, "BRL")
+	savings := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	recorder := serveAs(t, server, user.ID, http.MethodPost, "/goals", createGoalRequest{
+		Title:        "Trip",
+		TargetAmount: &money.Money{Amount: 500000, Currency: "BRL"},
+		WalletID:     savings.ID,
+		StartsOn:     "2024-01-01",
+		Deadline:     "2030-12-31",
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var goal goalResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &goal))
+	require.Equal(t, savings.ID, *goal.WalletID)
+	require.Zero(t, goal.Progress.Saved.Amount)
+
+	for _, req := range []createGoalRequest{
+		{Title: "Trip", TargetAmount: &money.Money{Amount: 0, Currency: "BRL"}},
+		{Title: "Trip", TargetAmount: &money.Money{Amount: 1, Currency: "USD"}, WalletID: savings.ID},
+		{Title: "Trip", TargetAmount: &money.Money{Amount: 1, Currency: "BRL"}, StartsOn: "2024-01-01", Deadline: "2023-12-31"},
+	} {
+		recorder = serveAs(t, server, user.ID, http.MethodPost, "/goals", req)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, recorder.Body.String())
+	}
+
+	// Transfers into the goal's wallet count toward it, those out of it
+	// count against it.
+	transfer := func(from, to db.Wallet, amount int64) db.TransferTxResult {
+		result, err := store.CreateTransferTx(context.Background(), db.CreateTransferTxParams{
+			LedgerID: category.LedgerID,
+			Title:    "Savings",
+			Date:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			From:     db.TransferLegParams{WalletID: from.ID, Amount: amount, Currency: "BRL"},
+			To:       db.TransferLegParams{WalletID: to.ID, Amount: amount, Currency: "BRL"},
+		})
+		require.NoError(t, err)
+		return result
+	}
+	in := transfer(checking, savings, 100000)
+	transfer(savings, checking, 20000)
+
+	deposit, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		LedgerID:   category.LedgerID,
+		CategoryID: sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:      "Deposit",
+		Type:       category.Type,
+		Amount:     30000,
+		Currency:   "BRL",
+		Date:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		WalletID:   checking.ID,
+	})
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("/goals/%d/contributions", goal.ID)
+	recorder = serveAs(t, server, user.ID, http.MethodPost, url, linkContributionRequest{AccountID: deposit.ID})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &goal))
+	require.Equal(t, money.New(110000, "BRL"), goal.Progress.Saved)
+	require.Len(t, goal.Contributions, 3)
+	require.True(t, goal.Contributions[1].Transfer)
+	require.Equal(t, money.New(-20000, "BRL"), goal.Contributions[1].Amount)
+	require.Equal(t, deposit.ID, goal.Contributions[2].AccountID)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPost, url, linkContributionRequest{AccountID: deposit.ID})
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodPost, url, linkContributionRequest{AccountID: in.From.ID})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodPost, url, linkContributionRequest{AccountID: 9999})
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, fmt.Sprintf("%s/%d", url, deposit.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, fmt.Sprintf("%s/%d", url, deposit.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, "/goals", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var goals []goalResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &goals))
+	require.Len(t, goals, 1)
+	require.Equal(t, money.New(80000, "BRL"), goals[0].Progress.Saved)
+	require.Empty(t, goals[0].Contributions)
+}
+
+func TestUpdateAndDeleteGoal(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	createTestCategory(t, store, user.ID)
+
+	recorder := serveAs(t, server, user.ID, http.MethodPost, "/goals", createGoalRequest{
+		Title:        "Emergency fund",
+		TargetAmount: &money.Money{Amount: 1000000, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var goal goalResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &goal))
+	require.Nil(t, goal.Deadline)
+	require.Nil(t, goal.Progress.OnTrack)
+
+	url := fmt.Sprintf("/goals/%d", goal.ID)
+	recorder = serveAs(t, server, user.ID, http.MethodPut, url, updateGoalRequest{
+		Title:        "Emergency fund",
+		TargetAmount: &money.Money{Amount: 1500000, Currency: "BRL"},
+		Deadline:     "2099-12-31",
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &goal))
+	require.Equal(t, money.New(1500000, "BRL"), goal.TargetAmount)
+	require.Equal(t, "2099-12-31", *goal.Deadline)
+	require.False(t, *goal.Progress.OnTrack)
+	require.NotNil(t, goal.Progress.MonthlyNeeded)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPut, url, updateGoalRequest{
+		Title:        "Emergency fund",
+		TargetAmount: &money.Money{Amount: 1500000, Currency: "USD"},
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	other := createTestLedgerUser(t, store)
+	createTestCategory(t, store, other.ID)
+	recorder = serveAs(t, server, other.ID, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, store.eventsOfType(auditEventGoalDeleted), 1)
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
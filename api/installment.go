@@ -0,0 +1,251 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/schedule"
+)
+
+var errInstallmentPlanNotFound = errors.New("installment plan not found")
+
+type createInstallmentPlanRequest struct {
+	CategoryID   int32        `json:"category_id" binding:"required"`
+	WalletID     int32        `json:"wallet_id" binding:"required"`
+	Title        string       `json:"title" binding:"required"`
+	Description  string       `json:"description"`
+	TotalAmount  *money.Money `json:"total_amount" binding:"required"`
+	Installments int32        `json:"installments" binding:"required,min=1,max=120"`
+	FirstDate    string       `json:"first_date" binding:"required"`
+	// Remainder is the installment that takes the cents left over by
+	// the split: "first", the default, or "last".
+	Remainder string `json:"remainder" binding:"omitempty,oneof=first last"`
+}
+
+// createInstallmentPlan records a purchase paid in installments
+// ("parcelado"): total_amount split into one transaction a month from
+// first_date. Its type is the type of its category.
+func (server *Server) createInstallmentPlan(ctx *gin.Context) {
+	var req createInstallmentPlanRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	firstDate, err := time.Parse(dateLayout, req.FirstDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !installmentTotal(ctx, *req.TotalAmount, req.Installments) {
+		return
+	}
+	if req.Remainder == "" {
+		req.Remainder = "first"
+	}
+
+	member := ledgerMember(ctx)
+	category, err := server.store.GetCategory(ctx, db.GetCategoryParams{
+		ID:       req.CategoryID,
+		LedgerID: member.LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	wallet, ok := server.transactionWallet(ctx, req.WalletID, *req.TotalAmount)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.CreateInstallmentPlanTx(ctx, db.CreateInstallmentPlanParams{
+		LedgerID:     member.LedgerID,
+		CreatedBy:    sql.NullInt32{Int32: member.UserID, Valid: true},
+		CategoryID:   category.ID,
+		WalletID:     wallet.ID,
+		Title:        req.Title,
+		Type:         category.Type,
+		Description:  req.Description,
+		TotalAmount:  req.TotalAmount.Amount,
+		Currency:     wallet.Currency,
+		Installments: req.Installments,
+		FirstDate:    firstDate,
+		Remainder:    req.Remainder,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanTxResponse(result))
+}
+
+// installmentTotal checks that total leaves at least a cent for each of
+// installments.
+func installmentTotal(ctx *gin.Context, total money.Money, installments int32) bool {
+	if total.Amount < int64(installments) {
+		err := fmt.Errorf("total_amount cannot be split into %d installments", installments)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+func (server *Server) listInstallmentPlans(ctx *gin.Context) {
+	plans, err := server.store.ListInstallmentPlans(ctx, ledgerMember(ctx).LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]installmentPlanResponse, len(plans))
+	for i, plan := range plans {
+		rsp[i] = newInstallmentPlanResponse(plan)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type installmentPlanURI struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getInstallmentPlan(ctx *gin.Context) {
+	var uri installmentPlanURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.GetInstallmentPlanTx(ctx, db.GetInstallmentPlanParams{
+		ID:       uri.ID,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errInstallmentPlanNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanTxResponse(result))
+}
+
+type updateInstallmentPlanRequest struct {
+	Title        string       `json:"title" binding:"required"`
+	Description  string       `json:"description"`
+	TotalAmount  *money.Money `json:"total_amount" binding:"required"`
+	Installments int32        `json:"installments" binding:"required,min=1,max=120"`
+}
+
+// updateInstallmentPlan changes a plan and all of its installments still
+// to come together. Installments due by today keep their amounts and
+// titles, and the rest of the total is split again over the others. Its
+// category, wallet and dates stay; cancel the plan and create a new one
+// to change them.
+func (server *Server) updateInstallmentPlan(ctx *gin.Context) {
+	var uri installmentPlanURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateInstallmentPlanRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !installmentTotal(ctx, *req.TotalAmount, req.Installments) {
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	plan, err := server.store.GetInstallmentPlan(ctx, db.GetInstallmentPlanParams{ID: uri.ID, LedgerID: ledgerID})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errInstallmentPlanNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if req.TotalAmount.Currency != plan.Currency {
+		err := fmt.Errorf("%w: installment plan %d is in %s", money.ErrCurrencyMismatch, plan.ID, plan.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.UpdateInstallmentPlanTx(ctx, db.UpdateInstallmentPlanTxParams{
+		ID:           plan.ID,
+		LedgerID:     ledgerID,
+		Title:        req.Title,
+		Description:  req.Description,
+		TotalAmount:  req.TotalAmount.Amount,
+		Installments: req.Installments,
+		Today:        schedule.Day(time.Now()),
+	})
+	if err != nil {
+		installmentPlanTxError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanTxResponse(result))
+}
+
+// cancelInstallmentPlan deletes the installments of a plan still to come,
+// as when the purchase is returned. Installments due by today are kept.
+func (server *Server) cancelInstallmentPlan(ctx *gin.Context) {
+	var uri installmentPlanURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	result, err := server.store.CancelInstallmentPlanTx(ctx, db.CancelInstallmentPlanTxParams{
+		ID:       uri.ID,
+		LedgerID: ledgerID,
+		Today:    schedule.Day(time.Now()),
+	})
+	if err != nil {
+		installmentPlanTxError(ctx, err)
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventInstallmentCancelled, fmt.Sprintf("installment plan %d in ledger %d", uri.ID, ledgerID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanTxResponse(result))
+}
+
+// installmentPlanTxError answers a failed change to an installment plan.
+func installmentPlanTxError(ctx *gin.Context, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, errorResponse(errInstallmentPlanNotFound))
+	case errors.Is(err, db.ErrInstallmentPlanCancelled), errors.Is(err, db.ErrInstallmentsDue):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
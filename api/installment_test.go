@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/schedule"
+)
+
+func createTestInstallmentPlan(t *testing.T, server *Server, userID int32, req createInstallmentPlanRequest) installmentPlanResponse {
+	recorder := serveAs(t, server, userID, http.MethodPost, "/installments", req)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var plan installmentPlanResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &plan))
+	return plan
+}
+
+func TestCreateInstallmentPlan(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	req := createInstallmentPlanRequest{
+		CategoryID:   category.ID,
+		WalletID:     wallet.ID,
+		Title:        "Laptop",
+		TotalAmount:  &money.Money{Amount: 100000, Currency: "BRL"},
+		Installments: 3,
+		FirstDate:    "2024-01-10",
+	}
+	plan := createTestInstallmentPlan(t, server, user.ID, req)
+	require.Equal(t, "first", plan.Remainder)
+	require.Equal(t, category.Type, plan.Type)
+	require.Len(t, plan.Accounts, 3)
+	require.Equal(t, money.New(33334, "BRL"), plan.Accounts[0].Amount)
+	require.Equal(t, money.New(33333, "BRL"), plan.Accounts[2].Amount)
+	require.Equal(t, "Laptop (3/3)", plan.Accounts[2].Title)
+
+	req.Remainder = "last"
+	plan = createTestInstallmentPlan(t, server, user.ID, req)
+	require.Equal(t, money.New(33334, "BRL"), plan.Accounts[2].Amount)
+
+	for _, tc := range []struct {
+		change func(req *createInstallmentPlanRequest)
+		status int
+	}{
+		{func(req *createInstallmentPlanRequest) { req.Installments = 0 }, http.StatusBadRequest},
+		{func(req *createInstallmentPlanRequest) { req.Remainder = "middle" }, http.StatusBadRequest},
+		{func(req *createInstallmentPlanRequest) { req.FirstDate = "10/01/2024" }, http.StatusBadRequest},
+		{func(req *createInstallmentPlanRequest) { req.TotalAmount = &money.Money{Amount: 2, Currency: "BRL"} }, http.StatusBadRequest},
+		{func(req *createInstallmentPlanRequest) { req.TotalAmount = &money.Money{Amount: 100, Currency: "USD"} }, http.StatusBadRequest},
+		{func(req *createInstallmentPlanRequest) { req.WalletID = 9999 }, http.StatusNotFound},
+		{func(req *createInstallmentPlanRequest) { req.CategoryID = 9999 }, http.StatusNotFound},
+	} {
+		bad := req
+		tc.change(&bad)
+		recorder := serveAs(t, server, user.ID, http.MethodPost, "/installments", bad)
+		require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+	}
+	require.Len(t, store.plans, 2)
+
+	recorder := serveAs(t, server, user.ID, http.MethodGet, "/installments", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var plans []installmentPlanResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &plans))
+	require.Len(t, plans, 2)
+	require.Empty(t, plans[0].Accounts)
+
+	other := createTestLedgerUser(t, store)
+	createTestCategory(t, store, other.ID)
+	recorder = serveAs(t, server, other.ID, http.MethodGet, fmt.Sprintf("/installments/%d", plan.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestUpdateAndCancelInstallmentPlan(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	// Two of the installments are due by today.
+	firstDate := schedule.Day(time.Now()).AddDate(0, 0, -40)
+	plan := createTestInstallmentPlan(t, server, user.ID, createInstallmentPlanRequest{
+		CategoryID:   category.ID,
+		WalletID:     wallet.ID,
+		Title:        "Sofa",
+		TotalAmount:  &money.Money{Amount: 120000, Currency: "BRL"},
+		Installments: 4,
+		FirstDate:    firstDate.Format(dateLayout),
+	})
+
+	url := fmt.Sprintf("/installments/%d", plan.ID)
+	recorder := serveAs(t, server, user.ID, http.MethodPut, url, updateInstallmentPlanRequest{
+		Title:        "Sofa",
+		TotalAmount:  &money.Money{Amount: 150000, Currency: "BRL"},
+		Installments: 5,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var updated installmentPlanResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &updated))
+	require.Len(t, updated.Accounts, 5)
+	require.Equal(t, money.New(30000, "BRL"), updated.Accounts[1].Amount)
+	require.Equal(t, money.New(30000, "BRL"), updated.Accounts[4].Amount)
+	require.Equal(t, "Sofa (2/4)", updated.Accounts[1].Title)
+	require.Equal(t, "Sofa (5/5)", updated.Accounts[4].Title)
+
+	for _, req := range []updateInstallmentPlanRequest{
+		{Title: "Sofa", TotalAmount: &money.Money{Amount: 150000, Currency: "BRL"}, Installments: 2},
+		{Title: "Sofa", TotalAmount: &money.Money{Amount: 60000, Currency: "BRL"}, Installments: 4},
+	} {
+		recorder = serveAs(t, server, user.ID, http.MethodPut, url, req)
+		require.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+	}
+	recorder = serveAs(t, server, user.ID, http.MethodPut, url, updateInstallmentPlanRequest{
+		Title:        "Sofa",
+		TotalAmount:  &money.Money{Amount: 150000, Currency: "USD"},
+		Installments: 4,
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPost, url+"/cancel", nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var cancelled installmentPlanResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &cancelled))
+	require.NotNil(t, cancelled.CancelledAt)
+	require.Len(t, cancelled.Accounts, 2)
+	require.NotContains(t, store.accounts, updated.Accounts[4].ID)
+	require.Len(t, store.eventsOfType(auditEventInstallmentCancelled), 1)
+
+	// Cancelled plans keep their category in use.
+	for _, account := range cancelled.Accounts {
+		delete(store.accounts, account.ID)
+	}
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, fmt.Sprintf("/category/%d", category.ID), nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPost, url+"/cancel", nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodPut, url, updateInstallmentPlanRequest{
+		Title:        "Sofa",
+		TotalAmount:  &money.Money{Amount: 150000, Currency: "BRL"},
+		Installments: 5,
+	})
+	require.Equal(t, http.StatusConflict, recorder.Code)
+}
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/mail"
+	"github.com/wil-ckaew/gofinance-backend/token"
+)
+
+var (
+	errLedgerMemberNotFound    = errors.New("ledger member not found")
+	errLedgerOwnerFixed        = errors.New("the owner of a ledger cannot leave it or change role")
+	errAlreadyLedgerMember     = errors.New("user is already a member of this ledger")
+	errInvitationNotFound      = errors.New("invitation not found")
+	errInvalidInvitation       = errors.New("invitation is invalid or expired")
+	errInvitationForOtherEmail = errors.New("invitation was sent to another email address")
+)
+
+type createLedgerRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// createLedger creates a shared ledger owned by the caller. Categories and
+// accounts are created in it by passing its id as ledger_id.
+func (server *Server) createLedger(ctx *gin.Context) {
+	var req createLedgerRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ledger, err := server.store.CreateLedgerTx(ctx, db.CreateLedgerParams{
+		Name:    req.Name,
+		OwnerID: authClaims(ctx).UserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventLedgerCreated, fmt.Sprintf("ledger %d", ledger.ID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newLedgerResponse(ledger, db.LedgerRoleOwner))
+}
+
+// listLedgers returns the ledgers the caller is a member of, starting
+// with their personal one.
+func (server *Server) listLedgers(ctx *gin.Context) {
+	userID := authClaims(ctx).UserID
+	_, err := server.store.EnsurePersonalLedgerTx(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ledgers, err := server.store.ListUserLedgers(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]ledgerResponse, len(ledgers))
+	for i, ledger := range ledgers {
+		rsp[i] = newLedgerListItemResponse(ledger)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) listLedgerMembers(ctx *gin.Context) {
+	members, err := server.store.ListLedgerMembers(ctx, ledgerMember(ctx).LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]ledgerMemberResponse, len(members))
+	for i, member := range members {
+		rsp[i] = newLedgerMemberResponse(member)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type ledgerMemberURI struct {
+	UserID int32 `uri:"user_id" binding:"required,min=1"`
+}
+
+type updateLedgerMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+// updateLedgerMemberRole lets the owner switch members between editor and
+// viewer.
+func (server *Server) updateLedgerMemberRole(ctx *gin.Context) {
+	var uri ledgerMemberURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateLedgerMemberRoleRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	owner := ledgerMember(ctx)
+	if uri.UserID == owner.UserID {
+		ctx.JSON(http.StatusConflict, errorResponse(errLedgerOwnerFixed))
+		return
+	}
+
+	member, err := server.store.UpdateLedgerMemberRole(ctx, db.UpdateLedgerMemberRoleParams{
+		LedgerID: owner.LedgerID,
+		UserID:   uri.UserID,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errLedgerMemberNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordLedgerMemberEvent(ctx, auditEventLedgerMemberRole, member.UserID,
+		fmt.Sprintf("%s in ledger %d", member.Role, member.LedgerID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+// removeLedgerMember removes a member from the ledger. The owner may remove
+// anyone else; other members may only leave themselves.
+func (server *Server) removeLedgerMember(ctx *gin.Context) {
+	var uri ledgerMemberURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	caller := ledgerMember(ctx)
+	if uri.UserID == caller.UserID && caller.Role == db.LedgerRoleOwner {
+		ctx.JSON(http.StatusConflict, errorResponse(errLedgerOwnerFixed))
+		return
+	}
+	if uri.UserID != caller.UserID && caller.Role != db.LedgerRoleOwner {
+		ctx.JSON(http.StatusForbidden, errorResponse(errPermissionDenied))
+		return
+	}
+
+	rows, err := server.store.DeleteLedgerMember(ctx, db.DeleteLedgerMemberParams{
+		LedgerID: caller.LedgerID,
+		UserID:   uri.UserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errLedgerMemberNotFound))
+		return
+	}
+
+	err = server.recordLedgerMemberEvent(ctx, auditEventLedgerMemberRemoved, uri.UserID,
+		fmt.Sprintf("ledger %d", caller.LedgerID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+// recordLedgerMemberEvent audits a change the caller made to the membership
+// of userID.
+func (server *Server) recordLedgerMemberEvent(ctx *gin.Context, eventType string, userID int32, details string) error {
+	user, err := server.store.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return server.recordAuditEvent(ctx, auditEvent{
+		Type:     eventType,
+		Outcome:  db.AuditOutcomeSuccess,
+		UserID:   user.ID,
+		Username: user.Username,
+		Details:  details,
+	})
+}
+
+type createLedgerInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+// createLedgerInvitation emails a single-use link that adds whoever signs
+// in with that email to the ledger, see acceptLedgerInvitation.
+func (server *Server) createLedgerInvitation(ctx *gin.Context) {
+	var req createLedgerInvitationRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ledger, err := server.store.GetLedger(ctx, ledgerMember(ctx).LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	invitee, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err == nil {
+		_, err = server.store.GetLedgerMember(ctx, db.GetLedgerMemberParams{
+			LedgerID: ledger.ID,
+			UserID:   invitee.ID,
+		})
+		if err == nil {
+			ctx.JSON(http.StatusConflict, errorResponse(errAlreadyLedgerMember))
+			return
+		}
+	}
+	if err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	inviteToken, inviteHash, err := token.NewOpaqueToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	claims := authClaims(ctx)
+	invitation, err := server.store.CreateLedgerInvitation(ctx, db.CreateLedgerInvitationParams{
+		LedgerID:  ledger.ID,
+		Email:     req.Email,
+		Role:      req.Role,
+		TokenHash: inviteHash,
+		InvitedBy: claims.UserID,
+		ExpiresAt: time.Now().Add(server.config.LedgerInvitationTTL),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventLedgerMemberInvited,
+		fmt.Sprintf("%s as %s in ledger %d", invitation.Email, invitation.Role, ledger.ID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.mailer.Send(ctx, mail.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("%s shared a GoFinance ledger with you", claims.Username),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to the ledger %q as %s. Sign in with this email address and use the link below to join it. It expires in %s and can only be used once.\n\n%s/invitations/accept?token=%s\n\nIf you do not know %s you can ignore this email.",
+			claims.Username, ledger.Name, invitation.Role, server.config.LedgerInvitationTTL, server.config.AppURL, url.QueryEscape(inviteToken), claims.Username),
+	})
+	if err != nil {
+		log.Printf("cannot send invitation %d for ledger %d: %v", invitation.ID, ledger.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, newLedgerInvitationResponse(invitation))
+}
+
+// listLedgerInvitations returns the invitations that can still be accepted.
+func (server *Server) listLedgerInvitations(ctx *gin.Context) {
+	invitations, err := server.store.ListLedgerInvitations(ctx, ledgerMember(ctx).LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]ledgerInvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		rsp[i] = newLedgerInvitationResponse(invitation)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokeLedgerInvitationRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) revokeLedgerInvitation(ctx *gin.Context) {
+	var req revokeLedgerInvitationRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	rows, err := server.store.DeleteLedgerInvitation(ctx, db.DeleteLedgerInvitationParams{
+		ID:       req.ID,
+		LedgerID: ledgerID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errInvitationNotFound))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventLedgerInviteRevoked, fmt.Sprintf("invitation %d in ledger %d", req.ID, ledgerID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type acceptLedgerInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// acceptLedgerInvitation adds the caller to the ledger of an invitation
+// sent to their email address. Members who accept another invitation to
+// the same ledger keep their role.
+func (server *Server) acceptLedgerInvitation(ctx *gin.Context) {
+	var req acceptLedgerInvitationRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	invitation, err := server.store.GetLedgerInvitationByToken(ctx, token.HashOpaqueToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidInvitation))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if invitation.AcceptedAt.Valid || time.Now().After(invitation.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidInvitation))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errInvitationForOtherEmail))
+		return
+	}
+
+	member, err := server.store.AcceptLedgerInvitationTx(ctx, db.AcceptLedgerInvitationTxParams{
+		InvitationID: invitation.ID,
+		LedgerID:     invitation.LedgerID,
+		UserID:       user.ID,
+		Role:         invitation.Role,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrLedgerInvitationUsed) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidInvitation))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ledger, err := server.store.GetLedger(ctx, member.LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventLedgerMemberJoined,
+		fmt.Sprintf("%s in ledger %d", member.Role, member.LedgerID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newLedgerResponse(ledger, member.Role))
+}
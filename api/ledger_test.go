@@ -0,0 +1,253 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+var invitationLinkRegexp = regexp.MustCompile(`/invitations/accept\?token=(\S+)`)
+
+func invitationTokenFromMailbox(t *testing.T, mailbox *bytes.Buffer) string {
+	matches := invitationLinkRegexp.FindAllStringSubmatch(mailbox.String(), -1)
+	require.NotEmpty(t, matches)
+	inviteToken, err := url.QueryUnescape(matches[len(matches)-1][1])
+	require.NoError(t, err)
+	return inviteToken
+}
+
+func createTestLedgerUser(t *testing.T, store *fakeStore) db.User {
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username: util.RandomString(6),
+		Email:    util.RandomEmail(8),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func createTestLedger(t *testing.T, server *Server, ownerID int32) ledgerResponse {
+	recorder := serveAs(t, server, ownerID, http.MethodPost, "/ledgers", createLedgerRequest{Name: "Household"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var ledger ledgerResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &ledger))
+	return ledger
+}
+
+// inviteTestMember invites user to the ledger with role and accepts the
+// invitation on their behalf.
+func inviteTestMember(t *testing.T, server *Server, mailbox *bytes.Buffer, ownerID int32, ledgerID int32, user db.User, role string) {
+	recorder := serveAs(t, server, ownerID, http.MethodPost, fmt.Sprintf("/ledgers/%d/invitations", ledgerID),
+		createLedgerInvitationRequest{Email: user.Email, Role: role})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPost, "/invitations/accept",
+		acceptLedgerInvitationRequest{Token: invitationTokenFromMailbox(t, mailbox)})
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestCreateAndListLedgers(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	owner := createTestLedgerUser(t, store)
+
+	ledger := createTestLedger(t, server, owner.ID)
+	require.Equal(t, "Household", ledger.Name)
+	require.Equal(t, db.LedgerRoleOwner, ledger.Role)
+	require.False(t, ledger.Personal)
+
+	recorder := serveAs(t, server, owner.ID, http.MethodGet, "/ledgers", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var ledgers []ledgerResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &ledgers))
+	require.Len(t, ledgers, 2)
+	require.Equal(t, ledger.ID, ledgers[0].ID)
+	require.True(t, ledgers[1].Personal)
+	require.Equal(t, db.PersonalLedgerName, ledgers[1].Name)
+	require.Len(t, store.eventsOfType(auditEventLedgerCreated), 1)
+}
+
+func TestLedgerInvitationFlow(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	owner := createTestLedgerUser(t, store)
+	editor := createTestLedgerUser(t, store)
+	ledger := createTestLedger(t, server, owner.ID)
+
+	recorder := serveAs(t, server, owner.ID, http.MethodPost, fmt.Sprintf("/ledgers/%d/invitations", ledger.ID),
+		createLedgerInvitationRequest{Email: editor.Email, Role: db.LedgerRoleEditor})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), "token")
+	inviteToken := invitationTokenFromMailbox(t, mailbox)
+
+	recorder = serveAs(t, server, owner.ID, http.MethodGet, fmt.Sprintf("/ledgers/%d/invitations", ledger.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var invitations []ledgerInvitationResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &invitations))
+	require.Len(t, invitations, 1)
+	require.Equal(t, editor.Email, invitations[0].Email)
+
+	// The invitation only works for the address it was sent to.
+	stranger := createTestLedgerUser(t, store)
+	recorder = serveAs(t, server, stranger.ID, http.MethodPost, "/invitations/accept", acceptLedgerInvitationRequest{Token: inviteToken})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveAs(t, server, editor.ID, http.MethodPost, "/invitations/accept", acceptLedgerInvitationRequest{Token: inviteToken})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var joined ledgerResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &joined))
+	require.Equal(t, ledger.ID, joined.ID)
+	require.Equal(t, db.LedgerRoleEditor, joined.Role)
+
+	recorder = serveAs(t, server, editor.ID, http.MethodPost, "/invitations/accept", acceptLedgerInvitationRequest{Token: inviteToken})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveAs(t, server, editor.ID, http.MethodGet, fmt.Sprintf("/ledgers/%d/members", ledger.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var members []ledgerMemberResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &members))
+	require.Len(t, members, 2)
+
+	// Members cannot be invited again.
+	recorder = serveAs(t, server, owner.ID, http.MethodPost, fmt.Sprintf("/ledgers/%d/invitations", ledger.ID),
+		createLedgerInvitationRequest{Email: editor.Email, Role: db.LedgerRoleViewer})
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	require.Len(t, store.eventsOfType(auditEventLedgerMemberInvited), 1)
+	require.Len(t, store.eventsOfType(auditEventLedgerMemberJoined), 1)
+}
+
+func TestRevokeLedgerInvitation(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	owner := createTestLedgerUser(t, store)
+	invitee := createTestLedgerUser(t, store)
+	ledger := createTestLedger(t, server, owner.ID)
+
+	recorder := serveAs(t, server, owner.ID, http.MethodPost, fmt.Sprintf("/ledgers/%d/invitations", ledger.ID),
+		createLedgerInvitationRequest{Email: invitee.Email, Role: db.LedgerRoleViewer})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var invitation ledgerInvitationResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &invitation))
+
+	invitationURL := fmt.Sprintf("/ledgers/%d/invitations/%d", ledger.ID, invitation.ID)
+	recorder = serveAs(t, server, owner.ID, http.MethodDelete, invitationURL, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = serveAs(t, server, owner.ID, http.MethodDelete, invitationURL, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveAs(t, server, invitee.ID, http.MethodPost, "/invitations/accept",
+		acceptLedgerInvitationRequest{Token: invitationTokenFromMailbox(t, mailbox)})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestLedgerRolePermissions(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	owner := createTestLedgerUser(t, store)
+	editor := createTestLedgerUser(t, store)
+	viewer := createTestLedgerUser(t, store)
+	outsider := createTestLedgerUser(t, store)
+	ledger := createTestLedger(t, server, owner.ID)
+	inviteTestMember(t, server, mailbox, owner.ID, ledger.ID, editor, db.LedgerRoleEditor)
+	inviteTestMember(t, server, mailbox, owner.ID, ledger.ID, viewer, db.LedgerRoleViewer)
+
+	categoryURL := fmt.Sprintf("/category?ledger_id=%d", ledger.ID)
+	newCategory := createCategoryRequest{Title: "Groceries", Type: "debit", Description: "Food"}
+
+	// Editors write to the shared ledger and are recorded as the creator.
+	recorder := serveAs(t, server, editor.ID, http.MethodPost, categoryURL, newCategory)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var category categoryResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &category))
+	require.Equal(t, ledger.ID, category.LedgerID)
+	require.NotNil(t, category.CreatedBy)
+	require.Equal(t, editor.ID, *category.CreatedBy)
+
+	// Viewers read it but cannot change it.
+	recorder = serveAs(t, server, viewer.ID, http.MethodGet,
+		fmt.Sprintf("/category/id/%d?ledger_id=%d", category.ID, ledger.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveAs(t, server, viewer.ID, http.MethodPost, categoryURL, newCategory)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	recorder = serveAs(t, server, viewer.ID, http.MethodDelete,
+		fmt.Sprintf("/category/%d?ledger_id=%d", category.ID, ledger.ID), nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Contains(t, store.categories, category.ID)
+
+	// Only the owner manages invitations.
+	recorder = serveAs(t, server, editor.ID, http.MethodPost, fmt.Sprintf("/ledgers/%d/invitations", ledger.ID),
+		createLedgerInvitationRequest{Email: outsider.Email, Role: db.LedgerRoleEditor})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Outsiders cannot tell the ledger exists.
+	recorder = serveAs(t, server, outsider.ID, http.MethodGet, categoryURL+"&type=debit", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Without ledger_id requests use the caller's personal ledger.
+	recorder = serveAs(t, server, editor.ID, http.MethodGet,
+		fmt.Sprintf("/category/id/%d", category.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestManageLedgerMembers(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	owner := createTestLedgerUser(t, store)
+	editor := createTestLedgerUser(t, store)
+	viewer := createTestLedgerUser(t, store)
+	ledger := createTestLedger(t, server, owner.ID)
+	inviteTestMember(t, server, mailbox, owner.ID, ledger.ID, editor, db.LedgerRoleEditor)
+	inviteTestMember(t, server, mailbox, owner.ID, ledger.ID, viewer, db.LedgerRoleViewer)
+
+	memberURL := func(userID int32) string {
+		return fmt.Sprintf("/ledgers/%d/members/%d", ledger.ID, userID)
+	}
+
+	recorder := serveAs(t, server, owner.ID, http.MethodPut, memberURL(viewer.ID)+"/role",
+		updateLedgerMemberRoleRequest{Role: db.LedgerRoleEditor})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, db.LedgerRoleEditor, store.members[ledgerMemberKeyPair{ledger.ID, viewer.ID}].Role)
+
+	recorder = serveAs(t, server, editor.ID, http.MethodPut, memberURL(viewer.ID)+"/role",
+		updateLedgerMemberRoleRequest{Role: db.LedgerRoleViewer})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveAs(t, server, owner.ID, http.MethodPut, memberURL(owner.ID)+"/role",
+		updateLedgerMemberRoleRequest{Role: db.LedgerRoleViewer})
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Members other than the owner may only remove themselves.
+	recorder = serveAs(t, server, editor.ID, http.MethodDelete, memberURL(viewer.ID), nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveAs(t, server, editor.ID, http.MethodDelete, memberURL(editor.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, store.members, ledgerMemberKeyPair{ledger.ID, editor.ID})
+
+	recorder = serveAs(t, server, owner.ID, http.MethodDelete, memberURL(owner.ID), nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = serveAs(t, server, owner.ID, http.MethodDelete, memberURL(viewer.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = serveAs(t, server, owner.ID, http.MethodDelete, memberURL(viewer.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Removed members lose access.
+	recorder = serveAs(t, server, viewer.ID, http.MethodGet, fmt.Sprintf("/ledgers/%d/members", ledger.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	require.Len(t, store.eventsOfType(auditEventLedgerMemberRole), 1)
+	require.Len(t, store.eventsOfType(auditEventLedgerMemberRemoved), 2)
+}
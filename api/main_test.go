@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/mail"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func newTestKeyRing(t *testing.T) *token.KeyRing {
+	ring, err := token.NewKeyRing("test", token.NewHMACKey("test", []byte(util.RandomString(32))))
+	require.NoError(t, err)
+	return ring
+}
+
+func newTestConfig() util.Config {
+	return util.Config{
+		AccessTokenDuration:        time.Minute,
+		RefreshTokenDuration:       time.Hour,
+		AppURL:                     "http://localhost:3000",
+		PasswordParams:             util.PasswordParams{Memory: 1024, Iterations: 1, Parallelism: 1},
+		PasswordResetTTL:           time.Hour,
+		EmailVerificationTTL:       time.Hour,
+		LedgerInvitationTTL:        time.Hour,
+		UnverifiedLoginPolicy:      util.UnverifiedLoginReadOnly,
+		MfaIssuer:                  "GoFinance",
+		MfaChallengeTTL:            time.Minute,
+		LoginMaxUserFailures:       3,
+		LoginMaxIPFailures:         10,
+		LoginFailureWindow:         time.Hour,
+		LoginLockoutDuration:       time.Minute,
+		OIDCAutoCreateUsers:        util.OIDCAutoCreateVerifiedEmail,
+		OIDCAuthRequestTTL:         time.Minute,
+		AccountDeletionGracePeriod: 24 * time.Hour,
+	}
+}
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	server, _ := newTestServerWithMailbox(t, store)
+	return server
+}
+
+// newTestServerWithMailbox returns a server whose emails are written to the
+// returned buffer.
+func newTestServerWithMailbox(t *testing.T, store db.Store) (*Server, *bytes.Buffer) {
+	config := newTestConfig()
+	mailbox := &bytes.Buffer{}
+	tokens := token.NewService(newTestKeyRing(t), config.AccessTokenDuration)
+	return NewServer(config, store, tokens, mail.NewLogMailer(mailbox, "test@gofinance.local")), mailbox
+}
+
+// createTestToken opens a session for userID in the server's store and
+// returns an access token bound to it. The token is issued for a user with
+// a verified email.
+func createTestToken(t *testing.T, server *Server, userID int32) string {
+	session, err := server.store.CreateSession(context.Background(), db.CreateSessionParams{
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	accessToken, _, err := server.tokens.CreateToken(token.Subject{
+		UserID:        userID,
+		Username:      util.RandomString(6),
+		EmailVerified: true,
+	}, session.ID)
+	require.NoError(t, err)
+	return accessToken
+}
+
+// serveAs sends a request to the server authenticated as userID. A zero
+// userID sends the request without an authorization header.
+func serveAs(t *testing.T, server *Server, userID int32, method, url string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	request, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	if userID != 0 {
+		request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", createTestToken(t, server, userID)))
+	}
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
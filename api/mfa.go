@@ -0,0 +1,362 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/totp"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	errMfaAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	errMfaNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	errInvalidMfaCode    = errors.New("two-factor code is invalid")
+	errInvalidMfaToken   = errors.New("mfa token is invalid or expired")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns recoveryCodeCount random codes formatted as
+// "xxxxx-xxxxx" together with their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return token.HashOpaqueToken(code)
+}
+
+// checkMfaCode accepts either a TOTP code that was not used before or an
+// unused recovery code, and consumes it.
+func (server *Server) checkMfaCode(ctx context.Context, mfa db.MfaTotp, code string) (bool, error) {
+	if step, ok := totp.Validate(mfa.Secret, code, time.Now()); ok {
+		rows, err := server.store.UseMfaTotpStep(ctx, db.UseMfaTotpStepParams{
+			Step:   step,
+			UserID: mfa.UserID,
+		})
+		return rows == 1, err
+	}
+
+	rows, err := server.store.UseMfaRecoveryCode(ctx, db.UseMfaRecoveryCodeParams{
+		UserID:   mfa.UserID,
+		CodeHash: hashRecoveryCode(code),
+	})
+	return rows == 1, err
+}
+
+// confirmedMfa returns the confirmed TOTP enrollment of a user, or
+// sql.ErrNoRows when two-factor authentication is off.
+func (server *Server) confirmedMfa(ctx context.Context, userID int32) (db.MfaTotp, error) {
+	mfa, err := server.store.GetMfaTotp(ctx, userID)
+	if err != nil {
+		return db.MfaTotp{}, err
+	}
+	if !mfa.ConfirmedAt.Valid {
+		return db.MfaTotp{}, sql.ErrNoRows
+	}
+	return mfa, nil
+}
+
+type mfaChallengeResponse struct {
+	MfaRequired       bool      `json:"mfa_required"`
+	MfaToken          string    `json:"mfa_token"`
+	MfaTokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+func (server *Server) mfaChallenge(user db.User) (mfaChallengeResponse, error) {
+	expiresAt := time.Now().Add(server.config.MfaChallengeTTL)
+	mfaToken, err := server.tokens.CreatePurposeToken(token.PurposeMfaChallenge, user.ID, "", server.config.MfaChallengeTTL)
+	if err != nil {
+		return mfaChallengeResponse{}, err
+	}
+	return mfaChallengeResponse{
+		MfaRequired:       true,
+		MfaToken:          mfaToken,
+		MfaTokenExpiresAt: expiresAt,
+	}, nil
+}
+
+type loginMfaRequest struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// loginMfa completes a login that returned an MFA challenge: the session
+// is only opened once a valid TOTP or recovery code is presented. Wrong
+// codes count as failed logins of the user.
+func (server *Server) loginMfa(ctx *gin.Context) {
+	var req loginMfaRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims, err := server.tokens.VerifyPurposeToken(token.PurposeMfaChallenge, req.MfaToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaToken))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if err = checkUserActive(user); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	throttleKeys := server.loginThrottleKeys(ctx, user.Username)
+	if !server.checkLoginThrottle(ctx, throttleKeys) {
+		return
+	}
+
+	mfa, err := server.confirmedMfa(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ok, err := server.checkMfaCode(ctx, mfa, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !ok {
+		err = server.recordLoginFailure(ctx, throttleKeys, auditEvent{
+			Type:     auditEventLogin,
+			UserID:   user.ID,
+			Username: user.Username,
+			Details:  "wrong MFA code",
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaCode))
+		return
+	}
+
+	err = server.clearLoginFailures(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.startSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type enrollMfaResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// enrollMfa generates a new TOTP secret. It only takes effect once it is
+// confirmed with a code; enrolling again before that replaces the secret.
+func (server *Server) enrollMfa(ctx *gin.Context) {
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.UpsertMfaTotp(ctx, db.UpsertMfaTotpParams{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errMfaAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollMfaResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(server.config.MfaIssuer, user.Email, secret),
+	})
+}
+
+type confirmMfaRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type confirmMfaResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmMfa enables two-factor authentication and returns the recovery
+// codes. They are only stored hashed and cannot be shown again.
+func (server *Server) confirmMfa(ctx *gin.Context) {
+	var req confirmMfaRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	mfa, err := server.store.GetMfaTotp(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errMfaNotEnrolled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if mfa.ConfirmedAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(errMfaAlreadyEnabled))
+		return
+	}
+
+	step, ok := totp.Validate(mfa.Secret, req.Code, time.Now())
+	if ok {
+		var rows int64
+		rows, err = server.store.UseMfaTotpStep(ctx, db.UseMfaTotpStepParams{Step: step, UserID: userID})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ok = rows == 1
+	}
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidMfaCode))
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.store.ConfirmMfaTx(ctx, db.ConfirmMfaTxParams{
+		UserID:             userID,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		if err == db.ErrMfaAlreadyConfirmed {
+			ctx.JSON(http.StatusConflict, errorResponse(errMfaAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventMfaEnabled, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmMfaResponse{RecoveryCodes: codes})
+}
+
+type disableMfaRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// disableMfa turns two-factor authentication off. The user has to
+// confirm it with a current TOTP or recovery code and, for accounts with
+// a password, with checkCurrentPassword too.
+func (server *Server) disableMfa(ctx *gin.Context) {
+	var req disableMfaRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Password != "" && !server.checkCurrentPassword(ctx, user, req.Password) {
+		return
+	}
+
+	mfa, err := server.confirmedMfa(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errMfaNotEnrolled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ok, err := server.checkMfaCode(ctx, mfa, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaCode))
+		return
+	}
+
+	err = server.store.DisableMfaTx(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventMfaDisabled, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/totp"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+// serveAsSession sends a JSON request authenticated with accessToken.
+func serveAsSession(t *testing.T, server *Server, accessToken, method, url string, body interface{}) (int, []byte) {
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(method, url, bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	return recorder.Code, recorder.Body.Bytes()
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.Code(secret, totp.Step(at))
+	require.NoError(t, err)
+	return code
+}
+
+// enableTestMfa enrolls and confirms TOTP for the session's user and
+// returns the secret and the recovery codes.
+func enableTestMfa(t *testing.T, server *Server, session sessionResponse) (string, []string) {
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp", nil)
+	require.Equal(t, http.StatusOK, status)
+	var enrolled enrollMfaResponse
+	require.NoError(t, json.Unmarshal(body, &enrolled))
+	require.True(t, strings.HasPrefix(enrolled.OtpauthURI, "otpauth://totp/"))
+	require.Contains(t, enrolled.OtpauthURI, "secret="+enrolled.Secret)
+
+	// A past period is used so the codes of later steps stay available to
+	// the test.
+	status, body = serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/confirm", confirmMfaRequest{
+		Code: totpCode(t, enrolled.Secret, time.Now().Add(-totp.Period)),
+	})
+	require.Equal(t, http.StatusOK, status)
+	var confirmed confirmMfaResponse
+	require.NoError(t, json.Unmarshal(body, &confirmed))
+	require.Len(t, confirmed.RecoveryCodes, recoveryCodeCount)
+
+	return enrolled.Secret, confirmed.RecoveryCodes
+}
+
+func loginChallenge(t *testing.T, server *Server, user createUserRequest) mfaChallengeResponse {
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: user.Username,
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var challenge mfaChallengeResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &challenge))
+	require.True(t, challenge.MfaRequired)
+	require.NotEmpty(t, challenge.MfaToken)
+	require.NotContains(t, recorder.Body.String(), "access_token")
+	return challenge
+}
+
+func loginMfaCode(t *testing.T, server *Server, mfaToken, code string) (int, sessionResponse) {
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login/mfa", loginMfaRequest{
+		MfaToken: mfaToken,
+		Code:     code,
+	})
+
+	var rsp sessionResponse
+	if recorder.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	}
+	return recorder.Code, rsp
+}
+
+func TestMfaLogin(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, session := loginTestUser(t, server)
+	secret, _ := enableTestMfa(t, server, session)
+
+	challenge := loginChallenge(t, server, user)
+
+	status, _ := loginMfaCode(t, server, challenge.MfaToken, "000000")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	// The code used to confirm the enrollment cannot be replayed.
+	status, _ = loginMfaCode(t, server, challenge.MfaToken, totpCode(t, secret, time.Now().Add(-totp.Period)))
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	code := totpCode(t, secret, time.Now())
+	status, rsp := loginMfaCode(t, server, challenge.MfaToken, code)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, rsp.AccessToken)
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, rsp.AccessToken, http.MethodGet, "/account/graph/debit"))
+
+	status, _ = loginMfaCode(t, server, challenge.MfaToken, code)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	// The challenge token is not an access token.
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, challenge.MfaToken, http.MethodGet, "/account/graph/debit"))
+}
+
+func TestMfaRecoveryCode(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, session := loginTestUser(t, server)
+	_, recoveryCodes := enableTestMfa(t, server, session)
+
+	challenge := loginChallenge(t, server, user)
+	status, _ := loginMfaCode(t, server, challenge.MfaToken, strings.ToUpper(recoveryCodes[0]))
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = loginMfaCode(t, server, challenge.MfaToken, recoveryCodes[0])
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = loginMfaCode(t, server, challenge.MfaToken, recoveryCodes[1])
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestMfaChallengeExpired(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, session := loginTestUser(t, server)
+	secret, _ := enableTestMfa(t, server, session)
+
+	server.config.MfaChallengeTTL = -time.Minute
+	challenge := loginChallenge(t, server, user)
+
+	status, _ := loginMfaCode(t, server, challenge.MfaToken, totpCode(t, secret, time.Now()))
+	require.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestMfaEnrollment(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, session := loginTestUser(t, server)
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/confirm", confirmMfaRequest{Code: "123456"})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp", nil)
+	require.Equal(t, http.StatusOK, status)
+	var enrolled enrollMfaResponse
+	require.NoError(t, json.Unmarshal(body, &enrolled))
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/confirm", confirmMfaRequest{Code: "123456"})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// Until it is confirmed, MFA does not change the login flow.
+	login(t, server, user.Username, user.Password)
+
+	enableTestMfa(t, server, session)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp", nil)
+	require.Equal(t, http.StatusConflict, status)
+}
+
+func TestDisableMfa(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, session := loginTestUser(t, server)
+	secret, _ := enableTestMfa(t, server, session)
+	code := totpCode(t, secret, time.Now())
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/disable", disableMfaRequest{
+		Password: util.RandomString(12),
+		Code:     code,
+	})
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Len(t, store.eventsOfType(auditEventReauthentication), 1)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/disable", disableMfaRequest{
+		Password: user.Password,
+		Code:     "000000",
+	})
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/disable", disableMfaRequest{
+		Password: user.Password,
+		Code:     code,
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	login(t, server, user.Username, user.Password)
+}
+
+func TestDisableMfaWithoutPassword(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, session := loginTestUser(t, server)
+	_, recoveryCodes := enableTestMfa(t, server, session)
+
+	// Users who signed up with OpenID Connect have no password to confirm.
+	for id, stored := range store.users {
+		if stored.Username == user.Username {
+			stored.Password = ""
+			store.users[id] = stored
+		}
+	}
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/disable", disableMfaRequest{Code: "000000"})
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPost, "/mfa/totp/disable", disableMfaRequest{Code: recoveryCodes[0]})
+	require.Equal(t, http.StatusOK, status)
+}
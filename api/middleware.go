@@ -0,0 +1,80 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wil-ckaew/gofinance-backend/token"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+	authorizationScopesKey  = "authorization_scopes"
+)
+
+var errInvalidAuthorizationHeader = errors.New("authorization header is missing or malformed")
+
+// authMiddleware verifies the bearer token and its session and stores the
+// claims in the gin context, aborting the request with 401 when the token
+// is missing, invalid or belongs to a revoked session. Personal access
+// tokens are accepted too; their scopes are stored alongside the claims
+// for requireScope.
+func (server *Server) authMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fields := strings.Fields(ctx.GetHeader(authorizationHeaderKey))
+		if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationTypeBearer {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errInvalidAuthorizationHeader))
+			return
+		}
+
+		if strings.HasPrefix(fields[1], personalAccessTokenPrefix) {
+			claims, scopes, err := server.verifyPersonalAccessToken(ctx, fields[1])
+			if err != nil {
+				if err == errInvalidPersonalAccessToken {
+					ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+					return
+				}
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+
+			ctx.Set(authorizationPayloadKey, claims)
+			ctx.Set(authorizationScopesKey, scopes)
+			ctx.Next()
+			return
+		}
+
+		claims, err := server.tokens.VerifyToken(fields[1])
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		session, err := server.store.GetSession(ctx, claims.SessionID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errSessionRevoked))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if session.RevokedAt.Valid || session.UserID != claims.UserID {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errSessionRevoked))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, claims)
+		ctx.Next()
+	}
+}
+
+// authClaims returns the claims stored by authMiddleware.
+func authClaims(ctx *gin.Context) *token.Claims {
+	return ctx.MustGet(authorizationPayloadKey).(*token.Claims)
+}
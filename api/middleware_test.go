@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/mail"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	keyRing := newTestKeyRing(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, server *Server, request *http.Request)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, server *Server, request *http.Request) {
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", createTestToken(t, server, 7)))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"user_id":7}`, recorder.Body.String())
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, server *Server, request *http.Request) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setupAuth: func(t *testing.T, server *Server, request *http.Request) {
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Basic %s", createTestToken(t, server, 7)))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			setupAuth: func(t *testing.T, server *Server, request *http.Request) {
+				request.Header.Set(authorizationHeaderKey, createTestToken(t, server, 7))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(t *testing.T, server *Server, request *http.Request) {
+				expired := *server
+				expired.tokens = token.NewService(keyRing, -time.Minute)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", createTestToken(t, &expired, 7)))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevokedSession",
+			setupAuth: func(t *testing.T, server *Server, request *http.Request) {
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", createTestToken(t, server, 7)))
+				require.NoError(t, server.store.RevokeUserSessions(context.Background(), 7))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownSession",
+			setupAuth: func(t *testing.T, server *Server, request *http.Request) {
+				accessToken, _, err := server.tokens.CreateToken(token.Subject{UserID: 7, Username: util.RandomString(6)}, 9999)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer(newTestConfig(), newFakeStore(), token.NewService(keyRing, time.Minute), mail.NewLogMailer(io.Discard, ""))
+			server.router.GET("/auth", server.authMiddleware(), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{"user_id": authClaims(ctx).UserID})
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/auth", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, server, request)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
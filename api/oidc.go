@@ -0,0 +1,384 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/oidc"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+var (
+	errUnknownOidcProvider = errors.New("identity provider is not configured")
+	errInvalidOidcState    = errors.New("sign-in request is invalid or expired")
+	errOidcLoginFailed     = errors.New("identity provider sign-in failed")
+	errOidcSignupDisabled  = errors.New("no account is linked to this identity")
+	errOidcEmailInUse      = errors.New("an account with this email exists, sign in with your password and link the provider")
+	errIdentityInUse       = errors.New("this identity is linked to another account")
+	errProviderLinked      = errors.New("another identity of this provider is linked, unlink it first")
+	errLastLoginMethod     = errors.New("set a password before unlinking your only identity provider")
+)
+
+var usernameUnsafeRegexp = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func newOidcProviders(config util.Config) map[string]*oidc.Provider {
+	providers := map[string]*oidc.Provider{}
+	for _, p := range config.OIDCProviders {
+		providers[p.Name] = oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  config.OIDCRedirectURL,
+			Scopes:       p.Scopes,
+		}, nil)
+	}
+	return providers
+}
+
+type oidcProviderRequest struct {
+	Provider string `uri:"provider" binding:"required"`
+}
+
+type oidcStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// startOidc stores the state, nonce and PKCE verifier of a new sign-in
+// request and returns the provider URL to send the user to.
+func (server *Server) startOidc(ctx *gin.Context, linkUserID sql.NullInt32) {
+	var req oidcProviderRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	provider, ok := server.oidcProviders[req.Provider]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, errorResponse(errUnknownOidcProvider))
+		return
+	}
+
+	state, stateHash, err := token.NewOpaqueToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	nonce, _, err := token.NewOpaqueToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.CreateOidcAuthRequest(ctx, db.CreateOidcAuthRequestParams{
+		StateHash:    stateHash,
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(server.config.OIDCAuthRequestTTL),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, oidcStartResponse{AuthorizationURL: authURL})
+}
+
+func (server *Server) startOidcLogin(ctx *gin.Context) {
+	server.startOidc(ctx, sql.NullInt32{})
+}
+
+func (server *Server) startOidcLink(ctx *gin.Context) {
+	server.startOidc(ctx, sql.NullInt32{Int32: authClaims(ctx).UserID, Valid: true})
+}
+
+type oidcCallbackRequest struct {
+	State string `json:"state" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// oidcCallback completes a sign-in request with the code the provider
+// redirected back with. Login requests answer like login; link requests
+// answer with the linked identity.
+func (server *Server) oidcCallback(ctx *gin.Context) {
+	var req oidcCallbackRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authRequest, err := server.store.ConsumeOidcAuthRequest(ctx, token.HashOpaqueToken(req.State))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidOidcState))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if time.Now().After(authRequest.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidOidcState))
+		return
+	}
+
+	provider, ok := server.oidcProviders[authRequest.Provider]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errUnknownOidcProvider))
+		return
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, req.Code, authRequest.CodeVerifier)
+	if err != nil {
+		log.Printf("cannot exchange %s authorization code: %v", provider.Name(), err)
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errOidcLoginFailed))
+		return
+	}
+	idToken, err := provider.VerifyIDToken(ctx, rawIDToken, authRequest.Nonce)
+	if err != nil {
+		log.Printf("cannot verify %s id token: %v", provider.Name(), err)
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errOidcLoginFailed))
+		return
+	}
+
+	if authRequest.LinkUserID.Valid {
+		server.linkIdentity(ctx, authRequest.LinkUserID.Int32, provider.Name(), idToken)
+		return
+	}
+
+	identity, err := server.store.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Provider: provider.Name(),
+		Subject:  idToken.Subject,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var user db.User
+	if err == nil {
+		user, err = server.store.GetUserById(ctx, identity.UserID)
+	} else {
+		user, err = server.createOidcUser(ctx, provider.Name(), idToken)
+	}
+	if err != nil {
+		switch err {
+		case errOidcSignupDisabled:
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		case errOidcEmailInUse:
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	server.completeLogin(ctx, user)
+}
+
+// createOidcUser applies the auto-create policy to an identity signing in
+// for the first time. Identities are never linked to an existing account
+// by email alone; the owner has to link them while signed in.
+func (server *Server) createOidcUser(ctx *gin.Context, provider string, idToken *oidc.IDToken) (db.User, error) {
+	switch server.config.OIDCAutoCreateUsers {
+	case util.OIDCAutoCreateAlways:
+	case util.OIDCAutoCreateVerifiedEmail:
+		if !idToken.EmailVerified {
+			return db.User{}, errOidcSignupDisabled
+		}
+	default:
+		return db.User{}, errOidcSignupDisabled
+	}
+	if idToken.Email == "" {
+		return db.User{}, errOidcSignupDisabled
+	}
+
+	_, err := server.store.GetUserByEmail(ctx, idToken.Email)
+	if err == nil {
+		return db.User{}, errOidcEmailInUse
+	}
+	if err != sql.ErrNoRows {
+		return db.User{}, err
+	}
+
+	username, err := server.availableUsername(ctx, idToken)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	return server.store.CreateOidcUserTx(ctx, db.CreateOidcUserTxParams{
+		Username:      username,
+		Email:         idToken.Email,
+		EmailVerified: idToken.EmailVerified,
+		Provider:      provider,
+		Subject:       idToken.Subject,
+	})
+}
+
+// availableUsername derives a username from the identity, adding a random
+// suffix when it is taken.
+func (server *Server) availableUsername(ctx *gin.Context, idToken *oidc.IDToken) (string, error) {
+	base := idToken.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(idToken.Email, "@", 2)[0]
+	}
+	base = usernameUnsafeRegexp.ReplaceAllString(base, "")
+	if len(base) > 30 {
+		base = base[:30]
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+	for {
+		_, err := server.store.GetUser(ctx, username)
+		if err == sql.ErrNoRows {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		username = base + "-" + util.RandomString(4)
+	}
+}
+
+func (server *Server) linkIdentity(ctx *gin.Context, userID int32, provider string, idToken *oidc.IDToken) {
+	identity, err := server.store.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  idToken.Subject,
+	})
+	if err == nil {
+		if identity.UserID != userID {
+			ctx.JSON(http.StatusConflict, errorResponse(errIdentityInUse))
+			return
+		}
+		ctx.JSON(http.StatusOK, newIdentityResponse(identity))
+		return
+	}
+	if err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	identity, err = server.store.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  idToken.Subject,
+		Email:    idToken.Email,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errProviderLinked))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordAuditEvent(ctx, auditEvent{
+		Type:     auditEventIdentityLinked,
+		Outcome:  db.AuditOutcomeSuccess,
+		UserID:   user.ID,
+		Username: user.Username,
+		Details:  provider,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newIdentityResponse(identity))
+}
+
+func (server *Server) listIdentities(ctx *gin.Context) {
+	identities, err := server.store.ListUserIdentities(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]identityResponse, len(identities))
+	for i, identity := range identities {
+		rsp[i] = newIdentityResponse(identity)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// unlinkIdentity removes a provider from the account, unless it is the
+// only way left to sign in.
+func (server *Server) unlinkIdentity(ctx *gin.Context) {
+	var req oidcProviderRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Password == "" {
+		identities, err := server.store.ListUserIdentities(ctx, user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if len(identities) <= 1 {
+			ctx.JSON(http.StatusConflict, errorResponse(errLastLoginMethod))
+			return
+		}
+	}
+
+	rows, err := server.store.DeleteUserIdentity(ctx, db.DeleteUserIdentityParams{
+		UserID:   user.ID,
+		Provider: req.Provider,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventIdentityUnlinked, req.Provider)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
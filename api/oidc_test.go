@@ -0,0 +1,258 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/oidc"
+	"github.com/wil-ckaew/gofinance-backend/oidc/oidctest"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+// newTestOidcProvider starts a mock identity provider and registers it
+// with the server as "mock".
+func newTestOidcProvider(t *testing.T, server *Server) *oidctest.Provider {
+	mock, err := oidctest.NewProvider("gofinance", util.RandomString(16))
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	server.oidcProviders = map[string]*oidc.Provider{
+		"mock": oidc.NewProvider(mock.Config("mock", server.config.AppURL+"/oidc/callback"), nil),
+	}
+	return mock
+}
+
+func randomIdentity() oidctest.Identity {
+	return oidctest.Identity{
+		Subject:           util.RandomString(12),
+		Email:             util.RandomEmail(8),
+		EmailVerified:     true,
+		PreferredUsername: util.RandomString(6),
+	}
+}
+
+// oidcSignIn starts a login, or a link when accessToken is set, signs
+// identity in at the mock provider and posts the callback.
+func oidcSignIn(t *testing.T, server *Server, mock *oidctest.Provider, accessToken string, identity oidctest.Identity) (int, []byte) {
+	url, status := "/oidc/mock/login", 0
+	var body []byte
+	if accessToken != "" {
+		url = "/oidc/mock/link"
+		status, body = serveAsSession(t, server, accessToken, http.MethodPost, url, nil)
+	} else {
+		recorder := serveAs(t, server, 0, http.MethodPost, url, nil)
+		status, body = recorder.Code, recorder.Body.Bytes()
+	}
+	require.Equal(t, http.StatusOK, status)
+
+	var start oidcStartResponse
+	require.NoError(t, json.Unmarshal(body, &start))
+	code, state, err := mock.Authorize(start.AuthorizationURL, identity)
+	require.NoError(t, err)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/oidc/callback", oidcCallbackRequest{State: state, Code: code})
+	return recorder.Code, recorder.Body.Bytes()
+}
+
+func TestOidcLoginCreatesUser(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	mock := newTestOidcProvider(t, server)
+	identity := randomIdentity()
+
+	status, body := oidcSignIn(t, server, mock, "", identity)
+	require.Equal(t, http.StatusOK, status)
+	var first sessionResponse
+	require.NoError(t, json.Unmarshal(body, &first))
+	require.NotEmpty(t, first.AccessToken)
+
+	require.Len(t, store.users, 1)
+	for _, user := range store.users {
+		require.Equal(t, identity.PreferredUsername, user.Username)
+		require.Equal(t, identity.Email, user.Email)
+		require.Empty(t, user.Password)
+		require.True(t, user.VerifiedAt.Valid)
+	}
+
+	status, body = oidcSignIn(t, server, mock, "", identity)
+	require.Equal(t, http.StatusOK, status)
+	var second sessionResponse
+	require.NoError(t, json.Unmarshal(body, &second))
+	require.NotEqual(t, first.SessionID, second.SessionID)
+	require.Len(t, store.users, 1)
+}
+
+func TestOidcLoginUsernameTaken(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	mock := newTestOidcProvider(t, server)
+	user, _ := loginTestUser(t, server)
+
+	identity := randomIdentity()
+	identity.PreferredUsername = user.Username
+	status, _ := oidcSignIn(t, server, mock, "", identity)
+	require.Equal(t, http.StatusOK, status)
+
+	identity2 := randomIdentity()
+	identity2.PreferredUsername = ""
+	identity2.Email = "Jane.Doe+fin@example.com"
+	status, _ = oidcSignIn(t, server, mock, "", identity2)
+	require.Equal(t, http.StatusOK, status)
+
+	usernames := map[string]bool{}
+	for _, u := range store.users {
+		usernames[u.Username] = true
+	}
+	require.Len(t, usernames, 3)
+	require.True(t, usernames["Jane.Doefin"])
+	for username := range usernames {
+		if username != user.Username && username != "Jane.Doefin" {
+			require.Regexp(t, "^"+user.Username+"-[a-z]{4}$", username)
+		}
+	}
+}
+
+func TestOidcAutoCreatePolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   string
+		identity func(identity *oidctest.Identity, existing createUserRequest)
+		status   int
+	}{
+		{
+			name:     "Off",
+			policy:   util.OIDCAutoCreateOff,
+			identity: func(identity *oidctest.Identity, existing createUserRequest) {},
+			status:   http.StatusForbidden,
+		},
+		{
+			name:   "UnverifiedEmail",
+			policy: util.OIDCAutoCreateVerifiedEmail,
+			identity: func(identity *oidctest.Identity, existing createUserRequest) {
+				identity.EmailVerified = false
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "UnverifiedEmailAlways",
+			policy: util.OIDCAutoCreateAlways,
+			identity: func(identity *oidctest.Identity, existing createUserRequest) {
+				identity.EmailVerified = false
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "EmailInUse",
+			policy: util.OIDCAutoCreateAlways,
+			identity: func(identity *oidctest.Identity, existing createUserRequest) {
+				identity.Email = existing.Email
+			},
+			status: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newFakeStore()
+			server := newTestServer(t, store)
+			server.config.OIDCAutoCreateUsers = tc.policy
+			mock := newTestOidcProvider(t, server)
+			existing, _ := loginTestUser(t, server)
+
+			identity := randomIdentity()
+			tc.identity(&identity, existing)
+			status, _ := oidcSignIn(t, server, mock, "", identity)
+			require.Equal(t, tc.status, status)
+			if tc.status != http.StatusOK {
+				require.Len(t, store.users, 1)
+				require.Empty(t, store.identities)
+			}
+		})
+	}
+}
+
+func TestOidcCallbackInvalidState(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	mock := newTestOidcProvider(t, server)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/oidc/mock/login", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var start oidcStartResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &start))
+	code, state, err := mock.Authorize(start.AuthorizationURL, randomIdentity())
+	require.NoError(t, err)
+
+	recorder = serveAs(t, server, 0, http.MethodPost, "/oidc/callback", oidcCallbackRequest{State: "forged", Code: code})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveAs(t, server, 0, http.MethodPost, "/oidc/callback", oidcCallbackRequest{State: state, Code: "forged"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serveAs(t, server, 0, http.MethodPost, "/oidc/callback", oidcCallbackRequest{State: state, Code: code})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestOidcUnknownProvider(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/oidc/unknown/login", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestOidcLinkAndUnlink(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	mock := newTestOidcProvider(t, server)
+	_, session := loginTestUser(t, server)
+	_, other := loginTestUser(t, server)
+	identity := randomIdentity()
+
+	status, body := oidcSignIn(t, server, mock, session.AccessToken, identity)
+	require.Equal(t, http.StatusOK, status)
+	var linked identityResponse
+	require.NoError(t, json.Unmarshal(body, &linked))
+	require.Equal(t, "mock", linked.Provider)
+	require.Equal(t, identity.Subject, linked.Subject)
+
+	status, _ = oidcSignIn(t, server, mock, session.AccessToken, identity)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = oidcSignIn(t, server, mock, other.AccessToken, identity)
+	require.Equal(t, http.StatusConflict, status)
+	status, _ = oidcSignIn(t, server, mock, session.AccessToken, randomIdentity())
+	require.Equal(t, http.StatusConflict, status)
+	require.Len(t, store.eventsOfType(auditEventIdentityLinked), 1)
+
+	status, body = oidcSignIn(t, server, mock, "", identity)
+	require.Equal(t, http.StatusOK, status)
+	var signedIn sessionResponse
+	require.NoError(t, json.Unmarshal(body, &signedIn))
+	require.Len(t, store.users, 2)
+
+	status, body = serveAsSession(t, server, signedIn.AccessToken, http.MethodGet, "/oidc/identities", nil)
+	require.Equal(t, http.StatusOK, status)
+	var identities []identityResponse
+	require.NoError(t, json.Unmarshal(body, &identities))
+	require.Len(t, identities, 1)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodDelete, "/oidc/mock/link", nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodDelete, "/oidc/mock/link", nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Empty(t, store.identities)
+	require.Len(t, store.eventsOfType(auditEventIdentityUnlinked), 1)
+}
+
+func TestOidcUnlinkLastLoginMethod(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	mock := newTestOidcProvider(t, server)
+
+	status, body := oidcSignIn(t, server, mock, "", randomIdentity())
+	require.Equal(t, http.StatusOK, status)
+	var session sessionResponse
+	require.NoError(t, json.Unmarshal(body, &session))
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodDelete, "/oidc/mock/link", nil)
+	require.Equal(t, http.StatusConflict, status)
+}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+type ownershipFixture struct {
+	server   *Server
+	store    *fakeStore
+	owner    db.User
+	intruder db.User
+	ledger   db.Ledger
+	category db.Category
+	wallet   db.Wallet
+	account  db.Account
+}
+
+func newOwnershipFixture(t *testing.T) ownershipFixture {
+	ctx := context.Background()
+	store := newFakeStore()
+
+	owner, err := store.CreateUser(ctx, db.CreateUserParams{Username: util.RandomString(6)})
+	require.NoError(t, err)
+	intruder, err := store.CreateUser(ctx, db.CreateUserParams{Username: util.RandomString(6)})
+	require.NoError(t, err)
+
+	ledger, err := store.EnsurePersonalLedgerTx(ctx, owner.ID)
+	require.NoError(t, err)
+
+	category, err := store.CreateCategory(ctx, db.CreateCategoryParams{
+		LedgerID:    ledger.ID,
+		CreatedBy:   sql.NullInt32{Int32: owner.ID, Valid: true},
+		Title:       util.RandomString(8),
+		Type:        "debit",
+		Description: util.RandomString(12),
+	})
+	require.NoError(t, err)
+
+	wallet, err := store.CreateWallet(ctx, db.CreateWalletParams{
+		LedgerID: ledger.ID,
+		Name:     util.RandomString(8),
+		Type:     "checking",
+		Currency: "BRL",
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccount(ctx, db.CreateAccountParams{
+		LedgerID:    ledger.ID,
+		CreatedBy:   sql.NullInt32{Int32: owner.ID, Valid: true},
+		CategoryID:  sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:       util.RandomString(8),
+		Type:        "debit",
+		Description: util.RandomString(12),
+		Amount:      4200,
+		Currency:    "BRL",
+		Date:        time.Now(),
+		WalletID:    wallet.ID,
+	})
+	require.NoError(t, err)
+
+	return ownershipFixture{
+		server:   newTestServer(t, store),
+		store:    store,
+		owner:    owner,
+		intruder: intruder,
+		ledger:   ledger,
+		category: category,
+		wallet:   wallet,
+		account:  account,
+	}
+}
+
+func TestOwnershipIsolation(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		url    func(f ownershipFixture) string
+		body   func(f ownershipFixture) interface{}
+		check  func(t *testing.T, f ownershipFixture, status int, body []byte)
+	}{
+		{
+			name:   "GetCategory",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/category/id/%d", f.category.ID) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+			},
+		},
+		{
+			name:   "UpdateCategory",
+			method: http.MethodPut,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/category/%d", f.category.ID) },
+			body: func(f ownershipFixture) interface{} {
+				return updateCategoryRequest{ID: f.category.ID, Title: "hijacked"}
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Equal(t, f.category.Title, f.store.categories[f.category.ID].Title)
+			},
+		},
+		{
+			name:   "DeleteCategory",
+			method: http.MethodDelete,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/category/%d", f.category.ID) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Contains(t, f.store.categories, f.category.ID)
+			},
+		},
+		{
+			name:   "ListCategories",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return "/category" },
+			body: func(f ownershipFixture) interface{} {
+				return getCategoriesRequest{Type: f.category.Type}
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusOK, status)
+				require.JSONEq(t, `[]`, string(body))
+			},
+		},
+		{
+			name:   "CreateAccountWithForeignCategory",
+			method: http.MethodPost,
+			url:    func(f ownershipFixture) string { return "/account" },
+			body: func(f ownershipFixture) interface{} {
+				return createAccountRequest{
+					CategoryID:  f.category.ID,
+					WalletID:    f.wallet.ID,
+					Title:       "injected",
+					Type:        f.category.Type,
+					Description: "injected",
+					Amount:      &money.Money{Amount: 100, Currency: "BRL"},
+					Date:        time.Now(),
+				}
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Len(t, f.store.accounts, 1)
+			},
+		},
+		{
+			name:   "CreateAccountInForeignWallet",
+			method: http.MethodPost,
+			url:    func(f ownershipFixture) string { return "/account" },
+			body: func(f ownershipFixture) interface{} {
+				category := createTestCategory(t, f.store, f.intruder.ID)
+				return createAccountRequest{
+					CategoryID:  category.ID,
+					WalletID:    f.wallet.ID,
+					Title:       "injected",
+					Type:        category.Type,
+					Description: "injected",
+					Amount:      &money.Money{Amount: 100, Currency: "BRL"},
+					Date:        time.Now(),
+				}
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Len(t, f.store.accounts, 1)
+			},
+		},
+		{
+			name:   "GetAccount",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/account/id/%d", f.account.ID) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+			},
+		},
+		{
+			name:   "UpdateAccount",
+			method: http.MethodPut,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/account/%d", f.account.ID) },
+			body: func(f ownershipFixture) interface{} {
+				return updateAccountRequest{
+					ID:     f.account.ID,
+					Title:  "hijacked",
+					Amount: &money.Money{Amount: 100, Currency: "BRL"},
+				}
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Equal(t, f.account.Amount, f.store.accounts[f.account.ID].Amount)
+			},
+		},
+		{
+			name:   "DeleteAccount",
+			method: http.MethodDelete,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/account/%d", f.account.ID) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Contains(t, f.store.accounts, f.account.ID)
+			},
+		},
+		{
+			name:   "ListAccounts",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return "/account" },
+			body: func(f ownershipFixture) interface{} {
+				return getAccountsRequest{Type: f.account.Type}
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusOK, status)
+				require.JSONEq(t, `[]`, string(body))
+			},
+		},
+		{
+			name:   "AccountGraph",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/account/graph/%s", f.account.Type) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusOK, status)
+				require.JSONEq(t, `0`, string(body))
+			},
+		},
+		{
+			name:   "AccountReports",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/account/reports/%s", f.account.Type) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusOK, status)
+				require.JSONEq(t, `[]`, string(body))
+			},
+		},
+		{
+			name:   "GetWallet",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/wallets/%d", f.wallet.ID) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+			},
+		},
+		{
+			name:   "WalletBalances",
+			method: http.MethodGet,
+			url: func(f ownershipFixture) string {
+				return fmt.Sprintf("/wallets/%d/balances?from=2024-01-01&to=2024-12-31", f.wallet.ID)
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+			},
+		},
+		{
+			name:   "UpdateWallet",
+			method: http.MethodPut,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/wallets/%d", f.wallet.ID) },
+			body: func(f ownershipFixture) interface{} {
+				return updateWalletRequest{
+					Name:           "hijacked",
+					Type:           "cash",
+					OpeningBalance: &money.Money{Amount: 100, Currency: "BRL"},
+				}
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Equal(t, f.wallet.Name, f.store.wallets[f.wallet.ID].Name)
+			},
+		},
+		{
+			name:   "ListWallets",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return "/wallets" },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusOK, status)
+				require.JSONEq(t, `[]`, string(body))
+			},
+		},
+		{
+			name:   "GetAccountInForeignLedger",
+			method: http.MethodGet,
+			url: func(f ownershipFixture) string {
+				return fmt.Sprintf("/account/id/%d?ledger_id=%d", f.account.ID, f.ledger.ID)
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+			},
+		},
+		{
+			name:   "DeleteCategoryInForeignLedger",
+			method: http.MethodDelete,
+			url: func(f ownershipFixture) string {
+				return fmt.Sprintf("/category/%d?ledger_id=%d", f.category.ID, f.ledger.ID)
+			},
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+				require.Contains(t, f.store.categories, f.category.ID)
+			},
+		},
+		{
+			name:   "ListForeignLedgerMembers",
+			method: http.MethodGet,
+			url:    func(f ownershipFixture) string { return fmt.Sprintf("/ledgers/%d/members", f.ledger.ID) },
+			check: func(t *testing.T, f ownershipFixture, status int, body []byte) {
+				require.Equal(t, http.StatusNotFound, status)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			f := newOwnershipFixture(t)
+
+			var body interface{}
+			if tc.body != nil {
+				body = tc.body(f)
+			}
+
+			recorder := serveAs(t, f.server, f.intruder.ID, tc.method, tc.url(f), body)
+			tc.check(t, f, recorder.Code, recorder.Body.Bytes())
+		})
+	}
+}
+
+func TestOwnerCanAccessOwnData(t *testing.T) {
+	f := newOwnershipFixture(t)
+
+	recorder := serveAs(t, f.server, f.owner.ID, http.MethodGet, fmt.Sprintf("/account/id/%d", f.account.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var account accountResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	require.Equal(t, f.account.ID, account.ID)
+
+	recorder = serveAs(t, f.server, f.owner.ID, http.MethodGet, fmt.Sprintf("/account/reports/%s", f.account.Type), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `[{"amount":"42.00","currency":"BRL"}]`, recorder.Body.String())
+
+	recorder = serveAs(t, f.server, f.owner.ID, http.MethodDelete, fmt.Sprintf("/category/%d", f.category.ID), nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	delete(f.store.accounts, f.account.ID)
+	recorder = serveAs(t, f.server, f.owner.ID, http.MethodDelete, fmt.Sprintf("/category/%d", f.category.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, f.store.categories, f.category.ID)
+}
+
+func TestCreateAccountIgnoresUserIDInBody(t *testing.T) {
+	f := newOwnershipFixture(t)
+
+	body := map[string]interface{}{
+		"user_id":     f.owner.ID,
+		"category_id": f.category.ID,
+		"wallet_id":   f.wallet.ID,
+		"title":       "spoofed",
+		"type":        f.category.Type,
+		"description": "spoofed",
+		"amount":      map[string]string{"amount": "1.00", "currency": "BRL"},
+		"date":        time.Now(),
+	}
+	recorder := serveAs(t, f.server, f.intruder.ID, http.MethodPost, "/account", body)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	for _, account := range f.store.accounts {
+		require.NotEqual(t, "spoofed", account.Title)
+	}
+}
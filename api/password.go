@@ -0,0 +1,242 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/mail"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+var errInvalidResetToken = errors.New("password reset token is invalid or expired")
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword emails a single-use reset link. It answers the same way
+// whether or not the email belongs to a user, so it cannot be used to
+// discover accounts.
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusAccepted, true)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if user.DeletedAt.Valid {
+		ctx.JSON(http.StatusAccepted, true)
+		return
+	}
+
+	err = server.sendPasswordResetEmail(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, true)
+}
+
+// sendPasswordResetEmail stores a new reset token for the user and mails
+// the link. Mail delivery errors are only logged.
+func (server *Server) sendPasswordResetEmail(ctx *gin.Context, user db.User) error {
+	resetToken, resetHash, err := token.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = server.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: resetHash,
+		ExpiresAt: time.Now().Add(server.config.PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = server.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your GoFinance password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s/password/reset?token=%s\n\nIf you did not ask for a new password you can ignore this email.",
+			user.Username, server.config.PasswordResetTTL, server.config.AppURL, url.QueryEscape(resetToken)),
+	})
+	if err != nil {
+		log.Printf("cannot send password reset email to user %d: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// resetPassword sets a new password with a reset token and signs the user
+// out of every device.
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	resetToken, err := server.store.GetPasswordResetToken(ctx, token.HashOpaqueToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if resetToken.UsedAt.Valid || time.Now().After(resetToken.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+		return
+	}
+
+	passwordHashed, err := util.HashPassword(req.Password, server.config.PasswordParams)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenID:  resetToken.ID,
+		UserID:   resetToken.UserID,
+		Password: passwordHashed,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrPasswordResetTokenUsed) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, resetToken.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordAuditEvent(ctx, auditEvent{
+		Type:     auditEventPasswordReset,
+		Outcome:  db.AuditOutcomeSuccess,
+		UserID:   user.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// changePassword sets a new password after checking the current one, see
+// checkCurrentPassword. Other sessions are signed out; the one making the
+// change stays open.
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims := authClaims(ctx)
+	user, err := server.store.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.checkCurrentPassword(ctx, user, req.CurrentPassword) {
+		return
+	}
+
+	passwordHashed, err := util.HashPassword(req.NewPassword, server.config.PasswordParams)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.store.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		UserID:    user.ID,
+		Password:  passwordHashed,
+		SessionID: claims.SessionID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventPasswordChanged, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+// checkCurrentPassword confirms a sensitive change with the signed in
+// user's password. Wrong passwords count towards the login throttle. When
+// it returns false the response has already been written.
+func (server *Server) checkCurrentPassword(ctx *gin.Context, user db.User, password string) bool {
+	throttleKeys := server.loginThrottleKeys(ctx, user.Username)
+	if !server.checkLoginThrottle(ctx, throttleKeys) {
+		return false
+	}
+
+	err := util.CheckPassword(password, user.Password)
+	if err != nil {
+		err = server.recordLoginFailure(ctx, throttleKeys, auditEvent{
+			Type:     auditEventReauthentication,
+			UserID:   user.ID,
+			Username: user.Username,
+			Details:  "wrong password",
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return false
+	}
+
+	err = server.clearLoginFailures(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
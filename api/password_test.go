@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+var resetLinkRegexp = regexp.MustCompile(`/password/reset\?token=(\S+)`)
+
+func resetTokenFromMailbox(t *testing.T, mailbox *bytes.Buffer) string {
+	matches := resetLinkRegexp.FindAllStringSubmatch(mailbox.String(), -1)
+	require.NotEmpty(t, matches)
+	resetToken, err := url.QueryUnescape(matches[len(matches)-1][1])
+	require.NoError(t, err)
+	return resetToken
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	server, mailbox := newTestServerWithMailbox(t, newFakeStore())
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/password/forgot", forgotPasswordRequest{
+		Email: util.RandomEmail(8),
+	})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.Zero(t, mailbox.Len())
+}
+
+func TestPasswordReset(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	user, session := loginTestUser(t, server)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/password/forgot", forgotPasswordRequest{Email: user.Email})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.Contains(t, mailbox.String(), "To: "+user.Email)
+	resetToken := resetTokenFromMailbox(t, mailbox)
+
+	for _, reset := range store.resets {
+		require.NotEqual(t, resetToken, reset.TokenHash)
+	}
+
+	newPassword := util.RandomString(12)
+	recorder = serveAs(t, server, 0, http.MethodPost, "/password/reset", resetPasswordRequest{
+		Token:    resetToken,
+		Password: newPassword,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, session.AccessToken, http.MethodPost, "/logout"))
+	status, _ := refresh(t, server, session.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	recorder = serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{Username: user.Username, Password: user.Password})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	login(t, server, user.Username, newPassword)
+
+	recorder = serveAs(t, server, 0, http.MethodPost, "/password/reset", resetPasswordRequest{
+		Token:    resetToken,
+		Password: util.RandomString(12),
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestPasswordResetInvalidatesOlderTokens(t *testing.T) {
+	server, mailbox := newTestServerWithMailbox(t, newFakeStore())
+	user, _ := loginTestUser(t, server)
+
+	serveAs(t, server, 0, http.MethodPost, "/password/forgot", forgotPasswordRequest{Email: user.Email})
+	firstToken := resetTokenFromMailbox(t, mailbox)
+	serveAs(t, server, 0, http.MethodPost, "/password/forgot", forgotPasswordRequest{Email: user.Email})
+	secondToken := resetTokenFromMailbox(t, mailbox)
+	require.NotEqual(t, firstToken, secondToken)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/password/reset", resetPasswordRequest{
+		Token:    secondToken,
+		Password: util.RandomString(12),
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveAs(t, server, 0, http.MethodPost, "/password/reset", resetPasswordRequest{
+		Token:    firstToken,
+		Password: util.RandomString(12),
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestPasswordResetExpiredToken(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	user, _ := loginTestUser(t, server)
+
+	serveAs(t, server, 0, http.MethodPost, "/password/forgot", forgotPasswordRequest{Email: user.Email})
+	resetToken := resetTokenFromMailbox(t, mailbox)
+	for id, reset := range store.resets {
+		reset.ExpiresAt = time.Now().Add(-time.Minute)
+		store.resets[id] = reset
+	}
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/password/reset", resetPasswordRequest{
+		Token:    resetToken,
+		Password: util.RandomString(12),
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// legacyPasswordHash is "legacy-secret" hashed with the SHA-512/256 and
+// bcrypt scheme used before argon2id, at bcrypt's minimum cost.
+const legacyPasswordHash = "$2a$04$ApAM.QfbpadZTRefvLBguemxUdM.rkVji5EaTkq44KlMQ4tlrfos6"
+
+func TestLoginRehashesLegacyPassword(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username: util.RandomString(6),
+		Password: legacyPasswordHash,
+		Email:    util.RandomEmail(8),
+	})
+	require.NoError(t, err)
+
+	login(t, server, user.Username, "legacy-secret")
+	rehashed := store.users[user.ID].Password
+	require.True(t, strings.HasPrefix(rehashed, "$argon2id$"))
+	require.False(t, util.PasswordNeedsRehash(rehashed, server.config.PasswordParams))
+
+	login(t, server, user.Username, "legacy-secret")
+	require.Equal(t, rehashed, store.users[user.ID].Password)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{Username: user.Username, Password: "wrong-secret"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLoginRehashesOutdatedParams(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, _ := loginTestUser(t, server)
+	stored, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	server.config.PasswordParams.Iterations++
+	login(t, server, user.Username, user.Password)
+	rehashed := store.users[stored.ID].Password
+	require.NotEqual(t, stored.Password, rehashed)
+	require.Contains(t, rehashed, fmt.Sprintf(",t=%d,", server.config.PasswordParams.Iterations))
+}
+
+func TestChangePassword(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, laptop := loginTestUser(t, server)
+	phone := login(t, server, user.Username, user.Password)
+
+	status, _ := serveAsSession(t, server, laptop.AccessToken, http.MethodPost, "/password/change", changePasswordRequest{
+		CurrentPassword: "wrong-password",
+		NewPassword:     util.RandomString(12),
+	})
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = serveAsSession(t, server, laptop.AccessToken, http.MethodPost, "/password/change", changePasswordRequest{
+		CurrentPassword: user.Password,
+		NewPassword:     "short",
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	newPassword := util.RandomString(12)
+	status, _ = serveAsSession(t, server, laptop.AccessToken, http.MethodPost, "/password/change", changePasswordRequest{
+		CurrentPassword: user.Password,
+		NewPassword:     newPassword,
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, phone.AccessToken, http.MethodPost, "/logout"))
+	status, _ = refresh(t, server, laptop.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{Username: user.Username, Password: user.Password})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	login(t, server, user.Username, newPassword)
+}
+
+func TestChangePasswordRequiresSession(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	_, session := loginTestUser(t, server)
+	pat := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:   "script",
+		Scopes: []string{scopeAccountsWrite},
+	}).Token
+
+	status, _ := serveAsSession(t, server, pat, http.MethodPost, "/password/change", changePasswordRequest{
+		CurrentPassword: "whatever",
+		NewPassword:     util.RandomString(12),
+	})
+	require.Equal(t, http.StatusForbidden, status)
+}
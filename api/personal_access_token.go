@@ -0,0 +1,200 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/token"
+)
+
+// personalAccessTokenPrefix marks bearer tokens that are personal access
+// tokens rather than JWTs. They look like "gfpat_<id>_<secret>".
+const personalAccessTokenPrefix = "gfpat_"
+
+// Scopes that can be granted to personal access tokens. Sessions opened
+// with a password have all of them.
+const (
+	scopeAccountsRead  = "accounts:read"
+	scopeAccountsWrite = "accounts:write"
+	scopeReportsRead   = "reports:read"
+)
+
+var (
+	errInvalidPersonalAccessToken = errors.New("personal access token is invalid, expired or revoked")
+	errInsufficientScope          = errors.New("token does not have the required scope")
+	errSessionRequired            = errors.New("this endpoint cannot be used with a personal access token")
+)
+
+// parsePersonalAccessToken splits a "gfpat_<id>_<secret>" token.
+func parsePersonalAccessToken(accessToken string) (int64, string, error) {
+	fields := strings.SplitN(strings.TrimPrefix(accessToken, personalAccessTokenPrefix), "_", 2)
+	if len(fields) != 2 || fields[1] == "" {
+		return 0, "", errInvalidPersonalAccessToken
+	}
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", errInvalidPersonalAccessToken
+	}
+	return id, fields[1], nil
+}
+
+// verifyPersonalAccessToken returns the claims and scopes of a valid
+// personal access token and records its use.
+func (server *Server) verifyPersonalAccessToken(ctx *gin.Context, accessToken string) (*token.Claims, []string, error) {
+	id, secret, err := parsePersonalAccessToken(accessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pat, err := server.store.GetPersonalAccessToken(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errInvalidPersonalAccessToken
+		}
+		return nil, nil, err
+	}
+
+	hash := token.HashOpaqueToken(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(pat.TokenHash)) != 1 ||
+		pat.RevokedAt.Valid || (pat.ExpiresAt.Valid && time.Now().After(pat.ExpiresAt.Time)) {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+
+	user, err := server.store.GetUserById(ctx, pat.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if checkUserActive(user) != nil || user.PasswordResetRequired {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+
+	err = server.store.TouchPersonalAccessToken(ctx, pat.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subject := tokenSubject(user)
+	claims := &token.Claims{
+		UserID:        subject.UserID,
+		Username:      subject.Username,
+		Role:          subject.Role,
+		EmailVerified: subject.EmailVerified,
+	}
+	return claims, pat.Scopes, nil
+}
+
+type createPersonalAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=accounts:read accounts:write reports:read"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createPersonalAccessTokenResponse struct {
+	personalAccessTokenResponse
+	Token string `json:"token"`
+}
+
+// createPersonalAccessToken issues a token for scripts. The token is only
+// returned here; the server keeps just its hash.
+func (server *Server) createPersonalAccessToken(ctx *gin.Context) {
+	var req createPersonalAccessTokenRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("expires_at must be in the future")))
+			return
+		}
+		expiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	secret, hash, err := token.NewOpaqueToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	pat, err := server.store.CreatePersonalAccessToken(ctx, db.CreatePersonalAccessTokenParams{
+		UserID:    authClaims(ctx).UserID,
+		Name:      req.Name,
+		TokenHash: hash,
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventTokenCreated, fmt.Sprintf("token %d (%s) with scopes %s", pat.ID, pat.Name, strings.Join(pat.Scopes, " ")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createPersonalAccessTokenResponse{
+		personalAccessTokenResponse: newPersonalAccessTokenResponse(pat),
+		Token:                       fmt.Sprintf("%s%d_%s", personalAccessTokenPrefix, pat.ID, secret),
+	})
+}
+
+func (server *Server) listPersonalAccessTokens(ctx *gin.Context) {
+	pats, err := server.store.ListPersonalAccessTokens(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]personalAccessTokenResponse, len(pats))
+	for i, pat := range pats {
+		rsp[i] = newPersonalAccessTokenResponse(pat)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokePersonalAccessTokenRequest struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
+func (server *Server) revokePersonalAccessToken(ctx *gin.Context) {
+	var req revokePersonalAccessTokenRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rows, err := server.store.RevokePersonalAccessToken(ctx, db.RevokePersonalAccessTokenParams{
+		ID:     req.ID,
+		UserID: authClaims(ctx).UserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventTokenRevoked, fmt.Sprintf("token %d", req.ID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+)
+
+func createTestPersonalAccessToken(t *testing.T, server *Server, session sessionResponse, req createPersonalAccessTokenRequest) createPersonalAccessTokenResponse {
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/tokens", req)
+	require.Equal(t, http.StatusOK, status)
+
+	var rsp createPersonalAccessTokenResponse
+	require.NoError(t, json.Unmarshal(body, &rsp))
+	require.True(t, strings.HasPrefix(rsp.Token, personalAccessTokenPrefix))
+	require.Equal(t, req.Scopes, rsp.Scopes)
+	return rsp
+}
+
+func TestPersonalAccessTokenScopes(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, session := loginTestUser(t, server)
+
+	pat := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:   "reporting script",
+		Scopes: []string{scopeAccountsRead, scopeReportsRead},
+	})
+	require.NotContains(t, store.pats[pat.ID].TokenHash, pat.Token)
+
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, pat.Token, http.MethodGet, "/account/graph/debit"))
+	require.Equal(t, http.StatusBadRequest, serveWithToken(t, server, pat.Token, http.MethodGet, "/account"))
+	require.Equal(t, http.StatusForbidden, serveWithToken(t, server, pat.Token, http.MethodPost, "/account"))
+	require.Equal(t, http.StatusForbidden, serveWithToken(t, server, pat.Token, http.MethodDelete, "/category/1"))
+	require.True(t, store.pats[pat.ID].LastUsedAt.Valid)
+
+	// Personal access tokens cannot manage tokens, MFA or sessions.
+	require.Equal(t, http.StatusForbidden, serveWithToken(t, server, pat.Token, http.MethodGet, "/tokens"))
+	require.Equal(t, http.StatusForbidden, serveWithToken(t, server, pat.Token, http.MethodPost, "/mfa/totp"))
+	require.Equal(t, http.StatusForbidden, serveWithToken(t, server, pat.Token, http.MethodPost, "/logout"))
+}
+
+func TestPersonalAccessTokenWriteScope(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, session := loginTestUser(t, server)
+	dbUser, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	store.VerifyUserEmail(context.Background(), db.VerifyUserEmailParams{ID: dbUser.ID, Email: dbUser.Email})
+
+	pat := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:   "import script",
+		Scopes: []string{scopeAccountsWrite},
+	})
+
+	status, body := serveAsSession(t, server, pat.Token, http.MethodPost, "/category", createCategoryRequest{
+		Title:       "Salary",
+		Type:        "credit",
+		Description: "imported",
+	})
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var category categoryResponse
+	require.NoError(t, json.Unmarshal(body, &category))
+	require.NotNil(t, category.CreatedBy)
+	require.Equal(t, dbUser.ID, *category.CreatedBy)
+	require.Equal(t, http.StatusForbidden, serveWithToken(t, server, pat.Token, http.MethodGet, fmt.Sprintf("/category/id/%d", category.ID)))
+}
+
+func TestPersonalAccessTokenRevokeAndExpiry(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, session := loginTestUser(t, server)
+	_, otherSession := loginTestUser(t, server)
+
+	expiresAt := time.Now().Add(time.Hour)
+	pat := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:      "expiring",
+		Scopes:    []string{scopeReportsRead},
+		ExpiresAt: &expiresAt,
+	})
+	other := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:   "forever",
+		Scopes: []string{scopeReportsRead},
+	})
+
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodGet, "/tokens", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.NotContains(t, string(body), pat.Token)
+	require.NotContains(t, string(body), "token_hash")
+	var listed []personalAccessTokenResponse
+	require.NoError(t, json.Unmarshal(body, &listed))
+	require.Len(t, listed, 2)
+	require.NotNil(t, listed[0].ExpiresAt)
+	require.Nil(t, listed[1].ExpiresAt)
+
+	// Expired tokens are rejected.
+	expired := store.pats[pat.ID]
+	expired.ExpiresAt.Time = time.Now().Add(-time.Second)
+	store.pats[pat.ID] = expired
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, pat.Token, http.MethodGet, "/account/graph/debit"))
+
+	// Other users cannot revoke the token.
+	url := fmt.Sprintf("/tokens/%d", other.ID)
+	require.Equal(t, http.StatusNotFound, serveWithToken(t, server, otherSession.AccessToken, http.MethodDelete, url))
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, other.Token, http.MethodGet, "/account/graph/debit"))
+
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, session.AccessToken, http.MethodDelete, url))
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, other.Token, http.MethodGet, "/account/graph/debit"))
+	require.Equal(t, http.StatusNotFound, serveWithToken(t, server, session.AccessToken, http.MethodDelete, url))
+}
+
+func TestCreatePersonalAccessTokenValidation(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	_, session := loginTestUser(t, server)
+	past := time.Now().Add(-time.Hour)
+
+	testCases := []createPersonalAccessTokenRequest{
+		{Name: "no scopes"},
+		{Name: "unknown scope", Scopes: []string{"admin"}},
+		{Name: "expired", Scopes: []string{scopeReportsRead}, ExpiresAt: &past},
+		{Scopes: []string{scopeReportsRead}},
+	}
+	for _, req := range testCases {
+		status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/tokens", req)
+		require.Equal(t, http.StatusBadRequest, status, req.Name)
+	}
+
+	for _, token := range []string{"gfpat_", "gfpat_1", "gfpat_x_secret", "gfpat_999_secret"} {
+		require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, token, http.MethodGet, "/account/graph/debit"))
+	}
+}
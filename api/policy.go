@@ -0,0 +1,242 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+// The authorization policy of the API lives in this file. Routes declare
+// what they need with the middlewares below; handlers only scope their
+// queries to the caller or to the ledger requireLedgerRole resolved.
+
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+// permission names an action outside the caller's own data.
+type permission string
+
+const (
+	permissionManageUsers permission = "users:manage"
+	permissionViewStats   permission = "stats:view"
+	permissionViewAudit   permission = "audit:view"
+	permissionManageRates permission = "exchange_rates:manage"
+)
+
+// rolePermissions lists what each role may do. Plain users only reach
+// their own data and have no entry.
+var rolePermissions = map[string][]permission{
+	roleAdmin: {permissionManageUsers, permissionViewStats, permissionViewAudit, permissionManageRates},
+}
+
+var (
+	errPermissionDenied       = errors.New("you are not allowed to do this")
+	errAccountDisabled        = errors.New("account has been disabled")
+	errAccountDeleted         = errors.New("account has been deleted")
+	errPasswordResetRequired  = errors.New("password must be reset before signing in, check your email")
+	errCannotChangeOwnAccount = errors.New("admins cannot disable or change the role of their own account")
+	errLedgerNotFound         = errors.New("ledger not found")
+	errInvalidLedgerID        = errors.New("ledger_id must be a positive integer")
+)
+
+// ledgerMemberKey holds the caller's membership in the ledger a request
+// works on, see requireLedgerRole.
+const ledgerMemberKey = "ledger_member"
+
+// ledgerRoleRanks orders ledger roles; each role may do everything the
+// roles below it may.
+var ledgerRoleRanks = map[string]int{
+	db.LedgerRoleViewer: 1,
+	db.LedgerRoleEditor: 2,
+	db.LedgerRoleOwner:  3,
+}
+
+// can reports whether the caller's role grants perm. Tokens issued before
+// roles existed carry no role and are treated as plain users.
+func can(claims *token.Claims, perm permission) bool {
+	for _, p := range rolePermissions[claims.Role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// canViewUser reports whether the caller may see the profile of user:
+// their own, or anyone's for admins.
+func canViewUser(claims *token.Claims, user db.User) bool {
+	return claims.UserID == user.ID || can(claims, permissionManageUsers)
+}
+
+// checkUserActive returns errAccountDisabled or errAccountDeleted for
+// accounts that cannot sign in, refresh tokens or use personal access
+// tokens.
+func checkUserActive(user db.User) error {
+	if user.DeletedAt.Valid {
+		return errAccountDeleted
+	}
+	if user.DisabledAt.Valid {
+		return errAccountDisabled
+	}
+	return nil
+}
+
+// authorize rejects callers whose role does not grant perm with 403 and
+// audits the attempt.
+func (server *Server) authorize(perm permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := authClaims(ctx)
+		if !can(claims, perm) {
+			err := server.recordAuditEvent(ctx, auditEvent{
+				Type:     auditEventPermissionDenied,
+				Outcome:  db.AuditOutcomeFailure,
+				UserID:   claims.UserID,
+				Username: claims.Username,
+				Details:  fmt.Sprintf("%s %s needs %s", ctx.Request.Method, ctx.FullPath(), perm),
+			})
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errPermissionDenied))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// requireLedgerRole resolves the ledger a request works on and rejects
+// callers whose role in it is below role. The ledger comes from the
+// ledger_id path or query parameter and defaults to the caller's personal
+// ledger. Ledgers the caller is not a member of answer 404, as if they did
+// not exist.
+func (server *Server) requireLedgerRole(role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := authClaims(ctx)
+
+		ledgerID, err := requestLedgerID(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if ledgerID == 0 {
+			ledger, err := server.store.EnsurePersonalLedgerTx(ctx, claims.UserID)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			ledgerID = ledger.ID
+		}
+
+		member, err := server.store.GetLedgerMember(ctx, db.GetLedgerMemberParams{
+			LedgerID: ledgerID,
+			UserID:   claims.UserID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, errorResponse(errLedgerNotFound))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if ledgerRoleRanks[member.Role] < ledgerRoleRanks[role] {
+			err = server.recordAuditEvent(ctx, auditEvent{
+				Type:     auditEventPermissionDenied,
+				Outcome:  db.AuditOutcomeFailure,
+				UserID:   claims.UserID,
+				Username: claims.Username,
+				Details:  fmt.Sprintf("%s %s needs %s role in ledger %d", ctx.Request.Method, ctx.FullPath(), role, ledgerID),
+			})
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errPermissionDenied))
+			return
+		}
+
+		ctx.Set(ledgerMemberKey, member)
+		ctx.Next()
+	}
+}
+
+// requestLedgerID returns the ledger_id path or query parameter, or 0 when
+// the request names no ledger.
+func requestLedgerID(ctx *gin.Context) (int32, error) {
+	value := ctx.Param("ledger_id")
+	if value == "" {
+		value = ctx.Query("ledger_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || id < 1 {
+		return 0, errInvalidLedgerID
+	}
+	return int32(id), nil
+}
+
+// ledgerMember returns the membership stored by requireLedgerRole.
+func ledgerMember(ctx *gin.Context) db.LedgerMember {
+	return ctx.MustGet(ledgerMemberKey).(db.LedgerMember)
+}
+
+// requireScope declares the scope a handler needs. Requests authenticated
+// with a personal access token that lacks it are rejected with 403.
+func (server *Server) requireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scopes, ok := ctx.Get(authorizationScopesKey)
+		if ok && !containsString(scopes.([]string), scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errInsufficientScope))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// requireSession rejects personal access tokens on account security
+// endpoints, which need a session opened with the password.
+func (server *Server) requireSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := ctx.Get(authorizationScopesKey); ok {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errSessionRequired))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// verifiedEmailMiddleware runs after authMiddleware. Under the readonly
+// policy it limits users with an unverified email to GET and HEAD requests.
+func (server *Server) verifiedEmailMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if server.config.UnverifiedLoginPolicy == util.UnverifiedLoginReadOnly && !authClaims(ctx).EmailVerified &&
+			ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errEmailNotVerified))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+var (
+	errUsernameTaken   = errors.New("username is already taken")
+	errInvalidUsername = errors.New("username may only contain letters, digits, '.', '_' and '-'")
+)
+
+func (server *Server) getProfile(ctx *gin.Context) {
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newProfileResponse(user))
+}
+
+type updateProfileRequest struct {
+	Username     *string `json:"username" binding:"omitempty,min=3,max=30"`
+	DisplayName  *string `json:"display_name" binding:"omitempty,max=100"`
+	BaseCurrency *string `json:"base_currency"`
+}
+
+// updateProfile changes the username, display name and the base currency
+// converted reports use. Fields left out of the request keep their value.
+// Access tokens carry the old username until they are refreshed.
+func (server *Server) updateProfile(ctx *gin.Context) {
+	var req updateProfileRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateUserProfileParams{
+		ID:           user.ID,
+		Username:     user.Username,
+		DisplayName:  user.DisplayName,
+		BaseCurrency: user.BaseCurrency,
+	}
+	if req.DisplayName != nil {
+		arg.DisplayName = *req.DisplayName
+	}
+	if req.BaseCurrency != nil {
+		if !money.IsCurrency(*req.BaseCurrency) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(money.ErrUnknownCurrency))
+			return
+		}
+		arg.BaseCurrency = *req.BaseCurrency
+	}
+	if req.Username != nil && *req.Username != user.Username {
+		if usernameUnsafeRegexp.MatchString(*req.Username) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidUsername))
+			return
+		}
+
+		_, err = server.store.GetUser(ctx, *req.Username)
+		if err == nil {
+			ctx.JSON(http.StatusConflict, errorResponse(errUsernameTaken))
+			return
+		}
+		if err != sql.ErrNoRows {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.Username = *req.Username
+	}
+
+	user, err = server.store.UpdateUserProfile(ctx, arg)
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errUsernameTaken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventProfileUpdated, fmt.Sprintf("username %q, display name %q, base currency %s", user.Username, user.DisplayName, user.BaseCurrency))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newProfileResponse(user))
+}
+
+type changeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password"`
+}
+
+// changeEmail starts an email change. The new address becomes the
+// account's email once the link mailed to it is followed, see verifyEmail;
+// until then the current address stays in use. Asking for the current
+// address cancels a pending change. Accounts with a password have to
+// confirm it.
+func (server *Server) changeEmail(ctx *gin.Context) {
+	var req changeEmailRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Password != "" && !server.checkCurrentPassword(ctx, user, req.Password) {
+		return
+	}
+
+	pendingEmail := sql.NullString{String: req.Email, Valid: req.Email != user.Email}
+	if pendingEmail.Valid {
+		_, err = server.store.GetUserByEmail(ctx, req.Email)
+		if err == nil {
+			ctx.JSON(http.StatusConflict, errorResponse(errEmailInUse))
+			return
+		}
+		if err != sql.ErrNoRows {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	err = server.store.SetUserPendingEmail(ctx, db.SetUserPendingEmailParams{
+		ID:           user.ID,
+		PendingEmail: pendingEmail,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	user.PendingEmail = pendingEmail
+
+	if !pendingEmail.Valid {
+		ctx.JSON(http.StatusOK, newProfileResponse(user))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventEmailChangeRequested, "new email "+req.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.sendEmailChangeEmails(ctx, user)
+	ctx.JSON(http.StatusAccepted, newProfileResponse(user))
+}
+
+type deleteProfileRequest struct {
+	Password string `json:"password"`
+}
+
+type deleteProfileResponse struct {
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+// deleteProfile soft-deletes the caller's account and signs it out
+// everywhere. The account and all its categories and accounts are purged
+// once ACCOUNT_DELETION_GRACE_PERIOD has passed. Accounts with a password
+// have to confirm it.
+func (server *Server) deleteProfile(ctx *gin.Context) {
+	var req deleteProfileRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Password != "" && !server.checkCurrentPassword(ctx, user, req.Password) {
+		return
+	}
+
+	user, err = server.store.DeleteUserTx(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventProfileDeleted, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deleteProfileResponse{
+		DeletedAt:  user.DeletedAt.Time,
+		PurgeAfter: user.DeletedAt.Time.Add(server.config.AccountDeletionGracePeriod),
+	})
+}
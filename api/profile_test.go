@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestUpdateProfile(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, session := loginTestUser(t, server)
+	other, _ := loginTestUser(t, server)
+
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodPatch, "/profile", updateProfileRequest{
+		DisplayName: stringPtr("Alice Doe"),
+	})
+	require.Equal(t, http.StatusOK, status)
+	var rsp profileResponse
+	require.NoError(t, json.Unmarshal(body, &rsp))
+	require.Equal(t, "Alice Doe", rsp.DisplayName)
+	require.Equal(t, user.Username, rsp.Username)
+	require.Equal(t, "BRL", rsp.BaseCurrency)
+
+	status, body = serveAsSession(t, server, session.AccessToken, http.MethodPatch, "/profile", updateProfileRequest{
+		BaseCurrency: stringPtr("USD"),
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &rsp))
+	require.Equal(t, "USD", rsp.BaseCurrency)
+	require.Equal(t, "Alice Doe", rsp.DisplayName)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPatch, "/profile", updateProfileRequest{
+		BaseCurrency: stringPtr("usd"),
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPatch, "/profile", updateProfileRequest{
+		Username: stringPtr(other.Username),
+	})
+	require.Equal(t, http.StatusConflict, status)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPatch, "/profile", updateProfileRequest{
+		Username: stringPtr("not allowed"),
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	newUsername := util.RandomString(8)
+	status, body = serveAsSession(t, server, session.AccessToken, http.MethodPatch, "/profile", updateProfileRequest{
+		Username: stringPtr(newUsername),
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &rsp))
+	require.Equal(t, newUsername, rsp.Username)
+	require.Equal(t, "Alice Doe", rsp.DisplayName)
+
+	login(t, server, newUsername, user.Password)
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{Username: user.Username, Password: user.Password})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestUsernameTaken(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, session := loginTestUser(t, server)
+	other, _ := loginTestUser(t, server)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user", createUserRequest{
+		Username: user.Username,
+		Password: util.RandomString(12),
+		Email:    util.RandomEmail(8),
+	})
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPatch, "/profile", updateProfileRequest{
+		Username: stringPtr(other.Username),
+	})
+	require.Equal(t, http.StatusConflict, status)
+}
+
+func TestChangeEmail(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	user, session := loginTestUser(t, server)
+	other, _ := loginTestUser(t, server)
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/profile/email", changeEmailRequest{
+		Email:    util.RandomEmail(8),
+		Password: "wrong-password",
+	})
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = serveAsSession(t, server, session.AccessToken, http.MethodPost, "/profile/email", changeEmailRequest{
+		Email:    other.Email,
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusConflict, status)
+
+	mailbox.Reset()
+	newEmail := util.RandomEmail(8)
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/profile/email", changeEmailRequest{
+		Email:    newEmail,
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusAccepted, status)
+	var rsp profileResponse
+	require.NoError(t, json.Unmarshal(body, &rsp))
+	require.Equal(t, user.Email, rsp.Email)
+	require.NotNil(t, rsp.PendingEmail)
+	require.Equal(t, newEmail, *rsp.PendingEmail)
+	require.Contains(t, mailbox.String(), "To: "+newEmail)
+	require.Contains(t, mailbox.String(), "To: "+user.Email)
+	verifyToken := verifyTokenFromMailbox(t, mailbox)
+
+	// The current address stays in use until the new one is confirmed.
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user/verify", verifyEmailRequest{Token: verifyToken})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	dbUser, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, newEmail, dbUser.Email)
+	require.False(t, dbUser.PendingEmail.Valid)
+	require.True(t, dbUser.VerifiedAt.Valid)
+
+	// Verifying twice is harmless.
+	recorder = serveAs(t, server, 0, http.MethodPost, "/user/verify", verifyEmailRequest{Token: verifyToken})
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestChangeEmailCancel(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	user, session := loginTestUser(t, server)
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/profile/email", changeEmailRequest{
+		Email:    util.RandomEmail(8),
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusAccepted, status)
+	verifyToken := verifyTokenFromMailbox(t, mailbox)
+
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodPost, "/profile/email", changeEmailRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusOK, status)
+	var rsp profileResponse
+	require.NoError(t, json.Unmarshal(body, &rsp))
+	require.Nil(t, rsp.PendingEmail)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user/verify", verifyEmailRequest{Token: verifyToken})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	dbUser, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, dbUser.Email)
+}
+
+func TestDeleteProfile(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	user, session := loginTestUser(t, server)
+	phone := login(t, server, user.Username, user.Password)
+	pat := createTestPersonalAccessToken(t, server, session, createPersonalAccessTokenRequest{
+		Name:   "script",
+		Scopes: []string{scopeAccountsRead},
+	}).Token
+
+	status, _ := serveAsSession(t, server, session.AccessToken, http.MethodDelete, "/profile", deleteProfileRequest{
+		Password: "wrong-password",
+	})
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, body := serveAsSession(t, server, session.AccessToken, http.MethodDelete, "/profile", deleteProfileRequest{
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusOK, status)
+	var rsp deleteProfileResponse
+	require.NoError(t, json.Unmarshal(body, &rsp))
+	require.WithinDuration(t, time.Now(), rsp.DeletedAt, time.Second)
+	require.Equal(t, rsp.DeletedAt.Add(24*time.Hour), rsp.PurgeAfter)
+
+	// The account is signed out everywhere and looks like it does not exist.
+	status, _ = refresh(t, server, phone.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, pat, http.MethodGet, "/category"))
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{Username: user.Username, Password: user.Password})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	mailbox.Reset()
+	recorder = serveAs(t, server, 0, http.MethodPost, "/password/forgot", forgotPasswordRequest{Email: user.Email})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.Zero(t, mailbox.Len())
+}
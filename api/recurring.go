@@ -0,0 +1,504 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/schedule"
+)
+
+var (
+	errRecurringNotFound   = errors.New("recurring transaction not found")
+	errRecurringEnd        = errors.New("ends_on must not be before starts_on")
+	errRecurringAmount     = errors.New("amount must be positive")
+	errOccurrenceNotFound  = errors.New("the recurring transaction does not occur on that day")
+	errOccurrenceGenerated = errors.New("the occurrence is already recorded; change its transaction instead")
+	errPreviewRange        = errors.New("a preview spans at most five years")
+)
+
+// recurringSchedule is how a recurring transaction repeats: an RRULE
+// such as FREQ=MONTHLY;BYMONTHDAY=5 that ends after ends_on or after
+// occurrence_count occurrences, or never without either.
+type recurringSchedule struct {
+	Rule            string `json:"rule" binding:"required"`
+	EndsOn          string `json:"ends_on"`
+	OccurrenceCount int32  `json:"occurrence_count" binding:"min=0"`
+}
+
+// parse validates the schedule of a recurring transaction starting on
+// startsOn and returns its rule written the way schedule.Rule does.
+func (req recurringSchedule) parse(startsOn time.Time) (rule string, endsOn sql.NullTime, count sql.NullInt32, err error) {
+	parsed, err := schedule.Parse(req.Rule)
+	if err != nil {
+		return
+	}
+	if req.EndsOn != "" {
+		endsOn.Time, err = time.Parse(dateLayout, req.EndsOn)
+		if err != nil {
+			return
+		}
+		if endsOn.Time.Before(startsOn) {
+			err = errRecurringEnd
+			return
+		}
+		endsOn.Valid = true
+	}
+	count = sql.NullInt32{Int32: req.OccurrenceCount, Valid: req.OccurrenceCount > 0}
+	return parsed.String(), endsOn, count, nil
+}
+
+type createRecurringRequest struct {
+	CategoryID  int32        `json:"category_id" binding:"required"`
+	WalletID    int32        `json:"wallet_id" binding:"required"`
+	Title       string       `json:"title" binding:"required"`
+	Description string       `json:"description"`
+	Amount      *money.Money `json:"amount" binding:"required"`
+	StartsOn    string       `json:"starts_on" binding:"required"`
+	recurringSchedule
+}
+
+// createRecurring adds a template for a transaction that repeats, such
+// as rent or a salary. Its type is the type of its category.
+func (server *Server) createRecurring(ctx *gin.Context) {
+	var req createRecurringRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Amount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errRecurringAmount))
+		return
+	}
+
+	startsOn, err := time.Parse(dateLayout, req.StartsOn)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	rule, endsOn, count, err := req.parse(startsOn)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	member := ledgerMember(ctx)
+	category, err := server.store.GetCategory(ctx, db.GetCategoryParams{
+		ID:       req.CategoryID,
+		LedgerID: member.LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	wallet, ok := server.transactionWallet(ctx, req.WalletID, *req.Amount)
+	if !ok {
+		return
+	}
+
+	recurring, err := server.store.CreateRecurringTransaction(ctx, db.CreateRecurringTransactionParams{
+		LedgerID:        member.LedgerID,
+		CreatedBy:       sql.NullInt32{Int32: member.UserID, Valid: true},
+		CategoryID:      category.ID,
+		WalletID:        wallet.ID,
+		Title:           req.Title,
+		Type:            category.Type,
+		Description:     req.Description,
+		Amount:          req.Amount.Amount,
+		Currency:        wallet.Currency,
+		Rule:            rule,
+		StartsOn:        startsOn,
+		EndsOn:          endsOn,
+		OccurrenceCount: count,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRecurringResponse(recurring))
+}
+
+func (server *Server) listRecurring(ctx *gin.Context) {
+	templates, err := server.store.ListRecurringTransactions(ctx, ledgerMember(ctx).LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]recurringResponse, len(templates))
+	for i, recurring := range templates {
+		rsp[i] = newRecurringResponse(recurring)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type recurringURI struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// ledgerRecurring loads a recurring transaction of the request's ledger,
+// answering 404 for those of other ledgers.
+func (server *Server) ledgerRecurring(ctx *gin.Context, id int32) (db.RecurringTransaction, bool) {
+	recurring, err := server.store.GetRecurringTransaction(ctx, db.GetRecurringTransactionParams{
+		ID:       id,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRecurringNotFound))
+			return db.RecurringTransaction{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.RecurringTransaction{}, false
+	}
+	return recurring, true
+}
+
+func (server *Server) getRecurring(ctx *gin.Context) {
+	var uri recurringURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	recurring, ok := server.ledgerRecurring(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRecurringResponse(recurring))
+}
+
+type updateRecurringRequest struct {
+	Title       string       `json:"title" binding:"required"`
+	Description string       `json:"description"`
+	Amount      *money.Money `json:"amount" binding:"required"`
+	recurringSchedule
+}
+
+// updateRecurring changes the occurrences of a recurring transaction that
+// are not recorded yet. Its category, wallet and start stay; create a new
+// one to change them.
+func (server *Server) updateRecurring(ctx *gin.Context) {
+	var uri recurringURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateRecurringRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Amount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errRecurringAmount))
+		return
+	}
+
+	recurring, ok := server.ledgerRecurring(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	if !recurringCurrency(ctx, recurring, *req.Amount) {
+		return
+	}
+	rule, endsOn, count, err := req.parse(recurring.StartsOn)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	recurring, err = server.store.UpdateRecurringTransaction(ctx, db.UpdateRecurringTransactionParams{
+		ID:              recurring.ID,
+		Title:           req.Title,
+		Description:     req.Description,
+		Amount:          req.Amount.Amount,
+		Rule:            rule,
+		EndsOn:          endsOn,
+		OccurrenceCount: count,
+		LedgerID:        recurring.LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRecurringNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRecurringResponse(recurring))
+}
+
+// recurringCurrency checks that amount is in the currency of a recurring
+// transaction.
+func recurringCurrency(ctx *gin.Context, recurring db.RecurringTransaction, amount money.Money) bool {
+	if amount.Currency != recurring.Currency {
+		err := fmt.Errorf("%w: recurring transaction %d is in %s", money.ErrCurrencyMismatch, recurring.ID, recurring.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// deleteRecurring stops a recurring transaction. The transactions it
+// already recorded are kept.
+func (server *Server) deleteRecurring(ctx *gin.Context) {
+	var uri recurringURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ledgerID := ledgerMember(ctx).LedgerID
+	rows, err := server.store.DeleteRecurringTransaction(ctx, db.DeleteRecurringTransactionParams{
+		ID:       uri.ID,
+		LedgerID: ledgerID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errRecurringNotFound))
+		return
+	}
+
+	server.recordOwnDeletion(ctx, auditEventRecurringDeleted, fmt.Sprintf("recurring transaction %d in ledger %d", uri.ID, ledgerID))
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type listOccurrencesRequest struct {
+	From time.Time `form:"from" time_format:"2006-01-02"`
+	To   time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+// listOccurrences previews the days a recurring transaction falls on from
+// from, today by default, to to, three months later by default, with
+// the occurrences skipped, modified or already recorded.
+func (server *Server) listOccurrences(ctx *gin.Context) {
+	var uri recurringURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listOccurrencesRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.From.IsZero() {
+		req.From = schedule.Day(time.Now())
+	}
+	if req.To.IsZero() {
+		req.To = req.From.AddDate(0, 3, 0)
+	}
+	if req.From.After(req.To) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidDateRange))
+		return
+	}
+	if req.To.After(req.From.AddDate(5, 0, 0)) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errPreviewRange))
+		return
+	}
+
+	recurring, ok := server.ledgerRecurring(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	s, err := recurring.Schedule()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	exceptions, err := server.store.ListRecurringOccurrences(ctx, db.ListRecurringOccurrencesParams{
+		RecurringID: recurring.ID,
+		FromDate:    req.From,
+		ToDate:      req.To,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	byDate := map[string]db.RecurringOccurrence{}
+	for _, exception := range exceptions {
+		byDate[exception.Date.Format(dateLayout)] = exception
+	}
+
+	occurrences := s.Between(req.From, req.To)
+	rsp := make([]occurrenceResponse, len(occurrences))
+	for i, occurrence := range occurrences {
+		rsp[i] = newOccurrenceResponse(recurring, occurrence, byDate[occurrence.Date.Format(dateLayout)])
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type occurrenceURI struct {
+	ID   int32  `uri:"id" binding:"required,min=1"`
+	Date string `uri:"date" binding:"required"`
+}
+
+// ledgerOccurrence loads the recurring transaction named in the path and
+// the occurrence on the day it names, answering 404 when the recurring
+// transaction does not fall on that day.
+func (server *Server) ledgerOccurrence(ctx *gin.Context) (db.RecurringTransaction, schedule.Occurrence, bool) {
+	var uri occurrenceURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.RecurringTransaction{}, schedule.Occurrence{}, false
+	}
+	date, err := time.Parse(dateLayout, uri.Date)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.RecurringTransaction{}, schedule.Occurrence{}, false
+	}
+
+	recurring, ok := server.ledgerRecurring(ctx, uri.ID)
+	if !ok {
+		return db.RecurringTransaction{}, schedule.Occurrence{}, false
+	}
+	s, err := recurring.Schedule()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.RecurringTransaction{}, schedule.Occurrence{}, false
+	}
+	occurrences := s.Between(date, date)
+	if len(occurrences) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errOccurrenceNotFound))
+		return db.RecurringTransaction{}, schedule.Occurrence{}, false
+	}
+	return recurring, occurrences[0], true
+}
+
+type modifyOccurrenceRequest struct {
+	Title       string       `json:"title"`
+	Description *string      `json:"description"`
+	Amount      *money.Money `json:"amount"`
+}
+
+// modifyOccurrence changes the title, description or amount of a single
+// occurrence before it is recorded, for a bill that differs one month.
+// Fields left out follow the recurring transaction; sending none undoes
+// earlier changes.
+func (server *Server) modifyOccurrence(ctx *gin.Context) {
+	recurring, occurrence, ok := server.ledgerOccurrence(ctx)
+	if !ok {
+		return
+	}
+
+	var req modifyOccurrenceRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ModifyRecurringOccurrenceParams{
+		RecurringID: recurring.ID,
+		Date:        occurrence.Date,
+		Title:       sql.NullString{String: req.Title, Valid: req.Title != ""},
+	}
+	if req.Description != nil {
+		arg.Description = sql.NullString{String: *req.Description, Valid: true}
+	}
+	if req.Amount != nil {
+		if !recurringCurrency(ctx, recurring, *req.Amount) {
+			return
+		}
+		arg.Amount = sql.NullInt64{Int64: req.Amount.Amount, Valid: true}
+	}
+
+	exception, err := server.store.ModifyRecurringOccurrence(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errOccurrenceGenerated))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newOccurrenceResponse(recurring, occurrence, exception))
+}
+
+// skipOccurrence keeps a single occurrence from being recorded.
+func (server *Server) skipOccurrence(ctx *gin.Context) {
+	recurring, occurrence, ok := server.ledgerOccurrence(ctx)
+	if !ok {
+		return
+	}
+
+	exception, err := server.store.SkipRecurringOccurrence(ctx, db.SkipRecurringOccurrenceParams{
+		RecurringID: recurring.ID,
+		Date:        occurrence.Date,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errOccurrenceGenerated))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newOccurrenceResponse(recurring, occurrence, exception))
+}
+
+// restoreOccurrence undoes skipping or modifying a single occurrence, so
+// it follows its recurring transaction again.
+func (server *Server) restoreOccurrence(ctx *gin.Context) {
+	recurring, occurrence, ok := server.ledgerOccurrence(ctx)
+	if !ok {
+		return
+	}
+
+	rows, err := server.store.DeleteRecurringOccurrence(ctx, db.DeleteRecurringOccurrenceParams{
+		RecurringID: recurring.ID,
+		Date:        occurrence.Date,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		// Either there was nothing to undo or the occurrence is recorded.
+		exceptions, err := server.store.ListRecurringOccurrences(ctx, db.ListRecurringOccurrencesParams{
+			RecurringID: recurring.ID,
+			FromDate:    occurrence.Date,
+			ToDate:      occurrence.Date,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if len(exceptions) > 0 {
+			ctx.JSON(http.StatusConflict, errorResponse(errOccurrenceGenerated))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, newOccurrenceResponse(recurring, occurrence, db.RecurringOccurrence{}))
+}
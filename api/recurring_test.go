@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+func createTestRecurring(t *testing.T, server *Server, userID int32, req createRecurringRequest) recurringResponse {
+	recorder := serveAs(t, server, userID, http.MethodPost, "/recurring", req)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var recurring recurringResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &recurring))
+	return recurring
+}
+
+func listTestOccurrences(t *testing.T, server *Server, userID, recurringID int32, from, to string) []occurrenceResponse {
+	url := fmt.Sprintf("/recurring/%d/occurrences?from=%s&to=%s", recurringID, from, to)
+	recorder := serveAs(t, server, userID, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var occurrences []occurrenceResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &occurrences))
+	return occurrences
+}
+
+func TestCreateRecurring(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	req := createRecurringRequest{
+		CategoryID: category.ID,
+		WalletID:   wallet.ID,
+		Title:      "Rent",
+		Amount:     &money.Money{Amount: 150000, Currency: "BRL"},
+		StartsOn:   "2024-01-01",
+		recurringSchedule: recurringSchedule{
+			Rule:            "freq=monthly;bymonthday=5",
+			OccurrenceCount: 12,
+		},
+	}
+	recurring := createTestRecurring(t, server, user.ID, req)
+	require.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=5", recurring.Rule)
+	require.Equal(t, category.Type, recurring.Type)
+	require.Equal(t, money.New(150000, "BRL"), recurring.Amount)
+	require.Equal(t, "2024-01-01", recurring.StartsOn)
+	require.Nil(t, recurring.EndsOn)
+	require.Equal(t, int32(12), *recurring.OccurrenceCount)
+
+	recorder := serveAs(t, server, user.ID, http.MethodGet, "/recurring", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"title":"Rent"`)
+
+	for _, tc := range []struct {
+		change func(req *createRecurringRequest)
+		status int
+	}{
+		{func(req *createRecurringRequest) { req.Rule = "FREQ=HOURLY" }, http.StatusBadRequest},
+		{func(req *createRecurringRequest) { req.StartsOn = "01/01/2024" }, http.StatusBadRequest},
+		{func(req *createRecurringRequest) { req.EndsOn = "2023-12-31" }, http.StatusBadRequest},
+		{func(req *createRecurringRequest) { req.Amount = &money.Money{Amount: 1, Currency: "USD"} }, http.StatusBadRequest},
+		{func(req *createRecurringRequest) { req.Amount = &money.Money{Amount: 0, Currency: "BRL"} }, http.StatusBadRequest},
+		{func(req *createRecurringRequest) { req.Amount = &money.Money{Amount: -150000, Currency: "BRL"} }, http.StatusBadRequest},
+		{func(req *createRecurringRequest) { req.WalletID = 9999 }, http.StatusNotFound},
+		{func(req *createRecurringRequest) { req.CategoryID = 9999 }, http.StatusNotFound},
+	} {
+		bad := req
+		tc.change(&bad)
+		recorder := serveAs(t, server, user.ID, http.MethodPost, "/recurring", bad)
+		require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+	}
+	require.Len(t, store.recurring, 1)
+
+	other := createTestLedgerUser(t, store)
+	createTestCategory(t, store, other.ID)
+	recorder = serveAs(t, server, other.ID, http.MethodGet, fmt.Sprintf("/recurring/%d", recurring.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestRecurringOccurrences(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	recurring := createTestRecurring(t, server, user.ID, createRecurringRequest{
+		CategoryID: category.ID,
+		WalletID:   wallet.ID,
+		Title:      "Salary",
+		Amount:     &money.Money{Amount: 500000, Currency: "BRL"},
+		StartsOn:   "2024-01-01",
+		recurringSchedule: recurringSchedule{
+			Rule: "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+		},
+	})
+
+	occurrences := listTestOccurrences(t, server, user.ID, recurring.ID, "2024-03-01", "2024-06-30")
+	require.Len(t, occurrences, 4)
+	require.Equal(t, 3, occurrences[0].Index)
+	require.Equal(t, "2024-03-29", occurrences[0].Date)
+	require.Equal(t, "2024-06-28", occurrences[3].Date)
+
+	occurrenceURL := fmt.Sprintf("/recurring/%d/occurrences/", recurring.ID)
+	recorder := serveAs(t, server, user.ID, http.MethodPost, occurrenceURL+"2024-04-30/skip", nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	description := "With the bonus"
+	recorder = serveAs(t, server, user.ID, http.MethodPut, occurrenceURL+"2024-05-31", modifyOccurrenceRequest{
+		Description: &description,
+		Amount:      &money.Money{Amount: 750000, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	occurrences = listTestOccurrences(t, server, user.ID, recurring.ID, "2024-04-01", "2024-05-31")
+	require.Len(t, occurrences, 2)
+	require.True(t, occurrences[0].Skipped)
+	require.False(t, occurrences[0].Modified)
+	require.True(t, occurrences[1].Modified)
+	require.Equal(t, "Salary", occurrences[1].Title)
+	require.Equal(t, description, occurrences[1].Description)
+	require.Equal(t, money.New(750000, "BRL"), occurrences[1].Amount)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, occurrenceURL+"2024-04-30", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	occurrences = listTestOccurrences(t, server, user.ID, recurring.ID, "2024-04-01", "2024-04-30")
+	require.False(t, occurrences[0].Skipped)
+
+	// Days the schedule does not fall on have no occurrence.
+	recorder = serveAs(t, server, user.ID, http.MethodPost, occurrenceURL+"2024-04-29/skip", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodPut, occurrenceURL+"2024-05-31", modifyOccurrenceRequest{
+		Amount: &money.Money{Amount: 1, Currency: "USD"},
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Recorded occurrences are changed through their transaction.
+	template, err := store.GetRecurringTransaction(context.Background(), db.GetRecurringTransactionParams{ID: recurring.ID, LedgerID: recurring.LedgerID})
+	require.NoError(t, err)
+	account, err := store.GenerateRecurringTx(context.Background(), db.GenerateRecurringTxParams{
+		Recurring: template,
+		Date:      time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	occurrences = listTestOccurrences(t, server, user.ID, recurring.ID, "2024-03-01", "2024-03-31")
+	require.True(t, occurrences[0].Generated)
+	require.Equal(t, account.ID, *occurrences[0].AccountID)
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		recorder = serveAs(t, server, user.ID, method, occurrenceURL+"2024-03-29", modifyOccurrenceRequest{})
+		require.Equal(t, http.StatusConflict, recorder.Code)
+	}
+	recorder = serveAs(t, server, user.ID, http.MethodPost, occurrenceURL+"2024-03-29/skip", nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, fmt.Sprintf("/recurring/%d/occurrences?from=2024-01-01&to=2030-01-01", recurring.ID), nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestRecurringOccurrencesFarAhead(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	recurring := createTestRecurring(t, server, user.ID, createRecurringRequest{
+		CategoryID: category.ID,
+		WalletID:   wallet.ID,
+		Title:      "Coffee",
+		Amount:     &money.Money{Amount: 500, Currency: "BRL"},
+		StartsOn:   "2024-01-01",
+		recurringSchedule: recurringSchedule{
+			Rule: "FREQ=DAILY",
+		},
+	})
+
+	occurrences := listTestOccurrences(t, server, user.ID, recurring.ID, "9999-12-30", "9999-12-31")
+	require.Len(t, occurrences, 2)
+	require.Equal(t, "9999-12-30", occurrences[0].Date)
+	require.Equal(t, 2913173, occurrences[0].Index)
+}
+
+func TestUpdateAndDeleteRecurring(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+
+	recurring := createTestRecurring(t, server, user.ID, createRecurringRequest{
+		CategoryID: category.ID,
+		WalletID:   wallet.ID,
+		Title:      "Streaming",
+		Amount:     &money.Money{Amount: 3990, Currency: "BRL"},
+		StartsOn:   "2024-01-15",
+		recurringSchedule: recurringSchedule{
+			Rule: "FREQ=MONTHLY",
+		},
+	})
+
+	categoryURL := fmt.Sprintf("/category/%d", category.ID)
+	recorder := serveAs(t, server, user.ID, http.MethodDelete, categoryURL, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Contains(t, store.categories, category.ID)
+
+	url := fmt.Sprintf("/recurring/%d", recurring.ID)
+	recorder = serveAs(t, server, user.ID, http.MethodPut, url, updateRecurringRequest{
+		Title:  "Streaming",
+		Amount: &money.Money{Amount: 4490, Currency: "BRL"},
+		recurringSchedule: recurringSchedule{
+			Rule:   "FREQ=WEEKLY;INTERVAL=2",
+			EndsOn: "2024-03-01",
+		},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var updated recurringResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &updated))
+	require.Equal(t, money.New(4490, "BRL"), updated.Amount)
+	require.Equal(t, "2024-03-01", *updated.EndsOn)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPut, url, updateRecurringRequest{
+		Title:  "Streaming",
+		Amount: &money.Money{Amount: -4490, Currency: "BRL"},
+		recurringSchedule: recurringSchedule{
+			Rule: "FREQ=MONTHLY",
+		},
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	occurrences := listTestOccurrences(t, server, user.ID, recurring.ID, "2024-01-01", "2024-12-31")
+	require.Len(t, occurrences, 4)
+	require.Equal(t, "2024-02-26", occurrences[3].Date)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, store.eventsOfType(auditEventRecurringDeleted), 1)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, categoryURL, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
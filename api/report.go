@@ -0,0 +1,255 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+var errMissingExchangeRate = errors.New("no exchange rate")
+
+// convertReportRequest asks for a report in the caller's base currency
+// instead of one sum per currency.
+type convertReportRequest struct {
+	Convert bool `form:"convert"`
+}
+
+// rateConverter converts amounts to one currency with the latest exchange
+// rate on or before their date, and remembers the rates it used.
+type rateConverter struct {
+	store db.Store
+	to    string
+	rates map[rateLookup]db.ExchangeRate
+	used  []db.ExchangeRate
+}
+
+type rateLookup struct {
+	currency string
+	date     time.Time
+}
+
+func newRateConverter(store db.Store, to string) *rateConverter {
+	return &rateConverter{store: store, to: to, rates: map[rateLookup]db.ExchangeRate{}}
+}
+
+// convert returns amount in the converter's currency. It fails with
+// errMissingExchangeRate when no rate for the date is stored.
+func (c *rateConverter) convert(ctx *gin.Context, amount money.Money, date time.Time) (money.Money, error) {
+	if amount.Currency == c.to {
+		return amount, nil
+	}
+
+	lookup := rateLookup{currency: amount.Currency, date: date}
+	stored, ok := c.rates[lookup]
+	if !ok {
+		var err error
+		stored, err = c.store.GetExchangeRate(ctx, db.GetExchangeRateParams{
+			FromCurrency: amount.Currency,
+			ToCurrency:   c.to,
+			Date:         date,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return money.Money{}, fmt.Errorf("%w from %s to %s on %s", errMissingExchangeRate,
+					amount.Currency, c.to, date.Format(dateLayout))
+			}
+			return money.Money{}, err
+		}
+		c.rates[lookup] = stored
+		c.markUsed(stored)
+	}
+
+	rate, err := money.ParseRate(stored.Rate)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if stored.FromCurrency != amount.Currency {
+		rate.Inv(rate)
+	}
+	return amount.Convert(c.to, rate)
+}
+
+func (c *rateConverter) markUsed(rate db.ExchangeRate) {
+	for _, used := range c.used {
+		if used.FromCurrency == rate.FromCurrency && used.ToCurrency == rate.ToCurrency && used.Date.Equal(rate.Date) {
+			return
+		}
+	}
+	c.used = append(c.used, rate)
+}
+
+// usedRates returns the rates convert used, in the order it first used
+// them.
+func (c *rateConverter) usedRates() []exchangeRateResponse {
+	rsp := make([]exchangeRateResponse, len(c.used))
+	for i, rate := range c.used {
+		rsp[i] = newExchangeRateResponse(rate)
+	}
+	return rsp
+}
+
+// baseCurrencyConverter converts to the caller's base currency.
+func (server *Server) baseCurrencyConverter(ctx *gin.Context) (*rateConverter, error) {
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		return nil, err
+	}
+	return newRateConverter(server.store, user.BaseCurrency), nil
+}
+
+// reportError answers with 422 when a report cannot be converted for lack
+// of exchange rates.
+func reportError(ctx *gin.Context, err error) {
+	if errors.Is(err, errMissingExchangeRate) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
+
+// getConvertedAccountReports sums accounts of a type in the caller's base
+// currency, converting each day's amounts with that day's rate.
+func (server *Server) getConvertedAccountReports(ctx *gin.Context, arg db.GetAccountsReportsByDateParams) {
+	converter, err := server.baseCurrencyConverter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	sums, err := server.store.GetAccountsReportsByDate(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	total := money.New(0, converter.to)
+	for _, sum := range sums {
+		converted, err := converter.convert(ctx, money.New(sum.Amount, sum.Currency), sum.Date)
+		if err == nil {
+			total, err = total.Add(converted)
+		}
+		if err != nil {
+			reportError(ctx, err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, convertedReportResponse{Total: total, Rates: converter.usedRates()})
+}
+
+type getCategoryReportsRequest struct {
+	Type string `uri:"type" binding:"required"`
+}
+
+// getCategoryReports breaks the accounts of a type down by category, with
+// one sum per currency or, with convert, a sum in the caller's base
+// currency.
+func (server *Server) getCategoryReports(ctx *gin.Context) {
+	var req getCategoryReportsRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query convertReportRequest
+	err = ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rows, err := server.store.GetCategoryReports(ctx, db.GetCategoryReportsParams{
+		LedgerID: ledgerMember(ctx).LedgerID,
+		Type:     req.Type,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if query.Convert {
+		server.convertCategoryReports(ctx, rows)
+		return
+	}
+
+	// Rows come ordered by category; sum each category's days per currency.
+	rsp := []categoryReportResponse{}
+	for _, row := range rows {
+		if len(rsp) == 0 || rsp[len(rsp)-1].CategoryID != row.CategoryID {
+			rsp = append(rsp, categoryReportResponse{
+				CategoryID:    row.CategoryID,
+				CategoryTitle: row.CategoryTitle,
+				Amounts:       []money.Money{},
+			})
+		}
+		err = addAmount(&rsp[len(rsp)-1].Amounts, money.New(row.Amount, row.Currency))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) convertCategoryReports(ctx *gin.Context, rows []db.GetCategoryReportsRow) {
+	converter, err := server.baseCurrencyConverter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := convertedCategoryReportResponse{
+		Total:      money.New(0, converter.to),
+		Categories: []convertedCategoryTotal{},
+	}
+	for _, row := range rows {
+		categories := rsp.Categories
+		if len(categories) == 0 || categories[len(categories)-1].CategoryID != row.CategoryID {
+			rsp.Categories = append(categories, convertedCategoryTotal{
+				CategoryID:    row.CategoryID,
+				CategoryTitle: row.CategoryTitle,
+				Amount:        money.New(0, converter.to),
+			})
+		}
+		category := &rsp.Categories[len(rsp.Categories)-1]
+
+		converted, err := converter.convert(ctx, money.New(row.Amount, row.Currency), row.Date)
+		if err == nil {
+			category.Amount, err = category.Amount.Add(converted)
+		}
+		if err == nil {
+			rsp.Total, err = rsp.Total.Add(converted)
+		}
+		if err != nil {
+			reportError(ctx, err)
+			return
+		}
+	}
+	rsp.Rates = converter.usedRates()
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// addAmount adds amount to the entry of its currency in amounts, keeping
+// them in the order currencies first appear.
+func addAmount(amounts *[]money.Money, amount money.Money) error {
+	for i, m := range *amounts {
+		if m.Currency == amount.Currency {
+			sum, err := m.Add(amount)
+			if err != nil {
+				return err
+			}
+			(*amounts)[i] = sum
+			return nil
+		}
+	}
+	*amounts = append(*amounts, amount)
+	return nil
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createTestAccountOn(t *testing.T, store *fakeStore, category db.Category, amount int64, currency, date string) {
+	day, err := time.Parse(dateLayout, date)
+	require.NoError(t, err)
+
+	_, err = store.CreateAccount(context.Background(), db.CreateAccountParams{
+		LedgerID:    category.LedgerID,
+		CategoryID:  sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:       util.RandomString(8),
+		Type:        category.Type,
+		Description: util.RandomString(12),
+		Amount:      amount,
+		Currency:    currency,
+		Date:        day,
+	})
+	require.NoError(t, err)
+}
+
+func importTestRates(t *testing.T, store *fakeStore, rates ...exchangeRateRequest) {
+	params := make([]db.UpsertExchangeRateParams, len(rates))
+	for i, rate := range rates {
+		var err error
+		params[i], err = rate.toParams()
+		require.NoError(t, err)
+	}
+	_, err := store.ImportExchangeRatesTx(context.Background(), params)
+	require.NoError(t, err)
+}
+
+func TestConvertedReports(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	travel := createTestCategory(t, store, user.ID)
+	groceries := createTestCategory(t, store, user.ID)
+
+	createTestAccountOn(t, store, travel, 10000, "USD", "2024-03-01")
+	createTestAccountOn(t, store, travel, 1000, "USD", "2024-03-02")
+	createTestAccountOn(t, store, travel, 1000, "JPY", "2024-03-02")
+	createTestAccountOn(t, store, groceries, 5000, "BRL", "2024-03-02")
+
+	importTestRates(t, store,
+		exchangeRateRequest{FromCurrency: "USD", ToCurrency: "BRL", Date: "2024-03-01", Rate: "5"},
+		// Only the inverse pair is stored for the second day.
+		exchangeRateRequest{FromCurrency: "BRL", ToCurrency: "USD", Date: "2024-03-02", Rate: "0.25"},
+		// A rate after the transaction is never used.
+		exchangeRateRequest{FromCurrency: "JPY", ToCurrency: "BRL", Date: "2024-03-03", Rate: "0.04"},
+	)
+
+	recorder := serveAs(t, server, user.ID, http.MethodGet, "/account/reports/debit?convert=true", nil)
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "JPY to BRL on 2024-03-02")
+
+	importTestRates(t, store, exchangeRateRequest{FromCurrency: "JPY", ToCurrency: "BRL", Date: "2024-02-28", Rate: "0.034"})
+
+	// 100 USD at 5, 10 USD at 1/0.25, 1000 JPY at 0.034 and 50 BRL.
+	recorder = serveAs(t, server, user.ID, http.MethodGet, "/account/reports/debit?convert=true", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{
+		"total": {"amount":"624.00","currency":"BRL"},
+		"rates": [
+			{"from_currency":"USD","to_currency":"BRL","date":"2024-03-01","rate":"5"},
+			{"from_currency":"JPY","to_currency":"BRL","date":"2024-02-28","rate":"0.034"},
+			{"from_currency":"BRL","to_currency":"USD","date":"2024-03-02","rate":"0.25"}
+		]
+	}`, recorder.Body.String())
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, "/account/reports/debit/categories", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, fmt.Sprintf(`[
+		{"category_id":%d,"category_title":%q,"amounts":[
+			{"amount":"110.00","currency":"USD"},
+			{"amount":"1000","currency":"JPY"}
+		]},
+		{"category_id":%d,"category_title":%q,"amounts":[
+			{"amount":"50.00","currency":"BRL"}
+		]}
+	]`, travel.ID, travel.Title, groceries.ID, groceries.Title), recorder.Body.String())
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, "/account/reports/debit/categories?convert=true", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, fmt.Sprintf(`{
+		"total": {"amount":"624.00","currency":"BRL"},
+		"categories": [
+			{"category_id":%d,"category_title":%q,"amount":{"amount":"574.00","currency":"BRL"}},
+			{"category_id":%d,"category_title":%q,"amount":{"amount":"50.00","currency":"BRL"}}
+		],
+		"rates": [
+			{"from_currency":"USD","to_currency":"BRL","date":"2024-03-01","rate":"5"},
+			{"from_currency":"JPY","to_currency":"BRL","date":"2024-02-28","rate":"0.034"},
+			{"from_currency":"BRL","to_currency":"USD","date":"2024-03-02","rate":"0.25"}
+		]
+	}`, travel.ID, travel.Title, groceries.ID, groceries.Title), recorder.Body.String())
+}
+
+func TestConvertedReportInBaseCurrency(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	createTestAccountOn(t, store, category, 5000, "BRL", "2024-03-02")
+
+	// Amounts already in the base currency need no rates.
+	recorder := serveAs(t, server, user.ID, http.MethodGet, "/account/reports/debit?convert=true", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"total":{"amount":"50.00","currency":"BRL"},"rates":[]}`, recorder.Body.String())
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, "/account/reports/debit?convert=maybe", nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
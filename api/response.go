@@ -0,0 +1,785 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/schedule"
+)
+
+// This file is the representation layer of the API: handlers never
+// serialize sqlc models directly but map them to the response types below.
+// Password hashes, token hashes, TOTP secrets and other credentials have no
+// place in any of them; response_test.go enforces it.
+
+// dateLayout is how calendar days, such as exchange rate dates and wallet
+// balance dates, are written in requests, responses and CSV files.
+const dateLayout = "2006-01-02"
+
+// monthLayout is how months, such as budget months, are written in
+// requests and responses.
+const monthLayout = "2006-01"
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// nullDatePtr writes a nullable calendar day in dateLayout.
+func nullDatePtr(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
+	}
+	date := t.Time.Format(dateLayout)
+	return &date
+}
+
+func nullInt32Ptr(n sql.NullInt32) *int32 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int32
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+type userResponse struct {
+	ID          int32      `json:"id"`
+	Username    string     `json:"username"`
+	DisplayName string     `json:"display_name"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	VerifiedAt  *time.Time `json:"verified_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newUserResponse(user db.User) userResponse {
+	return userResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+		Role:        user.Role,
+		VerifiedAt:  nullTimePtr(user.VerifiedAt),
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+// profileResponse is what users see of their own account, including an
+// email change that is waiting for confirmation.
+type profileResponse struct {
+	userResponse
+	PendingEmail *string `json:"pending_email"`
+	BaseCurrency string  `json:"base_currency"`
+}
+
+func newProfileResponse(user db.User) profileResponse {
+	return profileResponse{
+		userResponse: newUserResponse(user),
+		PendingEmail: nullStringPtr(user.PendingEmail),
+		BaseCurrency: user.BaseCurrency,
+	}
+}
+
+// adminUserResponse adds the account state admins manage to userResponse.
+type adminUserResponse struct {
+	userResponse
+	DisabledAt            *time.Time `json:"disabled_at"`
+	DeletedAt             *time.Time `json:"deleted_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+}
+
+func newAdminUserResponse(user db.User) adminUserResponse {
+	return adminUserResponse{
+		userResponse:          newUserResponse(user),
+		DisabledAt:            nullTimePtr(user.DisabledAt),
+		DeletedAt:             nullTimePtr(user.DeletedAt),
+		PasswordResetRequired: user.PasswordResetRequired,
+	}
+}
+
+type categoryResponse struct {
+	ID          int32     `json:"id"`
+	LedgerID    int32     `json:"ledger_id"`
+	CreatedBy   *int32    `json:"created_by"`
+	Title       string    `json:"title"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newCategoryResponse(category db.Category) categoryResponse {
+	return categoryResponse{
+		ID:          category.ID,
+		LedgerID:    category.LedgerID,
+		CreatedBy:   nullInt32Ptr(category.CreatedBy),
+		Title:       category.Title,
+		Type:        category.Type,
+		Description: category.Description,
+		CreatedAt:   category.CreatedAt,
+	}
+}
+
+// accountResponse is a transaction. Legs of a transfer between wallets
+// have a transfer_id instead of a category_id.
+type accountResponse struct {
+	ID          int32       `json:"id"`
+	LedgerID    int32       `json:"ledger_id"`
+	CreatedBy   *int32      `json:"created_by"`
+	CategoryID  *int32      `json:"category_id"`
+	Title       string      `json:"title"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+	WalletID    int32       `json:"wallet_id"`
+	TransferID  *int32      `json:"transfer_id"`
+	Date        time.Time   `json:"date"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		ID:          account.ID,
+		LedgerID:    account.LedgerID,
+		CreatedBy:   nullInt32Ptr(account.CreatedBy),
+		CategoryID:  nullInt32Ptr(account.CategoryID),
+		Title:       account.Title,
+		Type:        account.Type,
+		Description: account.Description,
+		Amount:      money.New(account.Amount, account.Currency),
+		WalletID:    account.WalletID,
+		TransferID:  nullInt32Ptr(account.TransferID),
+		Date:        account.Date,
+		CreatedAt:   account.CreatedAt,
+	}
+}
+
+// accountListItemResponse is an account as listed by getAccounts, with the
+// title of its category.
+type accountListItemResponse struct {
+	ID            int32       `json:"id"`
+	LedgerID      int32       `json:"ledger_id"`
+	CreatedBy     *int32      `json:"created_by"`
+	Title         string      `json:"title"`
+	Type          string      `json:"type"`
+	Description   string      `json:"description"`
+	Amount        money.Money `json:"amount"`
+	WalletID      int32       `json:"wallet_id"`
+	TransferID    *int32      `json:"transfer_id"`
+	Date          time.Time   `json:"date"`
+	CreatedAt     time.Time   `json:"created_at"`
+	CategoryTitle *string     `json:"category_title"`
+}
+
+func newAccountListItemResponse(row db.GetAccountsRow) accountListItemResponse {
+	return accountListItemResponse{
+		ID:            row.ID,
+		LedgerID:      row.LedgerID,
+		CreatedBy:     nullInt32Ptr(row.CreatedBy),
+		Title:         row.Title,
+		Type:          row.Type,
+		Description:   row.Description,
+		Amount:        money.New(row.Amount, row.Currency),
+		WalletID:      row.WalletID,
+		TransferID:    nullInt32Ptr(row.TransferID),
+		Date:          row.Date,
+		CreatedAt:     row.CreatedAt,
+		CategoryTitle: nullStringPtr(row.CategoryTitle),
+	}
+}
+
+// walletResponse is a wallet with its balance: the opening balance plus
+// its credit transactions minus its debit ones.
+type walletResponse struct {
+	ID             int32       `json:"id"`
+	LedgerID       int32       `json:"ledger_id"`
+	CreatedBy      *int32      `json:"created_by"`
+	Name           string      `json:"name"`
+	Type           string      `json:"type"`
+	OpeningBalance money.Money `json:"opening_balance"`
+	Balance        money.Money `json:"balance"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+func newWalletResponse(wallet db.Wallet, balance int64) walletResponse {
+	return walletResponse{
+		ID:             wallet.ID,
+		LedgerID:       wallet.LedgerID,
+		CreatedBy:      nullInt32Ptr(wallet.CreatedBy),
+		Name:           wallet.Name,
+		Type:           wallet.Type,
+		OpeningBalance: money.New(wallet.OpeningBalance, wallet.Currency),
+		Balance:        money.New(balance, wallet.Currency),
+		CreatedAt:      wallet.CreatedAt,
+	}
+}
+
+func newWalletListItemResponse(row db.ListWalletsRow) walletResponse {
+	return newWalletResponse(db.Wallet{
+		ID:             row.ID,
+		LedgerID:       row.LedgerID,
+		CreatedBy:      row.CreatedBy,
+		Name:           row.Name,
+		Type:           row.Type,
+		Currency:       row.Currency,
+		OpeningBalance: row.OpeningBalance,
+		CreatedAt:      row.CreatedAt,
+	}, row.Balance)
+}
+
+// walletBalanceResponse is the balance of a wallet at the end of date, or
+// after all its transactions when date is null.
+type walletBalanceResponse struct {
+	WalletID int32       `json:"wallet_id"`
+	Date     *string     `json:"date"`
+	Balance  money.Money `json:"balance"`
+}
+
+// walletBalancesResponse is a wallet's balance history: the balance before
+// the period and, for each day in it with transactions, the day's net
+// change and the balance at its end.
+type walletBalancesResponse struct {
+	WalletID       int32               `json:"wallet_id"`
+	OpeningBalance money.Money         `json:"opening_balance"`
+	Days           []walletDayResponse `json:"days"`
+}
+
+type walletDayResponse struct {
+	Date    string      `json:"date"`
+	Change  money.Money `json:"change"`
+	Balance money.Money `json:"balance"`
+}
+
+// transferResponse is a transfer between two wallets with the
+// transactions recording it in each of them.
+type transferResponse struct {
+	ID          int32               `json:"id"`
+	LedgerID    int32               `json:"ledger_id"`
+	CreatedBy   *int32              `json:"created_by"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Date        time.Time           `json:"date"`
+	From        transferLegResponse `json:"from"`
+	To          transferLegResponse `json:"to"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type transferLegResponse struct {
+	AccountID int32       `json:"account_id"`
+	WalletID  int32       `json:"wallet_id"`
+	Amount    money.Money `json:"amount"`
+}
+
+func newTransferResponse(result db.TransferTxResult) transferResponse {
+	return transferResponse{
+		ID:          result.Transfer.ID,
+		LedgerID:    result.Transfer.LedgerID,
+		CreatedBy:   nullInt32Ptr(result.Transfer.CreatedBy),
+		Title:       result.From.Title,
+		Description: result.From.Description,
+		Date:        result.From.Date,
+		From:        newTransferLegResponse(result.From),
+		To:          newTransferLegResponse(result.To),
+		CreatedAt:   result.Transfer.CreatedAt,
+	}
+}
+
+func newTransferLegResponse(leg db.Account) transferLegResponse {
+	return transferLegResponse{
+		AccountID: leg.ID,
+		WalletID:  leg.WalletID,
+		Amount:    money.New(leg.Amount, leg.Currency),
+	}
+}
+
+type recurringResponse struct {
+	ID              int32       `json:"id"`
+	LedgerID        int32       `json:"ledger_id"`
+	CreatedBy       *int32      `json:"created_by"`
+	CategoryID      int32       `json:"category_id"`
+	WalletID        int32       `json:"wallet_id"`
+	Title           string      `json:"title"`
+	Type            string      `json:"type"`
+	Description     string      `json:"description"`
+	Amount          money.Money `json:"amount"`
+	Rule            string      `json:"rule"`
+	StartsOn        string      `json:"starts_on"`
+	EndsOn          *string     `json:"ends_on"`
+	OccurrenceCount *int32      `json:"occurrence_count"`
+	// GeneratedThrough is the last day whose occurrences are recorded.
+	GeneratedThrough *string   `json:"generated_through"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func newRecurringResponse(recurring db.RecurringTransaction) recurringResponse {
+	return recurringResponse{
+		ID:               recurring.ID,
+		LedgerID:         recurring.LedgerID,
+		CreatedBy:        nullInt32Ptr(recurring.CreatedBy),
+		CategoryID:       recurring.CategoryID,
+		WalletID:         recurring.WalletID,
+		Title:            recurring.Title,
+		Type:             recurring.Type,
+		Description:      recurring.Description,
+		Amount:           money.New(recurring.Amount, recurring.Currency),
+		Rule:             recurring.Rule,
+		StartsOn:         recurring.StartsOn.Format(dateLayout),
+		EndsOn:           nullDatePtr(recurring.EndsOn),
+		OccurrenceCount:  nullInt32Ptr(recurring.OccurrenceCount),
+		GeneratedThrough: nullDatePtr(recurring.GeneratedThrough),
+		CreatedAt:        recurring.CreatedAt,
+	}
+}
+
+// occurrenceResponse is a day a recurring transaction falls on, as it
+// will be recorded or, once Generated, as it was. AccountID is the
+// transaction recording it while that exists.
+type occurrenceResponse struct {
+	Index       int         `json:"index"`
+	Date        string      `json:"date"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+	Skipped     bool        `json:"skipped"`
+	Modified    bool        `json:"modified"`
+	Generated   bool        `json:"generated"`
+	AccountID   *int32      `json:"account_id"`
+}
+
+// newOccurrenceResponse applies the changes made to an occurrence, if
+// any, to its recurring transaction.
+func newOccurrenceResponse(recurring db.RecurringTransaction, occurrence schedule.Occurrence, exception db.RecurringOccurrence) occurrenceResponse {
+	rsp := occurrenceResponse{
+		Index:       occurrence.Index,
+		Date:        occurrence.Date.Format(dateLayout),
+		Title:       recurring.Title,
+		Description: recurring.Description,
+		Amount:      money.New(recurring.Amount, recurring.Currency),
+		Skipped:     exception.Skipped,
+		Modified:    exception.Title.Valid || exception.Description.Valid || exception.Amount.Valid,
+		Generated:   exception.GeneratedAt.Valid,
+		AccountID:   nullInt32Ptr(exception.AccountID),
+	}
+	if exception.Title.Valid {
+		rsp.Title = exception.Title.String
+	}
+	if exception.Description.Valid {
+		rsp.Description = exception.Description.String
+	}
+	if exception.Amount.Valid {
+		rsp.Amount.Amount = exception.Amount.Int64
+	}
+	return rsp
+}
+
+type budgetResponse struct {
+	ID         int32       `json:"id"`
+	LedgerID   int32       `json:"ledger_id"`
+	CategoryID int32       `json:"category_id"`
+	Month      string      `json:"month"`
+	Amount     money.Money `json:"amount"`
+	Rollover   bool        `json:"rollover"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+func newBudgetResponse(budget db.Budget) budgetResponse {
+	return budgetResponse{
+		ID:         budget.ID,
+		LedgerID:   budget.LedgerID,
+		CategoryID: budget.CategoryID,
+		Month:      budget.Month.Format(monthLayout),
+		Amount:     money.New(budget.Amount, budget.Currency),
+		Rollover:   budget.Rollover,
+		CreatedAt:  budget.CreatedAt,
+	}
+}
+
+// budgetReportResponse compares the budget of every category in a month
+// with what it took. Rates are the exchange rates used to convert
+// amounts in other currencies.
+type budgetReportResponse struct {
+	Month      string                   `json:"month"`
+	Categories []budgetProgressResponse `json:"categories"`
+	Rates      []exchangeRateResponse   `json:"rates"`
+}
+
+// budgetProgressResponse is how a category stands against its budget:
+// it has Budgeted plus CarriedOver from the months before, took Spent
+// and has Remaining left, which is negative once it is over budget.
+// BudgetID is nil for a category without a budget for the month.
+type budgetProgressResponse struct {
+	CategoryID    int32       `json:"category_id"`
+	CategoryTitle string      `json:"category_title"`
+	BudgetID      *int32      `json:"budget_id"`
+	Rollover      bool        `json:"rollover"`
+	Budgeted      money.Money `json:"budgeted"`
+	CarriedOver   money.Money `json:"carried_over"`
+	Spent         money.Money `json:"spent"`
+	Remaining     money.Money `json:"remaining"`
+}
+
+// goalResponse is a savings goal with how far it is. Contributions are
+// left out of lists of goals.
+type goalResponse struct {
+	ID            int32                      `json:"id"`
+	LedgerID      int32                      `json:"ledger_id"`
+	CreatedBy     *int32                     `json:"created_by"`
+	WalletID      *int32                     `json:"wallet_id"`
+	Title         string                     `json:"title"`
+	TargetAmount  money.Money                `json:"target_amount"`
+	StartsOn      string                     `json:"starts_on"`
+	Deadline      *string                    `json:"deadline"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	Progress      goalProgressResponse       `json:"progress"`
+	Contributions []goalContributionResponse `json:"contributions,omitempty"`
+}
+
+func newGoalResponse(goal db.Goal) goalResponse {
+	return goalResponse{
+		ID:           goal.ID,
+		LedgerID:     goal.LedgerID,
+		CreatedBy:    nullInt32Ptr(goal.CreatedBy),
+		WalletID:     nullInt32Ptr(goal.WalletID),
+		Title:        goal.Title,
+		TargetAmount: money.New(goal.TargetAmount, goal.Currency),
+		StartsOn:     goal.StartsOn.Format(dateLayout),
+		Deadline:     nullDatePtr(goal.Deadline),
+		CreatedAt:    goal.CreatedAt,
+	}
+}
+
+// goalProgressResponse is how far a goal is. CompletedOn is the day the
+// target was reached, while it still is. MonthlyNeeded, what is left
+// over the months through the deadline, and OnTrack, whether saving at
+// AverageMonthly reaches the target by then, are only set for goals with
+// a deadline. ProjectedCompletion is nil when nothing is being saved.
+type goalProgressResponse struct {
+	Saved               money.Money  `json:"saved"`
+	Remaining           money.Money  `json:"remaining"`
+	Percent             int          `json:"percent"`
+	CompletedOn         *string      `json:"completed_on"`
+	AverageMonthly      money.Money  `json:"average_monthly"`
+	MonthlyNeeded       *money.Money `json:"monthly_needed"`
+	ProjectedCompletion *string      `json:"projected_completion"`
+	OnTrack             *bool        `json:"on_track"`
+}
+
+// goalContributionResponse is a transaction linked to a goal or a
+// transfer in or out of its wallet, which is negative.
+type goalContributionResponse struct {
+	AccountID int32       `json:"account_id"`
+	Date      string      `json:"date"`
+	Amount    money.Money `json:"amount"`
+	Transfer  bool        `json:"transfer"`
+}
+
+func newGoalContributionResponse(row db.ListGoalContributionsRow, currency string) goalContributionResponse {
+	return goalContributionResponse{
+		AccountID: row.AccountID,
+		Date:      row.Date.Format(dateLayout),
+		Amount:    money.New(row.Amount, currency),
+		Transfer:  row.TransferID.Valid,
+	}
+}
+
+// installmentPlanResponse is a purchase paid in installments with the
+// transactions recording them, which lists of plans leave out.
+type installmentPlanResponse struct {
+	ID           int32             `json:"id"`
+	LedgerID     int32             `json:"ledger_id"`
+	CreatedBy    *int32            `json:"created_by"`
+	CategoryID   int32             `json:"category_id"`
+	WalletID     int32             `json:"wallet_id"`
+	Title        string            `json:"title"`
+	Type         string            `json:"type"`
+	Description  string            `json:"description"`
+	TotalAmount  money.Money       `json:"total_amount"`
+	Installments int32             `json:"installments"`
+	FirstDate    string            `json:"first_date"`
+	Remainder    string            `json:"remainder"`
+	CancelledAt  *time.Time        `json:"cancelled_at"`
+	CreatedAt    time.Time         `json:"created_at"`
+	Accounts     []accountResponse `json:"accounts,omitempty"`
+}
+
+func newInstallmentPlanResponse(plan db.InstallmentPlan) installmentPlanResponse {
+	return installmentPlanResponse{
+		ID:           plan.ID,
+		LedgerID:     plan.LedgerID,
+		CreatedBy:    nullInt32Ptr(plan.CreatedBy),
+		CategoryID:   plan.CategoryID,
+		WalletID:     plan.WalletID,
+		Title:        plan.Title,
+		Type:         plan.Type,
+		Description:  plan.Description,
+		TotalAmount:  money.New(plan.TotalAmount, plan.Currency),
+		Installments: plan.Installments,
+		FirstDate:    plan.FirstDate.Format(dateLayout),
+		Remainder:    plan.Remainder,
+		CancelledAt:  nullTimePtr(plan.CancelledAt),
+		CreatedAt:    plan.CreatedAt,
+	}
+}
+
+func newInstallmentPlanTxResponse(result db.InstallmentPlanTxResult) installmentPlanResponse {
+	rsp := newInstallmentPlanResponse(result.Plan)
+	rsp.Accounts = make([]accountResponse, len(result.Installments))
+	for i, account := range result.Installments {
+		rsp.Accounts[i] = newAccountResponse(account)
+	}
+	return rsp
+}
+
+// exchangeRateResponse says one unit of from_currency was worth rate units
+// of to_currency on date.
+type exchangeRateResponse struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	Date         string `json:"date"`
+	Rate         string `json:"rate"`
+}
+
+func newExchangeRateResponse(rate db.ExchangeRate) exchangeRateResponse {
+	value := rate.Rate
+	parsed, err := money.ParseRate(rate.Rate)
+	if err == nil {
+		value = money.FormatRate(parsed)
+	}
+
+	return exchangeRateResponse{
+		FromCurrency: rate.FromCurrency,
+		ToCurrency:   rate.ToCurrency,
+		Date:         rate.Date.Format(dateLayout),
+		Rate:         value,
+	}
+}
+
+// convertedReportResponse is a report total in the caller's base currency
+// with the exchange rates used to get there.
+type convertedReportResponse struct {
+	Total money.Money            `json:"total"`
+	Rates []exchangeRateResponse `json:"rates"`
+}
+
+// categoryReportResponse sums a category's accounts per currency.
+type categoryReportResponse struct {
+	CategoryID    int32         `json:"category_id"`
+	CategoryTitle string        `json:"category_title"`
+	Amounts       []money.Money `json:"amounts"`
+}
+
+// convertedCategoryReportResponse is the category breakdown in the
+// caller's base currency with the exchange rates used to get there.
+type convertedCategoryReportResponse struct {
+	Total      money.Money              `json:"total"`
+	Categories []convertedCategoryTotal `json:"categories"`
+	Rates      []exchangeRateResponse   `json:"rates"`
+}
+
+type convertedCategoryTotal struct {
+	CategoryID    int32       `json:"category_id"`
+	CategoryTitle string      `json:"category_title"`
+	Amount        money.Money `json:"amount"`
+}
+
+// ledgerResponse is a ledger with the caller's role in it.
+type ledgerResponse struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Personal  bool      `json:"personal"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newLedgerResponse(ledger db.Ledger, role string) ledgerResponse {
+	return ledgerResponse{
+		ID:        ledger.ID,
+		Name:      ledger.Name,
+		Personal:  ledger.Personal,
+		Role:      role,
+		CreatedAt: ledger.CreatedAt,
+	}
+}
+
+func newLedgerListItemResponse(row db.ListUserLedgersRow) ledgerResponse {
+	return ledgerResponse{
+		ID:        row.ID,
+		Name:      row.Name,
+		Personal:  row.Personal,
+		Role:      row.Role,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+type ledgerMemberResponse struct {
+	UserID      int32     `json:"user_id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+func newLedgerMemberResponse(row db.ListLedgerMembersRow) ledgerMemberResponse {
+	return ledgerMemberResponse{
+		UserID:      row.UserID,
+		Username:    row.Username,
+		DisplayName: row.DisplayName,
+		Role:        row.Role,
+		JoinedAt:    row.CreatedAt,
+	}
+}
+
+// ledgerInvitationResponse leaves out the token hash.
+type ledgerInvitationResponse struct {
+	ID        int64     `json:"id"`
+	LedgerID  int32     `json:"ledger_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newLedgerInvitationResponse(invitation db.LedgerInvitation) ledgerInvitationResponse {
+	return ledgerInvitationResponse{
+		ID:        invitation.ID,
+		LedgerID:  invitation.LedgerID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
+
+type personalAccessTokenResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newPersonalAccessTokenResponse(pat db.PersonalAccessToken) personalAccessTokenResponse {
+	return personalAccessTokenResponse{
+		ID:         pat.ID,
+		Name:       pat.Name,
+		Scopes:     pat.Scopes,
+		ExpiresAt:  nullTimePtr(pat.ExpiresAt),
+		LastUsedAt: nullTimePtr(pat.LastUsedAt),
+		CreatedAt:  pat.CreatedAt,
+	}
+}
+
+type identityResponse struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newIdentityResponse(identity db.UserIdentity) identityResponse {
+	return identityResponse{
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	}
+}
+
+type systemStatsResponse struct {
+	Users          int64 `json:"users"`
+	VerifiedUsers  int64 `json:"verified_users"`
+	DisabledUsers  int64 `json:"disabled_users"`
+	Admins         int64 `json:"admins"`
+	ActiveSessions int64 `json:"active_sessions"`
+	Categories     int64 `json:"categories"`
+	Accounts       int64 `json:"accounts"`
+}
+
+func newSystemStatsResponse(stats db.GetSystemStatsRow) systemStatsResponse {
+	return systemStatsResponse{
+		Users:          stats.Users,
+		VerifiedUsers:  stats.VerifiedUsers,
+		DisabledUsers:  stats.DisabledUsers,
+		Admins:         stats.Admins,
+		ActiveSessions: stats.ActiveSessions,
+		Categories:     stats.Categories,
+		Accounts:       stats.Accounts,
+	}
+}
+
+// auditEventResponse is an audit log entry as users see their own
+// activity.
+type auditEventResponse struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Outcome   string    `json:"outcome"`
+	Username  string    `json:"username"`
+	Actor     string    `json:"actor"`
+	ClientIP  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newAuditEventResponse(event db.AuditEvent) auditEventResponse {
+	return auditEventResponse{
+		ID:        event.ID,
+		Type:      event.Type,
+		Outcome:   event.Outcome,
+		Username:  event.Username,
+		Actor:     event.Actor,
+		ClientIP:  event.ClientIp,
+		UserAgent: event.UserAgent,
+		Details:   event.Details,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+// adminAuditEventResponse adds the ids and the hash chain to
+// auditEventResponse.
+type adminAuditEventResponse struct {
+	auditEventResponse
+	UserID   *int32 `json:"user_id"`
+	ActorID  *int32 `json:"actor_id"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+func newAdminAuditEventResponse(event db.AuditEvent) adminAuditEventResponse {
+	return adminAuditEventResponse{
+		auditEventResponse: newAuditEventResponse(event),
+		UserID:             nullInt32Ptr(event.UserID),
+		ActorID:            nullInt32Ptr(event.ActorID),
+		PrevHash:           event.PrevHash,
+		Hash:               event.Hash,
+	}
+}
+
+type auditLogVerificationResponse struct {
+	Valid          bool   `json:"valid"`
+	Events         int64  `json:"events"`
+	HeadHash       string `json:"head_hash"`
+	FirstInvalidID *int64 `json:"first_invalid_id"`
+}
+
+func newAuditLogVerificationResponse(verification db.AuditLogVerification) auditLogVerificationResponse {
+	rsp := auditLogVerificationResponse{
+		Valid:    verification.Valid(),
+		Events:   verification.Events,
+		HeadHash: verification.HeadHash,
+	}
+	if !rsp.Valid {
+		rsp.FirstInvalidID = &verification.FirstInvalidID
+	}
+	return rsp
+}
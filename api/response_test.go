@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sensitiveFields are JSON names that must never appear in a response.
+// Secrets shown to their owner exactly once (a new refresh token, TOTP
+// secret or personal access token) use other names on purpose.
+var sensitiveFields = map[string]bool{
+	"password":                    true,
+	"password_hash":               true,
+	"token_hash":                  true,
+	"refresh_token_hash":          true,
+	"previous_refresh_token_hash": true,
+	"code_hash":                   true,
+	"state_hash":                  true,
+	"nonce":                       true,
+	"code_verifier":               true,
+}
+
+// TestResponseTypesAreSafe checks every *Response type of the package: no
+// sensitive JSON field and no sqlc model serialized as is.
+func TestResponseTypesAreSafe(t *testing.T) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+
+	checked := 0
+	for _, file := range pkgs["api"].Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok || !strings.HasSuffix(spec.Name.Name, "Response") {
+				return true
+			}
+			structType, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+
+			checked++
+			for _, field := range structType.Fields.List {
+				ast.Inspect(field.Type, func(node ast.Node) bool {
+					if sel, ok := node.(*ast.SelectorExpr); ok {
+						if pkg, ok := sel.X.(*ast.Ident); ok {
+							require.NotEqual(t, "db", pkg.Name, "%s exposes db.%s", spec.Name.Name, sel.Sel.Name)
+						}
+					}
+					return true
+				})
+
+				if field.Tag == nil {
+					continue
+				}
+				tag, err := strconv.Unquote(field.Tag.Value)
+				require.NoError(t, err)
+				name := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+				require.False(t, sensitiveFields[name], "%s has sensitive field %q", spec.Name.Name, name)
+			}
+			return false
+		})
+	}
+	require.NotZero(t, checked)
+}
+
+// requireSafeJSON fails if body has a sensitive field at any depth or
+// contains one of the given secret values.
+func requireSafeJSON(t *testing.T, body []byte, secrets ...string) {
+	var value interface{}
+	require.NoError(t, json.Unmarshal(body, &value))
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				require.False(t, sensitiveFields[key], "response has sensitive field %q: %s", key, body)
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(value)
+
+	for _, secret := range secrets {
+		require.NotContains(t, string(body), secret)
+	}
+}
+
+func TestUserResponsesNeverExposePasswordHash(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, adminSession := loginTestAdmin(t, server, store)
+
+	req, session := loginTestUser(t, server)
+	user, err := store.GetUser(context.Background(), req.Username)
+	require.NoError(t, err)
+	require.NotEmpty(t, user.Password)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user", createUserRequest{
+		Username: req.Username + "2",
+		Password: req.Password,
+		Email:    "2" + req.Email,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireSafeJSON(t, recorder.Body.Bytes(), req.Password)
+
+	urls := []string{"/user/" + user.Username, fmt.Sprintf("/user/id/%d", user.ID)}
+	for _, url := range urls {
+		status, body := serveAsSession(t, server, session.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusOK, status, url)
+		requireSafeJSON(t, body, user.Password)
+
+		var rsp userResponse
+		require.NoError(t, json.Unmarshal(body, &rsp))
+		require.Equal(t, user.ID, rsp.ID)
+		require.Equal(t, user.Email, rsp.Email)
+	}
+
+	adminURLs := []string{fmt.Sprintf("/admin/users/%d", user.ID), "/admin/users?page_id=1&page_size=10"}
+	for _, url := range adminURLs {
+		status, body := serveAsSession(t, server, adminSession.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusOK, status, url)
+		requireSafeJSON(t, body, user.Password)
+	}
+}
+
+func TestGetUserAuthorization(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	_, adminSession := loginTestAdmin(t, server, store)
+	owner, ownerSession := loginTestUser(t, server)
+	_, otherSession := loginTestUser(t, server)
+	user, err := store.GetUser(context.Background(), owner.Username)
+	require.NoError(t, err)
+
+	urls := []string{"/user/" + user.Username, fmt.Sprintf("/user/id/%d", user.ID)}
+	for _, url := range urls {
+		status, _ := serveAsSession(t, server, ownerSession.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusOK, status, url)
+
+		status, _ = serveAsSession(t, server, adminSession.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusOK, status, url)
+
+		status, _ = serveAsSession(t, server, otherSession.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusNotFound, status, url)
+
+		recorder := serveAs(t, server, 0, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code, url)
+	}
+
+	for _, url := range []string{"/user/nobody", "/user/id/9999"} {
+		status, _ := serveAsSession(t, server, otherSession.AccessToken, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusNotFound, status, url)
+	}
+}
@@ -3,36 +3,152 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/mail"
+	"github.com/wil-ckaew/gofinance-backend/oidc"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
 )
 
 type Server struct {
-	store  *db.SQLStore
+	config util.Config
+	store  db.Store
+	tokens *token.Service
+	mailer mail.Mailer
 	router *gin.Engine
+
+	oidcProviders map[string]*oidc.Provider
 }
 
-func NewServer(store *db.SQLStore) *Server {
-	server := &Server{store: store}
+func NewServer(config util.Config, store db.Store, tokens *token.Service, mailer mail.Mailer) *Server {
+	server := &Server{config: config, store: store, tokens: tokens, mailer: mailer}
+	server.oidcProviders = newOidcProviders(config)
 	router := gin.Default()
 
 	router.POST("/user", server.createUser)
-	router.GET("/user/:username", server.getUser)
-	router.GET("/user/id/:id", server.getUserById)
-
-	router.POST("/category", server.createCategory)
-	router.GET("/category/id/:id", server.getCategory)
-	router.GET("/category", server.getCategories)
-	router.DELETE("/category/:id", server.deleteCategory)
-	router.PUT("/category/:id", server.updateCategory)
-
-	router.POST("/account", server.createAccount)
-	router.GET("/account/id/:id", server.getAccount)
-	router.GET("/account", server.getAccounts)
-	router.GET("/account/graph/:user_id/:type", server.getAccountGraph)
-	router.GET("/account/reports/:user_id/:type", server.getAccountReports)
-	router.DELETE("/account/:id", server.deleteAccount)
-	router.PUT("/account/:id", server.updateAccount)
+	router.POST("/user/verify", server.verifyEmail)
+	router.POST("/user/verify/resend", server.resendVerification)
 
 	router.POST("/login", server.login)
+	router.POST("/login/mfa", server.loginMfa)
+	router.POST("/token/refresh", server.refreshToken)
+	router.POST("/password/forgot", server.forgotPassword)
+	router.POST("/password/reset", server.resetPassword)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
+	router.POST("/oidc/:provider/login", server.startOidcLogin)
+	router.POST("/oidc/callback", server.oidcCallback)
+
+	// Account security routes need a session opened with the password;
+	// personal access tokens are rejected there.
+	authRoutes := router.Group("/").Use(server.authMiddleware(), server.requireSession())
+
+	authRoutes.GET("/user/:username", server.getUser)
+	authRoutes.GET("/user/id/:id", server.getUserById)
+	authRoutes.GET("/profile", server.getProfile)
+	authRoutes.PATCH("/profile", server.updateProfile)
+	authRoutes.POST("/profile/email", server.changeEmail)
+	authRoutes.DELETE("/profile", server.deleteProfile)
+	authRoutes.GET("/audit", server.listOwnAuditEvents)
+	authRoutes.POST("/logout", server.logout)
+	authRoutes.POST("/logout/all", server.logoutAll)
+	authRoutes.POST("/password/change", server.changePassword)
+	authRoutes.POST("/mfa/totp", server.enrollMfa)
+	authRoutes.POST("/mfa/totp/confirm", server.confirmMfa)
+	authRoutes.POST("/mfa/totp/disable", server.disableMfa)
+	authRoutes.POST("/tokens", server.createPersonalAccessToken)
+	authRoutes.GET("/tokens", server.listPersonalAccessTokens)
+	authRoutes.DELETE("/tokens/:id", server.revokePersonalAccessToken)
+	authRoutes.GET("/oidc/identities", server.listIdentities)
+	authRoutes.POST("/oidc/:provider/link", server.startOidcLink)
+	authRoutes.DELETE("/oidc/:provider/link", server.unlinkIdentity)
+	authRoutes.POST("/ledgers", server.createLedger)
+	authRoutes.GET("/ledgers", server.listLedgers)
+	authRoutes.GET("/ledgers/:ledger_id/members", server.requireLedgerRole(db.LedgerRoleViewer), server.listLedgerMembers)
+	authRoutes.PUT("/ledgers/:ledger_id/members/:user_id/role", server.requireLedgerRole(db.LedgerRoleOwner), server.updateLedgerMemberRole)
+	authRoutes.DELETE("/ledgers/:ledger_id/members/:user_id", server.requireLedgerRole(db.LedgerRoleViewer), server.removeLedgerMember)
+	authRoutes.POST("/ledgers/:ledger_id/invitations", server.requireLedgerRole(db.LedgerRoleOwner), server.createLedgerInvitation)
+	authRoutes.GET("/ledgers/:ledger_id/invitations", server.requireLedgerRole(db.LedgerRoleOwner), server.listLedgerInvitations)
+	authRoutes.DELETE("/ledgers/:ledger_id/invitations/:id", server.requireLedgerRole(db.LedgerRoleOwner), server.revokeLedgerInvitation)
+	authRoutes.POST("/invitations/accept", server.acceptLedgerInvitation)
+
+	// The admin API needs a password session and a role granting the
+	// route's permission, see policy.go.
+	adminRoutes := router.Group("/admin").Use(server.authMiddleware(), server.requireSession())
+
+	adminRoutes.GET("/users", server.authorize(permissionManageUsers), server.listUsers)
+	adminRoutes.GET("/users/:id", server.authorize(permissionManageUsers), server.getAdminUser)
+	adminRoutes.PUT("/users/:id/role", server.authorize(permissionManageUsers), server.updateUserRole)
+	adminRoutes.POST("/users/:id/disable", server.authorize(permissionManageUsers), server.disableUser)
+	adminRoutes.POST("/users/:id/enable", server.authorize(permissionManageUsers), server.enableUser)
+	adminRoutes.POST("/users/:id/password-reset", server.authorize(permissionManageUsers), server.forcePasswordReset)
+	adminRoutes.GET("/stats", server.authorize(permissionViewStats), server.getSystemStats)
+	adminRoutes.GET("/audit", server.authorize(permissionViewAudit), server.listAuditEvents)
+	adminRoutes.GET("/audit/verify", server.authorize(permissionViewAudit), server.verifyAuditLog)
+	adminRoutes.GET("/exchange-rates", server.authorize(permissionManageRates), server.listExchangeRates)
+	adminRoutes.PUT("/exchange-rates", server.authorize(permissionManageRates), server.putExchangeRates)
+	adminRoutes.POST("/exchange-rates/import", server.authorize(permissionManageRates), server.uploadExchangeRates)
+
+	// Finance data is read-only for unverified users under the readonly
+	// policy; account security routes above stay available to them. Each
+	// route declares the scope a personal access token needs for it and the
+	// role the caller needs in the ledger it works on.
+	dataRoutes := router.Group("/").Use(server.authMiddleware(), server.verifiedEmailMiddleware())
+
+	dataRoutes.POST("/category", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.createCategory)
+	dataRoutes.GET("/category/id/:id", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getCategory)
+	dataRoutes.GET("/category", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getCategories)
+	dataRoutes.DELETE("/category/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.deleteCategory)
+	dataRoutes.PUT("/category/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.updateCategory)
+
+	dataRoutes.POST("/account", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.createAccount)
+	dataRoutes.GET("/account/id/:id", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getAccount)
+	dataRoutes.GET("/account", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getAccounts)
+	dataRoutes.GET("/account/graph/:type", server.requireScope(scopeReportsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getAccountGraph)
+	dataRoutes.GET("/account/reports/:type", server.requireScope(scopeReportsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getAccountReports)
+	dataRoutes.GET("/account/reports/:type/categories", server.requireScope(scopeReportsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getCategoryReports)
+	dataRoutes.DELETE("/account/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.deleteAccount)
+	dataRoutes.PUT("/account/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.updateAccount)
+
+	dataRoutes.POST("/wallets", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.createWallet)
+	dataRoutes.GET("/wallets", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.listWallets)
+	dataRoutes.GET("/wallets/:id", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getWallet)
+	dataRoutes.PUT("/wallets/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.updateWallet)
+	dataRoutes.DELETE("/wallets/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.deleteWallet)
+	dataRoutes.GET("/wallets/:id/balance", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getWalletBalance)
+	dataRoutes.GET("/wallets/:id/balances", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getWalletBalances)
+
+	dataRoutes.POST("/transfers", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.createTransfer)
+	dataRoutes.GET("/transfers/:id", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getTransfer)
+	dataRoutes.PUT("/transfers/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.updateTransfer)
+	dataRoutes.DELETE("/transfers/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.deleteTransfer)
+
+	dataRoutes.POST("/recurring", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.createRecurring)
+	dataRoutes.GET("/recurring", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.listRecurring)
+	dataRoutes.GET("/recurring/:id", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getRecurring)
+	dataRoutes.PUT("/recurring/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.updateRecurring)
+	dataRoutes.DELETE("/recurring/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.deleteRecurring)
+	dataRoutes.GET("/recurring/:id/occurrences", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.listOccurrences)
+	dataRoutes.PUT("/recurring/:id/occurrences/:date", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.modifyOccurrence)
+	dataRoutes.DELETE("/recurring/:id/occurrences/:date", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.restoreOccurrence)
+	dataRoutes.POST("/recurring/:id/occurrences/:date/skip", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.skipOccurrence)
+
+	dataRoutes.POST("/installments", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.createInstallmentPlan)
+	dataRoutes.GET("/installments", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.listInstallmentPlans)
+	dataRoutes.GET("/installments/:id", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getInstallmentPlan)
+	dataRoutes.PUT("/installments/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.updateInstallmentPlan)
+	dataRoutes.POST("/installments/:id/cancel", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.cancelInstallmentPlan)
+
+	dataRoutes.POST("/budgets", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.setBudget)
+	dataRoutes.GET("/budgets", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getBudgets)
+	dataRoutes.POST("/budgets/copy", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.copyBudgets)
+	dataRoutes.DELETE("/budgets/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.deleteBudget)
+
+	dataRoutes.POST("/goals", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.createGoal)
+	dataRoutes.GET("/goals", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.listGoals)
+	dataRoutes.GET("/goals/:id", server.requireScope(scopeAccountsRead), server.requireLedgerRole(db.LedgerRoleViewer), server.getGoal)
+	dataRoutes.PUT("/goals/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.updateGoal)
+	dataRoutes.DELETE("/goals/:id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.deleteGoal)
+	dataRoutes.POST("/goals/:id/contributions", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.linkContribution)
+	dataRoutes.DELETE("/goals/:id/contributions/:account_id", server.requireScope(scopeAccountsWrite), server.requireLedgerRole(db.LedgerRoleEditor), server.unlinkContribution)
 
 	server.router = router
 	return server
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/token"
+)
+
+var (
+	errInvalidRefreshToken = errors.New("refresh token is invalid")
+	errRefreshTokenReused  = errors.New("refresh token was already used, session revoked")
+	errSessionRevoked      = errors.New("session has been revoked")
+)
+
+type sessionResponse struct {
+	SessionID             int64     `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// startSession opens a new server-side session for the user, audits the
+// login and returns its first access/refresh token pair.
+func (server *Server) startSession(ctx *gin.Context, user db.User) (sessionResponse, error) {
+	refreshSecret, refreshHash, err := token.NewOpaqueToken()
+	if err != nil {
+		return sessionResponse{}, err
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		UserAgent:        ctx.Request.UserAgent(),
+		ClientIp:         ctx.ClientIP(),
+		ExpiresAt:        time.Now().Add(server.config.RefreshTokenDuration),
+	})
+	if err != nil {
+		return sessionResponse{}, err
+	}
+
+	err = server.recordAuditEvent(ctx, auditEvent{
+		Type:     auditEventLogin,
+		Outcome:  db.AuditOutcomeSuccess,
+		UserID:   user.ID,
+		Username: user.Username,
+		Details:  fmt.Sprintf("session %d", session.ID),
+	})
+	if err != nil {
+		return sessionResponse{}, err
+	}
+
+	return server.sessionTokens(session, user, refreshSecret)
+}
+
+func tokenSubject(user db.User) token.Subject {
+	return token.Subject{
+		UserID:        user.ID,
+		Username:      user.Username,
+		Role:          user.Role,
+		EmailVerified: user.VerifiedAt.Valid,
+	}
+}
+
+func (server *Server) sessionTokens(session db.Session, user db.User, refreshSecret string) (sessionResponse, error) {
+	accessToken, claims, err := server.tokens.CreateToken(tokenSubject(user), session.ID)
+	if err != nil {
+		return sessionResponse{}, err
+	}
+
+	return sessionResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  claims.ExpiresAt.Time,
+		RefreshToken:          fmt.Sprintf("%d.%s", session.ID, refreshSecret),
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// parseRefreshToken splits a "<session id>.<secret>" refresh token.
+func parseRefreshToken(refreshToken string) (int64, string, error) {
+	fields := strings.SplitN(refreshToken, ".", 2)
+	if len(fields) != 2 || fields[1] == "" {
+		return 0, "", errInvalidRefreshToken
+	}
+	sessionID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", errInvalidRefreshToken
+	}
+	return sessionID, fields[1], nil
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// refreshToken exchanges a refresh token for a new token pair. Refresh
+// tokens are single use: presenting the one the session last rotated out
+// means it leaked, so the whole session is revoked. Any other wrong secret
+// is rejected and leaves the session alone, as session ids are easy to
+// guess.
+func (server *Server) refreshToken(ctx *gin.Context) {
+	var req refreshTokenRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	sessionID, secret, err := parseRefreshToken(req.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidRefreshToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if session.RevokedAt.Valid || time.Now().After(session.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errSessionRevoked))
+		return
+	}
+
+	newSecret, newHash, err := token.NewOpaqueToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	secretHash := token.HashOpaqueToken(secret)
+	rotated, err := server.store.RotateSessionRefreshToken(ctx, db.RotateSessionRefreshTokenParams{
+		NewRefreshTokenHash: newHash,
+		ID:                  sessionID,
+		RefreshTokenHash:    secretHash,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if secretHash != session.PreviousRefreshTokenHash {
+				ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidRefreshToken))
+				return
+			}
+			err = server.store.RevokeSession(ctx, db.RevokeSessionParams{
+				ID:     session.ID,
+				UserID: session.UserID,
+			})
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errRefreshTokenReused))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, rotated.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if err = checkUserActive(user); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.sessionTokens(rotated, user, newSecret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) logout(ctx *gin.Context) {
+	claims := authClaims(ctx)
+	err := server.store.RevokeSession(ctx, db.RevokeSessionParams{
+		ID:     claims.SessionID,
+		UserID: claims.UserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventLogout, fmt.Sprintf("session %d", claims.SessionID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+func (server *Server) logoutAll(ctx *gin.Context) {
+	err := server.store.RevokeUserSessions(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.recordOwnAuditEvent(ctx, auditEventLogoutAll, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func loginTestUser(t *testing.T, server *Server) (createUserRequest, sessionResponse) {
+	user := createUserRequest{
+		Username: util.RandomString(6),
+		Password: util.RandomString(12),
+		Email:    util.RandomEmail(8),
+	}
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user", user)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	return user, login(t, server, user.Username, user.Password)
+}
+
+func login(t *testing.T, server *Server, username, password string) sessionResponse {
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: username,
+		Password: password,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp sessionResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.NotEmpty(t, rsp.AccessToken)
+	require.NotEmpty(t, rsp.RefreshToken)
+	return rsp
+}
+
+func refresh(t *testing.T, server *Server, refreshToken string) (int, sessionResponse) {
+	recorder := serveAs(t, server, 0, http.MethodPost, "/token/refresh", refreshTokenRequest{
+		RefreshToken: refreshToken,
+	})
+
+	var rsp sessionResponse
+	if recorder.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	}
+	return recorder.Code, rsp
+}
+
+func serveWithToken(t *testing.T, server *Server, accessToken, method, url string) int {
+	request, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	_, first := loginTestUser(t, server)
+
+	status, second := refresh(t, server, first.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, first.SessionID, second.SessionID)
+	require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, second.AccessToken, http.MethodPost, "/logout/all"))
+
+	status, _ = refresh(t, server, second.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	_, first := loginTestUser(t, server)
+
+	status, second := refresh(t, server, first.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = refresh(t, server, first.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = refresh(t, server, second.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, second.AccessToken, http.MethodPost, "/logout"))
+}
+
+func TestRefreshTokenGuessKeepsSession(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	_, first := loginTestUser(t, server)
+
+	// Anyone can guess a session id; a wrong secret must not sign its
+	// owner out, before or after the token was rotated.
+	guess := fmt.Sprintf("%d.%s", first.SessionID, util.RandomString(32))
+	status, _ := refresh(t, server, guess)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, second := refresh(t, server, first.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = refresh(t, server, guess)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = refresh(t, server, second.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestRefreshTokenMalformed(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+
+	for _, refreshToken := range []string{"garbage", "12.", "x.secret", "9999.secret"} {
+		status, _ := refresh(t, server, refreshToken)
+		require.Equal(t, http.StatusUnauthorized, status, refreshToken)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, laptop := loginTestUser(t, server)
+	phone := login(t, server, user.Username, user.Password)
+
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, laptop.AccessToken, http.MethodPost, "/logout"))
+	require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, laptop.AccessToken, http.MethodPost, "/logout"))
+	status, _ := refresh(t, server, laptop.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = refresh(t, server, phone.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestLogoutAllDevices(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, laptop := loginTestUser(t, server)
+	phone := login(t, server, user.Username, user.Password)
+
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, phone.AccessToken, http.MethodPost, "/logout/all"))
+
+	for _, session := range []sessionResponse{laptop, phone} {
+		require.Equal(t, http.StatusUnauthorized, serveWithToken(t, server, session.AccessToken, http.MethodPost, "/logout"))
+		status, _ := refresh(t, server, session.RefreshToken)
+		require.Equal(t, http.StatusUnauthorized, status)
+	}
+}
@@ -0,0 +1,1945 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+// fakeStore is an in-memory db.Store that applies the same user and
+// ledger scoping as the SQL queries. Methods a test does not need fall
+// through to the embedded nil Store and panic.
+type fakeStore struct {
+	db.Store
+	nextID     int32
+	users      map[int32]db.User
+	categories map[int32]db.Category
+	accounts   map[int32]db.Account
+	sessions   map[int64]db.Session
+	resets     map[int64]db.PasswordResetToken
+	mfa        map[int32]db.MfaTotp
+	recovery   map[int64]db.MfaRecoveryCode
+	throttles  map[string]db.LoginThrottle
+	events     []db.AuditEvent
+	pats       map[int64]db.PersonalAccessToken
+	oidcAuth   map[int64]db.OidcAuthRequest
+	identities map[int64]db.UserIdentity
+	ledgers    map[int32]db.Ledger
+	members    map[ledgerMemberKeyPair]db.LedgerMember
+	invites    map[int64]db.LedgerInvitation
+	rates      map[exchangeRateKey]db.ExchangeRate
+	wallets    map[int32]db.Wallet
+	transfers  map[int32]db.Transfer
+	recurring  map[int32]db.RecurringTransaction
+	exceptions map[occurrenceKey]db.RecurringOccurrence
+	plans      map[int32]db.InstallmentPlan
+	budgets    map[int32]db.Budget
+	goals      map[int32]db.Goal
+	// goalAccounts maps each transaction linked to a goal to the goal.
+	goalAccounts map[int32]int32
+	// installments holds the account of each installment of a plan, in
+	// order.
+	installments map[int32][]int32
+}
+
+type ledgerMemberKeyPair struct {
+	ledgerID int32
+	userID   int32
+}
+
+type occurrenceKey struct {
+	recurringID int32
+	date        string
+}
+
+type exchangeRateKey struct {
+	from string
+	to   string
+	date time.Time
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:      map[int32]db.User{},
+		categories: map[int32]db.Category{},
+		accounts:   map[int32]db.Account{},
+		sessions:   map[int64]db.Session{},
+		resets:     map[int64]db.PasswordResetToken{},
+		mfa:        map[int32]db.MfaTotp{},
+		recovery:   map[int64]db.MfaRecoveryCode{},
+		throttles:  map[string]db.LoginThrottle{},
+		pats:       map[int64]db.PersonalAccessToken{},
+		oidcAuth:   map[int64]db.OidcAuthRequest{},
+		identities: map[int64]db.UserIdentity{},
+		ledgers:    map[int32]db.Ledger{},
+		members:    map[ledgerMemberKeyPair]db.LedgerMember{},
+		invites:    map[int64]db.LedgerInvitation{},
+		rates:      map[exchangeRateKey]db.ExchangeRate{},
+		wallets:    map[int32]db.Wallet{},
+		transfers:  map[int32]db.Transfer{},
+		recurring:  map[int32]db.RecurringTransaction{},
+		exceptions: map[occurrenceKey]db.RecurringOccurrence{},
+		plans:      map[int32]db.InstallmentPlan{},
+		budgets:    map[int32]db.Budget{},
+		goals:      map[int32]db.Goal{},
+
+		goalAccounts: map[int32]int32{},
+
+		installments: map[int32][]int32{},
+	}
+}
+
+func (s *fakeStore) id() int32 {
+	s.nextID++
+	return s.nextID
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (s *fakeStore) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	for _, user := range s.users {
+		if user.Username == arg.Username {
+			return db.User{}, &pq.Error{Code: db.UniqueViolation}
+		}
+	}
+	user := db.User{
+		ID:           s.id(),
+		Username:     arg.Username,
+		Password:     arg.Password,
+		Email:        arg.Email,
+		Role:         "user",
+		BaseCurrency: "BRL",
+	}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *fakeStore) GetUser(ctx context.Context, username string) (db.User, error) {
+	for _, user := range s.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return db.User{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) GetUserById(ctx context.Context, id int32) (db.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return db.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *fakeStore) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return db.User{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) error {
+	user := s.users[arg.ID]
+	user.Password = arg.Password
+	user.PasswordResetRequired = false
+	s.users[arg.ID] = user
+	return nil
+}
+
+func (s *fakeStore) VerifyUserEmail(ctx context.Context, arg db.VerifyUserEmailParams) (int64, error) {
+	user, ok := s.users[arg.ID]
+	if !ok || user.Email != arg.Email || user.VerifiedAt.Valid {
+		return 0, nil
+	}
+	user.VerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.users[arg.ID] = user
+	return 1, nil
+}
+
+func (s *fakeStore) CreateCategory(ctx context.Context, arg db.CreateCategoryParams) (db.Category, error) {
+	category := db.Category{
+		ID:          s.id(),
+		LedgerID:    arg.LedgerID,
+		CreatedBy:   arg.CreatedBy,
+		Title:       arg.Title,
+		Type:        arg.Type,
+		Description: arg.Description,
+	}
+	s.categories[category.ID] = category
+	return category, nil
+}
+
+func (s *fakeStore) GetCategory(ctx context.Context, arg db.GetCategoryParams) (db.Category, error) {
+	category, ok := s.categories[arg.ID]
+	if !ok || category.LedgerID != arg.LedgerID {
+		return db.Category{}, sql.ErrNoRows
+	}
+	return category, nil
+}
+
+func (s *fakeStore) GetCategories(ctx context.Context, arg db.GetCategoriesParams) ([]db.Category, error) {
+	categories := []db.Category{}
+	for _, category := range s.categories {
+		if category.LedgerID == arg.LedgerID && category.Type == arg.Type &&
+			containsFold(category.Title, arg.Title) && containsFold(category.Description, arg.Description) {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
+func (s *fakeStore) UpdateCategories(ctx context.Context, arg db.UpdateCategoriesParams) (db.Category, error) {
+	category, ok := s.categories[arg.ID]
+	if !ok || category.LedgerID != arg.LedgerID {
+		return db.Category{}, sql.ErrNoRows
+	}
+	category.Title = arg.Title
+	category.Description = arg.Description
+	s.categories[category.ID] = category
+	return category, nil
+}
+
+func (s *fakeStore) DeleteCategories(ctx context.Context, arg db.DeleteCategoriesParams) (int64, error) {
+	category, ok := s.categories[arg.ID]
+	if !ok || category.LedgerID != arg.LedgerID {
+		return 0, nil
+	}
+	for _, account := range s.accounts {
+		if account.CategoryID.Int32 == category.ID {
+			return 0, &pq.Error{Code: db.ForeignKeyViolation}
+		}
+	}
+	for _, recurring := range s.recurring {
+		if recurring.CategoryID == category.ID {
+			return 0, &pq.Error{Code: db.ForeignKeyViolation}
+		}
+	}
+	for _, plan := range s.plans {
+		if plan.CategoryID == category.ID {
+			return 0, &pq.Error{Code: db.ForeignKeyViolation}
+		}
+	}
+	delete(s.categories, arg.ID)
+	return 1, nil
+}
+
+func (s *fakeStore) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	account := db.Account{
+		ID:          s.id(),
+		LedgerID:    arg.LedgerID,
+		CreatedBy:   arg.CreatedBy,
+		CategoryID:  arg.CategoryID,
+		Title:       arg.Title,
+		Type:        arg.Type,
+		Description: arg.Description,
+		Amount:      arg.Amount,
+		Currency:    arg.Currency,
+		Date:        arg.Date,
+		WalletID:    arg.WalletID,
+	}
+	s.accounts[account.ID] = account
+	return account, nil
+}
+
+func (s *fakeStore) CreateAccountTx(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	return s.CreateAccount(ctx, arg)
+}
+
+func (s *fakeStore) GetAccount(ctx context.Context, arg db.GetAccountParams) (db.Account, error) {
+	account, ok := s.accounts[arg.ID]
+	if !ok || account.LedgerID != arg.LedgerID {
+		return db.Account{}, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+func (s *fakeStore) GetAccounts(ctx context.Context, arg db.GetAccountsParams) ([]db.GetAccountsRow, error) {
+	rows := []db.GetAccountsRow{}
+	for _, account := range s.accounts {
+		if account.LedgerID != arg.LedgerID || account.Type != arg.Type ||
+			!containsFold(account.Title, arg.Title) || !containsFold(account.Description, arg.Description) {
+			continue
+		}
+		if arg.CategoryID.Valid && account.CategoryID != arg.CategoryID {
+			continue
+		}
+		if arg.WalletID.Valid && account.WalletID != arg.WalletID.Int32 {
+			continue
+		}
+		rows = append(rows, db.GetAccountsRow{
+			ID:          account.ID,
+			LedgerID:    account.LedgerID,
+			CreatedBy:   account.CreatedBy,
+			Title:       account.Title,
+			Type:        account.Type,
+			Description: account.Description,
+			Amount:      account.Amount,
+			Currency:    account.Currency,
+			Date:        account.Date,
+			WalletID:    account.WalletID,
+			TransferID:  account.TransferID,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	return rows, nil
+}
+
+func (s *fakeStore) GetAccountsGraph(ctx context.Context, arg db.GetAccountsGraphParams) (int64, error) {
+	var count int64
+	for _, account := range s.accounts {
+		if account.LedgerID == arg.LedgerID && account.Type == arg.Type && !account.TransferID.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *fakeStore) GetAccountsReports(ctx context.Context, arg db.GetAccountsReportsParams) ([]db.GetAccountsReportsRow, error) {
+	sums := map[string]int64{}
+	for _, account := range s.accounts {
+		if account.LedgerID == arg.LedgerID && account.Type == arg.Type && !account.TransferID.Valid {
+			sums[account.Currency] += account.Amount
+		}
+	}
+
+	rows := []db.GetAccountsReportsRow{}
+	for currency, amount := range sums {
+		rows = append(rows, db.GetAccountsReportsRow{Currency: currency, Amount: amount})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Currency < rows[j].Currency })
+	return rows, nil
+}
+
+func (s *fakeStore) GetAccountsReportsByDate(ctx context.Context, arg db.GetAccountsReportsByDateParams) ([]db.GetAccountsReportsByDateRow, error) {
+	type group struct {
+		currency string
+		date     time.Time
+	}
+	sums := map[group]int64{}
+	for _, account := range s.accounts {
+		if account.LedgerID == arg.LedgerID && account.Type == arg.Type && !account.TransferID.Valid {
+			sums[group{account.Currency, account.Date}] += account.Amount
+		}
+	}
+
+	rows := []db.GetAccountsReportsByDateRow{}
+	for g, amount := range sums {
+		rows = append(rows, db.GetAccountsReportsByDateRow{Currency: g.currency, Date: g.date, Amount: amount})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Date.Equal(rows[j].Date) {
+			return rows[i].Date.Before(rows[j].Date)
+		}
+		return rows[i].Currency < rows[j].Currency
+	})
+	return rows, nil
+}
+
+func (s *fakeStore) GetCategoryReports(ctx context.Context, arg db.GetCategoryReportsParams) ([]db.GetCategoryReportsRow, error) {
+	type group struct {
+		categoryID int32
+		currency   string
+		date       time.Time
+	}
+	sums := map[group]int64{}
+	for _, account := range s.accounts {
+		if account.LedgerID == arg.LedgerID && account.Type == arg.Type && account.CategoryID.Valid {
+			sums[group{account.CategoryID.Int32, account.Currency, account.Date}] += account.Amount
+		}
+	}
+
+	rows := []db.GetCategoryReportsRow{}
+	for g, amount := range sums {
+		rows = append(rows, db.GetCategoryReportsRow{
+			CategoryID:    g.categoryID,
+			CategoryTitle: s.categories[g.categoryID].Title,
+			Currency:      g.currency,
+			Date:          g.date,
+			Amount:        amount,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.CategoryID != b.CategoryID {
+			return a.CategoryID < b.CategoryID
+		}
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return a.Currency < b.Currency
+	})
+	return rows, nil
+}
+
+func (s *fakeStore) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+	account, ok := s.accounts[arg.ID]
+	if !ok || account.LedgerID != arg.LedgerID || account.TransferID.Valid {
+		return db.Account{}, sql.ErrNoRows
+	}
+	account.Title = arg.Title
+	account.Description = arg.Description
+	account.Amount = arg.Amount
+	account.Currency = arg.Currency
+	account.WalletID = arg.WalletID
+	s.accounts[account.ID] = account
+	return account, nil
+}
+
+func (s *fakeStore) UpdateAccountTx(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+	return s.UpdateAccount(ctx, arg)
+}
+
+func (s *fakeStore) DeleteAccount(ctx context.Context, arg db.DeleteAccountParams) (int64, error) {
+	account, ok := s.accounts[arg.ID]
+	if !ok || account.LedgerID != arg.LedgerID || account.TransferID.Valid {
+		return 0, nil
+	}
+	delete(s.accounts, arg.ID)
+	return 1, nil
+}
+
+func (s *fakeStore) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	session := db.Session{
+		ID:               int64(s.id()),
+		UserID:           arg.UserID,
+		RefreshTokenHash: arg.RefreshTokenHash,
+		UserAgent:        arg.UserAgent,
+		ClientIp:         arg.ClientIp,
+		ExpiresAt:        arg.ExpiresAt,
+		CreatedAt:        time.Now(),
+	}
+	s.sessions[session.ID] = session
+	return session, nil
+}
+
+func (s *fakeStore) GetSession(ctx context.Context, id int64) (db.Session, error) {
+	session, ok := s.sessions[id]
+	if !ok {
+		return db.Session{}, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func (s *fakeStore) RotateSessionRefreshToken(ctx context.Context, arg db.RotateSessionRefreshTokenParams) (db.Session, error) {
+	session, ok := s.sessions[arg.ID]
+	if !ok || session.RefreshTokenHash != arg.RefreshTokenHash || session.RevokedAt.Valid {
+		return db.Session{}, sql.ErrNoRows
+	}
+	session.PreviousRefreshTokenHash = session.RefreshTokenHash
+	session.RefreshTokenHash = arg.NewRefreshTokenHash
+	s.sessions[session.ID] = session
+	return session, nil
+}
+
+func (s *fakeStore) RevokeSession(ctx context.Context, arg db.RevokeSessionParams) error {
+	session, ok := s.sessions[arg.ID]
+	if ok && session.UserID == arg.UserID && !session.RevokedAt.Valid {
+		session.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		s.sessions[session.ID] = session
+	}
+	return nil
+}
+
+func (s *fakeStore) RevokeUserSessions(ctx context.Context, userID int32) error {
+	for id, session := range s.sessions {
+		if session.UserID == userID && !session.RevokedAt.Valid {
+			session.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			s.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) CreatePasswordResetToken(ctx context.Context, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+	reset := db.PasswordResetToken{
+		ID:        int64(s.id()),
+		UserID:    arg.UserID,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	s.resets[reset.ID] = reset
+	return reset, nil
+}
+
+func (s *fakeStore) GetPasswordResetToken(ctx context.Context, tokenHash string) (db.PasswordResetToken, error) {
+	for _, reset := range s.resets {
+		if reset.TokenHash == tokenHash {
+			return reset, nil
+		}
+	}
+	return db.PasswordResetToken{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) UsePasswordResetToken(ctx context.Context, id int64) (int64, error) {
+	reset, ok := s.resets[id]
+	if !ok || reset.UsedAt.Valid {
+		return 0, nil
+	}
+	reset.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.resets[id] = reset
+	return 1, nil
+}
+
+func (s *fakeStore) InvalidateUserPasswordResetTokens(ctx context.Context, userID int32) error {
+	for id, reset := range s.resets {
+		if reset.UserID == userID && !reset.UsedAt.Valid {
+			reset.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			s.resets[id] = reset
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) ResetPasswordTx(ctx context.Context, arg db.ResetPasswordTxParams) error {
+	rows, _ := s.UsePasswordResetToken(ctx, arg.TokenID)
+	if rows == 0 {
+		return db.ErrPasswordResetTokenUsed
+	}
+	s.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{ID: arg.UserID, Password: arg.Password})
+	s.InvalidateUserPasswordResetTokens(ctx, arg.UserID)
+	return s.RevokeUserSessions(ctx, arg.UserID)
+}
+
+func (s *fakeStore) UpsertMfaTotp(ctx context.Context, arg db.UpsertMfaTotpParams) (db.MfaTotp, error) {
+	if mfa, ok := s.mfa[arg.UserID]; ok && mfa.ConfirmedAt.Valid {
+		return db.MfaTotp{}, sql.ErrNoRows
+	}
+	mfa := db.MfaTotp{UserID: arg.UserID, Secret: arg.Secret, CreatedAt: time.Now()}
+	s.mfa[arg.UserID] = mfa
+	return mfa, nil
+}
+
+func (s *fakeStore) GetMfaTotp(ctx context.Context, userID int32) (db.MfaTotp, error) {
+	mfa, ok := s.mfa[userID]
+	if !ok {
+		return db.MfaTotp{}, sql.ErrNoRows
+	}
+	return mfa, nil
+}
+
+func (s *fakeStore) UseMfaTotpStep(ctx context.Context, arg db.UseMfaTotpStepParams) (int64, error) {
+	mfa, ok := s.mfa[arg.UserID]
+	if !ok || mfa.LastUsedStep >= arg.Step {
+		return 0, nil
+	}
+	mfa.LastUsedStep = arg.Step
+	s.mfa[arg.UserID] = mfa
+	return 1, nil
+}
+
+func (s *fakeStore) UseMfaRecoveryCode(ctx context.Context, arg db.UseMfaRecoveryCodeParams) (int64, error) {
+	for id, code := range s.recovery {
+		if code.UserID == arg.UserID && code.CodeHash == arg.CodeHash && !code.UsedAt.Valid {
+			code.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			s.recovery[id] = code
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *fakeStore) ConfirmMfaTx(ctx context.Context, arg db.ConfirmMfaTxParams) error {
+	mfa, ok := s.mfa[arg.UserID]
+	if !ok || mfa.ConfirmedAt.Valid {
+		return db.ErrMfaAlreadyConfirmed
+	}
+	mfa.ConfirmedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.mfa[arg.UserID] = mfa
+
+	s.deleteRecoveryCodes(arg.UserID)
+	for _, codeHash := range arg.RecoveryCodeHashes {
+		id := int64(s.id())
+		s.recovery[id] = db.MfaRecoveryCode{ID: id, UserID: arg.UserID, CodeHash: codeHash, CreatedAt: time.Now()}
+	}
+	return nil
+}
+
+func (s *fakeStore) DisableMfaTx(ctx context.Context, userID int32) error {
+	delete(s.mfa, userID)
+	s.deleteRecoveryCodes(userID)
+	return nil
+}
+
+func (s *fakeStore) deleteRecoveryCodes(userID int32) {
+	for id, code := range s.recovery {
+		if code.UserID == userID {
+			delete(s.recovery, id)
+		}
+	}
+}
+
+func (s *fakeStore) GetLoginThrottle(ctx context.Context, key string) (db.LoginThrottle, error) {
+	throttle, ok := s.throttles[key]
+	if !ok {
+		return db.LoginThrottle{}, sql.ErrNoRows
+	}
+	return throttle, nil
+}
+
+func (s *fakeStore) RecordLoginFailure(ctx context.Context, arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+	throttle, ok := s.throttles[arg.Key]
+	if !ok || throttle.LastFailedAt.Before(arg.ResetBefore) {
+		throttle.Key = arg.Key
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailedAt = time.Now()
+	s.throttles[arg.Key] = throttle
+	return throttle, nil
+}
+
+func (s *fakeStore) LockLogin(ctx context.Context, arg db.LockLoginParams) error {
+	throttle := s.throttles[arg.Key]
+	throttle.LockedUntil = arg.LockedUntil
+	throttle.Failures = 0
+	s.throttles[arg.Key] = throttle
+	return nil
+}
+
+func (s *fakeStore) ClearLoginThrottle(ctx context.Context, key string) error {
+	delete(s.throttles, key)
+	return nil
+}
+
+func (s *fakeStore) AppendAuditEventTx(ctx context.Context, arg db.AppendAuditEventTxParams) (db.AuditEvent, error) {
+	event := db.AuditEvent{
+		ID:        int64(len(s.events) + 1),
+		UserID:    arg.UserID,
+		Username:  arg.Username,
+		ActorID:   arg.ActorID,
+		Actor:     arg.Actor,
+		Type:      arg.Type,
+		Outcome:   arg.Outcome,
+		ClientIp:  arg.ClientIp,
+		UserAgent: arg.UserAgent,
+		Details:   arg.Details,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if len(s.events) > 0 {
+		event.PrevHash = s.events[len(s.events)-1].Hash
+	}
+	event.Hash = db.AuditEventHash(event)
+	s.events = append(s.events, event)
+	return event, nil
+}
+
+// eventsOfType returns the audit events of one type, oldest first.
+func (s *fakeStore) eventsOfType(eventType string) []db.AuditEvent {
+	var events []db.AuditEvent
+	for _, event := range s.events {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func pageAuditEvents(events []db.AuditEvent, limit, offset int32) []db.AuditEvent {
+	page := []db.AuditEvent{}
+	for i := len(events) - 1 - int(offset); i >= 0 && len(page) < int(limit); i-- {
+		page = append(page, events[i])
+	}
+	return page
+}
+
+func (s *fakeStore) ListUserAuditEvents(ctx context.Context, arg db.ListUserAuditEventsParams) ([]db.AuditEvent, error) {
+	var events []db.AuditEvent
+	for _, event := range s.events {
+		if event.UserID.Int32 == arg.UserID || event.ActorID.Int32 == arg.UserID {
+			events = append(events, event)
+		}
+	}
+	return pageAuditEvents(events, arg.PageLimit, arg.PageOffset), nil
+}
+
+func (s *fakeStore) ListAuditEvents(ctx context.Context, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	var events []db.AuditEvent
+	for _, event := range s.events {
+		if arg.UserID.Valid && event.UserID != arg.UserID && event.ActorID != arg.UserID {
+			continue
+		}
+		if (arg.Type.Valid && event.Type != arg.Type.String) || (arg.Outcome.Valid && event.Outcome != arg.Outcome.String) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return pageAuditEvents(events, arg.PageLimit, arg.PageOffset), nil
+}
+
+func (s *fakeStore) VerifyAuditLog(ctx context.Context) (db.AuditLogVerification, error) {
+	var verification db.AuditLogVerification
+	verification.Check(s.events)
+	return verification, nil
+}
+
+func (s *fakeStore) CreatePersonalAccessToken(ctx context.Context, arg db.CreatePersonalAccessTokenParams) (db.PersonalAccessToken, error) {
+	pat := db.PersonalAccessToken{
+		ID:        int64(s.id()),
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		Scopes:    arg.Scopes,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	s.pats[pat.ID] = pat
+	return pat, nil
+}
+
+func (s *fakeStore) GetPersonalAccessToken(ctx context.Context, id int64) (db.PersonalAccessToken, error) {
+	pat, ok := s.pats[id]
+	if !ok {
+		return db.PersonalAccessToken{}, sql.ErrNoRows
+	}
+	return pat, nil
+}
+
+func (s *fakeStore) ListPersonalAccessTokens(ctx context.Context, userID int32) ([]db.PersonalAccessToken, error) {
+	pats := []db.PersonalAccessToken{}
+	for _, pat := range s.pats {
+		if pat.UserID == userID && !pat.RevokedAt.Valid {
+			pats = append(pats, pat)
+		}
+	}
+	sort.Slice(pats, func(i, j int) bool { return pats[i].ID < pats[j].ID })
+	return pats, nil
+}
+
+func (s *fakeStore) RevokePersonalAccessToken(ctx context.Context, arg db.RevokePersonalAccessTokenParams) (int64, error) {
+	pat, ok := s.pats[arg.ID]
+	if !ok || pat.UserID != arg.UserID || pat.RevokedAt.Valid {
+		return 0, nil
+	}
+	pat.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.pats[arg.ID] = pat
+	return 1, nil
+}
+
+func (s *fakeStore) RevokeUserPersonalAccessTokens(ctx context.Context, userID int32) error {
+	for id, pat := range s.pats {
+		if pat.UserID == userID && !pat.RevokedAt.Valid {
+			pat.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			s.pats[id] = pat
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) TouchPersonalAccessToken(ctx context.Context, id int64) error {
+	pat := s.pats[id]
+	pat.LastUsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.pats[id] = pat
+	return nil
+}
+
+func (s *fakeStore) CreateOidcAuthRequest(ctx context.Context, arg db.CreateOidcAuthRequestParams) (db.OidcAuthRequest, error) {
+	authRequest := db.OidcAuthRequest{
+		ID:           int64(s.id()),
+		StateHash:    arg.StateHash,
+		Provider:     arg.Provider,
+		Nonce:        arg.Nonce,
+		CodeVerifier: arg.CodeVerifier,
+		LinkUserID:   arg.LinkUserID,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    time.Now(),
+	}
+	s.oidcAuth[authRequest.ID] = authRequest
+	return authRequest, nil
+}
+
+func (s *fakeStore) ConsumeOidcAuthRequest(ctx context.Context, stateHash string) (db.OidcAuthRequest, error) {
+	for id, authRequest := range s.oidcAuth {
+		if authRequest.StateHash == stateHash {
+			delete(s.oidcAuth, id)
+			return authRequest, nil
+		}
+	}
+	return db.OidcAuthRequest{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) CreateUserIdentity(ctx context.Context, arg db.CreateUserIdentityParams) (db.UserIdentity, error) {
+	for _, identity := range s.identities {
+		if identity.UserID == arg.UserID && identity.Provider == arg.Provider ||
+			identity.Provider == arg.Provider && identity.Subject == arg.Subject {
+			return db.UserIdentity{}, &pq.Error{Code: db.UniqueViolation}
+		}
+	}
+	identity := db.UserIdentity{
+		ID:        int64(s.id()),
+		UserID:    arg.UserID,
+		Provider:  arg.Provider,
+		Subject:   arg.Subject,
+		Email:     arg.Email,
+		CreatedAt: time.Now(),
+	}
+	s.identities[identity.ID] = identity
+	return identity, nil
+}
+
+func (s *fakeStore) GetUserIdentity(ctx context.Context, arg db.GetUserIdentityParams) (db.UserIdentity, error) {
+	for _, identity := range s.identities {
+		if identity.Provider == arg.Provider && identity.Subject == arg.Subject {
+			return identity, nil
+		}
+	}
+	return db.UserIdentity{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) ListUserIdentities(ctx context.Context, userID int32) ([]db.UserIdentity, error) {
+	identities := []db.UserIdentity{}
+	for _, identity := range s.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].ID < identities[j].ID })
+	return identities, nil
+}
+
+func (s *fakeStore) DeleteUserIdentity(ctx context.Context, arg db.DeleteUserIdentityParams) (int64, error) {
+	for id, identity := range s.identities {
+		if identity.UserID == arg.UserID && identity.Provider == arg.Provider {
+			delete(s.identities, id)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *fakeStore) CreateOidcUserTx(ctx context.Context, arg db.CreateOidcUserTxParams) (db.User, error) {
+	user, err := s.CreateUser(ctx, db.CreateUserParams{Username: arg.Username, Email: arg.Email})
+	if err != nil {
+		return db.User{}, err
+	}
+	if arg.EmailVerified {
+		s.VerifyUserEmail(ctx, db.VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+		user = s.users[user.ID]
+	}
+	_, err = s.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:   user.ID,
+		Provider: arg.Provider,
+		Subject:  arg.Subject,
+		Email:    arg.Email,
+	})
+	return user, err
+}
+
+func (s *fakeStore) ListUsers(ctx context.Context, arg db.ListUsersParams) ([]db.User, error) {
+	users := []db.User{}
+	for _, user := range s.users {
+		if containsFold(user.Username, arg.Search) || containsFold(user.Email, arg.Search) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	start, end := int(arg.PageOffset), int(arg.PageOffset+arg.PageLimit)
+	if start > len(users) {
+		start = len(users)
+	}
+	if end > len(users) {
+		end = len(users)
+	}
+	return users[start:end], nil
+}
+
+func (s *fakeStore) updateUser(id int32, update func(user *db.User)) (db.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return db.User{}, sql.ErrNoRows
+	}
+	update(&user)
+	s.users[id] = user
+	return user, nil
+}
+
+func (s *fakeStore) EnableUser(ctx context.Context, id int32) (db.User, error) {
+	return s.updateUser(id, func(user *db.User) { user.DisabledAt = sql.NullTime{} })
+}
+
+func (s *fakeStore) DisableUserTx(ctx context.Context, userID int32) (db.User, error) {
+	user, err := s.updateUser(userID, func(user *db.User) {
+		if !user.DisabledAt.Valid {
+			user.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	return user, s.RevokeUserSessions(ctx, userID)
+}
+
+func (s *fakeStore) UpdateUserRoleTx(ctx context.Context, arg db.UpdateUserRoleParams) (db.User, error) {
+	user, err := s.updateUser(arg.ID, func(user *db.User) { user.Role = arg.Role })
+	if err != nil {
+		return db.User{}, err
+	}
+	return user, s.RevokeUserSessions(ctx, arg.ID)
+}
+
+func (s *fakeStore) ForcePasswordResetTx(ctx context.Context, userID int32) (db.User, error) {
+	user, err := s.updateUser(userID, func(user *db.User) { user.PasswordResetRequired = true })
+	if err != nil {
+		return db.User{}, err
+	}
+	err = s.InvalidateUserPasswordResetTokens(ctx, userID)
+	if err != nil {
+		return db.User{}, err
+	}
+	err = s.RevokeUserPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return db.User{}, err
+	}
+	return user, s.RevokeUserSessions(ctx, userID)
+}
+
+func (s *fakeStore) GetSystemStats(ctx context.Context) (db.GetSystemStatsRow, error) {
+	stats := db.GetSystemStatsRow{
+		Categories: int64(len(s.categories)),
+		Accounts:   int64(len(s.accounts)),
+	}
+	for _, user := range s.users {
+		stats.Users++
+		if user.VerifiedAt.Valid {
+			stats.VerifiedUsers++
+		}
+		if user.DisabledAt.Valid {
+			stats.DisabledUsers++
+		}
+		if user.Role == "admin" {
+			stats.Admins++
+		}
+	}
+	for _, session := range s.sessions {
+		if !session.RevokedAt.Valid && time.Now().Before(session.ExpiresAt) {
+			stats.ActiveSessions++
+		}
+	}
+	return stats, nil
+}
+
+func (s *fakeStore) RehashUserPassword(ctx context.Context, arg db.RehashUserPasswordParams) (int64, error) {
+	user, ok := s.users[arg.ID]
+	if !ok || user.Password != arg.OldPassword {
+		return 0, nil
+	}
+	user.Password = arg.NewPassword
+	s.users[arg.ID] = user
+	return 1, nil
+}
+
+func (s *fakeStore) ChangePasswordTx(ctx context.Context, arg db.ChangePasswordTxParams) error {
+	s.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{ID: arg.UserID, Password: arg.Password})
+	s.InvalidateUserPasswordResetTokens(ctx, arg.UserID)
+	for id, session := range s.sessions {
+		if session.UserID == arg.UserID && session.ID != arg.SessionID && !session.RevokedAt.Valid {
+			session.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			s.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (db.User, error) {
+	for _, user := range s.users {
+		if user.ID != arg.ID && user.Username == arg.Username {
+			return db.User{}, &pq.Error{Code: db.UniqueViolation}
+		}
+	}
+	return s.updateUser(arg.ID, func(user *db.User) {
+		user.Username = arg.Username
+		user.DisplayName = arg.DisplayName
+		user.BaseCurrency = arg.BaseCurrency
+	})
+}
+
+func (s *fakeStore) SetUserPendingEmail(ctx context.Context, arg db.SetUserPendingEmailParams) error {
+	_, err := s.updateUser(arg.ID, func(user *db.User) { user.PendingEmail = arg.PendingEmail })
+	return err
+}
+
+func (s *fakeStore) ConfirmUserEmailChange(ctx context.Context, arg db.ConfirmUserEmailChangeParams) (int64, error) {
+	user, ok := s.users[arg.ID]
+	if !ok || !user.PendingEmail.Valid || user.PendingEmail.String != arg.Email {
+		return 0, nil
+	}
+	user.Email = arg.Email
+	user.PendingEmail = sql.NullString{}
+	user.VerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.users[arg.ID] = user
+	return 1, nil
+}
+
+func (s *fakeStore) DeleteUserTx(ctx context.Context, userID int32) (db.User, error) {
+	user, err := s.updateUser(userID, func(user *db.User) {
+		if !user.DeletedAt.Valid {
+			user.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+		user.PendingEmail = sql.NullString{}
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	err = s.InvalidateUserPasswordResetTokens(ctx, userID)
+	if err != nil {
+		return db.User{}, err
+	}
+	err = s.RevokeUserPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return db.User{}, err
+	}
+	return user, s.RevokeUserSessions(ctx, userID)
+}
+
+func (s *fakeStore) CreateLedgerTx(ctx context.Context, arg db.CreateLedgerParams) (db.Ledger, error) {
+	ledger := db.Ledger{
+		ID:        s.id(),
+		Name:      arg.Name,
+		OwnerID:   arg.OwnerID,
+		CreatedAt: time.Now(),
+	}
+	s.ledgers[ledger.ID] = ledger
+	s.addLedgerMember(ledger.ID, ledger.OwnerID, db.LedgerRoleOwner)
+	return ledger, nil
+}
+
+func (s *fakeStore) EnsurePersonalLedgerTx(ctx context.Context, userID int32) (db.Ledger, error) {
+	for _, ledger := range s.ledgers {
+		if ledger.Personal && ledger.OwnerID == userID {
+			return ledger, nil
+		}
+	}
+	ledger := db.Ledger{
+		ID:        s.id(),
+		Name:      db.PersonalLedgerName,
+		OwnerID:   userID,
+		Personal:  true,
+		CreatedAt: time.Now(),
+	}
+	s.ledgers[ledger.ID] = ledger
+	s.addLedgerMember(ledger.ID, userID, db.LedgerRoleOwner)
+	return ledger, nil
+}
+
+func (s *fakeStore) addLedgerMember(ledgerID, userID int32, role string) db.LedgerMember {
+	member := db.LedgerMember{
+		LedgerID:  ledgerID,
+		UserID:    userID,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+	s.members[ledgerMemberKeyPair{ledgerID, userID}] = member
+	return member
+}
+
+func (s *fakeStore) GetLedger(ctx context.Context, id int32) (db.Ledger, error) {
+	ledger, ok := s.ledgers[id]
+	if !ok {
+		return db.Ledger{}, sql.ErrNoRows
+	}
+	return ledger, nil
+}
+
+func (s *fakeStore) ListUserLedgers(ctx context.Context, userID int32) ([]db.ListUserLedgersRow, error) {
+	ledgers := []db.ListUserLedgersRow{}
+	for key, member := range s.members {
+		if key.userID != userID {
+			continue
+		}
+		ledger := s.ledgers[key.ledgerID]
+		ledgers = append(ledgers, db.ListUserLedgersRow{
+			ID:        ledger.ID,
+			Name:      ledger.Name,
+			OwnerID:   ledger.OwnerID,
+			Personal:  ledger.Personal,
+			CreatedAt: ledger.CreatedAt,
+			Role:      member.Role,
+		})
+	}
+	sort.Slice(ledgers, func(i, j int) bool { return ledgers[i].ID < ledgers[j].ID })
+	return ledgers, nil
+}
+
+func (s *fakeStore) GetLedgerMember(ctx context.Context, arg db.GetLedgerMemberParams) (db.LedgerMember, error) {
+	member, ok := s.members[ledgerMemberKeyPair{arg.LedgerID, arg.UserID}]
+	if !ok {
+		return db.LedgerMember{}, sql.ErrNoRows
+	}
+	return member, nil
+}
+
+func (s *fakeStore) ListLedgerMembers(ctx context.Context, ledgerID int32) ([]db.ListLedgerMembersRow, error) {
+	members := []db.ListLedgerMembersRow{}
+	for key, member := range s.members {
+		if key.ledgerID != ledgerID {
+			continue
+		}
+		user := s.users[key.userID]
+		members = append(members, db.ListLedgerMembersRow{
+			LedgerID:    member.LedgerID,
+			UserID:      member.UserID,
+			Role:        member.Role,
+			CreatedAt:   member.CreatedAt,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	return members, nil
+}
+
+func (s *fakeStore) UpdateLedgerMemberRole(ctx context.Context, arg db.UpdateLedgerMemberRoleParams) (db.LedgerMember, error) {
+	key := ledgerMemberKeyPair{arg.LedgerID, arg.UserID}
+	member, ok := s.members[key]
+	if !ok || member.Role == db.LedgerRoleOwner {
+		return db.LedgerMember{}, sql.ErrNoRows
+	}
+	member.Role = arg.Role
+	s.members[key] = member
+	return member, nil
+}
+
+func (s *fakeStore) DeleteLedgerMember(ctx context.Context, arg db.DeleteLedgerMemberParams) (int64, error) {
+	key := ledgerMemberKeyPair{arg.LedgerID, arg.UserID}
+	member, ok := s.members[key]
+	if !ok || member.Role == db.LedgerRoleOwner {
+		return 0, nil
+	}
+	delete(s.members, key)
+	return 1, nil
+}
+
+func (s *fakeStore) CreateLedgerInvitation(ctx context.Context, arg db.CreateLedgerInvitationParams) (db.LedgerInvitation, error) {
+	invitation := db.LedgerInvitation{
+		ID:        int64(s.id()),
+		LedgerID:  arg.LedgerID,
+		Email:     arg.Email,
+		Role:      arg.Role,
+		TokenHash: arg.TokenHash,
+		InvitedBy: arg.InvitedBy,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	s.invites[invitation.ID] = invitation
+	return invitation, nil
+}
+
+func (s *fakeStore) GetLedgerInvitationByToken(ctx context.Context, tokenHash string) (db.LedgerInvitation, error) {
+	for _, invitation := range s.invites {
+		if invitation.TokenHash == tokenHash {
+			return invitation, nil
+		}
+	}
+	return db.LedgerInvitation{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) ListLedgerInvitations(ctx context.Context, ledgerID int32) ([]db.LedgerInvitation, error) {
+	invitations := []db.LedgerInvitation{}
+	for _, invitation := range s.invites {
+		if invitation.LedgerID == ledgerID && !invitation.AcceptedAt.Valid {
+			invitations = append(invitations, invitation)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].ID < invitations[j].ID })
+	return invitations, nil
+}
+
+func (s *fakeStore) DeleteLedgerInvitation(ctx context.Context, arg db.DeleteLedgerInvitationParams) (int64, error) {
+	invitation, ok := s.invites[arg.ID]
+	if !ok || invitation.LedgerID != arg.LedgerID || invitation.AcceptedAt.Valid {
+		return 0, nil
+	}
+	delete(s.invites, arg.ID)
+	return 1, nil
+}
+
+func (s *fakeStore) AcceptLedgerInvitationTx(ctx context.Context, arg db.AcceptLedgerInvitationTxParams) (db.LedgerMember, error) {
+	invitation, ok := s.invites[arg.InvitationID]
+	if !ok || invitation.AcceptedAt.Valid || time.Now().After(invitation.ExpiresAt) {
+		return db.LedgerMember{}, db.ErrLedgerInvitationUsed
+	}
+	invitation.AcceptedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.invites[invitation.ID] = invitation
+
+	member, ok := s.members[ledgerMemberKeyPair{arg.LedgerID, arg.UserID}]
+	if ok {
+		return member, nil
+	}
+	return s.addLedgerMember(arg.LedgerID, arg.UserID, arg.Role), nil
+}
+
+func (s *fakeStore) ImportExchangeRatesTx(ctx context.Context, rates []db.UpsertExchangeRateParams) (int, error) {
+	for _, rate := range rates {
+		s.rates[exchangeRateKey{rate.FromCurrency, rate.ToCurrency, rate.Date}] = db.ExchangeRate{
+			FromCurrency: rate.FromCurrency,
+			ToCurrency:   rate.ToCurrency,
+			Date:         rate.Date,
+			Rate:         rate.Rate,
+			UpdatedAt:    time.Now(),
+		}
+	}
+	return len(rates), nil
+}
+
+// GetExchangeRate returns the latest rate on or before the date for the
+// pair in either direction, preferring the asked direction on the same day.
+func (s *fakeStore) GetExchangeRate(ctx context.Context, arg db.GetExchangeRateParams) (db.ExchangeRate, error) {
+	var found *db.ExchangeRate
+	for _, rate := range s.rates {
+		rate := rate
+		direct := rate.FromCurrency == arg.FromCurrency && rate.ToCurrency == arg.ToCurrency
+		inverse := rate.FromCurrency == arg.ToCurrency && rate.ToCurrency == arg.FromCurrency
+		if !(direct || inverse) || rate.Date.After(arg.Date) {
+			continue
+		}
+		if found == nil || rate.Date.After(found.Date) || rate.Date.Equal(found.Date) && direct {
+			found = &rate
+		}
+	}
+	if found == nil {
+		return db.ExchangeRate{}, sql.ErrNoRows
+	}
+	return *found, nil
+}
+
+func (s *fakeStore) ListExchangeRates(ctx context.Context, arg db.ListExchangeRatesParams) ([]db.ExchangeRate, error) {
+	rates := []db.ExchangeRate{}
+	for _, rate := range s.rates {
+		if !arg.Currency.Valid || rate.FromCurrency == arg.Currency.String || rate.ToCurrency == arg.Currency.String {
+			rates = append(rates, rate)
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i], rates[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.After(b.Date)
+		}
+		if a.FromCurrency != b.FromCurrency {
+			return a.FromCurrency < b.FromCurrency
+		}
+		return a.ToCurrency < b.ToCurrency
+	})
+
+	start, end := int(arg.PageOffset), int(arg.PageOffset+arg.PageLimit)
+	if start > len(rates) {
+		start = len(rates)
+	}
+	if end > len(rates) {
+		end = len(rates)
+	}
+	return rates[start:end], nil
+}
+
+func (s *fakeStore) CreateWallet(ctx context.Context, arg db.CreateWalletParams) (db.Wallet, error) {
+	wallet := db.Wallet{
+		ID:             s.id(),
+		LedgerID:       arg.LedgerID,
+		CreatedBy:      arg.CreatedBy,
+		Name:           arg.Name,
+		Type:           arg.Type,
+		Currency:       arg.Currency,
+		OpeningBalance: arg.OpeningBalance,
+		CreatedAt:      time.Now(),
+	}
+	s.wallets[wallet.ID] = wallet
+	return wallet, nil
+}
+
+func (s *fakeStore) GetWallet(ctx context.Context, arg db.GetWalletParams) (db.Wallet, error) {
+	wallet, ok := s.wallets[arg.ID]
+	if !ok || wallet.LedgerID != arg.LedgerID {
+		return db.Wallet{}, sql.ErrNoRows
+	}
+	return wallet, nil
+}
+
+// signedAmount is what a transaction adds to its wallet's balance.
+func signedAmount(account db.Account) int64 {
+	switch account.Type {
+	case "credit":
+		return account.Amount
+	case "debit":
+		return -account.Amount
+	}
+	return 0
+}
+
+func (s *fakeStore) GetWalletTransactionsTotal(ctx context.Context, arg db.GetWalletTransactionsTotalParams) (int64, error) {
+	var total int64
+	for _, account := range s.accounts {
+		if account.WalletID == arg.WalletID && (!arg.Date.Valid || !account.Date.After(arg.Date.Time)) {
+			total += signedAmount(account)
+		}
+	}
+	return total, nil
+}
+
+func (s *fakeStore) ListWalletDailyTotals(ctx context.Context, arg db.ListWalletDailyTotalsParams) ([]db.ListWalletDailyTotalsRow, error) {
+	totals := map[time.Time]int64{}
+	for _, account := range s.accounts {
+		if account.WalletID == arg.WalletID && !account.Date.Before(arg.FromDate) && !account.Date.After(arg.ToDate) {
+			totals[account.Date] += signedAmount(account)
+		}
+	}
+
+	rows := []db.ListWalletDailyTotalsRow{}
+	for date, total := range totals {
+		rows = append(rows, db.ListWalletDailyTotalsRow{Date: date, Total: total})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Date.Before(rows[j].Date) })
+	return rows, nil
+}
+
+func (s *fakeStore) ListWallets(ctx context.Context, ledgerID int32) ([]db.ListWalletsRow, error) {
+	rows := []db.ListWalletsRow{}
+	for _, wallet := range s.wallets {
+		if wallet.LedgerID != ledgerID {
+			continue
+		}
+		total, _ := s.GetWalletTransactionsTotal(ctx, db.GetWalletTransactionsTotalParams{WalletID: wallet.ID})
+		rows = append(rows, db.ListWalletsRow{
+			ID:             wallet.ID,
+			LedgerID:       wallet.LedgerID,
+			CreatedBy:      wallet.CreatedBy,
+			Name:           wallet.Name,
+			Type:           wallet.Type,
+			Currency:       wallet.Currency,
+			OpeningBalance: wallet.OpeningBalance,
+			CreatedAt:      wallet.CreatedAt,
+			Balance:        wallet.OpeningBalance + total,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Name != rows[j].Name {
+			return rows[i].Name < rows[j].Name
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return rows, nil
+}
+
+func (s *fakeStore) UpdateWallet(ctx context.Context, arg db.UpdateWalletParams) (db.Wallet, error) {
+	wallet, ok := s.wallets[arg.ID]
+	if !ok || wallet.LedgerID != arg.LedgerID {
+		return db.Wallet{}, sql.ErrNoRows
+	}
+	wallet.Name = arg.Name
+	wallet.Type = arg.Type
+	wallet.OpeningBalance = arg.OpeningBalance
+	s.wallets[wallet.ID] = wallet
+	return wallet, nil
+}
+
+func (s *fakeStore) DeleteWallet(ctx context.Context, arg db.DeleteWalletParams) (int64, error) {
+	wallet, ok := s.wallets[arg.ID]
+	if !ok || wallet.LedgerID != arg.LedgerID {
+		return 0, nil
+	}
+	for _, account := range s.accounts {
+		if account.WalletID == wallet.ID {
+			return 0, nil
+		}
+	}
+	for _, recurring := range s.recurring {
+		if recurring.WalletID == wallet.ID {
+			return 0, nil
+		}
+	}
+	for _, plan := range s.plans {
+		if plan.WalletID == wallet.ID {
+			return 0, nil
+		}
+	}
+	delete(s.wallets, arg.ID)
+	return 1, nil
+}
+
+func (s *fakeStore) CreateTransferTx(ctx context.Context, arg db.CreateTransferTxParams) (db.TransferTxResult, error) {
+	transfer := db.Transfer{
+		ID:        s.id(),
+		LedgerID:  arg.LedgerID,
+		CreatedBy: arg.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	s.transfers[transfer.ID] = transfer
+
+	leg := func(side db.TransferLegParams, legType string) db.Account {
+		account := db.Account{
+			ID:          s.id(),
+			LedgerID:    arg.LedgerID,
+			CreatedBy:   arg.CreatedBy,
+			Title:       arg.Title,
+			Type:        legType,
+			Description: arg.Description,
+			Amount:      side.Amount,
+			Currency:    side.Currency,
+			Date:        arg.Date,
+			WalletID:    side.WalletID,
+			TransferID:  sql.NullInt32{Int32: transfer.ID, Valid: true},
+		}
+		s.accounts[account.ID] = account
+		return account
+	}
+	return db.TransferTxResult{
+		Transfer: transfer,
+		From:     leg(arg.From, db.TransferLegFrom),
+		To:       leg(arg.To, db.TransferLegTo),
+	}, nil
+}
+
+func (s *fakeStore) GetTransferTx(ctx context.Context, arg db.GetTransferParams) (db.TransferTxResult, error) {
+	transfer, ok := s.transfers[arg.ID]
+	if !ok || transfer.LedgerID != arg.LedgerID {
+		return db.TransferTxResult{}, sql.ErrNoRows
+	}
+
+	result := db.TransferTxResult{Transfer: transfer}
+	for _, account := range s.accounts {
+		if !account.TransferID.Valid || account.TransferID.Int32 != transfer.ID {
+			continue
+		}
+		if account.Type == db.TransferLegFrom {
+			result.From = account
+		} else {
+			result.To = account
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStore) UpdateTransferTx(ctx context.Context, arg db.UpdateTransferTxParams) (db.TransferTxResult, error) {
+	result, err := s.GetTransferTx(ctx, db.GetTransferParams{ID: arg.ID, LedgerID: arg.LedgerID})
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	update := func(leg db.Account, amount int64) db.Account {
+		leg.Title = arg.Title
+		leg.Description = arg.Description
+		leg.Amount = amount
+		leg.Date = arg.Date
+		s.accounts[leg.ID] = leg
+		return leg
+	}
+	result.From = update(result.From, arg.FromAmount)
+	result.To = update(result.To, arg.ToAmount)
+	return result, nil
+}
+
+func (s *fakeStore) DeleteTransfer(ctx context.Context, arg db.DeleteTransferParams) (int64, error) {
+	transfer, ok := s.transfers[arg.ID]
+	if !ok || transfer.LedgerID != arg.LedgerID {
+		return 0, nil
+	}
+	for id, account := range s.accounts {
+		if account.TransferID.Valid && account.TransferID.Int32 == transfer.ID {
+			delete(s.accounts, id)
+		}
+	}
+	delete(s.transfers, arg.ID)
+	return 1, nil
+}
+
+func (s *fakeStore) CreateRecurringTransaction(ctx context.Context, arg db.CreateRecurringTransactionParams) (db.RecurringTransaction, error) {
+	recurring := db.RecurringTransaction{
+		ID:              s.id(),
+		LedgerID:        arg.LedgerID,
+		CreatedBy:       arg.CreatedBy,
+		CategoryID:      arg.CategoryID,
+		WalletID:        arg.WalletID,
+		Title:           arg.Title,
+		Type:            arg.Type,
+		Description:     arg.Description,
+		Amount:          arg.Amount,
+		Currency:        arg.Currency,
+		Rule:            arg.Rule,
+		StartsOn:        arg.StartsOn,
+		EndsOn:          arg.EndsOn,
+		OccurrenceCount: arg.OccurrenceCount,
+		CreatedAt:       time.Now(),
+	}
+	s.recurring[recurring.ID] = recurring
+	return recurring, nil
+}
+
+func (s *fakeStore) GetRecurringTransaction(ctx context.Context, arg db.GetRecurringTransactionParams) (db.RecurringTransaction, error) {
+	recurring, ok := s.recurring[arg.ID]
+	if !ok || recurring.LedgerID != arg.LedgerID {
+		return db.RecurringTransaction{}, sql.ErrNoRows
+	}
+	return recurring, nil
+}
+
+func (s *fakeStore) ListRecurringTransactions(ctx context.Context, ledgerID int32) ([]db.RecurringTransaction, error) {
+	templates := []db.RecurringTransaction{}
+	for _, recurring := range s.recurring {
+		if recurring.LedgerID == ledgerID {
+			templates = append(templates, recurring)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates, nil
+}
+
+func (s *fakeStore) UpdateRecurringTransaction(ctx context.Context, arg db.UpdateRecurringTransactionParams) (db.RecurringTransaction, error) {
+	recurring, err := s.GetRecurringTransaction(ctx, db.GetRecurringTransactionParams{ID: arg.ID, LedgerID: arg.LedgerID})
+	if err != nil {
+		return db.RecurringTransaction{}, err
+	}
+	recurring.Title = arg.Title
+	recurring.Description = arg.Description
+	recurring.Amount = arg.Amount
+	recurring.Rule = arg.Rule
+	recurring.EndsOn = arg.EndsOn
+	recurring.OccurrenceCount = arg.OccurrenceCount
+	s.recurring[recurring.ID] = recurring
+	return recurring, nil
+}
+
+func (s *fakeStore) DeleteRecurringTransaction(ctx context.Context, arg db.DeleteRecurringTransactionParams) (int64, error) {
+	if _, err := s.GetRecurringTransaction(ctx, db.GetRecurringTransactionParams(arg)); err != nil {
+		return 0, nil
+	}
+	for key := range s.exceptions {
+		if key.recurringID == arg.ID {
+			delete(s.exceptions, key)
+		}
+	}
+	delete(s.recurring, arg.ID)
+	return 1, nil
+}
+
+func (s *fakeStore) ListRecurringOccurrences(ctx context.Context, arg db.ListRecurringOccurrencesParams) ([]db.RecurringOccurrence, error) {
+	exceptions := []db.RecurringOccurrence{}
+	for key, exception := range s.exceptions {
+		if key.recurringID == arg.RecurringID && !exception.Date.Before(arg.FromDate) && !exception.Date.After(arg.ToDate) {
+			exceptions = append(exceptions, exception)
+		}
+	}
+	sort.Slice(exceptions, func(i, j int) bool { return exceptions[i].Date.Before(exceptions[j].Date) })
+	return exceptions, nil
+}
+
+// exception returns the stored exception for an occurrence, or a new one,
+// and whether it may still change.
+func (s *fakeStore) exception(recurringID int32, date time.Time) (db.RecurringOccurrence, occurrenceKey, bool) {
+	key := occurrenceKey{recurringID: recurringID, date: date.Format(dateLayout)}
+	exception, ok := s.exceptions[key]
+	if !ok {
+		exception = db.RecurringOccurrence{RecurringID: recurringID, Date: date}
+	}
+	return exception, key, !exception.GeneratedAt.Valid
+}
+
+func (s *fakeStore) SkipRecurringOccurrence(ctx context.Context, arg db.SkipRecurringOccurrenceParams) (db.RecurringOccurrence, error) {
+	exception, key, ok := s.exception(arg.RecurringID, arg.Date)
+	if !ok {
+		return db.RecurringOccurrence{}, sql.ErrNoRows
+	}
+	exception.Skipped = true
+	s.exceptions[key] = exception
+	return exception, nil
+}
+
+func (s *fakeStore) ModifyRecurringOccurrence(ctx context.Context, arg db.ModifyRecurringOccurrenceParams) (db.RecurringOccurrence, error) {
+	exception, key, ok := s.exception(arg.RecurringID, arg.Date)
+	if !ok {
+		return db.RecurringOccurrence{}, sql.ErrNoRows
+	}
+	exception.Title = arg.Title
+	exception.Description = arg.Description
+	exception.Amount = arg.Amount
+	s.exceptions[key] = exception
+	return exception, nil
+}
+
+func (s *fakeStore) DeleteRecurringOccurrence(ctx context.Context, arg db.DeleteRecurringOccurrenceParams) (int64, error) {
+	_, key, ok := s.exception(arg.RecurringID, arg.Date)
+	if _, stored := s.exceptions[key]; !stored || !ok {
+		return 0, nil
+	}
+	delete(s.exceptions, key)
+	return 1, nil
+}
+
+func (s *fakeStore) GenerateRecurringTx(ctx context.Context, arg db.GenerateRecurringTxParams) (db.Account, error) {
+	exception, key, ok := s.exception(arg.Recurring.ID, arg.Date)
+	if !ok || exception.Skipped {
+		return db.Account{}, sql.ErrNoRows
+	}
+
+	recurring := arg.Recurring
+	account, err := s.CreateAccount(ctx, db.CreateAccountParams{
+		LedgerID:    recurring.LedgerID,
+		CreatedBy:   recurring.CreatedBy,
+		CategoryID:  sql.NullInt32{Int32: recurring.CategoryID, Valid: true},
+		Title:       recurring.Title,
+		Type:        recurring.Type,
+		Description: recurring.Description,
+		Amount:      recurring.Amount,
+		Currency:    recurring.Currency,
+		Date:        arg.Date,
+		WalletID:    recurring.WalletID,
+	})
+	if err != nil {
+		return db.Account{}, err
+	}
+	exception.AccountID = sql.NullInt32{Int32: account.ID, Valid: true}
+	exception.GeneratedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.exceptions[key] = exception
+	return account, nil
+}
+
+func (s *fakeStore) CreateInstallmentPlanTx(ctx context.Context, arg db.CreateInstallmentPlanParams) (db.InstallmentPlanTxResult, error) {
+	plan := db.InstallmentPlan{
+		ID:           s.id(),
+		LedgerID:     arg.LedgerID,
+		CreatedBy:    arg.CreatedBy,
+		CategoryID:   arg.CategoryID,
+		WalletID:     arg.WalletID,
+		Title:        arg.Title,
+		Type:         arg.Type,
+		Description:  arg.Description,
+		TotalAmount:  arg.TotalAmount,
+		Currency:     arg.Currency,
+		Installments: arg.Installments,
+		FirstDate:    arg.FirstDate,
+		Remainder:    arg.Remainder,
+		CreatedAt:    time.Now(),
+	}
+	s.plans[plan.ID] = plan
+	s.addInstallments(plan, 0, plan.TotalAmount)
+	return s.installmentPlanResult(plan), nil
+}
+
+// addInstallments records the installments of plan after the first due,
+// splitting amount over them.
+func (s *fakeStore) addInstallments(plan db.InstallmentPlan, due int32, amount int64) {
+	parts := money.New(amount, plan.Currency).Split(int(plan.Installments-due), plan.Remainder == "first")
+	for i, part := range parts {
+		number := due + int32(i) + 1
+		account, _ := s.CreateAccount(context.Background(), db.CreateAccountParams{
+			LedgerID:    plan.LedgerID,
+			CreatedBy:   plan.CreatedBy,
+			CategoryID:  sql.NullInt32{Int32: plan.CategoryID, Valid: true},
+			Title:       fmt.Sprintf("%s (%d/%d)", plan.Title, number, plan.Installments),
+			Type:        plan.Type,
+			Description: plan.Description,
+			Amount:      part.Amount,
+			Currency:    plan.Currency,
+			Date:        plan.FirstDate.AddDate(0, int(number-1), 0),
+			WalletID:    plan.WalletID,
+		})
+		s.installments[plan.ID] = append(s.installments[plan.ID], account.ID)
+	}
+}
+
+// dueInstallments returns the installments of a plan due by today and
+// what they add up to.
+func (s *fakeStore) dueInstallments(plan db.InstallmentPlan, today time.Time) ([]int32, int64) {
+	var due []int32
+	var paid int64
+	for _, id := range s.installments[plan.ID] {
+		if !s.accounts[id].Date.After(today) {
+			due = append(due, id)
+			paid += s.accounts[id].Amount
+		}
+	}
+	return due, paid
+}
+
+// keepInstallments deletes the installments of a plan that are not in
+// due.
+func (s *fakeStore) keepInstallments(plan db.InstallmentPlan, due []int32) {
+	for _, id := range s.installments[plan.ID][len(due):] {
+		delete(s.accounts, id)
+	}
+	s.installments[plan.ID] = due
+}
+
+func (s *fakeStore) installmentPlanResult(plan db.InstallmentPlan) db.InstallmentPlanTxResult {
+	result := db.InstallmentPlanTxResult{Plan: plan}
+	for _, id := range s.installments[plan.ID] {
+		result.Installments = append(result.Installments, s.accounts[id])
+	}
+	return result
+}
+
+func (s *fakeStore) GetInstallmentPlan(ctx context.Context, arg db.GetInstallmentPlanParams) (db.InstallmentPlan, error) {
+	plan, ok := s.plans[arg.ID]
+	if !ok || plan.LedgerID != arg.LedgerID {
+		return db.InstallmentPlan{}, sql.ErrNoRows
+	}
+	return plan, nil
+}
+
+func (s *fakeStore) GetInstallmentPlanTx(ctx context.Context, arg db.GetInstallmentPlanParams) (db.InstallmentPlanTxResult, error) {
+	plan, err := s.GetInstallmentPlan(ctx, arg)
+	if err != nil {
+		return db.InstallmentPlanTxResult{}, err
+	}
+	return s.installmentPlanResult(plan), nil
+}
+
+func (s *fakeStore) ListInstallmentPlans(ctx context.Context, ledgerID int32) ([]db.InstallmentPlan, error) {
+	plans := []db.InstallmentPlan{}
+	for _, plan := range s.plans {
+		if plan.LedgerID == ledgerID {
+			plans = append(plans, plan)
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID > plans[j].ID })
+	return plans, nil
+}
+
+func (s *fakeStore) UpdateInstallmentPlanTx(ctx context.Context, arg db.UpdateInstallmentPlanTxParams) (db.InstallmentPlanTxResult, error) {
+	plan, err := s.GetInstallmentPlan(ctx, db.GetInstallmentPlanParams{ID: arg.ID, LedgerID: arg.LedgerID})
+	if err != nil {
+		return db.InstallmentPlanTxResult{}, err
+	}
+	if plan.CancelledAt.Valid {
+		return db.InstallmentPlanTxResult{}, db.ErrInstallmentPlanCancelled
+	}
+	due, paid := s.dueInstallments(plan, arg.Today)
+	count := int32(len(due))
+	if arg.Installments <= count || arg.TotalAmount-paid < int64(arg.Installments-count) {
+		return db.InstallmentPlanTxResult{}, db.ErrInstallmentsDue
+	}
+
+	plan.Title = arg.Title
+	plan.Description = arg.Description
+	plan.TotalAmount = arg.TotalAmount
+	plan.Installments = arg.Installments
+	s.plans[plan.ID] = plan
+	s.keepInstallments(plan, due)
+	s.addInstallments(plan, count, plan.TotalAmount-paid)
+	return s.installmentPlanResult(plan), nil
+}
+
+func (s *fakeStore) CancelInstallmentPlanTx(ctx context.Context, arg db.CancelInstallmentPlanTxParams) (db.InstallmentPlanTxResult, error) {
+	plan, err := s.GetInstallmentPlan(ctx, db.GetInstallmentPlanParams{ID: arg.ID, LedgerID: arg.LedgerID})
+	if err != nil {
+		return db.InstallmentPlanTxResult{}, err
+	}
+	if plan.CancelledAt.Valid {
+		return db.InstallmentPlanTxResult{}, db.ErrInstallmentPlanCancelled
+	}
+	due, _ := s.dueInstallments(plan, arg.Today)
+	s.keepInstallments(plan, due)
+	plan.CancelledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.plans[plan.ID] = plan
+	return s.installmentPlanResult(plan), nil
+}
+
+func (s *fakeStore) SetBudget(ctx context.Context, arg db.SetBudgetParams) (db.Budget, error) {
+	budget := db.Budget{ID: s.id(), CreatedAt: time.Now()}
+	for _, existing := range s.budgets {
+		if existing.CategoryID == arg.CategoryID && existing.Month.Equal(arg.Month) {
+			budget = existing
+		}
+	}
+	budget.LedgerID = arg.LedgerID
+	budget.CategoryID = arg.CategoryID
+	budget.Month = arg.Month
+	budget.Amount = arg.Amount
+	budget.Currency = arg.Currency
+	budget.Rollover = arg.Rollover
+	s.budgets[budget.ID] = budget
+	return budget, nil
+}
+
+func (s *fakeStore) ListBudgetsThrough(ctx context.Context, arg db.ListBudgetsThroughParams) ([]db.Budget, error) {
+	budgets := []db.Budget{}
+	for _, budget := range s.budgets {
+		if budget.LedgerID == arg.LedgerID && !budget.Month.After(arg.Month) {
+			budgets = append(budgets, budget)
+		}
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		if budgets[i].CategoryID != budgets[j].CategoryID {
+			return budgets[i].CategoryID < budgets[j].CategoryID
+		}
+		return budgets[i].Month.Before(budgets[j].Month)
+	})
+	return budgets, nil
+}
+
+func (s *fakeStore) CopyBudgets(ctx context.Context, arg db.CopyBudgetsParams) ([]db.Budget, error) {
+	budgeted := map[int32]bool{}
+	var from []db.Budget
+	for _, budget := range s.budgets {
+		if budget.LedgerID != arg.LedgerID {
+			continue
+		}
+		if budget.Month.Equal(arg.ToMonth) {
+			budgeted[budget.CategoryID] = true
+		}
+		if budget.Month.Equal(arg.FromMonth) {
+			from = append(from, budget)
+		}
+	}
+	sort.Slice(from, func(i, j int) bool { return from[i].CategoryID < from[j].CategoryID })
+
+	copied := []db.Budget{}
+	for _, budget := range from {
+		if budgeted[budget.CategoryID] {
+			continue
+		}
+		budget.ID = s.id()
+		budget.Month = arg.ToMonth
+		s.budgets[budget.ID] = budget
+		copied = append(copied, budget)
+	}
+	return copied, nil
+}
+
+func (s *fakeStore) DeleteBudget(ctx context.Context, arg db.DeleteBudgetParams) (int64, error) {
+	budget, ok := s.budgets[arg.ID]
+	if !ok || budget.LedgerID != arg.LedgerID {
+		return 0, nil
+	}
+	delete(s.budgets, arg.ID)
+	return 1, nil
+}
+
+func (s *fakeStore) GetBudgetSpending(ctx context.Context, arg db.GetBudgetSpendingParams) ([]db.GetBudgetSpendingRow, error) {
+	rows := []db.GetBudgetSpendingRow{}
+	for _, account := range s.accounts {
+		if account.LedgerID != arg.LedgerID || !account.CategoryID.Valid ||
+			account.Date.Before(arg.FromDate) || account.Date.After(arg.ToDate) {
+			continue
+		}
+		rows = append(rows, db.GetBudgetSpendingRow{
+			CategoryID: account.CategoryID.Int32,
+			Currency:   account.Currency,
+			Date:       account.Date,
+			Amount:     account.Amount,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].CategoryID != rows[j].CategoryID {
+			return rows[i].CategoryID < rows[j].CategoryID
+		}
+		return rows[i].Date.Before(rows[j].Date)
+	})
+	return rows, nil
+}
+
+func (s *fakeStore) CreateGoal(ctx context.Context, arg db.CreateGoalParams) (db.Goal, error) {
+	goal := db.Goal{
+		ID:           s.id(),
+		LedgerID:     arg.LedgerID,
+		CreatedBy:    arg.CreatedBy,
+		WalletID:     arg.WalletID,
+		Title:        arg.Title,
+		TargetAmount: arg.TargetAmount,
+		Currency:     arg.Currency,
+		StartsOn:     arg.StartsOn,
+		Deadline:     arg.Deadline,
+		CreatedAt:    time.Now(),
+	}
+	s.goals[goal.ID] = goal
+	return goal, nil
+}
+
+func (s *fakeStore) GetGoal(ctx context.Context, arg db.GetGoalParams) (db.Goal, error) {
+	goal, ok := s.goals[arg.ID]
+	if !ok || goal.LedgerID != arg.LedgerID {
+		return db.Goal{}, sql.ErrNoRows
+	}
+	return goal, nil
+}
+
+func (s *fakeStore) ListGoals(ctx context.Context, ledgerID int32) ([]db.Goal, error) {
+	goals := []db.Goal{}
+	for _, goal := range s.goals {
+		if goal.LedgerID == ledgerID {
+			goals = append(goals, goal)
+		}
+	}
+	sort.Slice(goals, func(i, j int) bool { return goals[i].ID < goals[j].ID })
+	return goals, nil
+}
+
+func (s *fakeStore) UpdateGoal(ctx context.Context, arg db.UpdateGoalParams) (db.Goal, error) {
+	goal, err := s.GetGoal(ctx, db.GetGoalParams{ID: arg.ID, LedgerID: arg.LedgerID})
+	if err != nil {
+		return db.Goal{}, err
+	}
+	goal.Title = arg.Title
+	goal.TargetAmount = arg.TargetAmount
+	goal.Deadline = arg.Deadline
+	s.goals[goal.ID] = goal
+	return goal, nil
+}
+
+func (s *fakeStore) DeleteGoal(ctx context.Context, arg db.DeleteGoalParams) (int64, error) {
+	if _, err := s.GetGoal(ctx, db.GetGoalParams{ID: arg.ID, LedgerID: arg.LedgerID}); err != nil {
+		return 0, nil
+	}
+	delete(s.goals, arg.ID)
+	for accountID, goalID := range s.goalAccounts {
+		if goalID == arg.ID {
+			delete(s.goalAccounts, accountID)
+		}
+	}
+	return 1, nil
+}
+
+func (s *fakeStore) LinkGoalContribution(ctx context.Context, arg db.LinkGoalContributionParams) (db.GoalContribution, error) {
+	if _, ok := s.goalAccounts[arg.AccountID]; ok {
+		return db.GoalContribution{}, sql.ErrNoRows
+	}
+	s.goalAccounts[arg.AccountID] = arg.GoalID
+	return db.GoalContribution{GoalID: arg.GoalID, AccountID: arg.AccountID, CreatedAt: time.Now()}, nil
+}
+
+func (s *fakeStore) UnlinkGoalContribution(ctx context.Context, arg db.UnlinkGoalContributionParams) (int64, error) {
+	if goalID, ok := s.goalAccounts[arg.AccountID]; !ok || goalID != arg.GoalID {
+		return 0, nil
+	}
+	delete(s.goalAccounts, arg.AccountID)
+	return 1, nil
+}
+
+func (s *fakeStore) ListGoalContributions(ctx context.Context, arg db.ListGoalContributionsParams) ([]db.ListGoalContributionsRow, error) {
+	rows := []db.ListGoalContributionsRow{}
+	for _, account := range s.accounts {
+		row := db.ListGoalContributionsRow{
+			AccountID:  account.ID,
+			Date:       account.Date,
+			Amount:     account.Amount,
+			TransferID: account.TransferID,
+		}
+		switch {
+		case s.goalAccounts[account.ID] == arg.GoalID:
+		case account.WalletID == arg.WalletID && account.TransferID.Valid && !account.Date.Before(arg.StartsOn):
+			if account.Type != "credit" {
+				row.Amount = -row.Amount
+			}
+		default:
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Date.Equal(rows[j].Date) {
+			return rows[i].Date.Before(rows[j].Date)
+		}
+		return rows[i].AccountID < rows[j].AccountID
+	})
+	return rows, nil
+}
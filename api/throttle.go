@@ -0,0 +1,170 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+var (
+	errInvalidCredentials   = errors.New("username or password is incorrect")
+	errTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+)
+
+var (
+	dummyPasswordHashOnce sync.Once
+	dummyPasswordHash     string
+)
+
+// checkPasswordOrDummy compares password against the user's hash, or
+// against a throwaway hash when the user does not exist or has no password,
+// so those take as long to reject as wrong passwords.
+func (server *Server) checkPasswordOrDummy(password string, user db.User, found bool) error {
+	if found && user.Password != "" {
+		return util.CheckPassword(password, user.Password)
+	}
+
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = util.HashPassword(util.RandomString(32), server.config.PasswordParams)
+	})
+	util.CheckPassword(password, dummyPasswordHash)
+	return errInvalidCredentials
+}
+
+type loginThrottleKey struct {
+	key         string
+	maxFailures int
+}
+
+// loginThrottleKeys returns the counters a login attempt is tracked by:
+// one for the username, whether it exists or not, and one for the client.
+func (server *Server) loginThrottleKeys(ctx *gin.Context, username string) []loginThrottleKey {
+	return []loginThrottleKey{
+		{key: userThrottleKey(username), maxFailures: server.config.LoginMaxUserFailures},
+		{key: "ip:" + ctx.ClientIP(), maxFailures: server.config.LoginMaxIPFailures},
+	}
+}
+
+func userThrottleKey(username string) string {
+	return "user:" + username
+}
+
+// loginBackoff returns how long to wait after the given number of
+// consecutive failures.
+func (server *Server) loginBackoff(failures int32) time.Duration {
+	base, max := server.config.LoginBackoffBase, server.config.LoginBackoffMax
+	if failures <= 0 || base <= 0 {
+		return 0
+	}
+
+	backoff := base
+	for i := int32(1); i < failures && backoff < max; i++ {
+		backoff *= 2
+	}
+	if max > 0 && backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// loginRetryAfter returns how long the caller has to wait before the
+// next login attempt is allowed, zero when it may try right away.
+func (server *Server) loginRetryAfter(ctx *gin.Context, keys []loginThrottleKey) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for _, k := range keys {
+		throttle, err := server.store.GetLoginThrottle(ctx, k.key)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return 0, err
+		}
+
+		until := throttle.LastFailedAt.Add(server.loginBackoff(throttle.Failures))
+		if throttle.LockedUntil.Valid && throttle.LockedUntil.Time.After(until) {
+			until = throttle.LockedUntil.Time
+		}
+		if d := until.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// checkLoginThrottle aborts the request with 429 and a Retry-After header
+// while the username or client is backing off or locked out.
+func (server *Server) checkLoginThrottle(ctx *gin.Context, keys []loginThrottleKey) bool {
+	wait, err := server.loginRetryAfter(ctx, keys)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if wait > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure audits a failed attempt, counts it and locks out the
+// counters that reached their threshold, auditing each lockout.
+func (server *Server) recordLoginFailure(ctx *gin.Context, keys []loginThrottleKey, event auditEvent) error {
+	event.Outcome = db.AuditOutcomeFailure
+	err := server.recordAuditEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, k := range keys {
+		throttle, err := server.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+			Key:         k.key,
+			ResetBefore: now.Add(-server.config.LoginFailureWindow),
+		})
+		if err != nil {
+			return err
+		}
+		if k.maxFailures <= 0 || int(throttle.Failures) < k.maxFailures {
+			continue
+		}
+
+		err = server.store.LockLogin(ctx, db.LockLoginParams{
+			Key:         k.key,
+			LockedUntil: sql.NullTime{Time: now.Add(server.config.LoginLockoutDuration), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = server.recordAuditEvent(ctx, auditEvent{
+			Type:     auditEventLoginLockout,
+			Outcome:  db.AuditOutcomeFailure,
+			UserID:   event.UserID,
+			Username: event.Username,
+			Details: fmt.Sprintf("%s locked for %s after %d failed logins",
+				k.key, server.config.LoginLockoutDuration, throttle.Failures),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearLoginFailures resets the username counter once a login succeeds.
+// The client counter is left to expire so a valid account cannot be used
+// to reset it.
+func (server *Server) clearLoginFailures(ctx *gin.Context, username string) error {
+	return server.store.ClearLoginThrottle(ctx, userThrottleKey(username))
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func failLogin(t *testing.T, server *Server, username string) {
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: username,
+		Password: util.RandomString(12),
+	})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLoginUnknownUserMatchesWrongPassword(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	user, _ := loginTestUser(t, server)
+
+	wrongPassword := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: user.Username,
+		Password: util.RandomString(12),
+	})
+	unknownUser := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: util.RandomString(8),
+		Password: util.RandomString(12),
+	})
+
+	require.Equal(t, http.StatusUnauthorized, wrongPassword.Code)
+	require.Equal(t, wrongPassword.Code, unknownUser.Code)
+	require.Equal(t, wrongPassword.Body.String(), unknownUser.Body.String())
+}
+
+func TestLoginLockout(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, _ := loginTestUser(t, server)
+	other, _ := loginTestUser(t, server)
+
+	for i := 0; i < server.config.LoginMaxUserFailures; i++ {
+		failLogin(t, server, user.Username)
+	}
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: user.Username,
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	retryAfter, err := strconv.Atoi(recorder.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	require.InDelta(t, server.config.LoginLockoutDuration.Seconds(), retryAfter, 1)
+
+	lockouts := store.eventsOfType(auditEventLoginLockout)
+	require.Len(t, lockouts, 1)
+	require.Equal(t, user.Username, lockouts[0].Username)
+	require.True(t, lockouts[0].UserID.Valid)
+
+	// Other users logging in from the same client are not affected.
+	login(t, server, other.Username, other.Password)
+
+	throttle := store.throttles[userThrottleKey(user.Username)]
+	throttle.LockedUntil.Time = time.Now().Add(-time.Second)
+	store.throttles[userThrottleKey(user.Username)] = throttle
+
+	login(t, server, user.Username, user.Password)
+	require.NotContains(t, store.throttles, userThrottleKey(user.Username))
+}
+
+func TestLoginLockoutUnknownUser(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	username := util.RandomString(8)
+
+	for i := 0; i < server.config.LoginMaxUserFailures; i++ {
+		failLogin(t, server, username)
+	}
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: username,
+		Password: util.RandomString(12),
+	})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	lockouts := store.eventsOfType(auditEventLoginLockout)
+	require.Len(t, lockouts, 1)
+	require.False(t, lockouts[0].UserID.Valid)
+}
+
+func TestLoginClientLockout(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	server.config.LoginMaxUserFailures = 0
+	user, _ := loginTestUser(t, server)
+
+	for i := 0; i < server.config.LoginMaxIPFailures; i++ {
+		failLogin(t, server, util.RandomString(8))
+	}
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: user.Username,
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Len(t, store.eventsOfType(auditEventLoginLockout), 1)
+}
+
+func TestLoginBackoff(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	server.config.LoginBackoffBase = time.Minute
+	server.config.LoginBackoffMax = time.Hour
+	user, _ := loginTestUser(t, server)
+
+	failLogin(t, server, user.Username)
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+		Username: user.Username,
+		Password: user.Password,
+	})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+}
+
+func TestLoginBackoffDuration(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+	server.config.LoginBackoffBase = time.Second
+	server.config.LoginBackoffMax = 30 * time.Second
+
+	require.Zero(t, server.loginBackoff(0))
+	require.Equal(t, time.Second, server.loginBackoff(1))
+	require.Equal(t, 2*time.Second, server.loginBackoff(2))
+	require.Equal(t, 16*time.Second, server.loginBackoff(5))
+	require.Equal(t, 30*time.Second, server.loginBackoff(6))
+	require.Equal(t, 30*time.Second, server.loginBackoff(1000))
+}
+
+func TestMfaLoginThrottled(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, session := loginTestUser(t, server)
+	enableTestMfa(t, server, session)
+
+	challenge := loginChallenge(t, server, user)
+	for i := 0; i < server.config.LoginMaxUserFailures; i++ {
+		status, _ := loginMfaCode(t, server, challenge.MfaToken, "000000")
+		require.Equal(t, http.StatusUnauthorized, status)
+	}
+
+	status, _ := loginMfaCode(t, server, challenge.MfaToken, "000000")
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Len(t, store.eventsOfType(auditEventLoginLockout), 1)
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+var (
+	errTransferNotFound   = errors.New("transfer not found")
+	errTransferSameWallet = errors.New("a transfer needs two different wallets")
+	errTransferAmount     = errors.New("a transfer moves a positive amount")
+	errTransferLegWallet  = errors.New("transfer legs stay in their wallets")
+	errTransferUnbalanced = errors.New("a transfer within one currency moves the same amount out and in")
+)
+
+type createTransferRequest struct {
+	FromWalletID int32        `json:"from_wallet_id" binding:"required"`
+	ToWalletID   int32        `json:"to_wallet_id" binding:"required"`
+	Title        string       `json:"title" binding:"required"`
+	Description  string       `json:"description"`
+	Amount       *money.Money `json:"amount" binding:"required"`
+	ToAmount     *money.Money `json:"to_amount"`
+	Date         time.Time    `json:"date" binding:"required"`
+}
+
+// createTransfer moves money from one wallet of the ledger to another.
+// amount leaves the source wallet; to_amount, which defaults to amount,
+// reaches the target one and is needed when the currencies differ.
+func (server *Server) createTransfer(ctx *gin.Context) {
+	var req createTransferRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.FromWalletID == req.ToWalletID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTransferSameWallet))
+		return
+	}
+
+	toAmount, ok := transferAmounts(ctx, *req.Amount, req.ToAmount)
+	if !ok {
+		return
+	}
+	from, ok := server.transactionWallet(ctx, req.FromWalletID, *req.Amount)
+	if !ok {
+		return
+	}
+	to, ok := server.transactionWallet(ctx, req.ToWalletID, toAmount)
+	if !ok {
+		return
+	}
+
+	member := ledgerMember(ctx)
+	result, err := server.store.CreateTransferTx(ctx, db.CreateTransferTxParams{
+		LedgerID:    member.LedgerID,
+		CreatedBy:   sql.NullInt32{Int32: member.UserID, Valid: true},
+		Title:       req.Title,
+		Description: req.Description,
+		Date:        req.Date,
+		From:        db.TransferLegParams{WalletID: from.ID, Amount: req.Amount.Amount, Currency: from.Currency},
+		To:          db.TransferLegParams{WalletID: to.ID, Amount: toAmount.Amount, Currency: to.Currency},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(result))
+}
+
+// transferAmounts checks that a transfer moves positive amounts and
+// returns the amount reaching the target wallet. Within one currency
+// nothing is exchanged, so both amounts must match for the journal to
+// balance.
+func transferAmounts(ctx *gin.Context, amount money.Money, toAmount *money.Money) (money.Money, bool) {
+	if toAmount == nil {
+		toAmount = &amount
+	}
+	if amount.Amount <= 0 || toAmount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTransferAmount))
+		return money.Money{}, false
+	}
+	if amount.Currency == toAmount.Currency && amount.Amount != toAmount.Amount {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTransferUnbalanced))
+		return money.Money{}, false
+	}
+	return *toAmount, true
+}
+
+type transferURI struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// ledgerTransfer loads a transfer of the request's ledger with its legs,
+// answering 404 for transfers of other ledgers.
+func (server *Server) ledgerTransfer(ctx *gin.Context, id int32) (db.TransferTxResult, bool) {
+	result, err := server.store.GetTransferTx(ctx, db.GetTransferParams{
+		ID:       id,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errTransferNotFound))
+			return db.TransferTxResult{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.TransferTxResult{}, false
+	}
+	return result, true
+}
+
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var uri transferURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, ok := server.ledgerTransfer(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(result))
+}
+
+type updateTransferRequest struct {
+	Title       string       `json:"title" binding:"required"`
+	Description string       `json:"description"`
+	Amount      *money.Money `json:"amount" binding:"required"`
+	ToAmount    *money.Money `json:"to_amount"`
+	Date        time.Time    `json:"date" binding:"required"`
+}
+
+// updateTransfer changes both legs of a transfer. Its wallets cannot
+// change; delete the transfer and record a new one instead.
+func (server *Server) updateTransfer(ctx *gin.Context) {
+	var uri transferURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateTransferRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	toAmount, ok := transferAmounts(ctx, *req.Amount, req.ToAmount)
+	if !ok {
+		return
+	}
+	transfer, ok := server.ledgerTransfer(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	if !legCurrency(ctx, transfer.From, *req.Amount) || !legCurrency(ctx, transfer.To, toAmount) {
+		return
+	}
+
+	result, err := server.store.UpdateTransferTx(ctx, db.UpdateTransferTxParams{
+		ID:          transfer.Transfer.ID,
+		LedgerID:    transfer.Transfer.LedgerID,
+		Title:       req.Title,
+		Description: req.Description,
+		Date:        req.Date,
+		FromAmount:  req.Amount.Amount,
+		ToAmount:    toAmount.Amount,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errTransferNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(result))
+}
+
+// legCurrency checks that amount is in the currency of a transfer leg's
+// wallet.
+func legCurrency(ctx *gin.Context, leg db.Account, amount money.Money) bool {
+	if amount.Currency != leg.Currency {
+		err := fmt.Errorf("%w: wallet %d is in %s", money.ErrCurrencyMismatch, leg.WalletID, leg.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// updateTransferLeg applies an edit of one leg made through the account
+// routes to its whole transfer. Title and description change on both
+// legs; so does the amount when both wallets share a currency.
+func (server *Server) updateTransferLeg(ctx *gin.Context, leg db.Account, req updateAccountRequest) {
+	if req.WalletID != 0 && req.WalletID != leg.WalletID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTransferLegWallet))
+		return
+	}
+	if !legCurrency(ctx, leg, *req.Amount) {
+		return
+	}
+	if req.Amount.Amount <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTransferAmount))
+		return
+	}
+
+	transfer, ok := server.ledgerTransfer(ctx, leg.TransferID.Int32)
+	if !ok {
+		return
+	}
+
+	arg := db.UpdateTransferTxParams{
+		ID:          transfer.Transfer.ID,
+		LedgerID:    transfer.Transfer.LedgerID,
+		Title:       req.Title,
+		Description: req.Description,
+		Date:        leg.Date,
+		FromAmount:  transfer.From.Amount,
+		ToAmount:    transfer.To.Amount,
+	}
+	sameCurrency := transfer.From.Currency == transfer.To.Currency
+	if leg.ID == transfer.From.ID || sameCurrency {
+		arg.FromAmount = req.Amount.Amount
+	}
+	if leg.ID == transfer.To.ID || sameCurrency {
+		arg.ToAmount = req.Amount.Amount
+	}
+
+	result, err := server.store.UpdateTransferTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	updated := result.From
+	if leg.ID == result.To.ID {
+		updated = result.To
+	}
+	ctx.JSON(http.StatusOK, newAccountResponse(updated))
+}
+
+func (server *Server) deleteTransfer(ctx *gin.Context) {
+	var uri transferURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.removeTransfer(ctx, uri.ID)
+}
+
+// removeTransfer deletes a transfer of the request's ledger and both of
+// its legs.
+func (server *Server) removeTransfer(ctx *gin.Context, id int32) {
+	ledgerID := ledgerMember(ctx).LedgerID
+	rows, err := server.store.DeleteTransfer(ctx, db.DeleteTransferParams{
+		ID:       id,
+		LedgerID: ledgerID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errTransferNotFound))
+		return
+	}
+
+	server.recordOwnDeletion(ctx, auditEventTransferDeleted, fmt.Sprintf("transfer %d in ledger %d", id, ledgerID))
+
+	ctx.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+func createTestTransfer(t *testing.T, server *Server, userID int32, req createTransferRequest) transferResponse {
+	recorder := serveAs(t, server, userID, http.MethodPost, "/transfers", req)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var transfer transferResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &transfer))
+	return transfer
+}
+
+func requireWalletBalance(t *testing.T, server *Server, userID int32, wallet db.Wallet, balance string) {
+	recorder := serveAs(t, server, userID, http.MethodGet, fmt.Sprintf("/wallets/%d/balance", wallet.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, fmt.Sprintf(`{"amount":%q,"currency":%q}`, balance, wallet.Currency),
+		string(jsonField(t, recorder.Body.Bytes(), "balance")))
+}
+
+func TestCreateTransfer(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	ledgerID := createTestCategory(t, store, user.ID).LedgerID
+	checking := createTestWallet(t, store, ledgerID, "BRL")
+	savings := createTestWallet(t, store, ledgerID, "BRL")
+	dollars := createTestWallet(t, store, ledgerID, "USD")
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	transfer := createTestTransfer(t, server, user.ID, createTransferRequest{
+		FromWalletID: checking.ID,
+		ToWalletID:   savings.ID,
+		Title:        "Savings",
+		Amount:       &money.Money{Amount: 2500, Currency: "BRL"},
+		Date:         date,
+	})
+	require.Equal(t, "Savings", transfer.Title)
+	require.Equal(t, checking.ID, transfer.From.WalletID)
+	require.Equal(t, money.New(2500, "BRL"), transfer.From.Amount)
+	require.Equal(t, savings.ID, transfer.To.WalletID)
+	require.Equal(t, money.New(2500, "BRL"), transfer.To.Amount)
+
+	requireWalletBalance(t, server, user.ID, checking, "-25.00")
+	requireWalletBalance(t, server, user.ID, savings, "25.00")
+
+	// Different currencies need the amount reaching the target wallet.
+	transfer = createTestTransfer(t, server, user.ID, createTransferRequest{
+		FromWalletID: checking.ID,
+		ToWalletID:   dollars.ID,
+		Title:        "Travel money",
+		Amount:       &money.Money{Amount: 5000, Currency: "BRL"},
+		ToAmount:     &money.Money{Amount: 1000, Currency: "USD"},
+		Date:         date,
+	})
+	require.Equal(t, money.New(1000, "USD"), transfer.To.Amount)
+	requireWalletBalance(t, server, user.ID, checking, "-75.00")
+	requireWalletBalance(t, server, user.ID, dollars, "10.00")
+
+	// Transfers are neither expense nor income.
+	for _, accountType := range []string{"debit", "credit"} {
+		recorder := serveAs(t, server, user.ID, http.MethodGet, "/account/reports/"+accountType, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.JSONEq(t, `[]`, recorder.Body.String())
+
+		recorder = serveAs(t, server, user.ID, http.MethodGet, "/account/graph/"+accountType, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "0", recorder.Body.String())
+	}
+
+	other := createTestLedgerUser(t, store)
+	foreign := createTestWallet(t, store, createTestCategory(t, store, other.ID).LedgerID, "BRL")
+
+	for _, tc := range []struct {
+		req    createTransferRequest
+		status int
+	}{
+		{createTransferRequest{FromWalletID: checking.ID, ToWalletID: checking.ID, Title: "x", Amount: &money.Money{Amount: 1, Currency: "BRL"}, Date: date}, http.StatusBadRequest},
+		{createTransferRequest{FromWalletID: checking.ID, ToWalletID: savings.ID, Title: "x", Amount: &money.Money{Currency: "BRL"}, Date: date}, http.StatusBadRequest},
+		{createTransferRequest{FromWalletID: checking.ID, ToWalletID: dollars.ID, Title: "x", Amount: &money.Money{Amount: 1, Currency: "BRL"}, Date: date}, http.StatusBadRequest},
+		{createTransferRequest{FromWalletID: checking.ID, ToWalletID: savings.ID, Title: "x", Amount: &money.Money{Amount: 1, Currency: "BRL"}, ToAmount: &money.Money{Amount: 2, Currency: "BRL"}, Date: date}, http.StatusBadRequest},
+		{createTransferRequest{FromWalletID: checking.ID, ToWalletID: foreign.ID, Title: "x", Amount: &money.Money{Amount: 1, Currency: "BRL"}, Date: date}, http.StatusNotFound},
+		{createTransferRequest{FromWalletID: foreign.ID, ToWalletID: savings.ID, Title: "x", Amount: &money.Money{Amount: 1, Currency: "BRL"}, Date: date}, http.StatusNotFound},
+	} {
+		recorder := serveAs(t, server, user.ID, http.MethodPost, "/transfers", tc.req)
+		require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+	}
+	require.Len(t, store.transfers, 2)
+	requireWalletBalance(t, server, other.ID, foreign, "0.00")
+}
+
+func TestUpdateTransferLegs(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	ledgerID := createTestCategory(t, store, user.ID).LedgerID
+	checking := createTestWallet(t, store, ledgerID, "BRL")
+	savings := createTestWallet(t, store, ledgerID, "BRL")
+	dollars := createTestWallet(t, store, ledgerID, "USD")
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	transfer := createTestTransfer(t, server, user.ID, createTransferRequest{
+		FromWalletID: checking.ID,
+		ToWalletID:   savings.ID,
+		Title:        "Savings",
+		Amount:       &money.Money{Amount: 2500, Currency: "BRL"},
+		Date:         date,
+	})
+
+	// Editing one leg edits both.
+	recorder := serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", transfer.To.AccountID), updateAccountRequest{
+		ID:     transfer.To.AccountID,
+		Title:  "Emergency fund",
+		Amount: &money.Money{Amount: 3000, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.JSONEq(t, fmt.Sprint(transfer.ID), string(jsonField(t, recorder.Body.Bytes(), "transfer_id")))
+	require.JSONEq(t, `null`, string(jsonField(t, recorder.Body.Bytes(), "category_id")))
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, fmt.Sprintf("/transfers/%d", transfer.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got transferResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, "Emergency fund", got.Title)
+	require.Equal(t, money.New(3000, "BRL"), got.From.Amount)
+	require.Equal(t, money.New(3000, "BRL"), got.To.Amount)
+	require.Equal(t, "Emergency fund", store.accounts[transfer.From.AccountID].Title)
+	requireWalletBalance(t, server, user.ID, checking, "-30.00")
+
+	// Legs stay in their wallets.
+	recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", transfer.From.AccountID), updateAccountRequest{
+		ID:       transfer.From.AccountID,
+		Amount:   &money.Money{Amount: 3000, Currency: "BRL"},
+		WalletID: savings.ID,
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Across currencies, only the edited leg's amount changes.
+	travel := createTestTransfer(t, server, user.ID, createTransferRequest{
+		FromWalletID: checking.ID,
+		ToWalletID:   dollars.ID,
+		Title:        "Travel money",
+		Amount:       &money.Money{Amount: 5000, Currency: "BRL"},
+		ToAmount:     &money.Money{Amount: 1000, Currency: "USD"},
+		Date:         date,
+	})
+	recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", travel.From.AccountID), updateAccountRequest{
+		ID:     travel.From.AccountID,
+		Title:  "Travel money",
+		Amount: &money.Money{Amount: 5500, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, int64(5500), store.accounts[travel.From.AccountID].Amount)
+	require.Equal(t, int64(1000), store.accounts[travel.To.AccountID].Amount)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/transfers/%d", travel.ID), updateTransferRequest{
+		Title:    "Trip",
+		Amount:   &money.Money{Amount: 6000, Currency: "BRL"},
+		ToAmount: &money.Money{Amount: 1100, Currency: "USD"},
+		Date:     date.AddDate(0, 0, 1),
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, money.New(6000, "BRL"), got.From.Amount)
+	require.Equal(t, money.New(1100, "USD"), got.To.Amount)
+	require.True(t, date.AddDate(0, 0, 1).Equal(store.accounts[travel.To.AccountID].Date))
+
+	recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/transfers/%d", travel.ID), updateTransferRequest{
+		Title:  "Trip",
+		Amount: &money.Money{Amount: 6000, Currency: "BRL"},
+		Date:   date,
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	other := createTestLedgerUser(t, store)
+	createTestCategory(t, store, other.ID)
+	recorder = serveAs(t, server, other.ID, http.MethodPut, fmt.Sprintf("/transfers/%d", travel.ID), updateTransferRequest{
+		Title:    "Trip",
+		Amount:   &money.Money{Amount: 1, Currency: "BRL"},
+		ToAmount: &money.Money{Amount: 1, Currency: "USD"},
+		Date:     date,
+	})
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = serveAs(t, server, other.ID, http.MethodGet, fmt.Sprintf("/transfers/%d", travel.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestDeleteTransferLegs(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	ledgerID := createTestCategory(t, store, user.ID).LedgerID
+	checking := createTestWallet(t, store, ledgerID, "BRL")
+	savings := createTestWallet(t, store, ledgerID, "BRL")
+
+	newTransfer := func() transferResponse {
+		return createTestTransfer(t, server, user.ID, createTransferRequest{
+			FromWalletID: checking.ID,
+			ToWalletID:   savings.ID,
+			Title:        "Savings",
+			Amount:       &money.Money{Amount: 2500, Currency: "BRL"},
+			Date:         time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	// Deleting one leg deletes the transfer.
+	transfer := newTransfer()
+	recorder := serveAs(t, server, user.ID, http.MethodDelete, fmt.Sprintf("/account/%d", transfer.From.AccountID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, store.accounts, transfer.To.AccountID)
+	recorder = serveAs(t, server, user.ID, http.MethodGet, fmt.Sprintf("/transfers/%d", transfer.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	transfer = newTransfer()
+	other := createTestLedgerUser(t, store)
+	createTestCategory(t, store, other.ID)
+	recorder = serveAs(t, server, other.ID, http.MethodDelete, fmt.Sprintf("/transfers/%d", transfer.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, fmt.Sprintf("/transfers/%d", transfer.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, store.accounts)
+	require.Len(t, store.eventsOfType(auditEventTransferDeleted), 2)
+	requireWalletBalance(t, server, user.ID, checking, "0.00")
+}
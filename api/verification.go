@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/mail"
+	"github.com/wil-ckaew/gofinance-backend/token"
+)
+
+var (
+	errInvalidVerificationToken = errors.New("verification token is invalid or expired")
+	errEmailNotVerified         = errors.New("email address is not verified")
+	errEmailInUse               = errors.New("email address is already in use")
+)
+
+// sendVerificationEmail mails a signed verification link for the user's
+// current email. Delivery failures are logged, the user can ask for a new
+// link with /user/verify/resend.
+func (server *Server) sendVerificationEmail(ctx context.Context, user db.User) {
+	verifyToken, err := server.tokens.CreatePurposeToken(token.PurposeEmailVerification, user.ID, user.Email, server.config.EmailVerificationTTL)
+	if err != nil {
+		log.Printf("cannot create verification token for user %d: %v", user.ID, err)
+		return
+	}
+
+	err = server.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your GoFinance email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address with the link below. It expires in %s.\n\n%s/user/verify?token=%s\n\nIf you did not create a GoFinance account you can ignore this email.",
+			user.Username, server.config.EmailVerificationTTL, server.config.AppURL, url.QueryEscape(verifyToken)),
+	})
+	if err != nil {
+		log.Printf("cannot send verification email to user %d: %v", user.ID, err)
+	}
+}
+
+// sendEmailChangeEmails mails a verification link to the pending email of
+// the user and lets the current address know a change was requested.
+// Delivery failures are logged.
+func (server *Server) sendEmailChangeEmails(ctx context.Context, user db.User) {
+	newEmail := user.PendingEmail.String
+	verifyToken, err := server.tokens.CreatePurposeToken(token.PurposeEmailVerification, user.ID, newEmail, server.config.EmailVerificationTTL)
+	if err != nil {
+		log.Printf("cannot create verification token for user %d: %v", user.ID, err)
+		return
+	}
+
+	err = server.mailer.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new GoFinance email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm this address as the new email of your account with the link below. It expires in %s.\n\n%s/user/verify?token=%s\n\nIf you did not ask for this change you can ignore this email.",
+			user.Username, server.config.EmailVerificationTTL, server.config.AppURL, url.QueryEscape(verifyToken)),
+	})
+	if err != nil {
+		log.Printf("cannot send verification email to user %d: %v", user.ID, err)
+	}
+
+	err = server.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Your GoFinance email is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email of your account to %s. The change only happens once the new address is confirmed.\n\nIf this was not you, change your password.",
+			user.Username, newEmail),
+	})
+	if err != nil {
+		log.Printf("cannot send email change notice to user %d: %v", user.ID, err)
+	}
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// verifyEmail marks the email a verification token was issued for as
+// verified. For a pending email change it also makes the new address the
+// account's email. Links sent for an address the user no longer has, or no
+// longer wants, are rejected.
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims, err := server.tokens.VerifyPurposeToken(token.PurposeEmailVerification, req.Token)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerificationToken))
+		return
+	}
+
+	rows, err := server.store.VerifyUserEmail(ctx, db.VerifyUserEmailParams{
+		ID:    claims.UserID,
+		Email: claims.Email,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows > 0 {
+		ctx.JSON(http.StatusOK, true)
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerificationToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if user.Email == claims.Email && user.VerifiedAt.Valid {
+		ctx.JSON(http.StatusOK, true)
+		return
+	}
+	if user.DeletedAt.Valid || !user.PendingEmail.Valid || user.PendingEmail.String != claims.Email {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerificationToken))
+		return
+	}
+
+	// Someone may have signed up with the address since the change was
+	// requested.
+	_, err = server.store.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		ctx.JSON(http.StatusConflict, errorResponse(errEmailInUse))
+		return
+	}
+	if err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rows, err = server.store.ConfirmUserEmailChange(ctx, db.ConfirmUserEmailChangeParams{
+		ID:    user.ID,
+		Email: claims.Email,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerificationToken))
+		return
+	}
+
+	err = server.recordAuditEvent(ctx, auditEvent{
+		Type:     auditEventEmailChanged,
+		Outcome:  db.AuditOutcomeSuccess,
+		UserID:   user.ID,
+		Username: user.Username,
+		Details:  fmt.Sprintf("from %s to %s", user.Email, claims.Email),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// resendVerification mails a fresh verification link. Like forgotPassword
+// it answers the same way for unknown and already verified addresses.
+func (server *Server) resendVerification(ctx *gin.Context) {
+	var req resendVerificationRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusAccepted, true)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.VerifiedAt.Valid && !user.DeletedAt.Valid {
+		server.sendVerificationEmail(ctx, user)
+	}
+
+	ctx.JSON(http.StatusAccepted, true)
+}
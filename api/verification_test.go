@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+var verifyLinkRegexp = regexp.MustCompile(`/user/verify\?token=(\S+)`)
+
+func verifyTokenFromMailbox(t *testing.T, mailbox *bytes.Buffer) string {
+	matches := verifyLinkRegexp.FindAllStringSubmatch(mailbox.String(), -1)
+	require.NotEmpty(t, matches)
+	verifyToken, err := url.QueryUnescape(matches[len(matches)-1][1])
+	require.NoError(t, err)
+	return verifyToken
+}
+
+func TestCreateUserRejectsInvalidEmail(t *testing.T) {
+	server := newTestServer(t, newFakeStore())
+
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user", createUserRequest{
+		Username: util.RandomString(6),
+		Password: util.RandomString(12),
+		Email:    "not-an-email",
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	store := newFakeStore()
+	server, mailbox := newTestServerWithMailbox(t, store)
+	user, session := loginTestUser(t, server)
+	require.Contains(t, mailbox.String(), "To: "+user.Email)
+
+	// Unverified users can read but not write under the readonly policy.
+	require.Equal(t, http.StatusOK, serveWithToken(t, server, session.AccessToken, http.MethodGet, "/account/graph/debit"))
+	require.Equal(t, http.StatusForbidden, serveWithToken(t, server, session.AccessToken, http.MethodPost, "/category"))
+
+	verifyToken := verifyTokenFromMailbox(t, mailbox)
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user/verify", verifyEmailRequest{Token: verifyToken})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	dbUser, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, dbUser.VerifiedAt.Valid)
+
+	// Verifying twice is harmless.
+	recorder = serveAs(t, server, 0, http.MethodPost, "/user/verify", verifyEmailRequest{Token: verifyToken})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// The refreshed access token carries the verified claim.
+	status, refreshed := refresh(t, server, session.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, http.StatusBadRequest, serveWithToken(t, server, refreshed.AccessToken, http.MethodPost, "/category"))
+
+	// No new link is sent once the email is verified.
+	mailbox.Reset()
+	recorder = serveAs(t, server, 0, http.MethodPost, "/user/verify/resend", resendVerificationRequest{Email: user.Email})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.Zero(t, mailbox.Len())
+}
+
+func TestVerifyEmailInvalidToken(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user, _ := loginTestUser(t, server)
+	dbUser, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	expired, err := server.tokens.CreatePurposeToken(token.PurposeEmailVerification, dbUser.ID, dbUser.Email, -time.Minute)
+	require.NoError(t, err)
+	staleEmail, err := server.tokens.CreatePurposeToken(token.PurposeEmailVerification, dbUser.ID, util.RandomEmail(8), time.Hour)
+	require.NoError(t, err)
+	accessToken := createTestToken(t, server, dbUser.ID)
+
+	for _, verifyToken := range []string{expired, staleEmail, accessToken, "garbage"} {
+		recorder := serveAs(t, server, 0, http.MethodPost, "/user/verify", verifyEmailRequest{Token: verifyToken})
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+
+	dbUser, err = store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, dbUser.VerifiedAt.Valid)
+}
+
+func TestResendVerification(t *testing.T) {
+	server, mailbox := newTestServerWithMailbox(t, newFakeStore())
+	user, _ := loginTestUser(t, server)
+
+	mailbox.Reset()
+	recorder := serveAs(t, server, 0, http.MethodPost, "/user/verify/resend", resendVerificationRequest{Email: user.Email})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	verifyToken := verifyTokenFromMailbox(t, mailbox)
+
+	recorder = serveAs(t, server, 0, http.MethodPost, "/user/verify", verifyEmailRequest{Token: verifyToken})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	mailbox.Reset()
+	recorder = serveAs(t, server, 0, http.MethodPost, "/user/verify/resend", resendVerificationRequest{Email: util.RandomEmail(8)})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.Zero(t, mailbox.Len())
+}
+
+func TestUnverifiedLoginPolicy(t *testing.T) {
+	testCases := []struct {
+		policy      string
+		loginStatus int
+		writeStatus int
+	}{
+		{util.UnverifiedLoginAllow, http.StatusOK, http.StatusBadRequest},
+		{util.UnverifiedLoginReadOnly, http.StatusOK, http.StatusForbidden},
+		{util.UnverifiedLoginDeny, http.StatusForbidden, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			server := newTestServer(t, newFakeStore())
+			server.config.UnverifiedLoginPolicy = tc.policy
+
+			user := createUserRequest{
+				Username: util.RandomString(6),
+				Password: util.RandomString(12),
+				Email:    util.RandomEmail(8),
+			}
+			require.Equal(t, http.StatusOK, serveAs(t, server, 0, http.MethodPost, "/user", user).Code)
+
+			recorder := serveAs(t, server, 0, http.MethodPost, "/login", loginRequest{
+				Username: user.Username,
+				Password: user.Password,
+			})
+			require.Equal(t, tc.loginStatus, recorder.Code)
+			if tc.loginStatus != http.StatusOK {
+				return
+			}
+
+			session := login(t, server, user.Username, user.Password)
+			require.Equal(t, tc.writeStatus, serveWithToken(t, server, session.AccessToken, http.MethodPost, "/category"))
+		})
+	}
+}
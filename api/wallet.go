@@ -0,0 +1,328 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+var (
+	errWalletInUse         = errors.New("wallet has transactions, recurring transactions or installment plans")
+	errWalletNotFound      = errors.New("wallet not found")
+	errInvalidDateRange    = errors.New("from must not be after to")
+	errWalletCurrencyFixed = errors.New("a wallet's currency cannot change")
+)
+
+type createWalletRequest struct {
+	Name           string       `json:"name" binding:"required"`
+	Type           string       `json:"type" binding:"required,oneof=checking savings cash credit_card investment"`
+	OpeningBalance *money.Money `json:"opening_balance" binding:"required"`
+}
+
+// createWallet adds a wallet to the ledger. Its currency is the currency
+// of the opening balance, and every transaction in it must use it.
+func (server *Server) createWallet(ctx *gin.Context) {
+	var req createWalletRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	member := ledgerMember(ctx)
+	wallet, err := server.store.CreateWallet(ctx, db.CreateWalletParams{
+		LedgerID:       member.LedgerID,
+		CreatedBy:      sql.NullInt32{Int32: member.UserID, Valid: true},
+		Name:           req.Name,
+		Type:           req.Type,
+		Currency:       req.OpeningBalance.Currency,
+		OpeningBalance: req.OpeningBalance.Amount,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWalletResponse(wallet, wallet.OpeningBalance))
+}
+
+// listWallets lists the ledger's wallets with their current balances.
+func (server *Server) listWallets(ctx *gin.Context) {
+	wallets, err := server.store.ListWallets(ctx, ledgerMember(ctx).LedgerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]walletResponse, len(wallets))
+	for i, wallet := range wallets {
+		rsp[i] = newWalletListItemResponse(wallet)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type walletURI struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// ledgerWallet loads the wallet named in the path from the request's
+// ledger, answering 404 for wallets of other ledgers.
+func (server *Server) ledgerWallet(ctx *gin.Context) (db.Wallet, bool) {
+	var uri walletURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Wallet{}, false
+	}
+
+	wallet, err := server.store.GetWallet(ctx, db.GetWalletParams{
+		ID:       uri.ID,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return db.Wallet{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Wallet{}, false
+	}
+	return wallet, true
+}
+
+// walletBalance returns the wallet's balance after all its transactions or,
+// with a valid until, after those up to and including that day.
+func (server *Server) walletBalance(ctx *gin.Context, wallet db.Wallet, until sql.NullTime) (int64, error) {
+	total, err := server.store.GetWalletTransactionsTotal(ctx, db.GetWalletTransactionsTotalParams{
+		WalletID: wallet.ID,
+		Date:     until,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	balance, err := money.New(wallet.OpeningBalance, wallet.Currency).Add(money.New(total, wallet.Currency))
+	return balance.Amount, err
+}
+
+func (server *Server) getWallet(ctx *gin.Context) {
+	wallet, ok := server.ledgerWallet(ctx)
+	if !ok {
+		return
+	}
+
+	balance, err := server.walletBalance(ctx, wallet, sql.NullTime{})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWalletResponse(wallet, balance))
+}
+
+type updateWalletRequest struct {
+	Name           string       `json:"name" binding:"required"`
+	Type           string       `json:"type" binding:"required,oneof=checking savings cash credit_card investment"`
+	OpeningBalance *money.Money `json:"opening_balance" binding:"required"`
+}
+
+// updateWallet renames a wallet, changes its type or corrects its opening
+// balance, which shifts every balance of the wallet by the difference.
+func (server *Server) updateWallet(ctx *gin.Context) {
+	wallet, ok := server.ledgerWallet(ctx)
+	if !ok {
+		return
+	}
+
+	var req updateWalletRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.OpeningBalance.Currency != wallet.Currency {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errWalletCurrencyFixed))
+		return
+	}
+
+	wallet, err = server.store.UpdateWallet(ctx, db.UpdateWalletParams{
+		ID:             wallet.ID,
+		Name:           req.Name,
+		Type:           req.Type,
+		OpeningBalance: req.OpeningBalance.Amount,
+		LedgerID:       wallet.LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	balance, err := server.walletBalance(ctx, wallet, sql.NullTime{})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWalletResponse(wallet, balance))
+}
+
+// deleteWallet deletes a wallet without transactions; wallets still
+// holding some, or used by a recurring transaction or installment plan,
+// answer 409.
+func (server *Server) deleteWallet(ctx *gin.Context) {
+	wallet, ok := server.ledgerWallet(ctx)
+	if !ok {
+		return
+	}
+
+	rows, err := server.store.DeleteWallet(ctx, db.DeleteWalletParams{
+		ID:       wallet.ID,
+		LedgerID: wallet.LedgerID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusConflict, errorResponse(errWalletInUse))
+		return
+	}
+
+	server.recordOwnDeletion(ctx, auditEventWalletDeleted, fmt.Sprintf("wallet %d in ledger %d", wallet.ID, wallet.LedgerID))
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type getWalletBalanceRequest struct {
+	Date time.Time `form:"date" time_format:"2006-01-02"`
+}
+
+// getWalletBalance answers the wallet's balance at the end of a day, or
+// after all its transactions without one.
+func (server *Server) getWalletBalance(ctx *gin.Context) {
+	wallet, ok := server.ledgerWallet(ctx)
+	if !ok {
+		return
+	}
+
+	var req getWalletBalanceRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	until := sql.NullTime{Time: req.Date, Valid: !req.Date.IsZero()}
+	balance, err := server.walletBalance(ctx, wallet, until)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := walletBalanceResponse{
+		WalletID: wallet.ID,
+		Balance:  money.New(balance, wallet.Currency),
+	}
+	if until.Valid {
+		date := until.Time.Format(dateLayout)
+		rsp.Date = &date
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getWalletBalancesRequest struct {
+	From time.Time `form:"from" binding:"required" time_format:"2006-01-02"`
+	To   time.Time `form:"to" binding:"required" time_format:"2006-01-02"`
+}
+
+// getWalletBalances returns the balance history of a wallet between two
+// days: the balance before the first one and the balance at the end of
+// every day in between with transactions.
+func (server *Server) getWalletBalances(ctx *gin.Context) {
+	wallet, ok := server.ledgerWallet(ctx)
+	if !ok {
+		return
+	}
+
+	var req getWalletBalancesRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.From.After(req.To) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidDateRange))
+		return
+	}
+
+	opening, err := server.walletBalance(ctx, wallet, sql.NullTime{Time: req.From.AddDate(0, 0, -1), Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	days, err := server.store.ListWalletDailyTotals(ctx, db.ListWalletDailyTotalsParams{
+		WalletID: wallet.ID,
+		FromDate: req.From,
+		ToDate:   req.To,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	balance := money.New(opening, wallet.Currency)
+	rsp := walletBalancesResponse{
+		WalletID:       wallet.ID,
+		OpeningBalance: balance,
+		Days:           make([]walletDayResponse, len(days)),
+	}
+	for i, day := range days {
+		change := money.New(day.Total, wallet.Currency)
+		balance, err = balance.Add(change)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		rsp.Days[i] = walletDayResponse{
+			Date:    day.Date.Format(dateLayout),
+			Change:  change,
+			Balance: balance,
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// transactionWallet loads the wallet a transaction is recorded in and
+// checks that the amount is in the wallet's currency.
+func (server *Server) transactionWallet(ctx *gin.Context, walletID int32, amount money.Money) (db.Wallet, bool) {
+	wallet, err := server.store.GetWallet(ctx, db.GetWalletParams{
+		ID:       walletID,
+		LedgerID: ledgerMember(ctx).LedgerID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return db.Wallet{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Wallet{}, false
+	}
+
+	if wallet.Currency != amount.Currency {
+		err = fmt.Errorf("%w: wallet %d is in %s", money.ErrCurrencyMismatch, wallet.ID, wallet.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Wallet{}, false
+	}
+	return wallet, true
+}
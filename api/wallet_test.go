@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/money"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createTestWallet(t *testing.T, store *fakeStore, ledgerID int32, currency string) db.Wallet {
+	wallet, err := store.CreateWallet(context.Background(), db.CreateWalletParams{
+		LedgerID: ledgerID,
+		Name:     util.RandomString(8),
+		Type:     "checking",
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return wallet
+}
+
+func TestCreateAndListWallets(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	createTestCategory(t, store, user.ID)
+
+	recorder := serveAs(t, server, user.ID, http.MethodPost, "/wallets", createWalletRequest{
+		Name:           "Card",
+		Type:           "credit_card",
+		OpeningBalance: &money.Money{Amount: -150000, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var card walletResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &card))
+	require.Equal(t, "credit_card", card.Type)
+	require.Equal(t, money.New(-150000, "BRL"), card.Balance)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPost, "/wallets", createWalletRequest{
+		Name:           "Brokerage",
+		Type:           "investment",
+		OpeningBalance: &money.Money{Amount: 1000, Currency: "USD"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, req := range []interface{}{
+		createWalletRequest{Name: "Mattress", Type: "mattress", OpeningBalance: &money.Money{Currency: "BRL"}},
+		createWalletRequest{Name: "Cash", Type: "cash"},
+		map[string]interface{}{"name": "Cash", "type": "cash", "opening_balance": map[string]string{"amount": "1", "currency": "XYZ"}},
+	} {
+		recorder = serveAs(t, server, user.ID, http.MethodPost, "/wallets", req)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, req)
+	}
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, "/wallets", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var wallets []walletResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &wallets))
+	require.Len(t, wallets, 2)
+	require.Equal(t, "Brokerage", wallets[0].Name)
+	require.Equal(t, money.New(1000, "USD"), wallets[0].Balance)
+	require.Equal(t, card.ID, wallets[1].ID)
+}
+
+func TestWalletBalances(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	expenses := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, expenses.LedgerID, "BRL")
+
+	income := createTestCategory(t, store, user.ID)
+	income.Type = "credit"
+	store.categories[income.ID] = income
+
+	createTransaction := func(category db.Category, amount, date string) accountResponse {
+		recorder := serveAs(t, server, user.ID, http.MethodPost, "/account", map[string]interface{}{
+			"category_id": category.ID,
+			"wallet_id":   wallet.ID,
+			"title":       util.RandomString(8),
+			"type":        category.Type,
+			"description": util.RandomString(12),
+			"amount":      map[string]string{"amount": amount, "currency": "BRL"},
+			"date":        date + "T00:00:00Z",
+		})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var account accountResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+		return account
+	}
+
+	recorder := serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/wallets/%d", wallet.ID), updateWalletRequest{
+		Name:           wallet.Name,
+		Type:           wallet.Type,
+		OpeningBalance: &money.Money{Amount: 10000, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	createTransaction(expenses, "30.00", "2024-03-01")
+	rent := createTransaction(expenses, "50.00", "2024-03-03")
+	createTransaction(income, "20.00", "2024-03-03")
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, fmt.Sprintf("/wallets/%d", wallet.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"amount":"40.00","currency":"BRL"}`, string(jsonField(t, recorder.Body.Bytes(), "balance")))
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, fmt.Sprintf("/wallets/%d/balance?date=2024-03-02", wallet.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, fmt.Sprintf(`{"wallet_id":%d,"date":"2024-03-02","balance":{"amount":"70.00","currency":"BRL"}}`, wallet.ID),
+		recorder.Body.String())
+
+	balancesURL := fmt.Sprintf("/wallets/%d/balances?from=2024-03-02&to=2024-03-31", wallet.ID)
+	recorder = serveAs(t, server, user.ID, http.MethodGet, balancesURL, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, fmt.Sprintf(`{
+		"wallet_id": %d,
+		"opening_balance": {"amount":"70.00","currency":"BRL"},
+		"days": [
+			{"date":"2024-03-03","change":{"amount":"-30.00","currency":"BRL"},"balance":{"amount":"40.00","currency":"BRL"}}
+		]
+	}`, wallet.ID), recorder.Body.String())
+
+	// Editing and deleting transactions moves the balances with them.
+	recorder = serveAs(t, server, user.ID, http.MethodPut, fmt.Sprintf("/account/%d", rent.ID), updateAccountRequest{
+		ID:     rent.ID,
+		Amount: &money.Money{Amount: 6000, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodGet, fmt.Sprintf("/wallets/%d", wallet.ID), nil)
+	require.JSONEq(t, `{"amount":"30.00","currency":"BRL"}`, string(jsonField(t, recorder.Body.Bytes(), "balance")))
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, fmt.Sprintf("/account/%d", rent.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = serveAs(t, server, user.ID, http.MethodGet, balancesURL, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"amount":"70.00","currency":"BRL"}`, string(jsonField(t, recorder.Body.Bytes(), "opening_balance")))
+	require.Contains(t, recorder.Body.String(), `"change":{"amount":"20.00","currency":"BRL"}`)
+
+	for _, url := range []string{
+		fmt.Sprintf("/wallets/%d/balance?date=03/02/2024", wallet.ID),
+		fmt.Sprintf("/wallets/%d/balances?from=2024-03-31&to=2024-03-01", wallet.ID),
+		fmt.Sprintf("/wallets/%d/balances?from=2024-03-01", wallet.ID),
+	} {
+		recorder = serveAs(t, server, user.ID, http.MethodGet, url, nil)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, url)
+	}
+}
+
+func TestUpdateAndDeleteWallet(t *testing.T) {
+	store := newFakeStore()
+	server := newTestServer(t, store)
+	user := createTestLedgerUser(t, store)
+	category := createTestCategory(t, store, user.ID)
+	wallet := createTestWallet(t, store, category.LedgerID, "BRL")
+	url := fmt.Sprintf("/wallets/%d", wallet.ID)
+
+	recorder := serveAs(t, server, user.ID, http.MethodPut, url, updateWalletRequest{
+		Name:           "Savings",
+		Type:           "savings",
+		OpeningBalance: &money.Money{Amount: 100, Currency: "USD"},
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveAs(t, server, user.ID, http.MethodPut, url, updateWalletRequest{
+		Name:           "Savings",
+		Type:           "savings",
+		OpeningBalance: &money.Money{Amount: 100, Currency: "BRL"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Savings", store.wallets[wallet.ID].Name)
+
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		LedgerID:   category.LedgerID,
+		CategoryID: sql.NullInt32{Int32: category.ID, Valid: true},
+		Type:       category.Type,
+		Amount:     100,
+		Currency:   "BRL",
+		WalletID:   wallet.ID,
+	})
+	require.NoError(t, err)
+
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	delete(store.accounts, account.ID)
+	recurring := createTestRecurring(t, server, user.ID, createRecurringRequest{
+		CategoryID: category.ID,
+		WalletID:   wallet.ID,
+		Title:      "Rent",
+		Amount:     &money.Money{Amount: 100, Currency: "BRL"},
+		StartsOn:   "2024-01-01",
+		recurringSchedule: recurringSchedule{
+			Rule: "freq=monthly",
+		},
+	})
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	delete(store.recurring, recurring.ID)
+	recorder = serveAs(t, server, user.ID, http.MethodDelete, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, store.wallets, wallet.ID)
+	require.Len(t, store.eventsOfType(auditEventWalletDeleted), 1)
+
+	recorder = serveAs(t, server, user.ID, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,53 @@
+// Command checkjournal verifies that the whole journal balances: every
+// entry and every ledger sums to zero in each currency, every transaction
+// is journaled, and every wallet's postings agree with its transactions.
+// It prints what it finds and exits non-zero when the books are off.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/lib/pq"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func main() {
+	config, err := util.LoadConfig(".env")
+	if err != nil {
+		log.Fatal("cannot load config: ", err)
+	}
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		log.Fatal("cannot connect to db: ", err)
+	}
+
+	verification, err := db.NewStore(conn).VerifyJournal(context.Background())
+	if err != nil {
+		log.Fatal("cannot verify journal: ", err)
+	}
+
+	for _, entry := range verification.UnbalancedEntries {
+		fmt.Printf("entry %d in ledger %d is off by %d %s\n", entry.EntryID, entry.LedgerID, entry.Total, entry.Currency)
+	}
+	for _, ledger := range verification.UnbalancedLedgers {
+		fmt.Printf("ledger %d is off by %d %s\n", ledger.LedgerID, ledger.Total, ledger.Currency)
+	}
+	for _, account := range verification.UnjournaledAccounts {
+		fmt.Printf("transaction %d in ledger %d has no journal entry\n", account.ID, account.LedgerID)
+	}
+	for _, wallet := range verification.WalletMismatches {
+		fmt.Printf("wallet %d in ledger %d: journal says %d, transactions say %d\n",
+			wallet.WalletID, wallet.LedgerID, wallet.JournalTotal, wallet.TransactionsTotal)
+	}
+
+	if !verification.Balanced() {
+		os.Exit(1)
+	}
+	fmt.Println("journal balances")
+}
@@ -0,0 +1,14 @@
+CREATE TABLE "sessions" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" int NOT NULL,
+    "refresh_token_hash" varchar NOT NULL,
+    "user_agent" varchar NOT NULL,
+    "client_ip" varchar NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "sessions" ("user_id");
@@ -0,0 +1,10 @@
+CREATE TABLE "password_reset_tokens" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" int NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
@@ -0,0 +1,4 @@
+ALTER TABLE "users" ADD COLUMN "verified_at" timestamptz;
+
+-- Accounts created before verification existed are trusted as they are.
+UPDATE "users" SET "verified_at" = "created_at";
@@ -0,0 +1,21 @@
+CREATE TABLE "mfa_totp" (
+    "user_id" int PRIMARY KEY NOT NULL,
+    "secret" varchar NOT NULL,
+    "confirmed_at" timestamptz,
+    "last_used_step" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "mfa_recovery_codes" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" int NOT NULL,
+    "code_hash" varchar NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "mfa_totp" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "mfa_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE UNIQUE INDEX ON "mfa_recovery_codes" ("user_id", "code_hash");
@@ -0,0 +1,20 @@
+CREATE TABLE "login_throttles" (
+    "key" varchar PRIMARY KEY NOT NULL,
+    "failures" int NOT NULL DEFAULT 0,
+    "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+    "locked_until" timestamptz
+);
+
+CREATE TABLE "security_events" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" int,
+    "type" varchar NOT NULL,
+    "username" varchar NOT NULL,
+    "client_ip" varchar NOT NULL,
+    "details" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "security_events" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "security_events" ("user_id");
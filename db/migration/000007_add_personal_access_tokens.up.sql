@@ -0,0 +1,15 @@
+CREATE TABLE "personal_access_tokens" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" int NOT NULL,
+    "name" varchar NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "scopes" varchar[] NOT NULL,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "personal_access_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "personal_access_tokens" ("user_id");
@@ -0,0 +1,27 @@
+CREATE TABLE "user_identities" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" int NOT NULL,
+    "provider" varchar NOT NULL,
+    "subject" varchar NOT NULL,
+    "email" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "oidc_auth_requests" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "state_hash" varchar UNIQUE NOT NULL,
+    "provider" varchar NOT NULL,
+    "nonce" varchar NOT NULL,
+    "code_verifier" varchar NOT NULL,
+    "link_user_id" int,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "oidc_auth_requests" ADD FOREIGN KEY ("link_user_id") REFERENCES "users" ("id");
+
+CREATE UNIQUE INDEX ON "user_identities" ("provider", "subject");
+
+CREATE UNIQUE INDEX ON "user_identities" ("user_id", "provider");
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'user';
+ALTER TABLE "users" ADD COLUMN "disabled_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN "password_reset_required" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('user', 'admin'));
@@ -0,0 +1,33 @@
+ALTER TABLE "oidc_auth_requests" DROP CONSTRAINT "oidc_auth_requests_link_user_id_fkey";
+ALTER TABLE "oidc_auth_requests" ADD FOREIGN KEY ("link_user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "user_identities" DROP CONSTRAINT "user_identities_user_id_fkey";
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "personal_access_tokens" DROP CONSTRAINT "personal_access_tokens_user_id_fkey";
+ALTER TABLE "personal_access_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "security_events" DROP CONSTRAINT "security_events_user_id_fkey";
+ALTER TABLE "security_events" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "mfa_recovery_codes" DROP CONSTRAINT "mfa_recovery_codes_user_id_fkey";
+ALTER TABLE "mfa_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "mfa_totp" DROP CONSTRAINT "mfa_totp_user_id_fkey";
+ALTER TABLE "mfa_totp" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "password_reset_tokens" DROP CONSTRAINT "password_reset_tokens_user_id_fkey";
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "sessions" DROP CONSTRAINT "sessions_user_id_fkey";
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_user_id_fkey";
+ALTER TABLE "accounts" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "categories" DROP CONSTRAINT "categories_user_id_fkey";
+ALTER TABLE "categories" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pending_email";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "display_name";
@@ -0,0 +1,36 @@
+-- audit_events replaces security_events, which is kept for the history
+-- recorded before the audit log existed. Entries are hash-chained: hash
+-- covers the entry and prev_hash, the hash of the entry before it. There
+-- is no foreign key to users so purging an account leaves the chain intact.
+CREATE TABLE "audit_events" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" int,
+    "username" varchar NOT NULL,
+    "actor_id" int,
+    "actor" varchar NOT NULL,
+    "type" varchar NOT NULL,
+    "outcome" varchar NOT NULL,
+    "client_ip" varchar NOT NULL,
+    "user_agent" varchar NOT NULL,
+    "details" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    "prev_hash" varchar NOT NULL,
+    "hash" varchar UNIQUE NOT NULL,
+    CONSTRAINT "audit_events_outcome_check" CHECK ("outcome" IN ('success', 'failure'))
+);
+
+CREATE INDEX ON "audit_events" ("user_id");
+
+CREATE INDEX ON "audit_events" ("actor_id");
+
+CREATE FUNCTION "audit_events_append_only"() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_no_update" BEFORE UPDATE OR DELETE ON "audit_events"
+    FOR EACH ROW EXECUTE FUNCTION "audit_events_append_only"();
+
+CREATE TRIGGER "audit_events_no_truncate" BEFORE TRUNCATE ON "audit_events"
+    FOR EACH STATEMENT EXECUTE FUNCTION "audit_events_append_only"();
@@ -0,0 +1,20 @@
+-- Every row goes back to the owner of its ledger, including rows other
+-- members created.
+UPDATE "accounts" a SET "created_by" = l."owner_id" FROM "ledgers" l WHERE l."id" = a."ledger_id";
+UPDATE "categories" c SET "created_by" = l."owner_id" FROM "ledgers" l WHERE l."id" = c."ledger_id";
+
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_created_by_fkey";
+ALTER TABLE "accounts" RENAME COLUMN "created_by" TO "user_id";
+ALTER TABLE "accounts" ALTER COLUMN "user_id" SET NOT NULL;
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "ledger_id";
+
+ALTER TABLE "categories" DROP CONSTRAINT "categories_created_by_fkey";
+ALTER TABLE "categories" RENAME COLUMN "created_by" TO "user_id";
+ALTER TABLE "categories" ALTER COLUMN "user_id" SET NOT NULL;
+ALTER TABLE "categories" ADD CONSTRAINT "categories_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "ledger_id";
+
+DROP TABLE IF EXISTS "ledger_invitations";
+DROP TABLE IF EXISTS "ledger_members";
+DROP TABLE IF EXISTS "ledgers";
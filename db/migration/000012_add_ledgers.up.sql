@@ -0,0 +1,75 @@
+CREATE TABLE "ledgers" (
+    "id" serial PRIMARY KEY NOT NULL,
+    "name" varchar NOT NULL,
+    "owner_id" int NOT NULL,
+    "personal" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Purging the owner removes the ledger with everything in it.
+ALTER TABLE "ledgers" ADD FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Every user has at most one personal ledger, the default one.
+CREATE UNIQUE INDEX ON "ledgers" ("owner_id") WHERE "personal";
+
+CREATE TABLE "ledger_members" (
+    "ledger_id" int NOT NULL,
+    "user_id" int NOT NULL,
+    "role" varchar NOT NULL CHECK ("role" IN ('owner', 'editor', 'viewer')),
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("ledger_id", "user_id")
+);
+
+ALTER TABLE "ledger_members" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "ledger_members" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "ledger_members" ("user_id");
+CREATE UNIQUE INDEX ON "ledger_members" ("ledger_id") WHERE "role" = 'owner';
+
+CREATE TABLE "ledger_invitations" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "email" varchar NOT NULL,
+    "role" varchar NOT NULL CHECK ("role" IN ('editor', 'viewer')),
+    "token_hash" varchar UNIQUE NOT NULL,
+    "invited_by" int NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "accepted_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "ledger_invitations" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "ledger_invitations" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "ledger_invitations" ("ledger_id");
+
+-- Existing data moves to a personal ledger per user.
+INSERT INTO "ledgers" ("name", "owner_id", "personal")
+SELECT 'Personal', "id", true FROM "users";
+
+INSERT INTO "ledger_members" ("ledger_id", "user_id", "role")
+SELECT "id", "owner_id", 'owner' FROM "ledgers";
+
+-- Categories and accounts belong to a ledger; user_id becomes the member
+-- who created the row and survives that member leaving or being purged.
+ALTER TABLE "categories" ADD COLUMN "ledger_id" int;
+UPDATE "categories" c SET "ledger_id" = l."id" FROM "ledgers" l WHERE l."owner_id" = c."user_id" AND l."personal";
+ALTER TABLE "categories" ALTER COLUMN "ledger_id" SET NOT NULL;
+ALTER TABLE "categories" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "categories" RENAME COLUMN "user_id" TO "created_by";
+ALTER TABLE "categories" ALTER COLUMN "created_by" DROP NOT NULL;
+ALTER TABLE "categories" DROP CONSTRAINT "categories_user_id_fkey";
+ALTER TABLE "categories" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "categories" ("ledger_id");
+
+ALTER TABLE "accounts" ADD COLUMN "ledger_id" int;
+UPDATE "accounts" a SET "ledger_id" = l."id" FROM "ledgers" l WHERE l."owner_id" = a."user_id" AND l."personal";
+ALTER TABLE "accounts" ALTER COLUMN "ledger_id" SET NOT NULL;
+ALTER TABLE "accounts" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "accounts" RENAME COLUMN "user_id" TO "created_by";
+ALTER TABLE "accounts" ALTER COLUMN "created_by" DROP NOT NULL;
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_user_id_fkey";
+ALTER TABLE "accounts" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "accounts" ("ledger_id");
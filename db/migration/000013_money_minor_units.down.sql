@@ -0,0 +1,5 @@
+-- Drops the currency and any fraction of a unit.
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "currency";
+
+ALTER TABLE "accounts" ALTER COLUMN "amount" TYPE integer USING ("amount" / 100)::integer;
+ALTER TABLE "accounts" RENAME COLUMN "amount" TO "value";
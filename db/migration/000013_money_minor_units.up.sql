@@ -0,0 +1,7 @@
+-- Amounts are stored in minor units (cents for BRL) of the currency next
+-- to them. Values so far were whole reais.
+ALTER TABLE "accounts" RENAME COLUMN "value" TO "amount";
+ALTER TABLE "accounts" ALTER COLUMN "amount" TYPE bigint USING "amount"::bigint * 100;
+
+ALTER TABLE "accounts" ADD COLUMN "currency" char(3) NOT NULL DEFAULT 'BRL' CHECK ("currency" ~ '^[A-Z]{3}$');
+ALTER TABLE "accounts" ALTER COLUMN "currency" DROP DEFAULT;
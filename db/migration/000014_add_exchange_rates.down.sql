@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "exchange_rates";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "base_currency";
@@ -0,0 +1,16 @@
+-- Reports can convert amounts to the currency the user thinks in.
+ALTER TABLE "users" ADD COLUMN "base_currency" char(3) NOT NULL DEFAULT 'BRL' CHECK ("base_currency" ~ '^[A-Z]{3}$');
+
+-- One unit of from_currency was worth rate units of to_currency on date.
+CREATE TABLE "exchange_rates" (
+    "from_currency" char(3) NOT NULL,
+    "to_currency" char(3) NOT NULL,
+    "date" date NOT NULL,
+    "rate" numeric(20, 10) NOT NULL CHECK ("rate" > 0),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("from_currency", "to_currency", "date"),
+    CHECK ("from_currency" <> "to_currency")
+);
+
+-- Looking a pair up the other way round.
+CREATE INDEX ON "exchange_rates" ("to_currency", "from_currency", "date");
@@ -0,0 +1,3 @@
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "wallet_id";
+
+DROP TABLE IF EXISTS "wallets";
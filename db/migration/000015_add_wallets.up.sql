@@ -0,0 +1,34 @@
+-- Where the money lives. The balance of a wallet is its opening balance
+-- plus its credit transactions minus its debit ones; it is never stored,
+-- so editing or deleting a transaction cannot leave it stale.
+CREATE TABLE "wallets" (
+    "id" serial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "created_by" int,
+    "name" varchar NOT NULL,
+    "type" varchar NOT NULL CHECK ("type" IN ('checking', 'savings', 'cash', 'credit_card', 'investment')),
+    "currency" char(3) NOT NULL CHECK ("currency" ~ '^[A-Z]{3}$'),
+    "opening_balance" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    UNIQUE ("id", "ledger_id", "currency")
+);
+
+ALTER TABLE "wallets" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "wallets" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "wallets" ("ledger_id");
+
+-- Existing transactions move to one wallet per ledger and currency.
+INSERT INTO "wallets" ("ledger_id", "name", "type", "currency")
+SELECT DISTINCT "ledger_id", 'Main ' || "currency", 'checking', "currency" FROM "accounts";
+
+ALTER TABLE "accounts" ADD COLUMN "wallet_id" int;
+UPDATE "accounts" a SET "wallet_id" = w."id" FROM "wallets" w
+WHERE w."ledger_id" = a."ledger_id" AND w."currency" = a."currency";
+ALTER TABLE "accounts" ALTER COLUMN "wallet_id" SET NOT NULL;
+
+-- A transaction is in the ledger and currency of its wallet, and a wallet
+-- with transactions cannot be deleted.
+ALTER TABLE "accounts" ADD FOREIGN KEY ("wallet_id", "ledger_id", "currency") REFERENCES "wallets" ("id", "ledger_id", "currency");
+
+CREATE INDEX ON "accounts" ("wallet_id", "date");
@@ -0,0 +1,7 @@
+DELETE FROM "accounts" WHERE "transfer_id" IS NOT NULL;
+
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "accounts_category_or_transfer";
+ALTER TABLE "accounts" ALTER COLUMN "category_id" SET NOT NULL;
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "transfer_id";
+
+DROP TABLE IF EXISTS "transfers";
@@ -0,0 +1,25 @@
+-- Money moved between two wallets of a ledger. A transfer is recorded as
+-- two linked transactions: a debit leg in the wallet it leaves and a
+-- credit leg in the wallet it reaches. The legs move wallet balances but
+-- are neither income nor expense, so reports leave them out.
+CREATE TABLE "transfers" (
+    "id" serial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "created_by" int,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "transfers" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "transfers" ("ledger_id");
+
+-- Deleting a transfer deletes both of its legs. Legs have no category;
+-- every other transaction keeps needing one.
+ALTER TABLE "accounts" ADD COLUMN "transfer_id" int;
+ALTER TABLE "accounts" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id") ON DELETE CASCADE;
+ALTER TABLE "accounts" ALTER COLUMN "category_id" DROP NOT NULL;
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_category_or_transfer"
+    CHECK (("category_id" IS NULL) = ("transfer_id" IS NOT NULL));
+
+CREATE INDEX ON "accounts" ("transfer_id");
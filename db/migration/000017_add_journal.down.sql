@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "postings";
+DROP TABLE IF EXISTS "journal_entries";
+DROP FUNCTION IF EXISTS "journal_entry_balanced"();
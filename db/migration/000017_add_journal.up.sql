@@ -0,0 +1,98 @@
+-- The double-entry journal underneath transactions and transfers. Each
+-- transaction and each transfer has one journal entry whose postings sum
+-- to zero in every currency. Postings are signed: a positive amount is a
+-- debit, money reaching a wallet or spent in an expense category; a
+-- negative one is a credit, money leaving a wallet or earned in an income
+-- category. A posting with neither wallet nor category is the currency
+-- exchange of a transfer between wallets in different currencies.
+--
+-- Wallet opening balances stay on the wallet and are not journaled.
+CREATE TABLE "journal_entries" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "account_id" int UNIQUE,
+    "transfer_id" int UNIQUE,
+    "date" date NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "journal_entries_source" CHECK (("account_id" IS NULL) <> ("transfer_id" IS NULL))
+);
+
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "journal_entries" ("ledger_id");
+
+CREATE TABLE "postings" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "entry_id" bigint NOT NULL,
+    "wallet_id" int,
+    "category_id" int,
+    "amount" bigint NOT NULL,
+    "currency" char(3) NOT NULL CHECK ("currency" ~ '^[A-Z]{3}$'),
+    CONSTRAINT "postings_one_side" CHECK ("wallet_id" IS NULL OR "category_id" IS NULL)
+);
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("entry_id") REFERENCES "journal_entries" ("id") ON DELETE CASCADE;
+ALTER TABLE "postings" ADD FOREIGN KEY ("wallet_id") REFERENCES "wallets" ("id");
+ALTER TABLE "postings" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id");
+
+CREATE INDEX ON "postings" ("entry_id");
+CREATE INDEX ON "postings" ("wallet_id");
+
+-- Journal what is already recorded. Transactions of types other than
+-- credit and debit never moved a balance and get entries without
+-- postings.
+INSERT INTO "journal_entries" ("ledger_id", "account_id", "date")
+SELECT "ledger_id", "id", "date" FROM "accounts" WHERE "transfer_id" IS NULL;
+
+INSERT INTO "postings" ("entry_id", "wallet_id", "amount", "currency")
+SELECT e."id", a."wallet_id", CASE a."type" WHEN 'credit' THEN a."amount" ELSE -a."amount" END, a."currency"
+FROM "accounts" a JOIN "journal_entries" e ON e."account_id" = a."id"
+WHERE a."type" IN ('credit', 'debit');
+
+INSERT INTO "postings" ("entry_id", "category_id", "amount", "currency")
+SELECT e."id", a."category_id", CASE a."type" WHEN 'credit' THEN -a."amount" ELSE a."amount" END, a."currency"
+FROM "accounts" a JOIN "journal_entries" e ON e."account_id" = a."id"
+WHERE a."type" IN ('credit', 'debit');
+
+INSERT INTO "journal_entries" ("ledger_id", "transfer_id", "date")
+SELECT t."ledger_id", t."id", (SELECT MIN(a."date") FROM "accounts" a WHERE a."transfer_id" = t."id")
+FROM "transfers" t;
+
+INSERT INTO "postings" ("entry_id", "wallet_id", "amount", "currency")
+SELECT e."id", a."wallet_id", CASE a."type" WHEN 'credit' THEN a."amount" ELSE -a."amount" END, a."currency"
+FROM "accounts" a JOIN "journal_entries" e ON e."transfer_id" = a."transfer_id";
+
+INSERT INTO "postings" ("entry_id", "amount", "currency")
+SELECT e."id", CASE a."type" WHEN 'credit' THEN -a."amount" ELSE a."amount" END, a."currency"
+FROM "accounts" a JOIN "journal_entries" e ON e."transfer_id" = a."transfer_id"
+WHERE EXISTS (
+    SELECT 1 FROM "accounts" b WHERE b."transfer_id" = a."transfer_id" AND b."currency" <> a."currency"
+);
+
+-- The books balance: at commit, every journal entry a transaction
+-- touched must sum to zero in each currency.
+CREATE FUNCTION "journal_entry_balanced"() RETURNS trigger AS $$
+DECLARE
+    entry bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        entry := OLD."entry_id";
+    ELSE
+        entry := NEW."entry_id";
+    END IF;
+
+    IF EXISTS (
+        SELECT 1 FROM "postings" WHERE "entry_id" = entry
+        GROUP BY "currency" HAVING SUM("amount") <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % does not balance', entry;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "postings_balanced" AFTER INSERT OR UPDATE OR DELETE ON "postings"
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION "journal_entry_balanced"();
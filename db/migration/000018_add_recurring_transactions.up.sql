@@ -0,0 +1,51 @@
+-- Templates for transactions that repeat, such as rent or a salary. rule
+-- is an RRULE like FREQ=MONTHLY;BYMONTHDAY=5, starting on starts_on and
+-- ending after ends_on or after occurrence_count occurrences. A worker
+-- turns occurrences into transactions as they come due; generated_through
+-- is the last day it has done so for.
+CREATE TABLE "recurring_transactions" (
+    "id" serial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "created_by" int,
+    "category_id" int NOT NULL,
+    "wallet_id" int NOT NULL,
+    "title" varchar NOT NULL,
+    "type" varchar NOT NULL,
+    "description" varchar NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" char(3) NOT NULL,
+    "rule" varchar NOT NULL,
+    "starts_on" date NOT NULL,
+    "ends_on" date,
+    "occurrence_count" int CHECK ("occurrence_count" > 0),
+    "generated_through" date,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("ends_on" >= "starts_on")
+);
+
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("wallet_id", "ledger_id", "currency") REFERENCES "wallets" ("id", "ledger_id", "currency") ON DELETE CASCADE;
+
+CREATE INDEX ON "recurring_transactions" ("ledger_id");
+
+-- Single occurrences that were skipped, changed ahead of time or already
+-- generated. title, description and amount override the template's when
+-- set. generated_at marks an occurrence the worker has turned into a
+-- transaction, so it never does so twice, even after that transaction is
+-- deleted.
+CREATE TABLE "recurring_occurrences" (
+    "recurring_id" int NOT NULL,
+    "date" date NOT NULL,
+    "skipped" boolean NOT NULL DEFAULT false,
+    "title" varchar,
+    "description" varchar,
+    "amount" bigint,
+    "account_id" int,
+    "generated_at" timestamptz,
+    PRIMARY KEY ("recurring_id", "date")
+);
+
+ALTER TABLE "recurring_occurrences" ADD FOREIGN KEY ("recurring_id") REFERENCES "recurring_transactions" ("id") ON DELETE CASCADE;
+ALTER TABLE "recurring_occurrences" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE SET NULL;
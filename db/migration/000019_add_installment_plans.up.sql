@@ -0,0 +1,41 @@
+-- A purchase paid in installments ("parcelado"). total_amount is split
+-- into installments transactions, one a month from first_date; the cent
+-- left over by the split goes to the first or the last of them. Editing
+-- or cancelling a plan only touches installments dated after the day it
+-- happens, which are still to come.
+CREATE TABLE "installment_plans" (
+    "id" serial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "created_by" int,
+    "category_id" int NOT NULL,
+    "wallet_id" int NOT NULL,
+    "title" varchar NOT NULL,
+    "type" varchar NOT NULL,
+    "description" varchar NOT NULL,
+    "total_amount" bigint NOT NULL CHECK ("total_amount" > 0),
+    "currency" char(3) NOT NULL,
+    "installments" int NOT NULL CHECK ("installments" > 0),
+    "first_date" date NOT NULL,
+    "remainder" varchar NOT NULL CHECK ("remainder" IN ('first', 'last')),
+    "cancelled_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("wallet_id", "ledger_id", "currency") REFERENCES "wallets" ("id", "ledger_id", "currency") ON DELETE CASCADE;
+
+CREATE INDEX ON "installment_plans" ("ledger_id");
+
+-- The transaction recording each installment of a plan. Deleting the
+-- transaction takes the installment out of the plan.
+CREATE TABLE "installments" (
+    "plan_id" int NOT NULL,
+    "number" int NOT NULL CHECK ("number" > 0),
+    "account_id" int UNIQUE NOT NULL,
+    PRIMARY KEY ("plan_id", "number")
+);
+
+ALTER TABLE "installments" ADD FOREIGN KEY ("plan_id") REFERENCES "installment_plans" ("id") ON DELETE CASCADE;
+ALTER TABLE "installments" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
@@ -0,0 +1,19 @@
+-- How much a category may take in a month. month is the first day of
+-- the month. With rollover, what is left of the budget at the end of the
+-- month is added to the budget of the next one.
+CREATE TABLE "budgets" (
+    "id" serial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "category_id" int NOT NULL,
+    "month" date NOT NULL CHECK (EXTRACT(DAY FROM "month") = 1),
+    "amount" bigint NOT NULL CHECK ("amount" >= 0),
+    "currency" char(3) NOT NULL CHECK ("currency" ~ '^[A-Z]{3}$'),
+    "rollover" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    UNIQUE ("category_id", "month")
+);
+
+ALTER TABLE "budgets" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "budgets" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "budgets" ("ledger_id", "month");
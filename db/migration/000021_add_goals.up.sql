@@ -0,0 +1,35 @@
+-- Something the ledger saves toward, such as a trip or an emergency
+-- fund. What is saved is the sum of the transactions linked to the goal
+-- plus, when it has a wallet, the transfers in and out of that wallet
+-- from starts_on on.
+CREATE TABLE "goals" (
+    "id" serial PRIMARY KEY NOT NULL,
+    "ledger_id" int NOT NULL,
+    "created_by" int,
+    "wallet_id" int,
+    "title" varchar NOT NULL,
+    "target_amount" bigint NOT NULL CHECK ("target_amount" > 0),
+    "currency" char(3) NOT NULL CHECK ("currency" ~ '^[A-Z]{3}$'),
+    "starts_on" date NOT NULL,
+    "deadline" date,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("deadline" >= "starts_on")
+);
+
+ALTER TABLE "goals" ADD FOREIGN KEY ("ledger_id") REFERENCES "ledgers" ("id") ON DELETE CASCADE;
+ALTER TABLE "goals" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+ALTER TABLE "goals" ADD FOREIGN KEY ("wallet_id") REFERENCES "wallets" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "goals" ("ledger_id");
+
+-- A transaction counted toward a goal. A transaction counts toward one
+-- goal at most.
+CREATE TABLE "goal_contributions" (
+    "goal_id" int NOT NULL,
+    "account_id" int UNIQUE NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("goal_id", "account_id")
+);
+
+ALTER TABLE "goal_contributions" ADD FOREIGN KEY ("goal_id") REFERENCES "goals" ("id") ON DELETE CASCADE;
+ALTER TABLE "goal_contributions" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
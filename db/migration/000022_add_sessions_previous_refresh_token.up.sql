@@ -0,0 +1,3 @@
+-- The refresh token a session rotated out last, to tell a leaked token
+-- being replayed apart from a wrong guess.
+ALTER TABLE "sessions" ADD COLUMN "previous_refresh_token_hash" varchar NOT NULL DEFAULT '';
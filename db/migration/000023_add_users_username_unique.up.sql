@@ -0,0 +1,3 @@
+-- Users sign in with their username, looked up as is, so no two accounts
+-- may share one.
+CREATE UNIQUE INDEX "users_username_key" ON "users" ("username");
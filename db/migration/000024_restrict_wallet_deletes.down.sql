@@ -0,0 +1,5 @@
+ALTER TABLE "installment_plans" DROP CONSTRAINT "installment_plans_wallet_id_ledger_id_currency_fkey";
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("wallet_id", "ledger_id", "currency") REFERENCES "wallets" ("id", "ledger_id", "currency") ON DELETE CASCADE;
+
+ALTER TABLE "recurring_transactions" DROP CONSTRAINT "recurring_transactions_wallet_id_ledger_id_currency_fkey";
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("wallet_id", "ledger_id", "currency") REFERENCES "wallets" ("id", "ledger_id", "currency") ON DELETE CASCADE;
@@ -0,0 +1,7 @@
+-- Deleting a wallet must not take the recurring transactions and
+-- installment plans booked to it along silently.
+ALTER TABLE "recurring_transactions" DROP CONSTRAINT "recurring_transactions_wallet_id_ledger_id_currency_fkey";
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("wallet_id", "ledger_id", "currency") REFERENCES "wallets" ("id", "ledger_id", "currency") ON DELETE RESTRICT;
+
+ALTER TABLE "installment_plans" DROP CONSTRAINT "installment_plans_wallet_id_ledger_id_currency_fkey";
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("wallet_id", "ledger_id", "currency") REFERENCES "wallets" ("id", "ledger_id", "currency") ON DELETE RESTRICT;
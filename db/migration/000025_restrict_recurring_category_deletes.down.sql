@@ -0,0 +1,2 @@
+ALTER TABLE "recurring_transactions" DROP CONSTRAINT "recurring_transactions_category_id_fkey";
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
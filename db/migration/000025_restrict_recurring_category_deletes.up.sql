@@ -0,0 +1,4 @@
+-- Deleting a category must not take the recurring transactions booked
+-- to it along silently.
+ALTER TABLE "recurring_transactions" DROP CONSTRAINT "recurring_transactions_category_id_fkey";
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE RESTRICT;
@@ -0,0 +1,2 @@
+ALTER TABLE "installment_plans" DROP CONSTRAINT "installment_plans_category_id_fkey";
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
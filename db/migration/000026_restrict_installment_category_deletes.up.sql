@@ -0,0 +1,4 @@
+-- Deleting a category must not take the installment plans booked to it
+-- along silently.
+ALTER TABLE "installment_plans" DROP CONSTRAINT "installment_plans_category_id_fkey";
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE RESTRICT;
@@ -0,0 +1,2 @@
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_type_check";
+ALTER TABLE "categories" DROP CONSTRAINT "categories_type_check";
@@ -0,0 +1,4 @@
+-- The journal only knows how to post debits and credits; a category or
+-- transaction of any other type would move no money at all.
+ALTER TABLE "categories" ADD CONSTRAINT "categories_type_check" CHECK ("type" IN ('debit', 'credit'));
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_type_check" CHECK ("type" IN ('debit', 'credit'));
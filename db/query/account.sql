@@ -1,37 +1,44 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (
-  user_id,
+  ledger_id,
+  created_by,
   category_id,
   title,
   type,
   description,
-  value,
-  date
+  amount,
+  currency,
+  date,
+  wallet_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND ledger_id = $2 LIMIT 1;
 
 -- name: GetAccounts :many
 SELECT
   a.id,
-  a.user_id,
+  a.ledger_id,
+  a.created_by,
   a.title,
   a.type,
   a.description,
-  a.value,
+  a.amount,
+  a.currency,
   a.date,
   a.created_at,
+  a.wallet_id,
+  a.transfer_id,
   c.title as category_title
 FROM
   accounts a
 LEFT JOIN
   categories c ON c.id = a.category_id
 WHERE
-  a.user_id = @user_id
+  a.ledger_id = @ledger_id
 AND
   a.type = @type
 AND
@@ -39,24 +46,58 @@ AND
 AND
   LOWER(a.description) LIKE CONCAT('%', LOWER(@description::text), '%')
 AND
-  a.category_id = COALESCE(sqlc.narg('category_id'), a.category_id)
+  (sqlc.narg('category_id')::int IS NULL OR a.category_id = sqlc.narg('category_id'))
 AND
-  a.date = COALESCE(sqlc.narg('date'), a.date);
+  a.date = COALESCE(sqlc.narg('date'), a.date)
+AND
+  a.wallet_id = COALESCE(sqlc.narg('wallet_id'), a.wallet_id);
+
+-- name: GetAccountsReports :many
+-- Transfers between wallets are neither income nor expense and are left
+-- out of reports.
+SELECT currency, SUM(amount)::bigint AS amount FROM accounts
+WHERE ledger_id = $1 AND type = $2 AND transfer_id IS NULL
+GROUP BY currency
+ORDER BY currency;
+
+-- name: GetAccountsReportsByDate :many
+SELECT currency, date, SUM(amount)::bigint AS amount FROM accounts
+WHERE ledger_id = $1 AND type = $2 AND transfer_id IS NULL
+GROUP BY currency, date
+ORDER BY date, currency;
 
--- name: GetAccountsReports :one
-SELECT SUM(value) AS sum_value FROM accounts
-where user_id = $1 and type = $2;
+-- name: GetCategoryReports :many
+SELECT
+  c.id AS category_id,
+  c.title AS category_title,
+  a.currency,
+  a.date,
+  SUM(a.amount)::bigint AS amount
+FROM
+  accounts a
+JOIN
+  categories c ON c.id = a.category_id
+WHERE
+  a.ledger_id = $1
+AND
+  a.type = $2
+GROUP BY
+  c.id, c.title, a.currency, a.date
+ORDER BY
+  c.id, a.date, a.currency;
 
 -- name: GetAccountsGraph :one
 SELECT COUNT(*) FROM accounts
-where user_id = $1 and type = $2;
+where ledger_id = $1 and type = $2 and transfer_id IS NULL;
 
 -- name: UpdateAccount :one
+-- Transfer legs change through their transfer.
 UPDATE accounts
-SET title = $2, description = $3, value = $4
-WHERE id = $1
+SET title = $2, description = $3, amount = $4, currency = $5, wallet_id = $6
+WHERE id = $1 AND ledger_id = $7 AND transfer_id IS NULL
 RETURNING *;
 
--- name: DeleteAccount :exec
+-- name: DeleteAccount :execrows
+-- Transfer legs are deleted with their transfer.
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND ledger_id = $2 AND transfer_id IS NULL;
\ No newline at end of file
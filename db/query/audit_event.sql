@@ -0,0 +1,48 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  user_id,
+  username,
+  actor_id,
+  actor,
+  type,
+  outcome,
+  client_ip,
+  user_agent,
+  details,
+  created_at,
+  prev_hash,
+  hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(7001);
+
+-- name: GetAuditLogHead :one
+SELECT hash FROM audit_events
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListAuditEventsAfter :many
+SELECT * FROM audit_events
+WHERE id > @after_id
+ORDER BY id
+LIMIT @page_limit;
+
+-- name: ListUserAuditEvents :many
+SELECT * FROM audit_events
+WHERE user_id = @user_id::int OR actor_id = @user_id::int
+ORDER BY id DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE
+  (sqlc.narg('user_id')::int IS NULL OR user_id = sqlc.narg('user_id') OR actor_id = sqlc.narg('user_id'))
+AND
+  type = COALESCE(sqlc.narg('type'), type)
+AND
+  outcome = COALESCE(sqlc.narg('outcome'), outcome)
+ORDER BY id DESC
+LIMIT @page_limit OFFSET @page_offset;
@@ -0,0 +1,51 @@
+-- name: SetBudget :one
+-- Sets the budget of a category for a month, replacing the one it had.
+INSERT INTO budgets (
+  ledger_id,
+  category_id,
+  month,
+  amount,
+  currency,
+  rollover
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (category_id, month)
+DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency, rollover = EXCLUDED.rollover
+RETURNING *;
+
+-- name: ListBudgetsThrough :many
+-- Lists the budgets of a ledger for month and the months before it, by
+-- category and month.
+SELECT * FROM budgets
+WHERE ledger_id = $1 AND month <= $2
+ORDER BY category_id, month;
+
+-- name: CopyBudgets :many
+-- Copies the budgets of a ledger for one month to another, leaving alone
+-- the categories that already have a budget there.
+INSERT INTO budgets (ledger_id, category_id, month, amount, currency, rollover)
+SELECT ledger_id, category_id, @to_month::date, amount, currency, rollover
+FROM budgets
+WHERE ledger_id = @ledger_id AND month = @from_month
+ON CONFLICT (category_id, month) DO NOTHING
+RETURNING *;
+
+-- name: DeleteBudget :execrows
+DELETE FROM budgets
+WHERE id = $1 AND ledger_id = $2;
+
+-- name: GetBudgetSpending :many
+-- Sums the transactions of the categories of a ledger per day and
+-- currency from from_date to to_date.
+SELECT
+  category_id::int AS category_id,
+  currency,
+  date,
+  SUM(amount)::bigint AS amount
+FROM accounts
+WHERE ledger_id = @ledger_id
+AND category_id IS NOT NULL
+AND date BETWEEN @from_date AND @to_date
+GROUP BY category_id, currency, date
+ORDER BY category_id, date, currency;
@@ -1,21 +1,22 @@
 -- name: CreateCategory :one
 INSERT INTO categories (
-    user_id,
+    ledger_id,
+    created_by,
     title,
     type,
     description
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 ) RETURNING *;
 
 -- name: GetCategory :one
 SELECT * FROM categories 
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND ledger_id = $2 LIMIT 1;
 
 -- name: GetCategories :many
 SELECT * FROM categories
 WHERE
-  user_id = $1
+  ledger_id = $1
 AND
   type = $2
 AND
@@ -24,26 +25,26 @@ AND
   LOWER(description) LIKE CONCAT('%', LOWER(@description::text), '%');
 
 
--- name: GetCategoriesByUserIdAndType :many
+-- name: GetCategoriesByLedgerIdAndType :many
 SELECT * FROM categories 
-WHERE user_id = $1 AND type = $2; 
+WHERE ledger_id = $1 AND type = $2; 
 
--- name: GetCategoriesByUserIdAndTypeAndTitle :many
+-- name: GetCategoriesByLedgerIdAndTypeAndTitle :many
 SELECT * FROM categories 
-WHERE user_id = $1 AND type = $2 
+WHERE ledger_id = $1 AND type = $2 
 AND title LIKE $3;
 
--- name: GetCategoriesByUserIdAndTypeAndDescription :many
+-- name: GetCategoriesByLedgerIdAndTypeAndDescription :many
 SELECT * FROM categories 
-WHERE user_id = $1 AND type = $2 
+WHERE ledger_id = $1 AND type = $2 
 AND description LIKE $3;
 
 -- name: UpdateCategories :one
 UPDATE categories 
 SET title = $2, description = $3 
-WHERE id = $1 
+WHERE id = $1 AND ledger_id = $4 
 RETURNING *;
 
--- name: DeleteCategories :exec
+-- name: DeleteCategories :execrows
 DELETE FROM categories 
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND ledger_id = $2;
\ No newline at end of file
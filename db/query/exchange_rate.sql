@@ -0,0 +1,32 @@
+-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (
+  from_currency,
+  to_currency,
+  date,
+  rate
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (from_currency, to_currency, date)
+DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()
+RETURNING *;
+
+-- name: GetExchangeRate :one
+-- The latest rate for the pair on or before date, stored in either
+-- direction. Rates stored from_currency to to_currency win on the same day.
+SELECT * FROM exchange_rates
+WHERE
+  ((from_currency = @from_currency AND to_currency = @to_currency)
+OR
+  (from_currency = @to_currency AND to_currency = @from_currency))
+AND
+  date <= @date
+ORDER BY date DESC, (from_currency = @from_currency) DESC
+LIMIT 1;
+
+-- name: ListExchangeRates :many
+SELECT * FROM exchange_rates
+WHERE
+  (sqlc.narg('currency')::text IS NULL OR from_currency = sqlc.narg('currency') OR to_currency = sqlc.narg('currency'))
+ORDER BY date DESC, from_currency, to_currency
+LIMIT @page_limit OFFSET @page_offset;
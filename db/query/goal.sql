@@ -0,0 +1,65 @@
+-- name: CreateGoal :one
+INSERT INTO goals (
+  ledger_id,
+  created_by,
+  wallet_id,
+  title,
+  target_amount,
+  currency,
+  starts_on,
+  deadline
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetGoal :one
+SELECT * FROM goals
+WHERE id = $1 AND ledger_id = $2 LIMIT 1;
+
+-- name: ListGoals :many
+SELECT * FROM goals
+WHERE ledger_id = $1
+ORDER BY id;
+
+-- name: UpdateGoal :one
+UPDATE goals
+SET title = $2, target_amount = $3, deadline = $4
+WHERE id = $1 AND ledger_id = $5
+RETURNING *;
+
+-- name: DeleteGoal :execrows
+DELETE FROM goals
+WHERE id = $1 AND ledger_id = $2;
+
+-- name: LinkGoalContribution :one
+-- Counts a transaction toward a goal. It returns no row when the
+-- transaction already counts toward a goal.
+INSERT INTO goal_contributions (
+  goal_id,
+  account_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: UnlinkGoalContribution :execrows
+DELETE FROM goal_contributions
+WHERE goal_id = $1 AND account_id = $2;
+
+-- name: ListGoalContributions :many
+-- Lists what was put toward a goal by date: the transactions linked to
+-- it and the transfers in and out of its wallet from starts_on on.
+SELECT a.id AS account_id, a.date, a.amount, a.transfer_id
+FROM goal_contributions c
+JOIN accounts a ON a.id = c.account_id
+WHERE c.goal_id = @goal_id
+UNION ALL
+SELECT a.id AS account_id, a.date,
+  (CASE a.type WHEN 'credit' THEN a.amount ELSE -a.amount END)::bigint AS amount,
+  a.transfer_id
+FROM accounts a
+WHERE a.wallet_id = @wallet_id
+AND a.transfer_id IS NOT NULL
+AND a.date >= @starts_on
+ORDER BY date, account_id;
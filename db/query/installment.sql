@@ -0,0 +1,67 @@
+-- name: CreateInstallmentPlan :one
+INSERT INTO installment_plans (
+  ledger_id,
+  created_by,
+  category_id,
+  wallet_id,
+  title,
+  type,
+  description,
+  total_amount,
+  currency,
+  installments,
+  first_date,
+  remainder
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: GetInstallmentPlan :one
+SELECT * FROM installment_plans
+WHERE id = $1 AND ledger_id = $2 LIMIT 1;
+
+-- name: ListInstallmentPlans :many
+SELECT * FROM installment_plans
+WHERE ledger_id = $1
+ORDER BY first_date DESC, id DESC;
+
+-- name: UpdateInstallmentPlan :one
+UPDATE installment_plans
+SET title = $2, description = $3, total_amount = $4, installments = $5
+WHERE id = $1 AND cancelled_at IS NULL
+RETURNING *;
+
+-- name: CancelInstallmentPlan :one
+UPDATE installment_plans
+SET cancelled_at = now()
+WHERE id = $1 AND cancelled_at IS NULL
+RETURNING *;
+
+-- name: CreateInstallment :one
+INSERT INTO installments (
+  plan_id,
+  number,
+  account_id
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: ListInstallments :many
+SELECT * FROM installments
+WHERE plan_id = $1
+ORDER BY number;
+
+-- name: ListInstallmentAccounts :many
+SELECT a.* FROM accounts a
+JOIN installments i ON i.account_id = a.id
+WHERE i.plan_id = $1
+ORDER BY i.number;
+
+-- name: DeleteInstallmentAccounts :execrows
+-- Deletes the transactions of the installments after number, which
+-- leave the plan with them.
+DELETE FROM accounts
+WHERE id IN (
+  SELECT account_id FROM installments
+  WHERE plan_id = $1 AND number > $2
+);
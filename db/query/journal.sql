@@ -0,0 +1,87 @@
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (
+  ledger_id,
+  account_id,
+  transfer_id,
+  date
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetAccountJournalEntry :one
+SELECT * FROM journal_entries
+WHERE account_id = $1 LIMIT 1;
+
+-- name: GetTransferJournalEntry :one
+SELECT * FROM journal_entries
+WHERE transfer_id = $1 LIMIT 1;
+
+-- name: DeleteAccountJournalEntry :exec
+DELETE FROM journal_entries
+WHERE account_id = $1;
+
+-- name: DeleteTransferJournalEntry :exec
+DELETE FROM journal_entries
+WHERE transfer_id = $1;
+
+-- name: CreatePosting :one
+INSERT INTO postings (
+  entry_id,
+  wallet_id,
+  category_id,
+  amount,
+  currency
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListPostings :many
+SELECT * FROM postings
+WHERE entry_id = $1
+ORDER BY id;
+
+-- name: ListUnbalancedJournalEntries :many
+-- Entries whose postings do not sum to zero in some currency.
+SELECT e.id AS entry_id, e.ledger_id, p.currency, SUM(p.amount)::bigint AS total
+FROM journal_entries e
+JOIN postings p ON p.entry_id = e.id
+GROUP BY e.id, e.ledger_id, p.currency
+HAVING SUM(p.amount) <> 0
+ORDER BY e.id, p.currency;
+
+-- name: ListUnbalancedLedgers :many
+-- Ledgers whose postings do not sum to zero in some currency.
+SELECT e.ledger_id, p.currency, SUM(p.amount)::bigint AS total
+FROM journal_entries e
+JOIN postings p ON p.entry_id = e.id
+GROUP BY e.ledger_id, p.currency
+HAVING SUM(p.amount) <> 0
+ORDER BY e.ledger_id, p.currency;
+
+-- name: ListUnjournaledAccounts :many
+-- Transactions with no journal entry of their own or of their transfer.
+SELECT a.id, a.ledger_id FROM accounts a
+WHERE NOT EXISTS (
+  SELECT 1 FROM journal_entries e
+  WHERE e.account_id = a.id OR e.transfer_id = a.transfer_id
+)
+ORDER BY a.id;
+
+-- name: ListWalletJournalMismatches :many
+-- Wallets whose postings do not add up to their credit transactions minus
+-- their debit ones.
+SELECT wallet_id, ledger_id, journal_total, transactions_total FROM (
+  SELECT
+    w.id AS wallet_id,
+    w.ledger_id,
+    COALESCE((
+      SELECT SUM(p.amount) FROM postings p WHERE p.wallet_id = w.id
+    ), 0)::bigint AS journal_total,
+    COALESCE((
+      SELECT SUM(CASE a.type WHEN 'credit' THEN a.amount WHEN 'debit' THEN -a.amount ELSE 0 END)
+      FROM accounts a WHERE a.wallet_id = w.id
+    ), 0)::bigint AS transactions_total
+  FROM wallets w
+) totals
+WHERE journal_total <> transactions_total
+ORDER BY wallet_id;
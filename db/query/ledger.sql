@@ -0,0 +1,111 @@
+-- name: CreateLedger :one
+INSERT INTO ledgers (
+  name,
+  owner_id
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: CreatePersonalLedger :one
+INSERT INTO ledgers (
+  name,
+  owner_id,
+  personal
+) VALUES (
+  $1, $2, true
+)
+ON CONFLICT (owner_id) WHERE personal DO NOTHING
+RETURNING *;
+
+-- name: GetLedger :one
+SELECT * FROM ledgers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPersonalLedger :one
+SELECT * FROM ledgers
+WHERE owner_id = $1 AND personal LIMIT 1;
+
+-- name: ListUserLedgers :many
+SELECT
+  l.id,
+  l.name,
+  l.owner_id,
+  l.personal,
+  l.created_at,
+  m.role
+FROM
+  ledgers l
+JOIN
+  ledger_members m ON m.ledger_id = l.id
+WHERE
+  m.user_id = $1
+ORDER BY l.id;
+
+-- name: CreateLedgerMember :one
+INSERT INTO ledger_members (
+  ledger_id,
+  user_id,
+  role
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetLedgerMember :one
+SELECT * FROM ledger_members
+WHERE ledger_id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: ListLedgerMembers :many
+SELECT
+  m.ledger_id,
+  m.user_id,
+  m.role,
+  m.created_at,
+  u.username,
+  u.display_name
+FROM
+  ledger_members m
+JOIN
+  users u ON u.id = m.user_id
+WHERE
+  m.ledger_id = $1
+ORDER BY m.created_at, m.user_id;
+
+-- name: UpdateLedgerMemberRole :one
+UPDATE ledger_members
+SET role = $3
+WHERE ledger_id = $1 AND user_id = $2 AND role <> 'owner'
+RETURNING *;
+
+-- name: DeleteLedgerMember :execrows
+DELETE FROM ledger_members
+WHERE ledger_id = $1 AND user_id = $2 AND role <> 'owner';
+
+-- name: CreateLedgerInvitation :one
+INSERT INTO ledger_invitations (
+  ledger_id,
+  email,
+  role,
+  token_hash,
+  invited_by,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetLedgerInvitationByToken :one
+SELECT * FROM ledger_invitations
+WHERE token_hash = $1 LIMIT 1;
+
+-- name: ListLedgerInvitations :many
+SELECT * FROM ledger_invitations
+WHERE ledger_id = $1 AND accepted_at IS NULL AND expires_at > now()
+ORDER BY id;
+
+-- name: AcceptLedgerInvitation :execrows
+UPDATE ledger_invitations
+SET accepted_at = now()
+WHERE id = $1 AND accepted_at IS NULL AND expires_at > now();
+
+-- name: DeleteLedgerInvitation :execrows
+DELETE FROM ledger_invitations
+WHERE id = $1 AND ledger_id = $2 AND accepted_at IS NULL;
@@ -0,0 +1,28 @@
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles
+WHERE key = $1 LIMIT 1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (
+  key,
+  failures,
+  last_failed_at
+) VALUES (
+  @key, 1, now()
+)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+    WHEN login_throttles.last_failed_at < @reset_before THEN 1
+    ELSE login_throttles.failures + 1
+  END,
+  last_failed_at = now()
+RETURNING *;
+
+-- name: LockLogin :exec
+UPDATE login_throttles
+SET locked_until = $2, failures = 0
+WHERE key = $1;
+
+-- name: ClearLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
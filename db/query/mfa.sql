@@ -0,0 +1,46 @@
+-- name: UpsertMfaTotp :one
+INSERT INTO mfa_totp (
+  user_id,
+  secret
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, created_at = now()
+WHERE mfa_totp.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetMfaTotp :one
+SELECT * FROM mfa_totp
+WHERE user_id = $1 LIMIT 1;
+
+-- name: ConfirmMfaTotp :execrows
+UPDATE mfa_totp
+SET confirmed_at = now()
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: UseMfaTotpStep :execrows
+UPDATE mfa_totp
+SET last_used_step = @step
+WHERE user_id = @user_id AND last_used_step < @step;
+
+-- name: DeleteMfaTotp :exec
+DELETE FROM mfa_totp
+WHERE user_id = $1;
+
+-- name: CreateMfaRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (
+  user_id,
+  code_hash
+) VALUES (
+  $1, $2
+);
+
+-- name: UseMfaRecoveryCode :execrows
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: DeleteMfaRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
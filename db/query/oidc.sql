@@ -0,0 +1,39 @@
+-- name: CreateOidcAuthRequest :one
+INSERT INTO oidc_auth_requests (
+  state_hash,
+  provider,
+  nonce,
+  code_verifier,
+  link_user_id,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ConsumeOidcAuthRequest :one
+DELETE FROM oidc_auth_requests
+WHERE state_hash = $1
+RETURNING *;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (
+  user_id,
+  provider,
+  subject,
+  email
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE provider = $1 AND subject = $2 LIMIT 1;
+
+-- name: ListUserIdentities :many
+SELECT * FROM user_identities
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities
+WHERE user_id = $1 AND provider = $2;
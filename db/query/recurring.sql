@@ -0,0 +1,115 @@
+-- name: CreateRecurringTransaction :one
+INSERT INTO recurring_transactions (
+  ledger_id,
+  created_by,
+  category_id,
+  wallet_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  rule,
+  starts_on,
+  ends_on,
+  occurrence_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING *;
+
+-- name: GetRecurringTransaction :one
+SELECT * FROM recurring_transactions
+WHERE id = $1 AND ledger_id = $2 LIMIT 1;
+
+-- name: ListRecurringTransactions :many
+SELECT * FROM recurring_transactions
+WHERE ledger_id = $1
+ORDER BY title, id;
+
+-- name: UpdateRecurringTransaction :one
+UPDATE recurring_transactions
+SET title = $2, description = $3, amount = $4, rule = $5, ends_on = $6, occurrence_count = $7
+WHERE id = $1 AND ledger_id = $8
+RETURNING *;
+
+-- name: DeleteRecurringTransaction :execrows
+-- The transactions it generated are kept.
+DELETE FROM recurring_transactions
+WHERE id = $1 AND ledger_id = $2;
+
+-- name: ListDueRecurringTransactions :many
+-- Templates that may have occurrences up to today not generated yet.
+SELECT * FROM recurring_transactions
+WHERE starts_on <= sqlc.arg('today')::date
+AND (generated_through IS NULL OR (
+  generated_through < sqlc.arg('today')::date
+  AND (ends_on IS NULL OR generated_through < ends_on)
+))
+ORDER BY id;
+
+-- name: SetRecurringGeneratedThrough :exec
+UPDATE recurring_transactions
+SET generated_through = $2
+WHERE id = $1;
+
+-- name: ListRecurringOccurrences :many
+SELECT * FROM recurring_occurrences
+WHERE recurring_id = $1
+AND date BETWEEN sqlc.arg('from_date')::date AND sqlc.arg('to_date')::date
+ORDER BY date;
+
+-- name: SkipRecurringOccurrence :one
+-- Generated occurrences cannot be skipped anymore; no row comes back
+-- for them.
+INSERT INTO recurring_occurrences (
+  recurring_id,
+  date,
+  skipped
+) VALUES (
+  $1, $2, true
+)
+ON CONFLICT (recurring_id, date) DO UPDATE SET skipped = true
+WHERE recurring_occurrences.generated_at IS NULL
+RETURNING *;
+
+-- name: ModifyRecurringOccurrence :one
+-- Generated occurrences cannot be modified anymore; no row comes back
+-- for them.
+INSERT INTO recurring_occurrences (
+  recurring_id,
+  date,
+  title,
+  description,
+  amount
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (recurring_id, date) DO UPDATE
+SET title = EXCLUDED.title, description = EXCLUDED.description, amount = EXCLUDED.amount
+WHERE recurring_occurrences.generated_at IS NULL
+RETURNING *;
+
+-- name: DeleteRecurringOccurrence :execrows
+-- Restores a skipped or modified occurrence to its template, unless it
+-- was generated.
+DELETE FROM recurring_occurrences
+WHERE recurring_id = $1 AND date = $2 AND generated_at IS NULL;
+
+-- name: GenerateRecurringOccurrence :one
+-- Claims an occurrence for generation. No row comes back when it was
+-- generated before or skipped.
+INSERT INTO recurring_occurrences (
+  recurring_id,
+  date,
+  generated_at
+) VALUES (
+  $1, $2, now()
+)
+ON CONFLICT (recurring_id, date) DO UPDATE SET generated_at = now()
+WHERE recurring_occurrences.generated_at IS NULL AND NOT recurring_occurrences.skipped
+RETURNING *;
+
+-- name: SetRecurringOccurrenceAccount :exec
+UPDATE recurring_occurrences
+SET account_id = $3
+WHERE recurring_id = $1 AND date = $2;
@@ -0,0 +1,38 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+  user_id,
+  refresh_token_hash,
+  user_agent,
+  client_ip,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: RotateSessionRefreshToken :one
+UPDATE sessions
+SET previous_refresh_token_hash = refresh_token_hash,
+    refresh_token_hash = @new_refresh_token_hash
+WHERE id = @id
+AND refresh_token_hash = @refresh_token_hash
+AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeSession :exec
+UPDATE sessions
+SET revoked_at = now()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeOtherUserSessions :exec
+UPDATE sessions
+SET revoked_at = now()
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL;
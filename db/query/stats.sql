@@ -0,0 +1,9 @@
+-- name: GetSystemStats :one
+SELECT
+  (SELECT COUNT(*) FROM users) AS users,
+  (SELECT COUNT(*) FROM users WHERE verified_at IS NOT NULL) AS verified_users,
+  (SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL) AS disabled_users,
+  (SELECT COUNT(*) FROM users WHERE role = 'admin') AS admins,
+  (SELECT COUNT(*) FROM sessions WHERE revoked_at IS NULL AND expires_at > now()) AS active_sessions,
+  (SELECT COUNT(*) FROM categories) AS categories,
+  (SELECT COUNT(*) FROM accounts) AS accounts;
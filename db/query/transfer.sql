@@ -0,0 +1,44 @@
+-- name: CreateTransfer :one
+INSERT INTO transfers (
+  ledger_id,
+  created_by
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetTransfer :one
+SELECT * FROM transfers
+WHERE id = $1 AND ledger_id = $2 LIMIT 1;
+
+-- name: DeleteTransfer :execrows
+-- Deletes both legs with it.
+DELETE FROM transfers
+WHERE id = $1 AND ledger_id = $2;
+
+-- name: CreateTransferLeg :one
+INSERT INTO accounts (
+  ledger_id,
+  created_by,
+  transfer_id,
+  wallet_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  date
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: ListTransferLegs :many
+-- The debit leg, leaving its wallet, comes first.
+SELECT * FROM accounts
+WHERE transfer_id = $1
+ORDER BY type DESC;
+
+-- name: UpdateTransferLeg :one
+UPDATE accounts
+SET title = $2, description = $3, amount = $4, date = $5
+WHERE id = $1 AND transfer_id = $6
+RETURNING *;
@@ -13,4 +13,82 @@ WHERE username = $1 LIMIT 1;
 
 -- name: GetUserById :one
 SELECT * FROM users
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2, password_reset_required = false
+WHERE id = $1;
+
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET password = @new_password
+WHERE id = @id AND password = @old_password;
+
+-- name: VerifyUserEmail :execrows
+UPDATE users
+SET verified_at = now()
+WHERE id = $1 AND email = $2 AND verified_at IS NULL;
+
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE
+  LOWER(username) LIKE CONCAT('%', LOWER(@search::text), '%')
+OR
+  LOWER(email) LIKE CONCAT('%', LOWER(@search::text), '%')
+ORDER BY id
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+RETURNING *;
+
+-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: RequireUserPasswordReset :one
+UPDATE users
+SET password_reset_required = true
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET username = $2, display_name = $3, base_currency = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserPendingEmail :exec
+UPDATE users
+SET pending_email = $2
+WHERE id = $1;
+
+-- name: ConfirmUserEmailChange :execrows
+UPDATE users
+SET email = pending_email, pending_email = NULL, verified_at = now()
+WHERE id = @id AND pending_email = @email::varchar;
+
+-- name: SoftDeleteUser :one
+UPDATE users
+SET deleted_at = COALESCE(deleted_at, now()), pending_email = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < @deleted_before::timestamptz;
@@ -0,0 +1,62 @@
+-- name: CreateWallet :one
+INSERT INTO wallets (
+  ledger_id,
+  created_by,
+  name,
+  type,
+  currency,
+  opening_balance
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetWallet :one
+SELECT * FROM wallets
+WHERE id = $1 AND ledger_id = $2 LIMIT 1;
+
+-- name: ListWallets :many
+SELECT
+  w.*,
+  (w.opening_balance + COALESCE((
+    SELECT SUM(p.amount)
+    FROM postings p
+    WHERE p.wallet_id = w.id
+  ), 0))::bigint AS balance
+FROM
+  wallets w
+WHERE
+  w.ledger_id = $1
+ORDER BY
+  w.name, w.id;
+
+-- name: GetWalletTransactionsTotal :one
+-- Money in minus money out of a wallet as journaled, of all its
+-- transactions or of those up to and including date.
+SELECT COALESCE(SUM(p.amount), 0)::bigint AS total
+FROM postings p
+JOIN journal_entries e ON e.id = p.entry_id
+WHERE p.wallet_id = @wallet_id
+AND (sqlc.narg('date')::date IS NULL OR e.date <= sqlc.narg('date'));
+
+-- name: ListWalletDailyTotals :many
+SELECT e.date, SUM(p.amount)::bigint AS total
+FROM postings p
+JOIN journal_entries e ON e.id = p.entry_id
+WHERE p.wallet_id = @wallet_id AND e.date BETWEEN @from_date AND @to_date
+GROUP BY e.date
+ORDER BY e.date;
+
+-- name: UpdateWallet :one
+UPDATE wallets
+SET name = $2, type = $3, opening_balance = $4
+WHERE id = $1 AND ledger_id = $5
+RETURNING *;
+
+-- name: DeleteWallet :execrows
+-- Wallets with transactions, recurring transactions or installment plans
+-- are kept.
+DELETE FROM wallets
+WHERE id = $1 AND ledger_id = $2
+AND NOT EXISTS (SELECT 1 FROM accounts WHERE wallet_id = $1)
+AND NOT EXISTS (SELECT 1 FROM recurring_transactions WHERE wallet_id = $1)
+AND NOT EXISTS (SELECT 1 FROM installment_plans WHERE wallet_id = $1);
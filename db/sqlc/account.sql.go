@@ -13,81 +13,112 @@ import (
 
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
-  user_id,
+  ledger_id,
+  created_by,
   category_id,
   title,
   type,
   description,
-  value,
-  date
+  amount,
+  currency,
+  date,
+  wallet_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, user_id, category_id, title, type, description, value, date, created_at
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, created_by, category_id, title, type, description, amount, date, created_at, ledger_id, currency, wallet_id, transfer_id
 `
 
 type CreateAccountParams struct {
-	UserID      int32     `json:"user_id"`
-	CategoryID  int32     `json:"category_id"`
-	Title       string    `json:"title"`
-	Type        string    `json:"type"`
-	Description string    `json:"description"`
-	Value       int32     `json:"value"`
-	Date        time.Time `json:"date"`
+	LedgerID    int32         `json:"ledger_id"`
+	CreatedBy   sql.NullInt32 `json:"created_by"`
+	CategoryID  sql.NullInt32 `json:"category_id"`
+	Title       string        `json:"title"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	Amount      int64         `json:"amount"`
+	Currency    string        `json:"currency"`
+	Date        time.Time     `json:"date"`
+	WalletID    int32         `json:"wallet_id"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
 	row := q.db.QueryRowContext(ctx, createAccount,
-		arg.UserID,
+		arg.LedgerID,
+		arg.CreatedBy,
 		arg.CategoryID,
 		arg.Title,
 		arg.Type,
 		arg.Description,
-		arg.Value,
+		arg.Amount,
+		arg.Currency,
 		arg.Date,
+		arg.WalletID,
 	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.CreatedBy,
 		&i.CategoryID,
 		&i.Title,
 		&i.Type,
 		&i.Description,
-		&i.Value,
+		&i.Amount,
 		&i.Date,
 		&i.CreatedAt,
+		&i.LedgerID,
+		&i.Currency,
+		&i.WalletID,
+		&i.TransferID,
 	)
 	return i, err
 }
 
-const deleteAccount = `-- name: DeleteAccount :exec
+const deleteAccount = `-- name: DeleteAccount :execrows
 DELETE FROM accounts
-WHERE id = $1
+WHERE id = $1 AND ledger_id = $2 AND transfer_id IS NULL
 `
 
-func (q *Queries) DeleteAccount(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
-	return err
+type DeleteAccountParams struct {
+	ID       int32 `json:"id"`
+	LedgerID int32 `json:"ledger_id"`
+}
+
+// Transfer legs are deleted with their transfer.
+func (q *Queries) DeleteAccount(ctx context.Context, arg DeleteAccountParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAccount, arg.ID, arg.LedgerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, user_id, category_id, title, type, description, value, date, created_at FROM accounts
-WHERE id = $1 LIMIT 1
+SELECT id, created_by, category_id, title, type, description, amount, date, created_at, ledger_id, currency, wallet_id, transfer_id FROM accounts
+WHERE id = $1 AND ledger_id = $2 LIMIT 1
 `
 
-func (q *Queries) GetAccount(ctx context.Context, id int32) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, id)
+type GetAccountParams struct {
+	ID       int32 `json:"id"`
+	LedgerID int32 `json:"ledger_id"`
+}
+
+func (q *Queries) GetAccount(ctx context.Context, arg GetAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccount, arg.ID, arg.LedgerID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.CreatedBy,
 		&i.CategoryID,
 		&i.Title,
 		&i.Type,
 		&i.Description,
-		&i.Value,
+		&i.Amount,
 		&i.Date,
 		&i.CreatedAt,
+		&i.LedgerID,
+		&i.Currency,
+		&i.WalletID,
+		&i.TransferID,
 	)
 	return i, err
 }
@@ -95,20 +126,24 @@ func (q *Queries) GetAccount(ctx context.Context, id int32) (Account, error) {
 const getAccounts = `-- name: GetAccounts :many
 SELECT
   a.id,
-  a.user_id,
+  a.ledger_id,
+  a.created_by,
   a.title,
   a.type,
   a.description,
-  a.value,
+  a.amount,
+  a.currency,
   a.date,
   a.created_at,
+  a.wallet_id,
+  a.transfer_id,
   c.title as category_title
 FROM
   accounts a
 LEFT JOIN
   categories c ON c.id = a.category_id
 WHERE
-  a.user_id = $1
+  a.ledger_id = $1
 AND
   a.type = $2
 AND
@@ -116,40 +151,48 @@ AND
 AND
   LOWER(a.description) LIKE CONCAT('%', LOWER($4::text), '%')
 AND
-  a.category_id = COALESCE($5, a.category_id)
+  ($5::int IS NULL OR a.category_id = $5)
 AND
   a.date = COALESCE($6, a.date)
+AND
+  a.wallet_id = COALESCE($7, a.wallet_id)
 `
 
 type GetAccountsParams struct {
-	UserID      int32         `json:"user_id"`
+	LedgerID    int32         `json:"ledger_id"`
 	Type        string        `json:"type"`
 	Title       string        `json:"title"`
 	Description string        `json:"description"`
 	CategoryID  sql.NullInt32 `json:"category_id"`
 	Date        sql.NullTime  `json:"date"`
+	WalletID    sql.NullInt32 `json:"wallet_id"`
 }
 
 type GetAccountsRow struct {
 	ID            int32          `json:"id"`
-	UserID        int32          `json:"user_id"`
+	LedgerID      int32          `json:"ledger_id"`
+	CreatedBy     sql.NullInt32  `json:"created_by"`
 	Title         string         `json:"title"`
 	Type          string         `json:"type"`
 	Description   string         `json:"description"`
-	Value         int32          `json:"value"`
+	Amount        int64          `json:"amount"`
+	Currency      string         `json:"currency"`
 	Date          time.Time      `json:"date"`
 	CreatedAt     time.Time      `json:"created_at"`
+	WalletID      int32          `json:"wallet_id"`
+	TransferID    sql.NullInt32  `json:"transfer_id"`
 	CategoryTitle sql.NullString `json:"category_title"`
 }
 
 func (q *Queries) GetAccounts(ctx context.Context, arg GetAccountsParams) ([]GetAccountsRow, error) {
 	rows, err := q.db.QueryContext(ctx, getAccounts,
-		arg.UserID,
+		arg.LedgerID,
 		arg.Type,
 		arg.Title,
 		arg.Description,
 		arg.CategoryID,
 		arg.Date,
+		arg.WalletID,
 	)
 	if err != nil {
 		return nil, err
@@ -160,13 +203,17 @@ func (q *Queries) GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Get
 		var i GetAccountsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.LedgerID,
+			&i.CreatedBy,
 			&i.Title,
 			&i.Type,
 			&i.Description,
-			&i.Value,
+			&i.Amount,
+			&i.Currency,
 			&i.Date,
 			&i.CreatedAt,
+			&i.WalletID,
+			&i.TransferID,
 			&i.CategoryTitle,
 		); err != nil {
 			return nil, err
@@ -184,70 +231,210 @@ func (q *Queries) GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Get
 
 const getAccountsGraph = `-- name: GetAccountsGraph :one
 SELECT COUNT(*) FROM accounts
-where user_id = $1 and type = $2
+where ledger_id = $1 and type = $2 and transfer_id IS NULL
 `
 
 type GetAccountsGraphParams struct {
-	UserID int32  `json:"user_id"`
-	Type   string `json:"type"`
+	LedgerID int32  `json:"ledger_id"`
+	Type     string `json:"type"`
 }
 
 func (q *Queries) GetAccountsGraph(ctx context.Context, arg GetAccountsGraphParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getAccountsGraph, arg.UserID, arg.Type)
+	row := q.db.QueryRowContext(ctx, getAccountsGraph, arg.LedgerID, arg.Type)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
-const getAccountsReports = `-- name: GetAccountsReports :one
-SELECT SUM(value) AS sum_value FROM accounts
-where user_id = $1 and type = $2
+const getAccountsReports = `-- name: GetAccountsReports :many
+SELECT currency, SUM(amount)::bigint AS amount FROM accounts
+WHERE ledger_id = $1 AND type = $2 AND transfer_id IS NULL
+GROUP BY currency
+ORDER BY currency
 `
 
 type GetAccountsReportsParams struct {
-	UserID int32  `json:"user_id"`
-	Type   string `json:"type"`
+	LedgerID int32  `json:"ledger_id"`
+	Type     string `json:"type"`
 }
 
-func (q *Queries) GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getAccountsReports, arg.UserID, arg.Type)
-	var sum_value int64
-	err := row.Scan(&sum_value)
-	return sum_value, err
+type GetAccountsReportsRow struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+// Transfers between wallets are neither income nor expense and are left
+// out of reports.
+func (q *Queries) GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) ([]GetAccountsReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountsReports, arg.LedgerID, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAccountsReportsRow{}
+	for rows.Next() {
+		var i GetAccountsReportsRow
+		if err := rows.Scan(&i.Currency, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAccountsReportsByDate = `-- name: GetAccountsReportsByDate :many
+SELECT currency, date, SUM(amount)::bigint AS amount FROM accounts
+WHERE ledger_id = $1 AND type = $2 AND transfer_id IS NULL
+GROUP BY currency, date
+ORDER BY date, currency
+`
+
+type GetAccountsReportsByDateParams struct {
+	LedgerID int32  `json:"ledger_id"`
+	Type     string `json:"type"`
+}
+
+type GetAccountsReportsByDateRow struct {
+	Currency string    `json:"currency"`
+	Date     time.Time `json:"date"`
+	Amount   int64     `json:"amount"`
+}
+
+func (q *Queries) GetAccountsReportsByDate(ctx context.Context, arg GetAccountsReportsByDateParams) ([]GetAccountsReportsByDateRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountsReportsByDate, arg.LedgerID, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAccountsReportsByDateRow{}
+	for rows.Next() {
+		var i GetAccountsReportsByDateRow
+		if err := rows.Scan(&i.Currency, &i.Date, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategoryReports = `-- name: GetCategoryReports :many
+SELECT
+  c.id AS category_id,
+  c.title AS category_title,
+  a.currency,
+  a.date,
+  SUM(a.amount)::bigint AS amount
+FROM
+  accounts a
+JOIN
+  categories c ON c.id = a.category_id
+WHERE
+  a.ledger_id = $1
+AND
+  a.type = $2
+GROUP BY
+  c.id, c.title, a.currency, a.date
+ORDER BY
+  c.id, a.date, a.currency
+`
+
+type GetCategoryReportsParams struct {
+	LedgerID int32  `json:"ledger_id"`
+	Type     string `json:"type"`
+}
+
+type GetCategoryReportsRow struct {
+	CategoryID    int32     `json:"category_id"`
+	CategoryTitle string    `json:"category_title"`
+	Currency      string    `json:"currency"`
+	Date          time.Time `json:"date"`
+	Amount        int64     `json:"amount"`
+}
+
+func (q *Queries) GetCategoryReports(ctx context.Context, arg GetCategoryReportsParams) ([]GetCategoryReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoryReports, arg.LedgerID, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetCategoryReportsRow{}
+	for rows.Next() {
+		var i GetCategoryReportsRow
+		if err := rows.Scan(
+			&i.CategoryID,
+			&i.CategoryTitle,
+			&i.Currency,
+			&i.Date,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-SET title = $2, description = $3, value = $4
-WHERE id = $1
-RETURNING id, user_id, category_id, title, type, description, value, date, created_at
+SET title = $2, description = $3, amount = $4, currency = $5, wallet_id = $6
+WHERE id = $1 AND ledger_id = $7 AND transfer_id IS NULL
+RETURNING id, created_by, category_id, title, type, description, amount, date, created_at, ledger_id, currency, wallet_id, transfer_id
 `
 
 type UpdateAccountParams struct {
 	ID          int32  `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
-	Value       int32  `json:"value"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	WalletID    int32  `json:"wallet_id"`
+	LedgerID    int32  `json:"ledger_id"`
 }
 
+// Transfer legs change through their transfer.
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
 	row := q.db.QueryRowContext(ctx, updateAccount,
 		arg.ID,
 		arg.Title,
 		arg.Description,
-		arg.Value,
+		arg.Amount,
+		arg.Currency,
+		arg.WalletID,
+		arg.LedgerID,
 	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.CreatedBy,
 		&i.CategoryID,
 		&i.Title,
 		&i.Type,
 		&i.Description,
-		&i.Value,
+		&i.Amount,
 		&i.Date,
 		&i.CreatedAt,
+		&i.LedgerID,
+		&i.Currency,
+		&i.WalletID,
+		&i.TransferID,
 	)
 	return i, err
 }
@@ -12,23 +12,30 @@ import (
 
 func createRandomAccount(t *testing.T) Account {
 	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.LedgerID, "BRL")
 	arg := CreateAccountParams{
-		UserID:      category.UserID,
-		CategoryID:  category.ID,
+		LedgerID:    category.LedgerID,
+		CreatedBy:   category.CreatedBy,
+		CategoryID:  sql.NullInt32{Int32: category.ID, Valid: true},
 		Title:       util.RandomString(12),
 		Type:        category.Type,
 		Description: util.RandomString(20),
-		Value:       10,
+		Amount:      1050,
+		Currency:    "BRL",
 		Date:        time.Now(),
+		WalletID:    wallet.ID,
 	}
 
-	account, err := testQueries.CreateAccount(context.Background(), arg)
+	account, err := testStore.CreateAccountTx(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account)
 
-	require.Equal(t, arg.UserID, account.UserID)
+	require.Equal(t, arg.LedgerID, account.LedgerID)
+	require.Equal(t, arg.CreatedBy, account.CreatedBy)
 	require.Equal(t, arg.CategoryID, account.CategoryID)
-	require.Equal(t, arg.Value, account.Value)
+	require.Equal(t, arg.Amount, account.Amount)
+	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.WalletID, account.WalletID)
 	require.Equal(t, arg.Title, account.Title)
 	require.Equal(t, arg.Type, account.Type)
 	require.Equal(t, arg.Description, account.Description)
@@ -45,13 +52,17 @@ func TestCreateAccount(t *testing.T) {
 
 func TestGetAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	account2, err := testQueries.GetAccount(context.Background(), GetAccountParams{
+		ID:       account1.ID,
+		LedgerID: account1.LedgerID,
+	})
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
-	require.Equal(t, account1.UserID, account2.UserID)
+	require.Equal(t, account1.LedgerID, account2.LedgerID)
 	require.Equal(t, account1.CategoryID, account2.CategoryID)
-	require.Equal(t, account1.Value, account2.Value)
+	require.Equal(t, account1.Amount, account2.Amount)
+	require.Equal(t, account1.Currency, account2.Currency)
 	require.Equal(t, account1.Title, account2.Title)
 	require.Equal(t, account1.Type, account2.Type)
 	require.Equal(t, account1.Description, account2.Description)
@@ -60,47 +71,97 @@ func TestGetAccount(t *testing.T) {
 	require.NotEmpty(t, account2.CreatedAt)
 }
 
+func TestGetAccountOfAnotherLedger(t *testing.T) {
+	account := createRandomAccount(t)
+	otherLedger := createRandomLedger(t)
+
+	_, err := testQueries.GetAccount(context.Background(), GetAccountParams{
+		ID:       account.ID,
+		LedgerID: otherLedger.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	account := createRandomAccount(t)
-	err := testQueries.DeleteAccount(context.Background(), account.ID)
+	rows, err := testQueries.DeleteAccount(context.Background(), DeleteAccountParams{
+		ID:       account.ID,
+		LedgerID: account.LedgerID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestDeleteAccountOfAnotherLedger(t *testing.T) {
+	account := createRandomAccount(t)
+	otherLedger := createRandomLedger(t)
+
+	rows, err := testQueries.DeleteAccount(context.Background(), DeleteAccountParams{
+		ID:       account.ID,
+		LedgerID: otherLedger.ID,
+	})
 	require.NoError(t, err)
+	require.Zero(t, rows)
 }
 
 func TestUpdateAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
+	wallet := createRandomWallet(t, account1.LedgerID, "USD")
 
 	arg := UpdateAccountParams{
 		ID:          account1.ID,
 		Title:       util.RandomString(12),
 		Description: util.RandomString(20),
-		Value:       15,
+		Amount:      1500,
+		Currency:    "USD",
+		WalletID:    wallet.ID,
+		LedgerID:    account1.LedgerID,
 	}
 
-	account2, err := testQueries.UpdateAccount(context.Background(), arg)
+	account2, err := testStore.UpdateAccountTx(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
 	require.Equal(t, account1.ID, account2.ID)
 	require.Equal(t, arg.Title, account2.Title)
 	require.Equal(t, arg.Description, account2.Description)
-	require.Equal(t, arg.Value, account2.Value)
+	require.Equal(t, arg.Amount, account2.Amount)
+	require.Equal(t, arg.Currency, account2.Currency)
+	require.Equal(t, arg.WalletID, account2.WalletID)
 	require.Equal(t, account1.CreatedAt, account2.CreatedAt)
 }
 
+func TestUpdateAccountToWalletInAnotherCurrency(t *testing.T) {
+	account := createRandomAccount(t)
+	wallet := createRandomWallet(t, account.LedgerID, "USD")
+
+	_, err := testStore.UpdateAccountTx(context.Background(), UpdateAccountParams{
+		ID:          account.ID,
+		Title:       account.Title,
+		Description: account.Description,
+		Amount:      account.Amount,
+		Currency:    account.Currency,
+		WalletID:    wallet.ID,
+		LedgerID:    account.LedgerID,
+	})
+	require.Error(t, err)
+}
+
 func TestListAccounts(t *testing.T) {
 	lastAccount := createRandomAccount(t)
 
 	arg := GetAccountsParams{
-		UserID: lastAccount.UserID,
-		Type:   lastAccount.Type,
-		CategoryID: sql.NullInt32{
-			Valid: true,
-			Int32: lastAccount.CategoryID,
-		},
+		LedgerID:   lastAccount.LedgerID,
+		Type:       lastAccount.Type,
+		CategoryID: lastAccount.CategoryID,
 		Date: sql.NullTime{
 			Valid: true,
 			Time:  lastAccount.Date,
 		},
+		WalletID: sql.NullInt32{
+			Valid: true,
+			Int32: lastAccount.WalletID,
+		},
 		Title:       lastAccount.Title,
 		Description: lastAccount.Description,
 	}
@@ -111,10 +172,12 @@ func TestListAccounts(t *testing.T) {
 
 	for _, account := range accounts {
 		require.Equal(t, lastAccount.ID, account.ID)
-		require.Equal(t, lastAccount.UserID, account.UserID)
+		require.Equal(t, lastAccount.LedgerID, account.LedgerID)
 		require.Equal(t, lastAccount.Title, account.Title)
 		require.Equal(t, lastAccount.Description, account.Description)
-		require.Equal(t, lastAccount.Value, account.Value)
+		require.Equal(t, lastAccount.Amount, account.Amount)
+		require.Equal(t, lastAccount.Currency, account.Currency)
+		require.Equal(t, lastAccount.WalletID, account.WalletID)
 		require.NotEmpty(t, lastAccount.CreatedAt)
 		require.NotEmpty(t, lastAccount.Date)
 	}
@@ -124,24 +187,79 @@ func TestListGetReports(t *testing.T) {
 	lastAccount := createRandomAccount(t)
 
 	arg := GetAccountsReportsParams{
-		UserID: lastAccount.UserID,
-		Type:   lastAccount.Type,
+		LedgerID: lastAccount.LedgerID,
+		Type:     lastAccount.Type,
+	}
+
+	wallets := map[string]int32{
+		"BRL": lastAccount.WalletID,
+		"USD": createRandomWallet(t, lastAccount.LedgerID, "USD").ID,
+	}
+	for _, currency := range []string{"BRL", "USD"} {
+		_, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+			LedgerID:    lastAccount.LedgerID,
+			CategoryID:  lastAccount.CategoryID,
+			Title:       util.RandomString(12),
+			Type:        lastAccount.Type,
+			Description: util.RandomString(20),
+			Amount:      1 << 40,
+			Currency:    currency,
+			Date:        time.Now(),
+			WalletID:    wallets[currency],
+		})
+		require.NoError(t, err)
 	}
 
-	sumValue, err := testQueries.GetAccountsReports(context.Background(), arg)
+	// Amounts are summed per currency and do not overflow int32.
+	sums, err := testQueries.GetAccountsReports(context.Background(), arg)
 	require.NoError(t, err)
-	require.NotEmpty(t, sumValue)
+	require.Equal(t, []GetAccountsReportsRow{
+		{Currency: "BRL", Amount: lastAccount.Amount + 1<<40},
+		{Currency: "USD", Amount: 1 << 40},
+	}, sums)
 }
 
 func TestListGetGraph(t *testing.T) {
 	lastAccount := createRandomAccount(t)
 
 	arg := GetAccountsGraphParams{
-		UserID: lastAccount.UserID,
-		Type:   lastAccount.Type,
+		LedgerID: lastAccount.LedgerID,
+		Type:     lastAccount.Type,
 	}
 
 	graphValue, err := testQueries.GetAccountsGraph(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, graphValue)
 }
+
+func TestGetCategoryReports(t *testing.T) {
+	account := createRandomAccount(t)
+	_, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+		LedgerID:    account.LedgerID,
+		CategoryID:  account.CategoryID,
+		Title:       util.RandomString(12),
+		Type:        account.Type,
+		Description: util.RandomString(20),
+		Amount:      250,
+		Currency:    account.Currency,
+		Date:        account.Date,
+		WalletID:    account.WalletID,
+	})
+	require.NoError(t, err)
+
+	arg := GetCategoryReportsParams{
+		LedgerID: account.LedgerID,
+		Type:     account.Type,
+	}
+	rows, err := testQueries.GetCategoryReports(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, account.CategoryID.Int32, rows[0].CategoryID)
+	require.Equal(t, account.Currency, rows[0].Currency)
+	require.Equal(t, account.Amount+250, rows[0].Amount)
+
+	byDate, err := testQueries.GetAccountsReportsByDate(context.Background(), GetAccountsReportsByDateParams(arg))
+	require.NoError(t, err)
+	require.Len(t, byDate, 1)
+	require.Equal(t, account.Amount+250, byDate[0].Amount)
+}
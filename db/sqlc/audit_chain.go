@@ -0,0 +1,89 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Outcomes of an audited action.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// auditEventContent is what an entry's hash covers, in a fixed order.
+type auditEventContent struct {
+	PrevHash  string `json:"prev_hash"`
+	CreatedAt string `json:"created_at"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	ActorID   string `json:"actor_id"`
+	Actor     string `json:"actor"`
+	Type      string `json:"type"`
+	Outcome   string `json:"outcome"`
+	ClientIp  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+	Details   string `json:"details"`
+}
+
+func nullInt32String(n sql.NullInt32) string {
+	if !n.Valid {
+		return ""
+	}
+	return strconv.FormatInt(int64(n.Int32), 10)
+}
+
+// AuditEventHash returns the hex SHA-256 of the entry's content and the
+// hash of the entry before it. The id is not covered; the order of the
+// log is given by the prev_hash links.
+func AuditEventHash(event AuditEvent) string {
+	content, _ := json.Marshal(auditEventContent{
+		PrevHash:  event.PrevHash,
+		CreatedAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		UserID:    nullInt32String(event.UserID),
+		Username:  event.Username,
+		ActorID:   nullInt32String(event.ActorID),
+		Actor:     event.Actor,
+		Type:      event.Type,
+		Outcome:   event.Outcome,
+		ClientIp:  event.ClientIp,
+		UserAgent: event.UserAgent,
+		Details:   event.Details,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLogVerification is the result of checking the audit log's hash
+// chain from its first entry.
+type AuditLogVerification struct {
+	Events   int64
+	HeadHash string
+	// FirstInvalidID is the id of the first entry whose hash does not
+	// match its content or whose prev_hash does not match the entry
+	// before it, or 0 when the whole chain checks out.
+	FirstInvalidID int64
+}
+
+func (v *AuditLogVerification) Valid() bool {
+	return v.FirstInvalidID == 0
+}
+
+// Check adds the next entries of the log, in id order, to the
+// verification. It stops at the first broken link and reports whether the
+// chain is still intact.
+func (v *AuditLogVerification) Check(events []AuditEvent) bool {
+	for _, event := range events {
+		if event.PrevHash != v.HeadHash || AuditEventHash(event) != event.Hash {
+			v.FirstInvalidID = event.ID
+			return false
+		}
+		v.HeadHash = event.Hash
+		v.Events++
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func appendRandomAuditEvent(t *testing.T) AuditEvent {
+	user := createRandomUser(t)
+	arg := AppendAuditEventTxParams{
+		UserID:    sql.NullInt32{Int32: user.ID, Valid: true},
+		Username:  user.Username,
+		ActorID:   sql.NullInt32{Int32: user.ID, Valid: true},
+		Actor:     user.Username,
+		Type:      "login",
+		Outcome:   AuditOutcomeSuccess,
+		ClientIp:  "192.0.2.1",
+		UserAgent: "test",
+		Details:   util.RandomString(12),
+	}
+
+	event, err := testStore.AppendAuditEventTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, event.ID)
+	require.Equal(t, arg.UserID, event.UserID)
+	require.Equal(t, arg.Details, event.Details)
+	require.Equal(t, AuditEventHash(event), event.Hash)
+	return event
+}
+
+func TestAppendAuditEventTx(t *testing.T) {
+	first := appendRandomAuditEvent(t)
+	second := appendRandomAuditEvent(t)
+	require.Equal(t, first.Hash, second.PrevHash)
+
+	events, err := testQueries.ListUserAuditEvents(context.Background(), ListUserAuditEventsParams{
+		UserID:    second.UserID.Int32,
+		PageLimit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, second, events[0])
+}
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	event := appendRandomAuditEvent(t)
+
+	_, err := testStore.db.ExecContext(context.Background(), "UPDATE audit_events SET details = '' WHERE id = $1", event.ID)
+	require.Error(t, err)
+
+	_, err = testStore.db.ExecContext(context.Background(), "DELETE FROM audit_events WHERE id = $1", event.ID)
+	require.Error(t, err)
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	event := appendRandomAuditEvent(t)
+
+	verification, err := testStore.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	require.True(t, verification.Valid())
+	require.Equal(t, event.Hash, verification.HeadHash)
+	require.GreaterOrEqual(t, verification.Events, int64(1))
+}
+
+func TestAuditLogVerificationCheck(t *testing.T) {
+	var events []AuditEvent
+	prevHash := ""
+	for i := 1; i <= 3; i++ {
+		event := AuditEvent{ID: int64(i), Type: "login", Outcome: AuditOutcomeSuccess, PrevHash: prevHash}
+		event.Hash = AuditEventHash(event)
+		events = append(events, event)
+		prevHash = event.Hash
+	}
+
+	var verification AuditLogVerification
+	require.True(t, verification.Check(events))
+	require.True(t, verification.Valid())
+	require.Equal(t, int64(3), verification.Events)
+
+	events[1].Outcome = AuditOutcomeFailure
+	verification = AuditLogVerification{}
+	require.False(t, verification.Check(events))
+	require.Equal(t, int64(2), verification.FirstInvalidID)
+	require.Equal(t, int64(1), verification.Events)
+}
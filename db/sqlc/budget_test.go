@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setRandomBudget(t *testing.T, category Category, month time.Time, amount int64) Budget {
+	arg := SetBudgetParams{
+		LedgerID:   category.LedgerID,
+		CategoryID: category.ID,
+		Month:      month,
+		Amount:     amount,
+		Currency:   "BRL",
+		Rollover:   true,
+	}
+	budget, err := testQueries.SetBudget(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Amount, budget.Amount)
+	require.True(t, arg.Month.Equal(budget.Month))
+	return budget
+}
+
+func TestSetBudgetReplacesTheMonth(t *testing.T) {
+	category := createRandomCategory(t)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	budget := setRandomBudget(t, category, march, 80000)
+	replaced := setRandomBudget(t, category, march, 85000)
+	require.Equal(t, budget.ID, replaced.ID)
+
+	_, err := testQueries.SetBudget(context.Background(), SetBudgetParams{
+		LedgerID:   category.LedgerID,
+		CategoryID: category.ID,
+		Month:      march.AddDate(0, 0, 1),
+		Currency:   "BRL",
+	})
+	require.Error(t, err)
+}
+
+func TestListAndCopyBudgets(t *testing.T) {
+	category := createRandomCategory(t)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	setRandomBudget(t, category, march.AddDate(0, -1, 0), 70000)
+	setRandomBudget(t, category, march, 80000)
+	setRandomBudget(t, category, march.AddDate(0, 1, 0), 90000)
+
+	budgets, err := testQueries.ListBudgetsThrough(context.Background(), ListBudgetsThroughParams{
+		LedgerID: category.LedgerID,
+		Month:    march,
+	})
+	require.NoError(t, err)
+	require.Len(t, budgets, 2)
+	require.Equal(t, int64(80000), budgets[1].Amount)
+
+	may := march.AddDate(0, 2, 0)
+	copied, err := testQueries.CopyBudgets(context.Background(), CopyBudgetsParams{
+		ToMonth:   may,
+		LedgerID:  category.LedgerID,
+		FromMonth: march,
+	})
+	require.NoError(t, err)
+	require.Len(t, copied, 1)
+	require.True(t, may.Equal(copied[0].Month))
+	require.Equal(t, int64(80000), copied[0].Amount)
+	require.True(t, copied[0].Rollover)
+
+	// The category already has a budget for May now.
+	copied, err = testQueries.CopyBudgets(context.Background(), CopyBudgetsParams{
+		ToMonth:   may,
+		LedgerID:  category.LedgerID,
+		FromMonth: march.AddDate(0, 1, 0),
+	})
+	require.NoError(t, err)
+	require.Empty(t, copied)
+}
+
+func TestGetBudgetSpending(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	category, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		LedgerID: wallet.LedgerID,
+		Title:    "Groceries",
+		Type:     "debit",
+	})
+	require.NoError(t, err)
+
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, date := range []time.Time{march, march, march.AddDate(0, 0, 30), march.AddDate(0, 1, 0)} {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			LedgerID:   wallet.LedgerID,
+			CategoryID: sql.NullInt32{Int32: category.ID, Valid: true},
+			Title:      "Market",
+			Type:       category.Type,
+			Amount:     1000,
+			Currency:   wallet.Currency,
+			Date:       date,
+			WalletID:   wallet.ID,
+		})
+		require.NoError(t, err)
+	}
+
+	rows, err := testQueries.GetBudgetSpending(context.Background(), GetBudgetSpendingParams{
+		LedgerID: wallet.LedgerID,
+		FromDate: march,
+		ToDate:   march.AddDate(0, 1, -1),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, category.ID, rows[0].CategoryID)
+	require.Equal(t, int64(2000), rows[0].Amount)
+	require.Equal(t, int64(1000), rows[1].Amount)
+}
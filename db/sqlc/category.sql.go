@@ -7,29 +7,33 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createCategory = `-- name: CreateCategory :one
 INSERT INTO categories (
-    user_id,
+    ledger_id,
+    created_by,
     title,
     type,
     description
 ) VALUES (
-    $1, $2, $3, $4
-) RETURNING id, user_id, title, type, description, created_at
+    $1, $2, $3, $4, $5
+) RETURNING id, created_by, title, type, description, created_at, ledger_id
 `
 
 type CreateCategoryParams struct {
-	UserID      int32  `json:"user_id"`
-	Title       string `json:"title"`
-	Type        string `json:"type"`
-	Description string `json:"description"`
+	LedgerID    int32         `json:"ledger_id"`
+	CreatedBy   sql.NullInt32 `json:"created_by"`
+	Title       string        `json:"title"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
 }
 
 func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
 	row := q.db.QueryRowContext(ctx, createCategory,
-		arg.UserID,
+		arg.LedgerID,
+		arg.CreatedBy,
 		arg.Title,
 		arg.Type,
 		arg.Description,
@@ -37,29 +41,38 @@ func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams)
 	var i Category
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.CreatedBy,
 		&i.Title,
 		&i.Type,
 		&i.Description,
 		&i.CreatedAt,
+		&i.LedgerID,
 	)
 	return i, err
 }
 
-const deleteCategories = `-- name: DeleteCategories :exec
+const deleteCategories = `-- name: DeleteCategories :execrows
 DELETE FROM categories 
-WHERE id = $1
+WHERE id = $1 AND ledger_id = $2
 `
 
-func (q *Queries) DeleteCategories(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteCategories, id)
-	return err
+type DeleteCategoriesParams struct {
+	ID       int32 `json:"id"`
+	LedgerID int32 `json:"ledger_id"`
+}
+
+func (q *Queries) DeleteCategories(ctx context.Context, arg DeleteCategoriesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCategories, arg.ID, arg.LedgerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getCategories = `-- name: GetCategories :many
-SELECT id, user_id, title, type, description, created_at FROM categories
+SELECT id, created_by, title, type, description, created_at, ledger_id FROM categories
 WHERE
-  user_id = $1
+  ledger_id = $1
 AND
   type = $2
 AND
@@ -69,7 +82,7 @@ AND
 `
 
 type GetCategoriesParams struct {
-	UserID      int32  `json:"user_id"`
+	LedgerID    int32  `json:"ledger_id"`
 	Type        string `json:"type"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
@@ -77,7 +90,7 @@ type GetCategoriesParams struct {
 
 func (q *Queries) GetCategories(ctx context.Context, arg GetCategoriesParams) ([]Category, error) {
 	rows, err := q.db.QueryContext(ctx, getCategories,
-		arg.UserID,
+		arg.LedgerID,
 		arg.Type,
 		arg.Title,
 		arg.Description,
@@ -91,11 +104,12 @@ func (q *Queries) GetCategories(ctx context.Context, arg GetCategoriesParams) ([
 		var i Category
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.CreatedBy,
 			&i.Title,
 			&i.Type,
 			&i.Description,
 			&i.CreatedAt,
+			&i.LedgerID,
 		); err != nil {
 			return nil, err
 		}
@@ -110,18 +124,18 @@ func (q *Queries) GetCategories(ctx context.Context, arg GetCategoriesParams) ([
 	return items, nil
 }
 
-const getCategoriesByUserIdAndType = `-- name: GetCategoriesByUserIdAndType :many
-SELECT id, user_id, title, type, description, created_at FROM categories 
-WHERE user_id = $1 AND type = $2
+const getCategoriesByLedgerIdAndType = `-- name: GetCategoriesByLedgerIdAndType :many
+SELECT id, created_by, title, type, description, created_at, ledger_id FROM categories 
+WHERE ledger_id = $1 AND type = $2
 `
 
-type GetCategoriesByUserIdAndTypeParams struct {
-	UserID int32  `json:"user_id"`
-	Type   string `json:"type"`
+type GetCategoriesByLedgerIdAndTypeParams struct {
+	LedgerID int32  `json:"ledger_id"`
+	Type     string `json:"type"`
 }
 
-func (q *Queries) GetCategoriesByUserIdAndType(ctx context.Context, arg GetCategoriesByUserIdAndTypeParams) ([]Category, error) {
-	rows, err := q.db.QueryContext(ctx, getCategoriesByUserIdAndType, arg.UserID, arg.Type)
+func (q *Queries) GetCategoriesByLedgerIdAndType(ctx context.Context, arg GetCategoriesByLedgerIdAndTypeParams) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoriesByLedgerIdAndType, arg.LedgerID, arg.Type)
 	if err != nil {
 		return nil, err
 	}
@@ -131,11 +145,12 @@ func (q *Queries) GetCategoriesByUserIdAndType(ctx context.Context, arg GetCateg
 		var i Category
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.CreatedBy,
 			&i.Title,
 			&i.Type,
 			&i.Description,
 			&i.CreatedAt,
+			&i.LedgerID,
 		); err != nil {
 			return nil, err
 		}
@@ -150,20 +165,20 @@ func (q *Queries) GetCategoriesByUserIdAndType(ctx context.Context, arg GetCateg
 	return items, nil
 }
 
-const getCategoriesByUserIdAndTypeAndDescription = `-- name: GetCategoriesByUserIdAndTypeAndDescription :many
-SELECT id, user_id, title, type, description, created_at FROM categories 
-WHERE user_id = $1 AND type = $2 
+const getCategoriesByLedgerIdAndTypeAndDescription = `-- name: GetCategoriesByLedgerIdAndTypeAndDescription :many
+SELECT id, created_by, title, type, description, created_at, ledger_id FROM categories 
+WHERE ledger_id = $1 AND type = $2 
 AND description LIKE $3
 `
 
-type GetCategoriesByUserIdAndTypeAndDescriptionParams struct {
-	UserID      int32  `json:"user_id"`
+type GetCategoriesByLedgerIdAndTypeAndDescriptionParams struct {
+	LedgerID    int32  `json:"ledger_id"`
 	Type        string `json:"type"`
 	Description string `json:"description"`
 }
 
-func (q *Queries) GetCategoriesByUserIdAndTypeAndDescription(ctx context.Context, arg GetCategoriesByUserIdAndTypeAndDescriptionParams) ([]Category, error) {
-	rows, err := q.db.QueryContext(ctx, getCategoriesByUserIdAndTypeAndDescription, arg.UserID, arg.Type, arg.Description)
+func (q *Queries) GetCategoriesByLedgerIdAndTypeAndDescription(ctx context.Context, arg GetCategoriesByLedgerIdAndTypeAndDescriptionParams) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoriesByLedgerIdAndTypeAndDescription, arg.LedgerID, arg.Type, arg.Description)
 	if err != nil {
 		return nil, err
 	}
@@ -173,11 +188,12 @@ func (q *Queries) GetCategoriesByUserIdAndTypeAndDescription(ctx context.Context
 		var i Category
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.CreatedBy,
 			&i.Title,
 			&i.Type,
 			&i.Description,
 			&i.CreatedAt,
+			&i.LedgerID,
 		); err != nil {
 			return nil, err
 		}
@@ -192,20 +208,20 @@ func (q *Queries) GetCategoriesByUserIdAndTypeAndDescription(ctx context.Context
 	return items, nil
 }
 
-const getCategoriesByUserIdAndTypeAndTitle = `-- name: GetCategoriesByUserIdAndTypeAndTitle :many
-SELECT id, user_id, title, type, description, created_at FROM categories 
-WHERE user_id = $1 AND type = $2 
+const getCategoriesByLedgerIdAndTypeAndTitle = `-- name: GetCategoriesByLedgerIdAndTypeAndTitle :many
+SELECT id, created_by, title, type, description, created_at, ledger_id FROM categories 
+WHERE ledger_id = $1 AND type = $2 
 AND title LIKE $3
 `
 
-type GetCategoriesByUserIdAndTypeAndTitleParams struct {
-	UserID int32  `json:"user_id"`
-	Type   string `json:"type"`
-	Title  string `json:"title"`
+type GetCategoriesByLedgerIdAndTypeAndTitleParams struct {
+	LedgerID int32  `json:"ledger_id"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
 }
 
-func (q *Queries) GetCategoriesByUserIdAndTypeAndTitle(ctx context.Context, arg GetCategoriesByUserIdAndTypeAndTitleParams) ([]Category, error) {
-	rows, err := q.db.QueryContext(ctx, getCategoriesByUserIdAndTypeAndTitle, arg.UserID, arg.Type, arg.Title)
+func (q *Queries) GetCategoriesByLedgerIdAndTypeAndTitle(ctx context.Context, arg GetCategoriesByLedgerIdAndTypeAndTitleParams) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoriesByLedgerIdAndTypeAndTitle, arg.LedgerID, arg.Type, arg.Title)
 	if err != nil {
 		return nil, err
 	}
@@ -215,11 +231,12 @@ func (q *Queries) GetCategoriesByUserIdAndTypeAndTitle(ctx context.Context, arg
 		var i Category
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.CreatedBy,
 			&i.Title,
 			&i.Type,
 			&i.Description,
 			&i.CreatedAt,
+			&i.LedgerID,
 		); err != nil {
 			return nil, err
 		}
@@ -235,20 +252,26 @@ func (q *Queries) GetCategoriesByUserIdAndTypeAndTitle(ctx context.Context, arg
 }
 
 const getCategory = `-- name: GetCategory :one
-SELECT id, user_id, title, type, description, created_at FROM categories 
-WHERE id = $1 LIMIT 1
+SELECT id, created_by, title, type, description, created_at, ledger_id FROM categories 
+WHERE id = $1 AND ledger_id = $2 LIMIT 1
 `
 
-func (q *Queries) GetCategory(ctx context.Context, id int32) (Category, error) {
-	row := q.db.QueryRowContext(ctx, getCategory, id)
+type GetCategoryParams struct {
+	ID       int32 `json:"id"`
+	LedgerID int32 `json:"ledger_id"`
+}
+
+func (q *Queries) GetCategory(ctx context.Context, arg GetCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategory, arg.ID, arg.LedgerID)
 	var i Category
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.CreatedBy,
 		&i.Title,
 		&i.Type,
 		&i.Description,
 		&i.CreatedAt,
+		&i.LedgerID,
 	)
 	return i, err
 }
@@ -256,26 +279,33 @@ func (q *Queries) GetCategory(ctx context.Context, id int32) (Category, error) {
 const updateCategories = `-- name: UpdateCategories :one
 UPDATE categories 
 SET title = $2, description = $3 
-WHERE id = $1 
-RETURNING id, user_id, title, type, description, created_at
+WHERE id = $1 AND ledger_id = $4 
+RETURNING id, created_by, title, type, description, created_at, ledger_id
 `
 
 type UpdateCategoriesParams struct {
 	ID          int32  `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
+	LedgerID    int32  `json:"ledger_id"`
 }
 
 func (q *Queries) UpdateCategories(ctx context.Context, arg UpdateCategoriesParams) (Category, error) {
-	row := q.db.QueryRowContext(ctx, updateCategories, arg.ID, arg.Title, arg.Description)
+	row := q.db.QueryRowContext(ctx, updateCategories,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.LedgerID,
+	)
 	var i Category
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.CreatedBy,
 		&i.Title,
 		&i.Type,
 		&i.Description,
 		&i.CreatedAt,
+		&i.LedgerID,
 	)
 	return i, err
 }
@@ -2,16 +2,19 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/wil-ckaew/gofinance-backend/util"
 )
 
 func createRandomCategory(t *testing.T) Category {
-	user := createRandomUser(t)
+	ledger := createRandomLedger(t)
 	arg := CreateCategoryParams{
-		UserID:      user.ID,
+		LedgerID:    ledger.ID,
+		CreatedBy:   sql.NullInt32{Int32: ledger.OwnerID, Valid: true},
 		Title:       util.RandomString(12),
 		Type:        "debit",
 		Description: util.RandomString(20),
@@ -21,7 +24,8 @@ func createRandomCategory(t *testing.T) Category {
 	require.NoError(t, err)
 	require.NotEmpty(t, category)
 
-	require.Equal(t, arg.UserID, category.UserID)
+	require.Equal(t, arg.LedgerID, category.LedgerID)
+	require.Equal(t, arg.CreatedBy, category.CreatedBy)
 	require.Equal(t, arg.Title, category.Title)
 	require.Equal(t, arg.Type, category.Type)
 	require.Equal(t, arg.Description, category.Description)
@@ -36,7 +40,10 @@ func TestCreateCategory(t *testing.T) {
 
 func TestGetCategory(t *testing.T) {
 	category1 := createRandomCategory(t)
-	category2, err := testQueries.GetCategory(context.Background(), category1.ID)
+	category2, err := testQueries.GetCategory(context.Background(), GetCategoryParams{
+		ID:       category1.ID,
+		LedgerID: category1.LedgerID,
+	})
 	require.NoError(t, err)
 	require.NotEmpty(t, category2)
 
@@ -47,10 +54,70 @@ func TestGetCategory(t *testing.T) {
 	require.NotEmpty(t, category2.CreatedAt)
 }
 
+func TestGetCategoryOfAnotherLedger(t *testing.T) {
+	category := createRandomCategory(t)
+	otherLedger := createRandomLedger(t)
+
+	_, err := testQueries.GetCategory(context.Background(), GetCategoryParams{
+		ID:       category.ID,
+		LedgerID: otherLedger.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateCategoryUnknownType(t *testing.T) {
+	ledger := createRandomLedger(t)
+	_, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		LedgerID:    ledger.ID,
+		Title:       util.RandomString(12),
+		Type:        "transfer",
+		Description: util.RandomString(20),
+	})
+	require.Error(t, err)
+}
+
 func TestDeleteCategory(t *testing.T) {
 	category := createRandomCategory(t)
-	err := testQueries.DeleteCategories(context.Background(), category.ID)
+	rows, err := testQueries.DeleteCategories(context.Background(), DeleteCategoriesParams{
+		ID:       category.ID,
+		LedgerID: category.LedgerID,
+	})
 	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestDeleteCategoryUsedByRecurringTransaction(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	recurring := createRandomRecurringTransaction(t, wallet, "FREQ=MONTHLY", time.Now())
+
+	_, err := testQueries.DeleteCategories(context.Background(), DeleteCategoriesParams{
+		ID:       recurring.CategoryID,
+		LedgerID: recurring.LedgerID,
+	})
+	require.Equal(t, ForeignKeyViolation, ErrorCode(err))
+
+	_, err = testQueries.GetRecurringTransaction(context.Background(), GetRecurringTransactionParams{
+		ID:       recurring.ID,
+		LedgerID: recurring.LedgerID,
+	})
+	require.NoError(t, err)
+}
+
+func TestDeleteCategoryUsedByInstallmentPlan(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	result := createRandomInstallmentPlan(t, wallet, 120000, 4, time.Now())
+	_, err := testStore.CancelInstallmentPlanTx(context.Background(), CancelInstallmentPlanTxParams{
+		ID:       result.Plan.ID,
+		LedgerID: result.Plan.LedgerID,
+		Today:    time.Now().AddDate(0, 0, -1),
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.DeleteCategories(context.Background(), DeleteCategoriesParams{
+		ID:       result.Plan.CategoryID,
+		LedgerID: result.Plan.LedgerID,
+	})
+	require.Equal(t, ForeignKeyViolation, ErrorCode(err))
 }
 
 func TestUpdateCategory(t *testing.T) {
@@ -60,6 +127,7 @@ func TestUpdateCategory(t *testing.T) {
 		ID:          category1.ID,
 		Title:       util.RandomString(12),
 		Description: util.RandomString(20),
+		LedgerID:    category1.LedgerID,
 	}
 
 	category2, err := testQueries.UpdateCategories(context.Background(), arg)
@@ -76,7 +144,7 @@ func TestListCategories(t *testing.T) {
 	lastCategory := createRandomCategory(t)
 
 	arg := GetCategoriesParams{
-		UserID:      lastCategory.UserID,
+		LedgerID:    lastCategory.LedgerID,
 		Type:        lastCategory.Type,
 		Title:       lastCategory.Title,
 		Description: lastCategory.Description,
@@ -88,7 +156,7 @@ func TestListCategories(t *testing.T) {
 
 	for _, category := range categorys {
 		require.Equal(t, lastCategory.ID, category.ID)
-		require.Equal(t, lastCategory.UserID, category.UserID)
+		require.Equal(t, lastCategory.LedgerID, category.LedgerID)
 		require.Equal(t, lastCategory.Title, category.Title)
 		require.Equal(t, lastCategory.Description, category.Description)
 		require.NotEmpty(t, lastCategory.CreatedAt)
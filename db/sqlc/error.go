@@ -0,0 +1,25 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// UniqueViolation is the PostgreSQL error code of an insert or update
+// that breaks a unique constraint.
+const UniqueViolation = "23505"
+
+// ForeignKeyViolation is the PostgreSQL error code of a delete that would
+// leave rows referencing the deleted one.
+const ForeignKeyViolation = "23503"
+
+// ErrorCode returns the PostgreSQL error code of err, or "" when err did
+// not come from the database.
+func ErrorCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
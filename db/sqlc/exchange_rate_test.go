@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+// randomCurrencyPair returns two made-up currency codes so tests do not
+// see each other's rates.
+func randomCurrencyPair() (string, string) {
+	from := strings.ToUpper(util.RandomString(3))
+	to := strings.ToUpper(util.RandomString(3))
+	for to == from {
+		to = strings.ToUpper(util.RandomString(3))
+	}
+	return from, to
+}
+
+func day(date string) time.Time {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestUpsertExchangeRate(t *testing.T) {
+	from, to := randomCurrencyPair()
+	arg := UpsertExchangeRateParams{
+		FromCurrency: from,
+		ToCurrency:   to,
+		Date:         day("2026-03-02"),
+		Rate:         "5.1234",
+	}
+
+	rate1, err := testQueries.UpsertExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, from, rate1.FromCurrency)
+	require.Equal(t, to, rate1.ToCurrency)
+	require.True(t, arg.Date.Equal(rate1.Date))
+	require.Equal(t, "5.1234000000", rate1.Rate)
+
+	arg.Rate = "5.2"
+	rate2, err := testQueries.UpsertExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, "5.2000000000", rate2.Rate)
+
+	rates, err := testQueries.ListExchangeRates(context.Background(), ListExchangeRatesParams{
+		Currency:  sql.NullString{String: to, Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+	require.Equal(t, rate2.Rate, rates[0].Rate)
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	from, to := randomCurrencyPair()
+	for _, arg := range []UpsertExchangeRateParams{
+		{FromCurrency: from, ToCurrency: to, Date: day("2026-03-02"), Rate: "5"},
+		{FromCurrency: to, ToCurrency: from, Date: day("2026-03-04"), Rate: "0.25"},
+		{FromCurrency: from, ToCurrency: to, Date: day("2026-03-04"), Rate: "4"},
+	} {
+		_, err := testQueries.UpsertExchangeRate(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	get := func(from, to, date string) (ExchangeRate, error) {
+		return testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+			FromCurrency: from,
+			ToCurrency:   to,
+			Date:         day(date),
+		})
+	}
+
+	// Days without a rate use the latest earlier one.
+	rate, err := get(from, to, "2026-03-03")
+	require.NoError(t, err)
+	require.True(t, day("2026-03-02").Equal(rate.Date))
+
+	// Rates in the asked direction win over inverse ones of the same day.
+	rate, err = get(from, to, "2026-03-10")
+	require.NoError(t, err)
+	require.Equal(t, from, rate.FromCurrency)
+	require.Equal(t, "4.0000000000", rate.Rate)
+
+	rate, err = get(to, from, "2026-03-10")
+	require.NoError(t, err)
+	require.Equal(t, to, rate.FromCurrency)
+
+	rate, err = get(to, from, "2026-03-03")
+	require.NoError(t, err)
+	require.Equal(t, from, rate.FromCurrency)
+
+	_, err = get(from, to, "2026-03-01")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestImportExchangeRatesTx(t *testing.T) {
+	from, to := randomCurrencyPair()
+	rates := []UpsertExchangeRateParams{
+		{FromCurrency: from, ToCurrency: to, Date: day("2026-03-02"), Rate: "5"},
+		{FromCurrency: from, ToCurrency: to, Date: day("2026-03-03"), Rate: "5.1"},
+	}
+
+	n, err := testStore.ImportExchangeRatesTx(context.Background(), rates)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// A rejected row rolls the whole import back.
+	_, err = testStore.ImportExchangeRatesTx(context.Background(), []UpsertExchangeRateParams{
+		{FromCurrency: from, ToCurrency: to, Date: day("2026-03-04"), Rate: "5.2"},
+		{FromCurrency: from, ToCurrency: to, Date: day("2026-03-05"), Rate: "-1"},
+	})
+	require.Error(t, err)
+
+	stored, err := testQueries.ListExchangeRates(context.Background(), ListExchangeRatesParams{
+		Currency:  sql.NullString{String: from, Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListGoalContributions(t *testing.T) {
+	ledger := createRandomLedger(t)
+	checking := createRandomWallet(t, ledger.ID, "BRL")
+	savings := createRandomWallet(t, ledger.ID, "BRL")
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	goal, err := testQueries.CreateGoal(context.Background(), CreateGoalParams{
+		LedgerID:     ledger.ID,
+		WalletID:     sql.NullInt32{Int32: savings.ID, Valid: true},
+		Title:        "Trip",
+		TargetAmount: 500000,
+		Currency:     "BRL",
+		StartsOn:     march,
+	})
+	require.NoError(t, err)
+
+	in := createRandomTransfer(t, checking, savings, 100000)
+	out := createRandomTransfer(t, savings, checking, 20000)
+	category, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		LedgerID: ledger.ID,
+		Title:    "Savings",
+		Type:     "debit",
+	})
+	require.NoError(t, err)
+	deposit, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+		LedgerID:   ledger.ID,
+		CategoryID: sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:      "Deposit",
+		Type:       category.Type,
+		Amount:     30000,
+		Currency:   "BRL",
+		Date:       march.AddDate(0, 0, 1),
+		WalletID:   checking.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.LinkGoalContribution(context.Background(), LinkGoalContributionParams{GoalID: goal.ID, AccountID: deposit.ID})
+	require.NoError(t, err)
+	_, err = testQueries.LinkGoalContribution(context.Background(), LinkGoalContributionParams{GoalID: goal.ID, AccountID: deposit.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	contributions, err := testQueries.ListGoalContributions(context.Background(), ListGoalContributionsParams{
+		GoalID:   goal.ID,
+		WalletID: goal.WalletID.Int32,
+		StartsOn: goal.StartsOn,
+	})
+	require.NoError(t, err)
+	require.Len(t, contributions, 3)
+	amounts := map[int32]int64{}
+	for _, contribution := range contributions {
+		amounts[contribution.AccountID] = contribution.Amount
+	}
+	require.Equal(t, int64(100000), amounts[in.To.ID])
+	require.Equal(t, int64(-20000), amounts[out.From.ID])
+	require.Equal(t, int64(30000), amounts[deposit.ID])
+
+	// Transfers from before the goal started do not count.
+	contributions, err = testQueries.ListGoalContributions(context.Background(), ListGoalContributionsParams{
+		GoalID:   goal.ID,
+		WalletID: goal.WalletID.Int32,
+		StartsOn: march.AddDate(0, 0, 1),
+	})
+	require.NoError(t, err)
+	require.Len(t, contributions, 1)
+}
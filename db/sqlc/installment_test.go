@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomInstallmentPlan(t *testing.T, wallet Wallet, total int64, installments int32, firstDate time.Time) InstallmentPlanTxResult {
+	category, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		LedgerID:    wallet.LedgerID,
+		Title:       util.RandomString(12),
+		Type:        "debit",
+		Description: util.RandomString(20),
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.CreateInstallmentPlanTx(context.Background(), CreateInstallmentPlanParams{
+		LedgerID:     wallet.LedgerID,
+		CategoryID:   category.ID,
+		WalletID:     wallet.ID,
+		Title:        "Laptop",
+		Type:         category.Type,
+		Description:  util.RandomString(20),
+		TotalAmount:  total,
+		Currency:     wallet.Currency,
+		Installments: installments,
+		FirstDate:    firstDate,
+		Remainder:    "first",
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Installments, int(installments))
+	return result
+}
+
+func TestInstallmentDate(t *testing.T) {
+	first := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, first, installmentDate(first, 1))
+	require.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), installmentDate(first, 2))
+	require.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), installmentDate(first, 3))
+	require.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), installmentDate(first, 13))
+}
+
+func TestCreateInstallmentPlanTx(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	first := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	result := createRandomInstallmentPlan(t, wallet, 100000, 3, first)
+
+	require.Equal(t, int64(33334), result.Installments[0].Amount)
+	require.Equal(t, int64(33333), result.Installments[2].Amount)
+	require.Equal(t, "Laptop (2/3)", result.Installments[1].Title)
+	require.True(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC).Equal(result.Installments[1].Date))
+
+	for _, account := range result.Installments {
+		_, err := testQueries.GetAccountJournalEntry(context.Background(), sql.NullInt32{Int32: account.ID, Valid: true})
+		require.NoError(t, err)
+	}
+}
+
+func TestUpdateInstallmentPlanTxKeepsDueInstallments(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	first := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	plan := createRandomInstallmentPlan(t, wallet, 120000, 4, first).Plan
+	today := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	result, err := testStore.UpdateInstallmentPlanTx(context.Background(), UpdateInstallmentPlanTxParams{
+		ID:           plan.ID,
+		LedgerID:     plan.LedgerID,
+		Title:        "Notebook",
+		Description:  plan.Description,
+		TotalAmount:  160001,
+		Installments: 5,
+		Today:        today,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Installments, 5)
+	require.Equal(t, "Laptop (2/4)", result.Installments[1].Title)
+	require.Equal(t, int64(30000), result.Installments[1].Amount)
+	require.Equal(t, "Notebook (3/5)", result.Installments[2].Title)
+	require.Equal(t, int64(33335), result.Installments[2].Amount)
+	require.Equal(t, int64(33333), result.Installments[4].Amount)
+	require.True(t, time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC).Equal(result.Installments[4].Date))
+
+	result, err = testStore.UpdateInstallmentPlanTx(context.Background(), UpdateInstallmentPlanTxParams{
+		ID:           plan.ID,
+		LedgerID:     plan.LedgerID,
+		Title:        "Notebook",
+		TotalAmount:  160001,
+		Installments: 3,
+		Today:        today,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Installments, 3)
+	require.Equal(t, int64(100001), result.Installments[2].Amount)
+
+	for _, arg := range []UpdateInstallmentPlanTxParams{
+		{Installments: 2, TotalAmount: 160001},
+		{Installments: 3, TotalAmount: 60000},
+	} {
+		arg.ID, arg.LedgerID, arg.Today = plan.ID, plan.LedgerID, today
+		_, err = testStore.UpdateInstallmentPlanTx(context.Background(), arg)
+		require.ErrorIs(t, err, ErrInstallmentsDue)
+	}
+}
+
+func TestCancelInstallmentPlanTx(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	first := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	created := createRandomInstallmentPlan(t, wallet, 120000, 4, first)
+	arg := CancelInstallmentPlanTxParams{
+		ID:       created.Plan.ID,
+		LedgerID: created.Plan.LedgerID,
+		Today:    time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	result, err := testStore.CancelInstallmentPlanTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Plan.CancelledAt.Valid)
+	require.Len(t, result.Installments, 2)
+
+	_, err = testQueries.GetAccount(context.Background(), GetAccountParams{ID: created.Installments[3].ID, LedgerID: wallet.LedgerID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testStore.CancelInstallmentPlanTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInstallmentPlanCancelled)
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func requirePostings(t *testing.T, entry JournalEntry, want []CreatePostingParams) {
+	postings, err := testQueries.ListPostings(context.Background(), entry.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, len(want))
+	for i, posting := range postings {
+		require.Equal(t, entry.ID, posting.EntryID)
+		require.Equal(t, want[i].WalletID, posting.WalletID)
+		require.Equal(t, want[i].CategoryID, posting.CategoryID)
+		require.Equal(t, want[i].Amount, posting.Amount)
+		require.Equal(t, want[i].Currency, posting.Currency)
+	}
+}
+
+func TestAccountJournalEntry(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	income := createWalletTransaction(t, wallet, "credit", 5000, time.Now())
+
+	entry, err := testQueries.GetAccountJournalEntry(context.Background(), sql.NullInt32{Int32: income.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, wallet.LedgerID, entry.LedgerID)
+	requirePostings(t, entry, []CreatePostingParams{
+		{WalletID: sql.NullInt32{Int32: wallet.ID, Valid: true}, Amount: 5000, Currency: "BRL"},
+		{CategoryID: income.CategoryID, Amount: -5000, Currency: "BRL"},
+	})
+
+	// An edit replaces the entry, moving the wallet posting along.
+	other := createRandomWallet(t, wallet.LedgerID, "USD")
+	_, err = testStore.UpdateAccountTx(context.Background(), UpdateAccountParams{
+		ID:          income.ID,
+		Title:       income.Title,
+		Description: income.Description,
+		Amount:      700,
+		Currency:    "USD",
+		WalletID:    other.ID,
+		LedgerID:    income.LedgerID,
+	})
+	require.NoError(t, err)
+
+	entry, err = testQueries.GetAccountJournalEntry(context.Background(), sql.NullInt32{Int32: income.ID, Valid: true})
+	require.NoError(t, err)
+	requirePostings(t, entry, []CreatePostingParams{
+		{WalletID: sql.NullInt32{Int32: other.ID, Valid: true}, Amount: 700, Currency: "USD"},
+		{CategoryID: income.CategoryID, Amount: -700, Currency: "USD"},
+	})
+
+	_, err = testQueries.DeleteAccount(context.Background(), DeleteAccountParams{ID: income.ID, LedgerID: income.LedgerID})
+	require.NoError(t, err)
+	_, err = testQueries.GetAccountJournalEntry(context.Background(), sql.NullInt32{Int32: income.ID, Valid: true})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferJournalEntry(t *testing.T) {
+	ledger := createRandomLedger(t)
+	checking := createRandomWallet(t, ledger.ID, "BRL")
+	dollars := createRandomWallet(t, ledger.ID, "USD")
+
+	transfer, err := testStore.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		LedgerID: ledger.ID,
+		Title:    util.RandomString(12),
+		Date:     time.Now(),
+		From:     TransferLegParams{WalletID: checking.ID, Amount: 5000, Currency: "BRL"},
+		To:       TransferLegParams{WalletID: dollars.ID, Amount: 1000, Currency: "USD"},
+	})
+	require.NoError(t, err)
+
+	entry, err := testQueries.GetTransferJournalEntry(context.Background(), sql.NullInt32{Int32: transfer.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	requirePostings(t, entry, []CreatePostingParams{
+		{WalletID: sql.NullInt32{Int32: checking.ID, Valid: true}, Amount: -5000, Currency: "BRL"},
+		{WalletID: sql.NullInt32{Int32: dollars.ID, Valid: true}, Amount: 1000, Currency: "USD"},
+		{Amount: 5000, Currency: "BRL"},
+		{Amount: -1000, Currency: "USD"},
+	})
+
+	wallets, err := testQueries.ListWallets(context.Background(), ledger.ID)
+	require.NoError(t, err)
+	for _, wallet := range wallets {
+		if wallet.ID == checking.ID {
+			require.Equal(t, checking.OpeningBalance-5000, wallet.Balance)
+		} else {
+			require.Equal(t, dollars.OpeningBalance+1000, wallet.Balance)
+		}
+	}
+}
+
+func TestUnbalancedJournalEntryIsRejected(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	account := createWalletTransaction(t, wallet, "debit", 100, time.Now())
+
+	err := testStore.execTx(context.Background(), func(q *Queries) error {
+		err := q.DeleteAccountJournalEntry(context.Background(), sql.NullInt32{Int32: account.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		return postJournalEntry(context.Background(), q, CreateJournalEntryParams{
+			LedgerID:  account.LedgerID,
+			AccountID: sql.NullInt32{Int32: account.ID, Valid: true},
+			Date:      account.Date,
+		}, []CreatePostingParams{
+			{WalletID: sql.NullInt32{Int32: wallet.ID, Valid: true}, Amount: -100, Currency: "BRL"},
+			{CategoryID: account.CategoryID, Amount: 90, Currency: "BRL"},
+		})
+	})
+	require.Error(t, err)
+
+	// The original entry survives the failed commit.
+	_, err = testQueries.GetAccountJournalEntry(context.Background(), sql.NullInt32{Int32: account.ID, Valid: true})
+	require.NoError(t, err)
+}
+
+func TestVerifyJournal(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	createWalletTransaction(t, wallet, "credit", 5000, time.Now())
+	createWalletTransaction(t, wallet, "debit", 1200, time.Now())
+
+	verification, err := testStore.VerifyJournal(context.Background())
+	require.NoError(t, err)
+	for _, mismatch := range verification.WalletMismatches {
+		require.NotEqual(t, wallet.ID, mismatch.WalletID)
+	}
+	for _, account := range verification.UnjournaledAccounts {
+		require.NotEqual(t, wallet.LedgerID, account.LedgerID)
+	}
+
+	// A transaction recorded around the journal is caught.
+	category := createRandomCategory(t)
+	bypass := createRandomWallet(t, category.LedgerID, "BRL")
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		LedgerID:    category.LedgerID,
+		CategoryID:  sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:       util.RandomString(12),
+		Type:        "debit",
+		Description: util.RandomString(20),
+		Amount:      300,
+		Currency:    "BRL",
+		Date:        time.Now(),
+		WalletID:    bypass.ID,
+	})
+	require.NoError(t, err)
+
+	verification, err = testStore.VerifyJournal(context.Background())
+	require.NoError(t, err)
+	require.False(t, verification.Balanced())
+	require.Contains(t, verification.UnjournaledAccounts, ListUnjournaledAccountsRow{ID: account.ID, LedgerID: account.LedgerID})
+	require.Contains(t, verification.WalletMismatches, ListWalletJournalMismatchesRow{
+		WalletID:          bypass.ID,
+		LedgerID:          bypass.LedgerID,
+		JournalTotal:      0,
+		TransactionsTotal: -300,
+	})
+
+	_, err = testQueries.DeleteAccount(context.Background(), DeleteAccountParams{ID: account.ID, LedgerID: account.LedgerID})
+	require.NoError(t, err)
+}
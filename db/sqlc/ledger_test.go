@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomLedger(t *testing.T) Ledger {
+	owner := createRandomUser(t)
+	arg := CreateLedgerParams{
+		Name:    util.RandomString(10),
+		OwnerID: owner.ID,
+	}
+
+	ledger, err := testStore.CreateLedgerTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, ledger.ID)
+	require.Equal(t, arg.Name, ledger.Name)
+	require.Equal(t, arg.OwnerID, ledger.OwnerID)
+	require.False(t, ledger.Personal)
+	require.NotZero(t, ledger.CreatedAt)
+
+	return ledger
+}
+
+func addRandomLedgerMember(t *testing.T, ledger Ledger, role string) LedgerMember {
+	user := createRandomUser(t)
+	member, err := testQueries.CreateLedgerMember(context.Background(), CreateLedgerMemberParams{
+		LedgerID: ledger.ID,
+		UserID:   user.ID,
+		Role:     role,
+	})
+	require.NoError(t, err)
+	return member
+}
+
+func TestCreateLedgerTx(t *testing.T) {
+	ledger := createRandomLedger(t)
+
+	member, err := testQueries.GetLedgerMember(context.Background(), GetLedgerMemberParams{
+		LedgerID: ledger.ID,
+		UserID:   ledger.OwnerID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, LedgerRoleOwner, member.Role)
+}
+
+func TestEnsurePersonalLedgerTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	ledger1, err := testStore.EnsurePersonalLedgerTx(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.True(t, ledger1.Personal)
+	require.Equal(t, PersonalLedgerName, ledger1.Name)
+	require.Equal(t, user.ID, ledger1.OwnerID)
+
+	ledger2, err := testStore.EnsurePersonalLedgerTx(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, ledger1, ledger2)
+
+	ledgers, err := testQueries.ListUserLedgers(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, ledgers, 1)
+	require.Equal(t, ledger1.ID, ledgers[0].ID)
+	require.Equal(t, LedgerRoleOwner, ledgers[0].Role)
+}
+
+func TestLedgerMemberRoles(t *testing.T) {
+	ledger := createRandomLedger(t)
+	member := addRandomLedgerMember(t, ledger, LedgerRoleViewer)
+
+	updated, err := testQueries.UpdateLedgerMemberRole(context.Background(), UpdateLedgerMemberRoleParams{
+		LedgerID: ledger.ID,
+		UserID:   member.UserID,
+		Role:     LedgerRoleEditor,
+	})
+	require.NoError(t, err)
+	require.Equal(t, LedgerRoleEditor, updated.Role)
+
+	// The owner can neither be demoted nor removed.
+	_, err = testQueries.UpdateLedgerMemberRole(context.Background(), UpdateLedgerMemberRoleParams{
+		LedgerID: ledger.ID,
+		UserID:   ledger.OwnerID,
+		Role:     LedgerRoleViewer,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err := testQueries.DeleteLedgerMember(context.Background(), DeleteLedgerMemberParams{
+		LedgerID: ledger.ID,
+		UserID:   ledger.OwnerID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	members, err := testQueries.ListLedgerMembers(context.Background(), ledger.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+
+	rows, err = testQueries.DeleteLedgerMember(context.Background(), DeleteLedgerMemberParams{
+		LedgerID: ledger.ID,
+		UserID:   member.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestAcceptLedgerInvitationTx(t *testing.T) {
+	ledger := createRandomLedger(t)
+	invitee := createRandomUser(t)
+
+	invitation, err := testQueries.CreateLedgerInvitation(context.Background(), CreateLedgerInvitationParams{
+		LedgerID:  ledger.ID,
+		Email:     invitee.Email,
+		Role:      LedgerRoleEditor,
+		TokenHash: util.RandomString(32),
+		InvitedBy: ledger.OwnerID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	pending, err := testQueries.ListLedgerInvitations(context.Background(), ledger.ID)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	arg := AcceptLedgerInvitationTxParams{
+		InvitationID: invitation.ID,
+		LedgerID:     ledger.ID,
+		UserID:       invitee.ID,
+		Role:         invitation.Role,
+	}
+	member, err := testStore.AcceptLedgerInvitationTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, invitee.ID, member.UserID)
+	require.Equal(t, LedgerRoleEditor, member.Role)
+
+	_, err = testStore.AcceptLedgerInvitationTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrLedgerInvitationUsed)
+
+	pending, err = testQueries.ListLedgerInvitations(context.Background(), ledger.ID)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestPurgedMemberKeepsLedgerRows(t *testing.T) {
+	ledger := createRandomLedger(t)
+	member := addRandomLedgerMember(t, ledger, LedgerRoleEditor)
+
+	category, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		LedgerID:    ledger.ID,
+		CreatedBy:   sql.NullInt32{Int32: member.UserID, Valid: true},
+		Title:       util.RandomString(12),
+		Type:        "debit",
+		Description: util.RandomString(20),
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.DeleteUserTx(context.Background(), member.UserID)
+	require.NoError(t, err)
+	_, err = testQueries.PurgeDeletedUsers(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	category, err = testQueries.GetCategory(context.Background(), GetCategoryParams{
+		ID:       category.ID,
+		LedgerID: ledger.ID,
+	})
+	require.NoError(t, err)
+	require.False(t, category.CreatedBy.Valid)
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	key := "user:" + util.RandomString(8)
+	arg := RecordLoginFailureParams{Key: key, ResetBefore: time.Now().Add(-time.Hour)}
+
+	throttle, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), throttle.Failures)
+	require.False(t, throttle.LockedUntil.Valid)
+
+	throttle, err = testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), throttle.Failures)
+
+	// Failures older than the window start a new count.
+	throttle, err = testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), throttle.Failures)
+}
+
+func TestLockAndClearLogin(t *testing.T) {
+	key := "ip:" + util.RandomString(8)
+	_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{Key: key, ResetBefore: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+
+	lockedUntil := time.Now().Add(time.Hour)
+	err = testQueries.LockLogin(context.Background(), LockLoginParams{
+		Key:         key,
+		LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+	})
+	require.NoError(t, err)
+
+	throttle, err := testQueries.GetLoginThrottle(context.Background(), key)
+	require.NoError(t, err)
+	require.Zero(t, throttle.Failures)
+	require.WithinDuration(t, lockedUntil, throttle.LockedUntil.Time, time.Second)
+
+	require.NoError(t, testQueries.ClearLoginThrottle(context.Background(), key))
+	_, err = testQueries.GetLoginThrottle(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
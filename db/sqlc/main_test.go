@@ -15,6 +15,7 @@ const (
 )
 
 var testQueries *Queries
+var testStore *SQLStore
 
 func TestMain(m *testing.M) {
 	conn, err := sql.Open(dbDriver, dbSource)
@@ -22,5 +23,6 @@ func TestMain(m *testing.M) {
 		log.Fatal("cannot connect to db: ", err)
 	}
 	testQueries = New(conn)
+	testStore = NewStore(conn)
 	os.Exit(m.Run())
 }
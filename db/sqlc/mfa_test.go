@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomMfaTotp(t *testing.T) MfaTotp {
+	arg := UpsertMfaTotpParams{
+		UserID: createRandomUser(t).ID,
+		Secret: util.RandomString(32),
+	}
+
+	totp, err := testQueries.UpsertMfaTotp(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.UserID, totp.UserID)
+	require.Equal(t, arg.Secret, totp.Secret)
+	require.False(t, totp.ConfirmedAt.Valid)
+	require.Zero(t, totp.LastUsedStep)
+
+	return totp
+}
+
+func TestUpsertMfaTotp(t *testing.T) {
+	totp1 := createRandomMfaTotp(t)
+
+	arg := UpsertMfaTotpParams{UserID: totp1.UserID, Secret: util.RandomString(32)}
+	totp2, err := testQueries.UpsertMfaTotp(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Secret, totp2.Secret)
+
+	rows, err := testQueries.ConfirmMfaTotp(context.Background(), totp1.UserID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// A confirmed secret cannot be replaced by a new enrollment.
+	_, err = testQueries.UpsertMfaTotp(context.Background(), UpsertMfaTotpParams{UserID: totp1.UserID, Secret: util.RandomString(32)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	totp3, err := testQueries.GetMfaTotp(context.Background(), totp1.UserID)
+	require.NoError(t, err)
+	require.Equal(t, totp2.Secret, totp3.Secret)
+	require.True(t, totp3.ConfirmedAt.Valid)
+}
+
+func TestUseMfaTotpStep(t *testing.T) {
+	totp := createRandomMfaTotp(t)
+
+	arg := UseMfaTotpStepParams{Step: 1000, UserID: totp.UserID}
+	rows, err := testQueries.UseMfaTotpStep(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseMfaTotpStep(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.UseMfaTotpStep(context.Background(), UseMfaTotpStepParams{Step: 999, UserID: totp.UserID})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestConfirmAndDisableMfaTx(t *testing.T) {
+	totp := createRandomMfaTotp(t)
+	codes := []string{util.RandomString(20), util.RandomString(20)}
+
+	arg := ConfirmMfaTxParams{UserID: totp.UserID, RecoveryCodeHashes: codes}
+	require.NoError(t, testStore.ConfirmMfaTx(context.Background(), arg))
+	require.ErrorIs(t, testStore.ConfirmMfaTx(context.Background(), arg), ErrMfaAlreadyConfirmed)
+
+	useArg := UseMfaRecoveryCodeParams{UserID: totp.UserID, CodeHash: codes[0]}
+	rows, err := testQueries.UseMfaRecoveryCode(context.Background(), useArg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseMfaRecoveryCode(context.Background(), useArg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	require.NoError(t, testStore.DisableMfaTx(context.Background(), totp.UserID))
+
+	_, err = testQueries.GetMfaTotp(context.Background(), totp.UserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	rows, err = testQueries.UseMfaRecoveryCode(context.Background(), UseMfaRecoveryCodeParams{UserID: totp.UserID, CodeHash: codes[1]})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
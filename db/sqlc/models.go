@@ -5,34 +5,305 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
 type Account struct {
-	ID          int32     `json:"id"`
-	UserID      int32     `json:"user_id"`
-	CategoryID  int32     `json:"category_id"`
-	Title       string    `json:"title"`
-	Type        string    `json:"type"`
-	Description string    `json:"description"`
-	Value       int32     `json:"value"`
-	Date        time.Time `json:"date"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int32         `json:"id"`
+	CreatedBy   sql.NullInt32 `json:"created_by"`
+	CategoryID  sql.NullInt32 `json:"category_id"`
+	Title       string        `json:"title"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	Amount      int64         `json:"amount"`
+	Date        time.Time     `json:"date"`
+	CreatedAt   time.Time     `json:"created_at"`
+	LedgerID    int32         `json:"ledger_id"`
+	Currency    string        `json:"currency"`
+	WalletID    int32         `json:"wallet_id"`
+	TransferID  sql.NullInt32 `json:"transfer_id"`
+}
+
+type AuditEvent struct {
+	ID        int64         `json:"id"`
+	UserID    sql.NullInt32 `json:"user_id"`
+	Username  string        `json:"username"`
+	ActorID   sql.NullInt32 `json:"actor_id"`
+	Actor     string        `json:"actor"`
+	Type      string        `json:"type"`
+	Outcome   string        `json:"outcome"`
+	ClientIp  string        `json:"client_ip"`
+	UserAgent string        `json:"user_agent"`
+	Details   string        `json:"details"`
+	CreatedAt time.Time     `json:"created_at"`
+	PrevHash  string        `json:"prev_hash"`
+	Hash      string        `json:"hash"`
+}
+
+type Budget struct {
+	ID         int32     `json:"id"`
+	LedgerID   int32     `json:"ledger_id"`
+	CategoryID int32     `json:"category_id"`
+	Month      time.Time `json:"month"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+	Rollover   bool      `json:"rollover"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type Category struct {
-	ID          int32     `json:"id"`
-	UserID      int32     `json:"user_id"`
-	Title       string    `json:"title"`
-	Type        string    `json:"type"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int32         `json:"id"`
+	CreatedBy   sql.NullInt32 `json:"created_by"`
+	Title       string        `json:"title"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	CreatedAt   time.Time     `json:"created_at"`
+	LedgerID    int32         `json:"ledger_id"`
 }
 
-type User struct {
+type ExchangeRate struct {
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Date         time.Time `json:"date"`
+	Rate         string    `json:"rate"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Goal struct {
+	ID           int32         `json:"id"`
+	LedgerID     int32         `json:"ledger_id"`
+	CreatedBy    sql.NullInt32 `json:"created_by"`
+	WalletID     sql.NullInt32 `json:"wallet_id"`
+	Title        string        `json:"title"`
+	TargetAmount int64         `json:"target_amount"`
+	Currency     string        `json:"currency"`
+	StartsOn     time.Time     `json:"starts_on"`
+	Deadline     sql.NullTime  `json:"deadline"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type GoalContribution struct {
+	GoalID    int32     `json:"goal_id"`
+	AccountID int32     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Installment struct {
+	PlanID    int32 `json:"plan_id"`
+	Number    int32 `json:"number"`
+	AccountID int32 `json:"account_id"`
+}
+
+type InstallmentPlan struct {
+	ID           int32         `json:"id"`
+	LedgerID     int32         `json:"ledger_id"`
+	CreatedBy    sql.NullInt32 `json:"created_by"`
+	CategoryID   int32         `json:"category_id"`
+	WalletID     int32         `json:"wallet_id"`
+	Title        string        `json:"title"`
+	Type         string        `json:"type"`
+	Description  string        `json:"description"`
+	TotalAmount  int64         `json:"total_amount"`
+	Currency     string        `json:"currency"`
+	Installments int32         `json:"installments"`
+	FirstDate    time.Time     `json:"first_date"`
+	Remainder    string        `json:"remainder"`
+	CancelledAt  sql.NullTime  `json:"cancelled_at"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID         int64         `json:"id"`
+	LedgerID   int32         `json:"ledger_id"`
+	AccountID  sql.NullInt32 `json:"account_id"`
+	TransferID sql.NullInt32 `json:"transfer_id"`
+	Date       time.Time     `json:"date"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type Ledger struct {
 	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
+	Name      string    `json:"name"`
+	OwnerID   int32     `json:"owner_id"`
+	Personal  bool      `json:"personal"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LedgerInvitation struct {
+	ID         int64        `json:"id"`
+	LedgerID   int32        `json:"ledger_id"`
+	Email      string       `json:"email"`
+	Role       string       `json:"role"`
+	TokenHash  string       `json:"token_hash"`
+	InvitedBy  int32        `json:"invited_by"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	AcceptedAt sql.NullTime `json:"accepted_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type LedgerMember struct {
+	LedgerID  int32     `json:"ledger_id"`
+	UserID    int32     `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	Key          string       `json:"key"`
+	Failures     int32        `json:"failures"`
+	LastFailedAt time.Time    `json:"last_failed_at"`
+	LockedUntil  sql.NullTime `json:"locked_until"`
+}
+
+type MfaRecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int32        `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type MfaTotp struct {
+	UserID       int32        `json:"user_id"`
+	Secret       string       `json:"secret"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	LastUsedStep int64        `json:"last_used_step"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type OidcAuthRequest struct {
+	ID           int64         `json:"id"`
+	StateHash    string        `json:"state_hash"`
+	Provider     string        `json:"provider"`
+	Nonce        string        `json:"nonce"`
+	CodeVerifier string        `json:"code_verifier"`
+	LinkUserID   sql.NullInt32 `json:"link_user_id"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64        `json:"id"`
+	UserID    int32        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type PersonalAccessToken struct {
+	ID         int64        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	Name       string       `json:"name"`
+	TokenHash  string       `json:"token_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type Posting struct {
+	ID         int64         `json:"id"`
+	EntryID    int64         `json:"entry_id"`
+	WalletID   sql.NullInt32 `json:"wallet_id"`
+	CategoryID sql.NullInt32 `json:"category_id"`
+	Amount     int64         `json:"amount"`
+	Currency   string        `json:"currency"`
+}
+
+type RecurringOccurrence struct {
+	RecurringID int32          `json:"recurring_id"`
+	Date        time.Time      `json:"date"`
+	Skipped     bool           `json:"skipped"`
+	Title       sql.NullString `json:"title"`
+	Description sql.NullString `json:"description"`
+	Amount      sql.NullInt64  `json:"amount"`
+	AccountID   sql.NullInt32  `json:"account_id"`
+	GeneratedAt sql.NullTime   `json:"generated_at"`
+}
+
+type RecurringTransaction struct {
+	ID               int32         `json:"id"`
+	LedgerID         int32         `json:"ledger_id"`
+	CreatedBy        sql.NullInt32 `json:"created_by"`
+	CategoryID       int32         `json:"category_id"`
+	WalletID         int32         `json:"wallet_id"`
+	Title            string        `json:"title"`
+	Type             string        `json:"type"`
+	Description      string        `json:"description"`
+	Amount           int64         `json:"amount"`
+	Currency         string        `json:"currency"`
+	Rule             string        `json:"rule"`
+	StartsOn         time.Time     `json:"starts_on"`
+	EndsOn           sql.NullTime  `json:"ends_on"`
+	OccurrenceCount  sql.NullInt32 `json:"occurrence_count"`
+	GeneratedThrough sql.NullTime  `json:"generated_through"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+type SecurityEvent struct {
+	ID        int64         `json:"id"`
+	UserID    sql.NullInt32 `json:"user_id"`
+	Type      string        `json:"type"`
+	Username  string        `json:"username"`
+	ClientIp  string        `json:"client_ip"`
+	Details   string        `json:"details"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Session struct {
+	ID                       int64        `json:"id"`
+	UserID                   int32        `json:"user_id"`
+	RefreshTokenHash         string       `json:"refresh_token_hash"`
+	UserAgent                string       `json:"user_agent"`
+	ClientIp                 string       `json:"client_ip"`
+	ExpiresAt                time.Time    `json:"expires_at"`
+	RevokedAt                sql.NullTime `json:"revoked_at"`
+	CreatedAt                time.Time    `json:"created_at"`
+	PreviousRefreshTokenHash string       `json:"previous_refresh_token_hash"`
+}
+
+type Transfer struct {
+	ID        int32         `json:"id"`
+	LedgerID  int32         `json:"ledger_id"`
+	CreatedBy sql.NullInt32 `json:"created_by"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type User struct {
+	ID                    int32          `json:"id"`
+	Username              string         `json:"username"`
+	Password              string         `json:"password"`
+	Email                 string         `json:"email"`
+	CreatedAt             time.Time      `json:"created_at"`
+	VerifiedAt            sql.NullTime   `json:"verified_at"`
+	Role                  string         `json:"role"`
+	DisabledAt            sql.NullTime   `json:"disabled_at"`
+	PasswordResetRequired bool           `json:"password_reset_required"`
+	DisplayName           string         `json:"display_name"`
+	PendingEmail          sql.NullString `json:"pending_email"`
+	DeletedAt             sql.NullTime   `json:"deleted_at"`
+	BaseCurrency          string         `json:"base_currency"`
+}
+
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int32     `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
 	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+type Wallet struct {
+	ID             int32         `json:"id"`
+	LedgerID       int32         `json:"ledger_id"`
+	CreatedBy      sql.NullInt32 `json:"created_by"`
+	Name           string        `json:"name"`
+	Type           string        `json:"type"`
+	Currency       string        `json:"currency"`
+	OpeningBalance int64         `json:"opening_balance"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func TestConsumeOidcAuthRequest(t *testing.T) {
+	arg := CreateOidcAuthRequestParams{
+		StateHash:    util.RandomString(64),
+		Provider:     "google",
+		Nonce:        util.RandomString(32),
+		CodeVerifier: util.RandomString(43),
+		LinkUserID:   sql.NullInt32{Int32: createRandomUser(t).ID, Valid: true},
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+	authRequest1, err := testQueries.CreateOidcAuthRequest(context.Background(), arg)
+	require.NoError(t, err)
+
+	authRequest2, err := testQueries.ConsumeOidcAuthRequest(context.Background(), arg.StateHash)
+	require.NoError(t, err)
+	require.Equal(t, authRequest1.ID, authRequest2.ID)
+	require.Equal(t, arg.Nonce, authRequest2.Nonce)
+	require.Equal(t, arg.CodeVerifier, authRequest2.CodeVerifier)
+	require.Equal(t, arg.LinkUserID, authRequest2.LinkUserID)
+
+	_, err = testQueries.ConsumeOidcAuthRequest(context.Background(), arg.StateHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUserIdentities(t *testing.T) {
+	user := createRandomUser(t)
+	arg := CreateUserIdentityParams{
+		UserID:   user.ID,
+		Provider: "google",
+		Subject:  util.RandomString(21),
+		Email:    user.Email,
+	}
+	identity1, err := testQueries.CreateUserIdentity(context.Background(), arg)
+	require.NoError(t, err)
+
+	identity2, err := testQueries.GetUserIdentity(context.Background(), GetUserIdentityParams{
+		Provider: arg.Provider,
+		Subject:  arg.Subject,
+	})
+	require.NoError(t, err)
+	require.Equal(t, identity1, identity2)
+
+	_, err = testQueries.CreateUserIdentity(context.Background(), CreateUserIdentityParams{
+		UserID:   createRandomUser(t).ID,
+		Provider: arg.Provider,
+		Subject:  arg.Subject,
+	})
+	require.Error(t, err)
+
+	identities, err := testQueries.ListUserIdentities(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []UserIdentity{identity1}, identities)
+
+	rows, err := testQueries.DeleteUserIdentity(context.Background(), DeleteUserIdentityParams{
+		UserID:   user.ID,
+		Provider: arg.Provider,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestCreateOidcUserTx(t *testing.T) {
+	arg := CreateOidcUserTxParams{
+		Username:      util.RandomString(6),
+		Email:         util.RandomEmail(8),
+		EmailVerified: true,
+		Provider:      "google",
+		Subject:       util.RandomString(21),
+	}
+
+	user, err := testStore.CreateOidcUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+	require.Empty(t, user.Password)
+	require.True(t, user.VerifiedAt.Valid)
+
+	identity, err := testStore.GetUserIdentity(context.Background(), GetUserIdentityParams{
+		Provider: arg.Provider,
+		Subject:  arg.Subject,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.ID, identity.UserID)
+}
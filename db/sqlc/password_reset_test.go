@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomPasswordResetToken(t *testing.T, user User) PasswordResetToken {
+	arg := CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: util.RandomString(64),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	reset, err := testQueries.CreatePasswordResetToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, reset)
+
+	require.Equal(t, arg.UserID, reset.UserID)
+	require.Equal(t, arg.TokenHash, reset.TokenHash)
+	require.WithinDuration(t, arg.ExpiresAt, reset.ExpiresAt, time.Second)
+	require.False(t, reset.UsedAt.Valid)
+
+	return reset
+}
+
+func TestGetPasswordResetToken(t *testing.T) {
+	reset1 := createRandomPasswordResetToken(t, createRandomUser(t))
+	reset2, err := testQueries.GetPasswordResetToken(context.Background(), reset1.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, reset1.ID, reset2.ID)
+}
+
+func TestUsePasswordResetToken(t *testing.T) {
+	reset := createRandomPasswordResetToken(t, createRandomUser(t))
+
+	rows, err := testQueries.UsePasswordResetToken(context.Background(), reset.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UsePasswordResetToken(context.Background(), reset.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	session := createRandomSession(t)
+	user, err := testQueries.GetUserById(context.Background(), session.UserID)
+	require.NoError(t, err)
+	reset := createRandomPasswordResetToken(t, user)
+	other := createRandomPasswordResetToken(t, user)
+
+	arg := ResetPasswordTxParams{
+		TokenID:  reset.ID,
+		UserID:   user.ID,
+		Password: util.RandomString(20),
+	}
+	err = testStore.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	updated, err := testQueries.GetUserById(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Password, updated.Password)
+
+	revoked, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	otherAfter, err := testQueries.GetPasswordResetToken(context.Background(), other.TokenHash)
+	require.NoError(t, err)
+	require.True(t, otherAfter.UsedAt.Valid)
+
+	err = testStore.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPasswordResetTokenUsed)
+}
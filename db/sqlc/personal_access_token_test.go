@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomPersonalAccessToken(t *testing.T, user User) PersonalAccessToken {
+	arg := CreatePersonalAccessTokenParams{
+		UserID:    user.ID,
+		Name:      util.RandomString(10),
+		TokenHash: util.RandomString(64),
+		Scopes:    []string{"accounts:read", "reports:read"},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	pat, err := testQueries.CreatePersonalAccessToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.UserID, pat.UserID)
+	require.Equal(t, arg.Name, pat.Name)
+	require.Equal(t, arg.Scopes, pat.Scopes)
+	require.WithinDuration(t, arg.ExpiresAt.Time, pat.ExpiresAt.Time, time.Second)
+	require.False(t, pat.LastUsedAt.Valid)
+	require.False(t, pat.RevokedAt.Valid)
+
+	return pat
+}
+
+func TestGetPersonalAccessToken(t *testing.T) {
+	pat1 := createRandomPersonalAccessToken(t, createRandomUser(t))
+
+	require.NoError(t, testQueries.TouchPersonalAccessToken(context.Background(), pat1.ID))
+
+	pat2, err := testQueries.GetPersonalAccessToken(context.Background(), pat1.ID)
+	require.NoError(t, err)
+	require.Equal(t, pat1.TokenHash, pat2.TokenHash)
+	require.Equal(t, pat1.Scopes, pat2.Scopes)
+	require.True(t, pat2.LastUsedAt.Valid)
+}
+
+func TestListAndRevokePersonalAccessTokens(t *testing.T) {
+	user := createRandomUser(t)
+	pat1 := createRandomPersonalAccessToken(t, user)
+	pat2 := createRandomPersonalAccessToken(t, user)
+	createRandomPersonalAccessToken(t, createRandomUser(t))
+
+	rows, err := testQueries.RevokePersonalAccessToken(context.Background(), RevokePersonalAccessTokenParams{
+		ID:     pat1.ID,
+		UserID: pat2.UserID + 1,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.RevokePersonalAccessToken(context.Background(), RevokePersonalAccessTokenParams{
+		ID:     pat1.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	pats, err := testQueries.ListPersonalAccessTokens(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, pats, 1)
+	require.Equal(t, pat2.ID, pats[0].ID)
+}
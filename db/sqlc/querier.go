@@ -6,27 +6,202 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AcceptLedgerInvitation(ctx context.Context, id int64) (int64, error)
+	CancelInstallmentPlan(ctx context.Context, id int32) (InstallmentPlan, error)
+	ClearLoginThrottle(ctx context.Context, key string) error
+	ConfirmMfaTotp(ctx context.Context, userID int32) (int64, error)
+	ConfirmUserEmailChange(ctx context.Context, arg ConfirmUserEmailChangeParams) (int64, error)
+	ConsumeOidcAuthRequest(ctx context.Context, stateHash string) (OidcAuthRequest, error)
+	// Copies the budgets of a ledger for one month to another, leaving alone
+	// the categories that already have a budget there.
+	CopyBudgets(ctx context.Context, arg CopyBudgetsParams) ([]Budget, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
 	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateGoal(ctx context.Context, arg CreateGoalParams) (Goal, error)
+	CreateInstallment(ctx context.Context, arg CreateInstallmentParams) (Installment, error)
+	CreateInstallmentPlan(ctx context.Context, arg CreateInstallmentPlanParams) (InstallmentPlan, error)
+	CreateJournalEntry(ctx context.Context, arg CreateJournalEntryParams) (JournalEntry, error)
+	CreateLedger(ctx context.Context, arg CreateLedgerParams) (Ledger, error)
+	CreateLedgerInvitation(ctx context.Context, arg CreateLedgerInvitationParams) (LedgerInvitation, error)
+	CreateLedgerMember(ctx context.Context, arg CreateLedgerMemberParams) (LedgerMember, error)
+	CreateMfaRecoveryCode(ctx context.Context, arg CreateMfaRecoveryCodeParams) error
+	CreateOidcAuthRequest(ctx context.Context, arg CreateOidcAuthRequestParams) (OidcAuthRequest, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreatePersonalLedger(ctx context.Context, arg CreatePersonalLedgerParams) (Ledger, error)
+	CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error)
+	CreateRecurringTransaction(ctx context.Context, arg CreateRecurringTransactionParams) (RecurringTransaction, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferLeg(ctx context.Context, arg CreateTransferLegParams) (Account, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteAccount(ctx context.Context, id int32) error
-	DeleteCategories(ctx context.Context, id int32) error
-	GetAccount(ctx context.Context, id int32) (Account, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error)
+	// Transfer legs are deleted with their transfer.
+	DeleteAccount(ctx context.Context, arg DeleteAccountParams) (int64, error)
+	DeleteAccountJournalEntry(ctx context.Context, accountID sql.NullInt32) error
+	DeleteBudget(ctx context.Context, arg DeleteBudgetParams) (int64, error)
+	DeleteCategories(ctx context.Context, arg DeleteCategoriesParams) (int64, error)
+	DeleteGoal(ctx context.Context, arg DeleteGoalParams) (int64, error)
+	// Deletes the transactions of the installments after number, which
+	// leave the plan with them.
+	DeleteInstallmentAccounts(ctx context.Context, arg DeleteInstallmentAccountsParams) (int64, error)
+	DeleteLedgerInvitation(ctx context.Context, arg DeleteLedgerInvitationParams) (int64, error)
+	DeleteLedgerMember(ctx context.Context, arg DeleteLedgerMemberParams) (int64, error)
+	DeleteMfaRecoveryCodes(ctx context.Context, userID int32) error
+	DeleteMfaTotp(ctx context.Context, userID int32) error
+	// Restores a skipped or modified occurrence to its template, unless it
+	// was generated.
+	DeleteRecurringOccurrence(ctx context.Context, arg DeleteRecurringOccurrenceParams) (int64, error)
+	// The transactions it generated are kept.
+	DeleteRecurringTransaction(ctx context.Context, arg DeleteRecurringTransactionParams) (int64, error)
+	// Deletes both legs with it.
+	DeleteTransfer(ctx context.Context, arg DeleteTransferParams) (int64, error)
+	DeleteTransferJournalEntry(ctx context.Context, transferID sql.NullInt32) error
+	DeleteUserIdentity(ctx context.Context, arg DeleteUserIdentityParams) (int64, error)
+	// Wallets with transactions are kept.
+	DeleteWallet(ctx context.Context, arg DeleteWalletParams) (int64, error)
+	DisableUser(ctx context.Context, id int32) (User, error)
+	EnableUser(ctx context.Context, id int32) (User, error)
+	// Claims an occurrence for generation. No row comes back when it was
+	// generated before or skipped.
+	GenerateRecurringOccurrence(ctx context.Context, arg GenerateRecurringOccurrenceParams) (RecurringOccurrence, error)
+	GetAccount(ctx context.Context, arg GetAccountParams) (Account, error)
+	GetAccountJournalEntry(ctx context.Context, accountID sql.NullInt32) (JournalEntry, error)
 	GetAccounts(ctx context.Context, arg GetAccountsParams) ([]GetAccountsRow, error)
 	GetAccountsGraph(ctx context.Context, arg GetAccountsGraphParams) (int64, error)
-	GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) (int64, error)
+	// Transfers between wallets are neither income nor expense and are left
+	// out of reports.
+	GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) ([]GetAccountsReportsRow, error)
+	GetAccountsReportsByDate(ctx context.Context, arg GetAccountsReportsByDateParams) ([]GetAccountsReportsByDateRow, error)
+	GetAuditLogHead(ctx context.Context) (string, error)
+	// Sums the transactions of the categories of a ledger per day and
+	// currency from from_date to to_date.
+	GetBudgetSpending(ctx context.Context, arg GetBudgetSpendingParams) ([]GetBudgetSpendingRow, error)
 	GetCategories(ctx context.Context, arg GetCategoriesParams) ([]Category, error)
-	GetCategoriesByUserIdAndType(ctx context.Context, arg GetCategoriesByUserIdAndTypeParams) ([]Category, error)
-	GetCategoriesByUserIdAndTypeAndDescription(ctx context.Context, arg GetCategoriesByUserIdAndTypeAndDescriptionParams) ([]Category, error)
-	GetCategoriesByUserIdAndTypeAndTitle(ctx context.Context, arg GetCategoriesByUserIdAndTypeAndTitleParams) ([]Category, error)
-	GetCategory(ctx context.Context, id int32) (Category, error)
+	GetCategoriesByLedgerIdAndType(ctx context.Context, arg GetCategoriesByLedgerIdAndTypeParams) ([]Category, error)
+	GetCategoriesByLedgerIdAndTypeAndDescription(ctx context.Context, arg GetCategoriesByLedgerIdAndTypeAndDescriptionParams) ([]Category, error)
+	GetCategoriesByLedgerIdAndTypeAndTitle(ctx context.Context, arg GetCategoriesByLedgerIdAndTypeAndTitleParams) ([]Category, error)
+	GetCategory(ctx context.Context, arg GetCategoryParams) (Category, error)
+	GetCategoryReports(ctx context.Context, arg GetCategoryReportsParams) ([]GetCategoryReportsRow, error)
+	// The latest rate for the pair on or before date, stored in either
+	// direction. Rates stored from_currency to to_currency win on the same day.
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	GetGoal(ctx context.Context, arg GetGoalParams) (Goal, error)
+	GetInstallmentPlan(ctx context.Context, arg GetInstallmentPlanParams) (InstallmentPlan, error)
+	GetLedger(ctx context.Context, id int32) (Ledger, error)
+	GetLedgerInvitationByToken(ctx context.Context, tokenHash string) (LedgerInvitation, error)
+	GetLedgerMember(ctx context.Context, arg GetLedgerMemberParams) (LedgerMember, error)
+	GetLoginThrottle(ctx context.Context, key string) (LoginThrottle, error)
+	GetMfaTotp(ctx context.Context, userID int32) (MfaTotp, error)
+	GetPasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	GetPersonalAccessToken(ctx context.Context, id int64) (PersonalAccessToken, error)
+	GetPersonalLedger(ctx context.Context, ownerID int32) (Ledger, error)
+	GetRecurringTransaction(ctx context.Context, arg GetRecurringTransactionParams) (RecurringTransaction, error)
+	GetSession(ctx context.Context, id int64) (Session, error)
+	GetSystemStats(ctx context.Context) (GetSystemStatsRow, error)
+	GetTransfer(ctx context.Context, arg GetTransferParams) (Transfer, error)
+	GetTransferJournalEntry(ctx context.Context, transferID sql.NullInt32) (JournalEntry, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserById(ctx context.Context, id int32) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetWallet(ctx context.Context, arg GetWalletParams) (Wallet, error)
+	// Money in minus money out of a wallet as journaled, of all its
+	// transactions or of those up to and including date.
+	GetWalletTransactionsTotal(ctx context.Context, arg GetWalletTransactionsTotalParams) (int64, error)
+	InvalidateUserPasswordResetTokens(ctx context.Context, userID int32) error
+	// Counts a transaction toward a goal. It returns no row when the
+	// transaction already counts toward a goal.
+	LinkGoalContribution(ctx context.Context, arg LinkGoalContributionParams) (GoalContribution, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListAuditEventsAfter(ctx context.Context, arg ListAuditEventsAfterParams) ([]AuditEvent, error)
+	// Lists the budgets of a ledger for month and the months before it, by
+	// category and month.
+	ListBudgetsThrough(ctx context.Context, arg ListBudgetsThroughParams) ([]Budget, error)
+	// Templates that may have occurrences up to today not generated yet.
+	ListDueRecurringTransactions(ctx context.Context, today time.Time) ([]RecurringTransaction, error)
+	ListExchangeRates(ctx context.Context, arg ListExchangeRatesParams) ([]ExchangeRate, error)
+	// Lists what was put toward a goal by date: the transactions linked to
+	// it and the transfers in and out of its wallet from starts_on on.
+	ListGoalContributions(ctx context.Context, arg ListGoalContributionsParams) ([]ListGoalContributionsRow, error)
+	ListGoals(ctx context.Context, ledgerID int32) ([]Goal, error)
+	ListInstallmentAccounts(ctx context.Context, planID int32) ([]Account, error)
+	ListInstallmentPlans(ctx context.Context, ledgerID int32) ([]InstallmentPlan, error)
+	ListInstallments(ctx context.Context, planID int32) ([]Installment, error)
+	ListLedgerInvitations(ctx context.Context, ledgerID int32) ([]LedgerInvitation, error)
+	ListLedgerMembers(ctx context.Context, ledgerID int32) ([]ListLedgerMembersRow, error)
+	ListPersonalAccessTokens(ctx context.Context, userID int32) ([]PersonalAccessToken, error)
+	ListPostings(ctx context.Context, entryID int64) ([]Posting, error)
+	ListRecurringOccurrences(ctx context.Context, arg ListRecurringOccurrencesParams) ([]RecurringOccurrence, error)
+	ListRecurringTransactions(ctx context.Context, ledgerID int32) ([]RecurringTransaction, error)
+	// The debit leg, leaving its wallet, comes first.
+	ListTransferLegs(ctx context.Context, transferID sql.NullInt32) ([]Account, error)
+	// Entries whose postings do not sum to zero in some currency.
+	ListUnbalancedJournalEntries(ctx context.Context) ([]ListUnbalancedJournalEntriesRow, error)
+	// Ledgers whose postings do not sum to zero in some currency.
+	ListUnbalancedLedgers(ctx context.Context) ([]ListUnbalancedLedgersRow, error)
+	// Transactions with no journal entry of their own or of their transfer.
+	ListUnjournaledAccounts(ctx context.Context) ([]ListUnjournaledAccountsRow, error)
+	ListUserAuditEvents(ctx context.Context, arg ListUserAuditEventsParams) ([]AuditEvent, error)
+	ListUserIdentities(ctx context.Context, userID int32) ([]UserIdentity, error)
+	ListUserLedgers(ctx context.Context, userID int32) ([]ListUserLedgersRow, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListWalletDailyTotals(ctx context.Context, arg ListWalletDailyTotalsParams) ([]ListWalletDailyTotalsRow, error)
+	// Wallets whose postings do not add up to their credit transactions minus
+	// their debit ones.
+	ListWalletJournalMismatches(ctx context.Context) ([]ListWalletJournalMismatchesRow, error)
+	ListWallets(ctx context.Context, ledgerID int32) ([]ListWalletsRow, error)
+	LockAuditLog(ctx context.Context) error
+	LockLogin(ctx context.Context, arg LockLoginParams) error
+	// Generated occurrences cannot be modified anymore; no row comes back
+	// for them.
+	ModifyRecurringOccurrence(ctx context.Context, arg ModifyRecurringOccurrenceParams) (RecurringOccurrence, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error)
+	RequireUserPasswordReset(ctx context.Context, id int32) (User, error)
+	RevokeOtherUserSessions(ctx context.Context, arg RevokeOtherUserSessionsParams) error
+	RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (int64, error)
+	RevokeSession(ctx context.Context, arg RevokeSessionParams) error
+	RevokeUserPersonalAccessTokens(ctx context.Context, userID int32) error
+	RevokeUserSessions(ctx context.Context, userID int32) error
+	RotateSessionRefreshToken(ctx context.Context, arg RotateSessionRefreshTokenParams) (Session, error)
+	// Sets the budget of a category for a month, replacing the one it had.
+	SetBudget(ctx context.Context, arg SetBudgetParams) (Budget, error)
+	SetRecurringGeneratedThrough(ctx context.Context, arg SetRecurringGeneratedThroughParams) error
+	SetRecurringOccurrenceAccount(ctx context.Context, arg SetRecurringOccurrenceAccountParams) error
+	SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) error
+	// Generated occurrences cannot be skipped anymore; no row comes back
+	// for them.
+	SkipRecurringOccurrence(ctx context.Context, arg SkipRecurringOccurrenceParams) (RecurringOccurrence, error)
+	SoftDeleteUser(ctx context.Context, id int32) (User, error)
+	TouchPersonalAccessToken(ctx context.Context, id int64) error
+	UnlinkGoalContribution(ctx context.Context, arg UnlinkGoalContributionParams) (int64, error)
+	// Transfer legs change through their transfer.
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
 	UpdateCategories(ctx context.Context, arg UpdateCategoriesParams) (Category, error)
+	UpdateGoal(ctx context.Context, arg UpdateGoalParams) (Goal, error)
+	UpdateInstallmentPlan(ctx context.Context, arg UpdateInstallmentPlanParams) (InstallmentPlan, error)
+	UpdateLedgerMemberRole(ctx context.Context, arg UpdateLedgerMemberRoleParams) (LedgerMember, error)
+	UpdateRecurringTransaction(ctx context.Context, arg UpdateRecurringTransactionParams) (RecurringTransaction, error)
+	UpdateTransferLeg(ctx context.Context, arg UpdateTransferLegParams) (Account, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateWallet(ctx context.Context, arg UpdateWalletParams) (Wallet, error)
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error)
+	UpsertMfaTotp(ctx context.Context, arg UpsertMfaTotpParams) (MfaTotp, error)
+	UseMfaRecoveryCode(ctx context.Context, arg UseMfaRecoveryCodeParams) (int64, error)
+	UseMfaTotpStep(ctx context.Context, arg UseMfaTotpStepParams) (int64, error)
+	UsePasswordResetToken(ctx context.Context, id int64) (int64, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomRecurringTransaction(t *testing.T, wallet Wallet, rule string, startsOn time.Time) RecurringTransaction {
+	category, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		LedgerID:    wallet.LedgerID,
+		Title:       util.RandomString(12),
+		Type:        "debit",
+		Description: util.RandomString(20),
+	})
+	require.NoError(t, err)
+
+	arg := CreateRecurringTransactionParams{
+		LedgerID:    wallet.LedgerID,
+		CategoryID:  category.ID,
+		WalletID:    wallet.ID,
+		Title:       util.RandomString(12),
+		Type:        category.Type,
+		Description: util.RandomString(20),
+		Amount:      4200,
+		Currency:    wallet.Currency,
+		Rule:        rule,
+		StartsOn:    startsOn,
+	}
+	recurring, err := testQueries.CreateRecurringTransaction(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, recurring.ID)
+	require.Equal(t, arg.Rule, recurring.Rule)
+	require.Equal(t, arg.Amount, recurring.Amount)
+	require.True(t, arg.StartsOn.Equal(recurring.StartsOn))
+	require.False(t, recurring.GeneratedThrough.Valid)
+	return recurring
+}
+
+func TestGenerateRecurringIsIdempotent(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	recurring := createRandomRecurringTransaction(t, wallet, "FREQ=MONTHLY", date)
+
+	account, err := testStore.GenerateRecurringTx(context.Background(), GenerateRecurringTxParams{Recurring: recurring, Date: date})
+	require.NoError(t, err)
+	require.Equal(t, recurring.Title, account.Title)
+	require.Equal(t, recurring.Amount, account.Amount)
+	require.Equal(t, sql.NullInt32{Int32: recurring.CategoryID, Valid: true}, account.CategoryID)
+	require.True(t, date.Equal(account.Date))
+
+	_, err = testQueries.GetAccountJournalEntry(context.Background(), sql.NullInt32{Int32: account.ID, Valid: true})
+	require.NoError(t, err)
+
+	_, err = testStore.GenerateRecurringTx(context.Background(), GenerateRecurringTxParams{Recurring: recurring, Date: date})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	occurrences, err := testQueries.ListRecurringOccurrences(context.Background(), ListRecurringOccurrencesParams{
+		RecurringID: recurring.ID,
+		FromDate:    date,
+		ToDate:      date,
+	})
+	require.NoError(t, err)
+	require.Len(t, occurrences, 1)
+	require.Equal(t, sql.NullInt32{Int32: account.ID, Valid: true}, occurrences[0].AccountID)
+	require.True(t, occurrences[0].GeneratedAt.Valid)
+
+	// A generated occurrence can no longer change.
+	_, err = testQueries.SkipRecurringOccurrence(context.Background(), SkipRecurringOccurrenceParams{RecurringID: recurring.ID, Date: date})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	rows, err := testQueries.DeleteRecurringOccurrence(context.Background(), DeleteRecurringOccurrenceParams{RecurringID: recurring.ID, Date: date})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	// Deleting the transaction does not bring the occurrence back.
+	_, err = testQueries.DeleteAccount(context.Background(), DeleteAccountParams{ID: account.ID, LedgerID: account.LedgerID})
+	require.NoError(t, err)
+	_, err = testStore.GenerateRecurringTx(context.Background(), GenerateRecurringTxParams{Recurring: recurring, Date: date})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGenerateRecurringAppliesOccurrenceChanges(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	first := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 1, 0)
+	recurring := createRandomRecurringTransaction(t, wallet, "FREQ=MONTHLY", first)
+
+	skipped, err := testQueries.SkipRecurringOccurrence(context.Background(), SkipRecurringOccurrenceParams{RecurringID: recurring.ID, Date: first})
+	require.NoError(t, err)
+	require.True(t, skipped.Skipped)
+
+	modified, err := testQueries.ModifyRecurringOccurrence(context.Background(), ModifyRecurringOccurrenceParams{
+		RecurringID: recurring.ID,
+		Date:        second,
+		Amount:      sql.NullInt64{Int64: 12345, Valid: true},
+	})
+	require.NoError(t, err)
+	require.False(t, modified.Skipped)
+	require.False(t, modified.Title.Valid)
+
+	_, err = testStore.GenerateRecurringTx(context.Background(), GenerateRecurringTxParams{Recurring: recurring, Date: first})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	account, err := testStore.GenerateRecurringTx(context.Background(), GenerateRecurringTxParams{Recurring: recurring, Date: second})
+	require.NoError(t, err)
+	require.Equal(t, int64(12345), account.Amount)
+	require.Equal(t, recurring.Title, account.Title)
+}
+
+func TestListDueRecurringTransactions(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	today := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	due := createRandomRecurringTransaction(t, wallet, "FREQ=DAILY", today.AddDate(0, 0, -3))
+	future := createRandomRecurringTransaction(t, wallet, "FREQ=DAILY", today.AddDate(0, 0, 1))
+	done := createRandomRecurringTransaction(t, wallet, "FREQ=DAILY", today.AddDate(0, 0, -3))
+	err := testQueries.SetRecurringGeneratedThrough(context.Background(), SetRecurringGeneratedThroughParams{
+		ID:               done.ID,
+		GeneratedThrough: sql.NullTime{Time: today, Valid: true},
+	})
+	require.NoError(t, err)
+
+	templates, err := testQueries.ListDueRecurringTransactions(context.Background(), today)
+	require.NoError(t, err)
+	ids := map[int32]bool{}
+	for _, recurring := range templates {
+		ids[recurring.ID] = true
+	}
+	require.True(t, ids[due.ID])
+	require.False(t, ids[future.ID])
+	require.False(t, ids[done.ID])
+}
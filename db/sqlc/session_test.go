@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomSession(t *testing.T) Session {
+	user := createRandomUser(t)
+	arg := CreateSessionParams{
+		UserID:           user.ID,
+		RefreshTokenHash: util.RandomString(64),
+		UserAgent:        util.RandomString(10),
+		ClientIp:         "127.0.0.1",
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.UserID, session.UserID)
+	require.Equal(t, arg.RefreshTokenHash, session.RefreshTokenHash)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.False(t, session.RevokedAt.Valid)
+	require.NotEmpty(t, session.CreatedAt)
+
+	return session
+}
+
+func TestCreateSession(t *testing.T) {
+	createRandomSession(t)
+}
+
+func TestGetSession(t *testing.T) {
+	session1 := createRandomSession(t)
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, session1.ID, session2.ID)
+	require.Equal(t, session1.UserID, session2.UserID)
+	require.Equal(t, session1.RefreshTokenHash, session2.RefreshTokenHash)
+}
+
+func TestRotateSessionRefreshToken(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	arg := RotateSessionRefreshTokenParams{
+		NewRefreshTokenHash: util.RandomString(64),
+		ID:                  session1.ID,
+		RefreshTokenHash:    session1.RefreshTokenHash,
+	}
+	session2, err := testQueries.RotateSessionRefreshToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.NewRefreshTokenHash, session2.RefreshTokenHash)
+	require.Equal(t, session1.RefreshTokenHash, session2.PreviousRefreshTokenHash)
+
+	_, err = testQueries.RotateSessionRefreshToken(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRevokeSession(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	err := testQueries.RevokeSession(context.Background(), RevokeSessionParams{
+		ID:     session1.ID,
+		UserID: session1.UserID,
+	})
+	require.NoError(t, err)
+
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, session2.RevokedAt.Valid)
+
+	_, err = testQueries.RotateSessionRefreshToken(context.Background(), RotateSessionRefreshTokenParams{
+		NewRefreshTokenHash: util.RandomString(64),
+		ID:                  session1.ID,
+		RefreshTokenHash:    session1.RefreshTokenHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	err := testQueries.RevokeUserSessions(context.Background(), session1.UserID)
+	require.NoError(t, err)
+
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, session2.RevokedAt.Valid)
+}
@@ -1,9 +1,39 @@
 package db
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
 
 type Store interface {
 	Querier
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) error
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) error
+	ConfirmMfaTx(ctx context.Context, arg ConfirmMfaTxParams) error
+	DisableMfaTx(ctx context.Context, userID int32) error
+	CreateOidcUserTx(ctx context.Context, arg CreateOidcUserTxParams) (User, error)
+	DisableUserTx(ctx context.Context, userID int32) (User, error)
+	UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	ForcePasswordResetTx(ctx context.Context, userID int32) (User, error)
+	DeleteUserTx(ctx context.Context, userID int32) (User, error)
+	AppendAuditEventTx(ctx context.Context, arg AppendAuditEventTxParams) (AuditEvent, error)
+	VerifyAuditLog(ctx context.Context) (AuditLogVerification, error)
+	CreateLedgerTx(ctx context.Context, arg CreateLedgerParams) (Ledger, error)
+	EnsurePersonalLedgerTx(ctx context.Context, userID int32) (Ledger, error)
+	AcceptLedgerInvitationTx(ctx context.Context, arg AcceptLedgerInvitationTxParams) (LedgerMember, error)
+	ImportExchangeRatesTx(ctx context.Context, rates []UpsertExchangeRateParams) (int, error)
+	CreateTransferTx(ctx context.Context, arg CreateTransferTxParams) (TransferTxResult, error)
+	GetTransferTx(ctx context.Context, arg GetTransferParams) (TransferTxResult, error)
+	UpdateTransferTx(ctx context.Context, arg UpdateTransferTxParams) (TransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
+	UpdateAccountTx(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	VerifyJournal(ctx context.Context) (JournalVerification, error)
+	GenerateRecurringTx(ctx context.Context, arg GenerateRecurringTxParams) (Account, error)
+	CreateInstallmentPlanTx(ctx context.Context, arg CreateInstallmentPlanParams) (InstallmentPlanTxResult, error)
+	GetInstallmentPlanTx(ctx context.Context, arg GetInstallmentPlanParams) (InstallmentPlanTxResult, error)
+	UpdateInstallmentPlanTx(ctx context.Context, arg UpdateInstallmentPlanTxParams) (InstallmentPlanTxResult, error)
+	CancelInstallmentPlanTx(ctx context.Context, arg CancelInstallmentPlanTxParams) (InstallmentPlanTxResult, error)
 }
 
 type SQLStore struct {
@@ -17,3 +47,23 @@ func NewStore(db *sql.DB) *SQLStore {
 		Queries: New(db),
 	}
 }
+
+// execTx runs fn inside a database transaction, rolling it back when fn
+// returns an error.
+func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	q := New(tx)
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
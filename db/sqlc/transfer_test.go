@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomTransfer(t *testing.T, from, to Wallet, amount int64) TransferTxResult {
+	arg := CreateTransferTxParams{
+		LedgerID:    from.LedgerID,
+		Title:       util.RandomString(12),
+		Description: util.RandomString(20),
+		Date:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		From:        TransferLegParams{WalletID: from.ID, Amount: amount, Currency: from.Currency},
+		To:          TransferLegParams{WalletID: to.ID, Amount: amount, Currency: to.Currency},
+	}
+
+	result, err := testStore.CreateTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, result.Transfer.ID)
+	require.Equal(t, arg.LedgerID, result.Transfer.LedgerID)
+
+	for _, leg := range []Account{result.From, result.To} {
+		require.Equal(t, sql.NullInt32{Int32: result.Transfer.ID, Valid: true}, leg.TransferID)
+		require.False(t, leg.CategoryID.Valid)
+		require.Equal(t, arg.Title, leg.Title)
+		require.Equal(t, amount, leg.Amount)
+	}
+	require.Equal(t, TransferLegFrom, result.From.Type)
+	require.Equal(t, from.ID, result.From.WalletID)
+	require.Equal(t, TransferLegTo, result.To.Type)
+	require.Equal(t, to.ID, result.To.WalletID)
+
+	return result
+}
+
+func TestTransferMovesBalancesButNotReports(t *testing.T) {
+	ledger := createRandomLedger(t)
+	checking := createRandomWallet(t, ledger.ID, "BRL")
+	savings := createRandomWallet(t, ledger.ID, "BRL")
+	createRandomTransfer(t, checking, savings, 2500)
+
+	wallets, err := testQueries.ListWallets(context.Background(), ledger.ID)
+	require.NoError(t, err)
+	balances := map[int32]int64{}
+	for _, wallet := range wallets {
+		balances[wallet.ID] = wallet.Balance
+	}
+	require.Equal(t, checking.OpeningBalance-2500, balances[checking.ID])
+	require.Equal(t, savings.OpeningBalance+2500, balances[savings.ID])
+
+	for _, accountType := range []string{"debit", "credit"} {
+		sums, err := testQueries.GetAccountsReports(context.Background(), GetAccountsReportsParams{
+			LedgerID: ledger.ID,
+			Type:     accountType,
+		})
+		require.NoError(t, err)
+		require.Empty(t, sums)
+
+		count, err := testQueries.GetAccountsGraph(context.Background(), GetAccountsGraphParams{
+			LedgerID: ledger.ID,
+			Type:     accountType,
+		})
+		require.NoError(t, err)
+		require.Zero(t, count)
+	}
+
+	// Listings still show the legs.
+	accounts, err := testQueries.GetAccounts(context.Background(), GetAccountsParams{
+		LedgerID: ledger.ID,
+		Type:     "credit",
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.True(t, accounts[0].TransferID.Valid)
+	require.False(t, accounts[0].CategoryTitle.Valid)
+}
+
+func TestCreateTransferTxIsAtomic(t *testing.T) {
+	ledger := createRandomLedger(t)
+	checking := createRandomWallet(t, ledger.ID, "BRL")
+	dollars := createRandomWallet(t, ledger.ID, "USD")
+
+	// The credit leg does not match its wallet's currency.
+	_, err := testStore.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		LedgerID: ledger.ID,
+		Title:    util.RandomString(12),
+		Date:     time.Now(),
+		From:     TransferLegParams{WalletID: checking.ID, Amount: 100, Currency: "BRL"},
+		To:       TransferLegParams{WalletID: dollars.ID, Amount: 100, Currency: "BRL"},
+	})
+	require.Error(t, err)
+
+	total, err := testQueries.GetWalletTransactionsTotal(context.Background(), GetWalletTransactionsTotalParams{
+		WalletID: checking.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, total)
+}
+
+func TestUpdateTransferTx(t *testing.T) {
+	ledger := createRandomLedger(t)
+	checking := createRandomWallet(t, ledger.ID, "BRL")
+	dollars := createRandomWallet(t, ledger.ID, "USD")
+	transfer := createRandomTransfer(t, checking, dollars, 1000)
+
+	arg := UpdateTransferTxParams{
+		ID:          transfer.Transfer.ID,
+		LedgerID:    ledger.ID,
+		Title:       util.RandomString(12),
+		Description: util.RandomString(20),
+		Date:        time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		FromAmount:  5500,
+		ToAmount:    1000,
+	}
+	result, err := testStore.UpdateTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, transfer.From.ID, result.From.ID)
+	require.Equal(t, int64(5500), result.From.Amount)
+	require.Equal(t, transfer.To.ID, result.To.ID)
+	require.Equal(t, int64(1000), result.To.Amount)
+	for _, leg := range []Account{result.From, result.To} {
+		require.Equal(t, arg.Title, leg.Title)
+		require.Equal(t, arg.Description, leg.Description)
+		require.True(t, arg.Date.Equal(leg.Date))
+	}
+
+	got, err := testStore.GetTransferTx(context.Background(), GetTransferParams{ID: arg.ID, LedgerID: ledger.ID})
+	require.NoError(t, err)
+	require.Equal(t, result, got)
+
+	arg.LedgerID = createRandomLedger(t).ID
+	_, err = testStore.UpdateTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferLegsChangeOnlyThroughTheTransfer(t *testing.T) {
+	ledger := createRandomLedger(t)
+	transfer := createRandomTransfer(t, createRandomWallet(t, ledger.ID, "BRL"), createRandomWallet(t, ledger.ID, "BRL"), 700)
+
+	_, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:       transfer.From.ID,
+		Title:    util.RandomString(12),
+		Amount:   1,
+		Currency: "BRL",
+		WalletID: transfer.From.WalletID,
+		LedgerID: ledger.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err := testQueries.DeleteAccount(context.Background(), DeleteAccountParams{ID: transfer.To.ID, LedgerID: ledger.ID})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.DeleteTransfer(context.Background(), DeleteTransferParams{ID: transfer.Transfer.ID, LedgerID: ledger.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	for _, leg := range []Account{transfer.From, transfer.To} {
+		_, err = testQueries.GetAccount(context.Background(), GetAccountParams{ID: leg.ID, LedgerID: ledger.ID})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+}
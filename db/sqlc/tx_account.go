@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// CreateAccountTx records a transaction together with its journal entry.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return journalAccount(ctx, q, account)
+	})
+	return account, err
+}
+
+// UpdateAccountTx changes a transaction and replaces its journal entry.
+func (store *SQLStore) UpdateAccountTx(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	var account Account
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = q.UpdateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteAccountJournalEntry(ctx, sql.NullInt32{Int32: account.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		return journalAccount(ctx, q, account)
+	})
+	return account, err
+}
+
+func journalAccount(ctx context.Context, q *Queries, account Account) error {
+	return postJournalEntry(ctx, q, CreateJournalEntryParams{
+		LedgerID:  account.LedgerID,
+		AccountID: sql.NullInt32{Int32: account.ID, Valid: true},
+		Date:      account.Date,
+	}, accountPostings(account))
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+)
+
+// DisableUserTx disables the account and revokes its sessions, so the
+// user is signed out everywhere at once.
+func (store *SQLStore) DisableUserTx(ctx context.Context, userID int32) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.DisableUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeUserSessions(ctx, userID)
+	})
+	return user, err
+}
+
+// UpdateUserRoleTx changes the role of the user and revokes their sessions,
+// since access tokens carry the role they were issued with.
+func (store *SQLStore) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.UpdateUserRole(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeUserSessions(ctx, arg.ID)
+	})
+	return user, err
+}
+
+// ForcePasswordResetTx stops the current password from being used to sign
+// in, revokes every session and personal access token and invalidates
+// outstanding reset tokens. The user has to go through the password reset
+// flow to sign in again.
+func (store *SQLStore) ForcePasswordResetTx(ctx context.Context, userID int32) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.RequireUserPasswordReset(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidateUserPasswordResetTokens(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeUserPersonalAccessTokens(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeUserSessions(ctx, userID)
+	})
+	return user, err
+}
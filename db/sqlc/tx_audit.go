@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type AppendAuditEventTxParams struct {
+	UserID    sql.NullInt32
+	Username  string
+	ActorID   sql.NullInt32
+	Actor     string
+	Type      string
+	Outcome   string
+	ClientIp  string
+	UserAgent string
+	Details   string
+}
+
+// auditPageSize is how many entries VerifyAuditLog reads at a time.
+const auditPageSize = 500
+
+// AppendAuditEventTx adds an entry at the end of the audit log, chained to
+// the current last entry. Appends are serialized with an advisory lock so
+// two entries never share a prev_hash.
+func (store *SQLStore) AppendAuditEventTx(ctx context.Context, arg AppendAuditEventTxParams) (AuditEvent, error) {
+	var event AuditEvent
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.LockAuditLog(ctx)
+		if err != nil {
+			return err
+		}
+
+		prevHash, err := q.GetAuditLogHead(ctx)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		// Postgres keeps microseconds; hash what will be read back.
+		event = AuditEvent{
+			UserID:    arg.UserID,
+			Username:  arg.Username,
+			ActorID:   arg.ActorID,
+			Actor:     arg.Actor,
+			Type:      arg.Type,
+			Outcome:   arg.Outcome,
+			ClientIp:  arg.ClientIp,
+			UserAgent: arg.UserAgent,
+			Details:   arg.Details,
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+			PrevHash:  prevHash,
+		}
+		event.Hash = AuditEventHash(event)
+
+		event, err = q.CreateAuditEvent(ctx, CreateAuditEventParams{
+			UserID:    event.UserID,
+			Username:  event.Username,
+			ActorID:   event.ActorID,
+			Actor:     event.Actor,
+			Type:      event.Type,
+			Outcome:   event.Outcome,
+			ClientIp:  event.ClientIp,
+			UserAgent: event.UserAgent,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+			PrevHash:  event.PrevHash,
+			Hash:      event.Hash,
+		})
+		return err
+	})
+	return event, err
+}
+
+// VerifyAuditLog walks the whole audit log and checks its hash chain. A
+// truncated log still verifies; compare HeadHash and Events with a value
+// recorded earlier to detect that.
+func (store *SQLStore) VerifyAuditLog(ctx context.Context) (AuditLogVerification, error) {
+	var verification AuditLogVerification
+	var afterID int64
+	for {
+		events, err := store.ListAuditEventsAfter(ctx, ListAuditEventsAfterParams{
+			AfterID:   afterID,
+			PageLimit: auditPageSize,
+		})
+		if err != nil {
+			return verification, err
+		}
+		if !verification.Check(events) || len(events) < auditPageSize {
+			return verification, nil
+		}
+		afterID = events[len(events)-1].ID
+	}
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+)
+
+type ChangePasswordTxParams struct {
+	UserID    int32  `json:"user_id"`
+	Password  string `json:"password"`
+	SessionID int64  `json:"session_id"`
+}
+
+// ChangePasswordTx stores the new password hash, invalidates outstanding
+// reset tokens and revokes every session of the user except SessionID,
+// the one the password was changed from.
+func (store *SQLStore) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		err := q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:       arg.UserID,
+			Password: arg.Password,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidateUserPasswordResetTokens(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeOtherUserSessions(ctx, RevokeOtherUserSessionsParams{
+			UserID: arg.UserID,
+			ID:     arg.SessionID,
+		})
+	})
+}
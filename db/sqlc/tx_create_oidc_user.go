@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+)
+
+type CreateOidcUserTxParams struct {
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+}
+
+// CreateOidcUserTx creates a user signing in with an identity provider for
+// the first time and links the identity to it. The user has no password
+// until they set one through the password reset flow.
+func (store *SQLStore) CreateOidcUserTx(ctx context.Context, arg CreateOidcUserTxParams) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, CreateUserParams{
+			Username: arg.Username,
+			Email:    arg.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.EmailVerified {
+			_, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+			if err != nil {
+				return err
+			}
+			user, err = q.GetUserById(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = q.CreateUserIdentity(ctx, CreateUserIdentityParams{
+			UserID:   user.ID,
+			Provider: arg.Provider,
+			Subject:  arg.Subject,
+			Email:    arg.Email,
+		})
+		return err
+	})
+	return user, err
+}
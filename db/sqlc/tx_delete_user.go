@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+)
+
+// DeleteUserTx soft-deletes the account, signs it out everywhere and
+// invalidates outstanding password reset links. The rows are removed by
+// PurgeDeletedUsers once the grace period has passed.
+func (store *SQLStore) DeleteUserTx(ctx context.Context, userID int32) (User, error) {
+	var user User
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.SoftDeleteUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidateUserPasswordResetTokens(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeUserSessions(ctx, userID)
+	})
+	return user, err
+}
@@ -0,0 +1,21 @@
+package db
+
+import "context"
+
+// ImportExchangeRatesTx stores every rate or none of them. Rates already
+// stored for the same pair and date are replaced.
+func (store *SQLStore) ImportExchangeRatesTx(ctx context.Context, rates []UpsertExchangeRateParams) (int, error) {
+	err := store.execTx(ctx, func(q *Queries) error {
+		for _, rate := range rates {
+			_, err := q.UpsertExchangeRate(ctx, rate)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
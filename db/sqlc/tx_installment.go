@@ -0,0 +1,264 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wil-ckaew/gofinance-backend/money"
+)
+
+var (
+	ErrInstallmentPlanCancelled = errors.New("installment plan was cancelled")
+	ErrInstallmentsDue          = errors.New("installments already due cannot change")
+)
+
+type InstallmentPlanTxResult struct {
+	Plan         InstallmentPlan `json:"plan"`
+	Installments []Account       `json:"installments"`
+}
+
+// installmentDate returns the day installment number of a plan falls on:
+// the day of the month of first, number-1 months later, moved back to
+// the last day of months that are too short.
+func installmentDate(first time.Time, number int32) time.Time {
+	year, month, day := first.Date()
+	start := time.Date(year, month+time.Month(number-1), 1, 0, 0, 0, 0, time.UTC)
+	if last := start.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return start.AddDate(0, 0, day-1)
+}
+
+// dueInstallments returns how many installments of plan fall on or
+// before today.
+func dueInstallments(plan InstallmentPlan, today time.Time) int32 {
+	due := int32(0)
+	for due < plan.Installments && !installmentDate(plan.FirstDate, due+1).After(today) {
+		due++
+	}
+	return due
+}
+
+func installmentTitle(title string, number, installments int32) string {
+	return fmt.Sprintf("%s (%d/%d)", title, number, installments)
+}
+
+// recordInstallment records installment number of plan as a journaled
+// transaction linked to the plan.
+func recordInstallment(ctx context.Context, q *Queries, plan InstallmentPlan, number int32, amount int64) (Account, error) {
+	account, err := q.CreateAccount(ctx, CreateAccountParams{
+		LedgerID:    plan.LedgerID,
+		CreatedBy:   plan.CreatedBy,
+		CategoryID:  sql.NullInt32{Int32: plan.CategoryID, Valid: true},
+		Title:       installmentTitle(plan.Title, number, plan.Installments),
+		Type:        plan.Type,
+		Description: plan.Description,
+		Amount:      amount,
+		Currency:    plan.Currency,
+		Date:        installmentDate(plan.FirstDate, number),
+		WalletID:    plan.WalletID,
+	})
+	if err != nil {
+		return Account{}, err
+	}
+
+	err = journalAccount(ctx, q, account)
+	if err != nil {
+		return Account{}, err
+	}
+	_, err = q.CreateInstallment(ctx, CreateInstallmentParams{
+		PlanID:    plan.ID,
+		Number:    number,
+		AccountID: account.ID,
+	})
+	return account, err
+}
+
+func getInstallmentPlanWithAccounts(ctx context.Context, q *Queries, arg GetInstallmentPlanParams) (InstallmentPlanTxResult, error) {
+	plan, err := q.GetInstallmentPlan(ctx, arg)
+	if err != nil {
+		return InstallmentPlanTxResult{}, err
+	}
+	accounts, err := q.ListInstallmentAccounts(ctx, plan.ID)
+	if err != nil {
+		return InstallmentPlanTxResult{}, err
+	}
+	return InstallmentPlanTxResult{Plan: plan, Installments: accounts}, nil
+}
+
+// CreateInstallmentPlanTx records an installment plan together with the
+// transaction of each of its installments.
+func (store *SQLStore) CreateInstallmentPlanTx(ctx context.Context, arg CreateInstallmentPlanParams) (InstallmentPlanTxResult, error) {
+	var result InstallmentPlanTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		plan, err := q.CreateInstallmentPlan(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Plan = plan
+		total := money.New(plan.TotalAmount, plan.Currency)
+		for i, amount := range total.Split(int(plan.Installments), plan.Remainder == "first") {
+			account, err := recordInstallment(ctx, q, plan, int32(i+1), amount.Amount)
+			if err != nil {
+				return err
+			}
+			result.Installments = append(result.Installments, account)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetInstallmentPlanTx returns an installment plan of the ledger with the
+// transactions of its installments.
+func (store *SQLStore) GetInstallmentPlanTx(ctx context.Context, arg GetInstallmentPlanParams) (InstallmentPlanTxResult, error) {
+	var result InstallmentPlanTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = getInstallmentPlanWithAccounts(ctx, q, arg)
+		return err
+	})
+	return result, err
+}
+
+type UpdateInstallmentPlanTxParams struct {
+	ID           int32     `json:"id"`
+	LedgerID     int32     `json:"ledger_id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	TotalAmount  int64     `json:"total_amount"`
+	Installments int32     `json:"installments"`
+	Today        time.Time `json:"today"`
+}
+
+// UpdateInstallmentPlanTx changes a plan and all of its installments
+// still to come together. Installments due by today keep their amounts
+// and titles, so they go on reading "(2/4)" after the plan grows to six;
+// what is left of the new total is split again over the rest, which are
+// added or removed to match the new number of installments. It returns
+// ErrInstallmentsDue when that leaves no installment to change or less
+// than a cent for each.
+func (store *SQLStore) UpdateInstallmentPlanTx(ctx context.Context, arg UpdateInstallmentPlanTxParams) (InstallmentPlanTxResult, error) {
+	var result InstallmentPlanTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := getInstallmentPlanWithAccounts(ctx, q, GetInstallmentPlanParams{ID: arg.ID, LedgerID: arg.LedgerID})
+		if err != nil {
+			return err
+		}
+		if current.Plan.CancelledAt.Valid {
+			return ErrInstallmentPlanCancelled
+		}
+		installments, err := q.ListInstallments(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		due := dueInstallments(current.Plan, arg.Today)
+		remaining := arg.TotalAmount
+		accounts := map[int32]Account{}
+		for i, installment := range installments {
+			if installment.Number <= due {
+				remaining -= current.Installments[i].Amount
+			}
+			accounts[installment.Number] = current.Installments[i]
+		}
+		if arg.Installments <= due || remaining < int64(arg.Installments-due) {
+			return ErrInstallmentsDue
+		}
+
+		plan, err := q.UpdateInstallmentPlan(ctx, UpdateInstallmentPlanParams{
+			ID:           arg.ID,
+			Title:        arg.Title,
+			Description:  arg.Description,
+			TotalAmount:  arg.TotalAmount,
+			Installments: arg.Installments,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteInstallmentAccounts(ctx, DeleteInstallmentAccountsParams{PlanID: plan.ID, Number: plan.Installments})
+		if err != nil {
+			return err
+		}
+
+		amounts := money.New(remaining, plan.Currency).Split(int(plan.Installments-due), plan.Remainder == "first")
+		for i, amount := range amounts {
+			number := due + int32(i) + 1
+			account, ok := accounts[number]
+			if !ok {
+				_, err = recordInstallment(ctx, q, plan, number, amount.Amount)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			account, err = q.UpdateAccount(ctx, UpdateAccountParams{
+				ID:          account.ID,
+				Title:       installmentTitle(plan.Title, number, plan.Installments),
+				Description: plan.Description,
+				Amount:      amount.Amount,
+				Currency:    account.Currency,
+				WalletID:    account.WalletID,
+				LedgerID:    account.LedgerID,
+			})
+			if err != nil {
+				return err
+			}
+			err = q.DeleteAccountJournalEntry(ctx, sql.NullInt32{Int32: account.ID, Valid: true})
+			if err != nil {
+				return err
+			}
+			err = journalAccount(ctx, q, account)
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err = getInstallmentPlanWithAccounts(ctx, q, GetInstallmentPlanParams{ID: plan.ID, LedgerID: plan.LedgerID})
+		return err
+	})
+	return result, err
+}
+
+type CancelInstallmentPlanTxParams struct {
+	ID       int32     `json:"id"`
+	LedgerID int32     `json:"ledger_id"`
+	Today    time.Time `json:"today"`
+}
+
+// CancelInstallmentPlanTx cancels a plan and deletes the transactions of
+// its installments still to come. Installments due by today stay.
+func (store *SQLStore) CancelInstallmentPlanTx(ctx context.Context, arg CancelInstallmentPlanTxParams) (InstallmentPlanTxResult, error) {
+	var result InstallmentPlanTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		plan, err := q.GetInstallmentPlan(ctx, GetInstallmentPlanParams{ID: arg.ID, LedgerID: arg.LedgerID})
+		if err != nil {
+			return err
+		}
+		if plan.CancelledAt.Valid {
+			return ErrInstallmentPlanCancelled
+		}
+
+		_, err = q.DeleteInstallmentAccounts(ctx, DeleteInstallmentAccountsParams{
+			PlanID: plan.ID,
+			Number: dueInstallments(plan, arg.Today),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CancelInstallmentPlan(ctx, plan.ID)
+		if err != nil {
+			return err
+		}
+
+		result, err = getInstallmentPlanWithAccounts(ctx, q, GetInstallmentPlanParams{ID: plan.ID, LedgerID: plan.LedgerID})
+		return err
+	})
+	return result, err
+}
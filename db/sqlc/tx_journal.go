@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// walletAmount is what a transaction adds to its wallet's balance:
+// credits add their amount and debits take it away. ok is false for
+// transactions of any other type, which move no money.
+func walletAmount(account Account) (amount int64, ok bool) {
+	switch account.Type {
+	case "credit":
+		return account.Amount, true
+	case "debit":
+		return -account.Amount, true
+	}
+	return 0, false
+}
+
+// accountPostings balances a transaction's wallet against its category:
+// an expense debits the category with what leaves the wallet, an income
+// credits it with what reaches the wallet.
+func accountPostings(account Account) []CreatePostingParams {
+	amount, ok := walletAmount(account)
+	if !ok {
+		return nil
+	}
+	return []CreatePostingParams{
+		{WalletID: sql.NullInt32{Int32: account.WalletID, Valid: true}, Amount: amount, Currency: account.Currency},
+		{CategoryID: account.CategoryID, Amount: -amount, Currency: account.Currency},
+	}
+}
+
+// transferPostings balances the two legs of a transfer against each
+// other. Legs in different currencies each balance against a currency
+// exchange posting instead.
+func transferPostings(from, to Account) []CreatePostingParams {
+	postings := []CreatePostingParams{
+		{WalletID: sql.NullInt32{Int32: from.WalletID, Valid: true}, Amount: -from.Amount, Currency: from.Currency},
+		{WalletID: sql.NullInt32{Int32: to.WalletID, Valid: true}, Amount: to.Amount, Currency: to.Currency},
+	}
+	if from.Currency != to.Currency {
+		postings = append(postings,
+			CreatePostingParams{Amount: from.Amount, Currency: from.Currency},
+			CreatePostingParams{Amount: -to.Amount, Currency: to.Currency},
+		)
+	}
+	return postings
+}
+
+// postJournalEntry records an entry with its postings. The database
+// rejects the transaction at commit if they do not balance.
+func postJournalEntry(ctx context.Context, q *Queries, arg CreateJournalEntryParams, postings []CreatePostingParams) error {
+	entry, err := q.CreateJournalEntry(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	for _, posting := range postings {
+		posting.EntryID = entry.ID
+		_, err = q.CreatePosting(ctx, posting)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JournalVerification lists everything that keeps the books from
+// balancing. It is empty when they do.
+type JournalVerification struct {
+	UnbalancedEntries   []ListUnbalancedJournalEntriesRow `json:"unbalanced_entries"`
+	UnbalancedLedgers   []ListUnbalancedLedgersRow        `json:"unbalanced_ledgers"`
+	UnjournaledAccounts []ListUnjournaledAccountsRow      `json:"unjournaled_accounts"`
+	WalletMismatches    []ListWalletJournalMismatchesRow  `json:"wallet_mismatches"`
+}
+
+// Balanced reports whether the verification found nothing wrong.
+func (v JournalVerification) Balanced() bool {
+	return len(v.UnbalancedEntries) == 0 && len(v.UnbalancedLedgers) == 0 &&
+		len(v.UnjournaledAccounts) == 0 && len(v.WalletMismatches) == 0
+}
+
+// VerifyJournal checks the whole journal from one snapshot: every entry
+// and every ledger sums to zero in each currency, every transaction is
+// journaled, and every wallet's postings agree with its transactions.
+func (store *SQLStore) VerifyJournal(ctx context.Context) (JournalVerification, error) {
+	var verification JournalVerification
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return verification, err
+	}
+	defer tx.Rollback()
+
+	q := New(tx)
+	verification.UnbalancedEntries, err = q.ListUnbalancedJournalEntries(ctx)
+	if err != nil {
+		return verification, err
+	}
+	verification.UnbalancedLedgers, err = q.ListUnbalancedLedgers(ctx)
+	if err != nil {
+		return verification, err
+	}
+	verification.UnjournaledAccounts, err = q.ListUnjournaledAccounts(ctx)
+	if err != nil {
+		return verification, err
+	}
+	verification.WalletMismatches, err = q.ListWalletJournalMismatches(ctx)
+	if err != nil {
+		return verification, err
+	}
+	return verification, tx.Commit()
+}
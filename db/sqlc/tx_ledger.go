@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Roles of a ledger member. Owners manage members and invitations, editors
+// change categories and accounts, viewers only read them.
+const (
+	LedgerRoleOwner  = "owner"
+	LedgerRoleEditor = "editor"
+	LedgerRoleViewer = "viewer"
+)
+
+// PersonalLedgerName is the name of the ledger every user starts with.
+const PersonalLedgerName = "Personal"
+
+var ErrLedgerInvitationUsed = errors.New("invitation was already accepted or has expired")
+
+// CreateLedgerTx creates a ledger and makes its owner a member.
+func (store *SQLStore) CreateLedgerTx(ctx context.Context, arg CreateLedgerParams) (Ledger, error) {
+	var ledger Ledger
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		ledger, err = q.CreateLedger(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateLedgerMember(ctx, CreateLedgerMemberParams{
+			LedgerID: ledger.ID,
+			UserID:   ledger.OwnerID,
+			Role:     LedgerRoleOwner,
+		})
+		return err
+	})
+	return ledger, err
+}
+
+// EnsurePersonalLedgerTx returns the user's personal ledger, creating it on
+// first use. Concurrent calls for the same user end up with the same
+// ledger.
+func (store *SQLStore) EnsurePersonalLedgerTx(ctx context.Context, userID int32) (Ledger, error) {
+	ledger, err := store.GetPersonalLedger(ctx, userID)
+	if err != sql.ErrNoRows {
+		return ledger, err
+	}
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		ledger, err = q.CreatePersonalLedger(ctx, CreatePersonalLedgerParams{
+			Name:    PersonalLedgerName,
+			OwnerID: userID,
+		})
+		if err == sql.ErrNoRows {
+			// Another request created it first.
+			ledger, err = q.GetPersonalLedger(ctx, userID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateLedgerMember(ctx, CreateLedgerMemberParams{
+			LedgerID: ledger.ID,
+			UserID:   userID,
+			Role:     LedgerRoleOwner,
+		})
+		return err
+	})
+	return ledger, err
+}
+
+type AcceptLedgerInvitationTxParams struct {
+	InvitationID int64  `json:"invitation_id"`
+	LedgerID     int32  `json:"ledger_id"`
+	UserID       int32  `json:"user_id"`
+	Role         string `json:"role"`
+}
+
+// AcceptLedgerInvitationTx consumes an invitation and adds the user to the
+// ledger with the invited role. Users who already are members keep their
+// role.
+func (store *SQLStore) AcceptLedgerInvitationTx(ctx context.Context, arg AcceptLedgerInvitationTxParams) (LedgerMember, error) {
+	var member LedgerMember
+	err := store.execTx(ctx, func(q *Queries) error {
+		rows, err := q.AcceptLedgerInvitation(ctx, arg.InvitationID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrLedgerInvitationUsed
+		}
+
+		member, err = q.GetLedgerMember(ctx, GetLedgerMemberParams{
+			LedgerID: arg.LedgerID,
+			UserID:   arg.UserID,
+		})
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		member, err = q.CreateLedgerMember(ctx, CreateLedgerMemberParams{
+			LedgerID: arg.LedgerID,
+			UserID:   arg.UserID,
+			Role:     arg.Role,
+		})
+		return err
+	})
+	return member, err
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrMfaAlreadyConfirmed = errors.New("two-factor authentication is already enabled")
+
+type ConfirmMfaTxParams struct {
+	UserID             int32    `json:"user_id"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+// ConfirmMfaTx enables the pending TOTP enrollment of a user and replaces
+// their recovery codes.
+func (store *SQLStore) ConfirmMfaTx(ctx context.Context, arg ConfirmMfaTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		rows, err := q.ConfirmMfaTotp(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrMfaAlreadyConfirmed
+		}
+
+		err = q.DeleteMfaRecoveryCodes(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			err = q.CreateMfaRecoveryCode(ctx, CreateMfaRecoveryCodeParams{
+				UserID:   arg.UserID,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DisableMfaTx removes the TOTP secret and the recovery codes of a user.
+func (store *SQLStore) DisableMfaTx(ctx context.Context, userID int32) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteMfaTotp(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return q.DeleteMfaRecoveryCodes(ctx, userID)
+	})
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/wil-ckaew/gofinance-backend/schedule"
+)
+
+// Schedule returns when the recurring transaction occurs.
+func (recurring RecurringTransaction) Schedule() (schedule.Schedule, error) {
+	rule, err := schedule.Parse(recurring.Rule)
+	if err != nil {
+		return schedule.Schedule{}, err
+	}
+	return schedule.Schedule{
+		Rule:  rule,
+		Start: recurring.StartsOn,
+		Until: recurring.EndsOn.Time,
+		Count: int(recurring.OccurrenceCount.Int32),
+	}, nil
+}
+
+type GenerateRecurringTxParams struct {
+	Recurring RecurringTransaction
+	Date      time.Time
+}
+
+// GenerateRecurringTx records the occurrence of a recurring transaction
+// on a day as a journaled transaction, with the title, description and
+// amount it was modified to. It returns sql.ErrNoRows and records nothing
+// when the occurrence was generated before or skipped, so generating an
+// occurrence again is harmless.
+func (store *SQLStore) GenerateRecurringTx(ctx context.Context, arg GenerateRecurringTxParams) (Account, error) {
+	var account Account
+	err := store.execTx(ctx, func(q *Queries) error {
+		recurring := arg.Recurring
+		occurrence, err := q.GenerateRecurringOccurrence(ctx, GenerateRecurringOccurrenceParams{
+			RecurringID: recurring.ID,
+			Date:        arg.Date,
+		})
+		if err != nil {
+			return err
+		}
+
+		title, description, amount := recurring.Title, recurring.Description, recurring.Amount
+		if occurrence.Title.Valid {
+			title = occurrence.Title.String
+		}
+		if occurrence.Description.Valid {
+			description = occurrence.Description.String
+		}
+		if occurrence.Amount.Valid {
+			amount = occurrence.Amount.Int64
+		}
+
+		account, err = q.CreateAccount(ctx, CreateAccountParams{
+			LedgerID:    recurring.LedgerID,
+			CreatedBy:   recurring.CreatedBy,
+			CategoryID:  sql.NullInt32{Int32: recurring.CategoryID, Valid: true},
+			Title:       title,
+			Type:        recurring.Type,
+			Description: description,
+			Amount:      amount,
+			Currency:    recurring.Currency,
+			Date:        occurrence.Date,
+			WalletID:    recurring.WalletID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = journalAccount(ctx, q, account)
+		if err != nil {
+			return err
+		}
+		return q.SetRecurringOccurrenceAccount(ctx, SetRecurringOccurrenceAccountParams{
+			RecurringID: recurring.ID,
+			Date:        occurrence.Date,
+			AccountID:   sql.NullInt32{Int32: account.ID, Valid: true},
+		})
+	})
+	return account, err
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrPasswordResetTokenUsed = errors.New("password reset token was already used")
+
+type ResetPasswordTxParams struct {
+	TokenID  int64  `json:"token_id"`
+	UserID   int32  `json:"user_id"`
+	Password string `json:"password"`
+}
+
+// ResetPasswordTx consumes a reset token, stores the new password hash and
+// revokes every session and outstanding reset token of the user.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		rows, err := q.UsePasswordResetToken(ctx, arg.TokenID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrPasswordResetTokenUsed
+		}
+
+		err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:       arg.UserID,
+			Password: arg.Password,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidateUserPasswordResetTokens(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeUserSessions(ctx, arg.UserID)
+	})
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Types of the two legs of a transfer: money leaves the debit leg's
+// wallet and reaches the credit leg's one.
+const (
+	TransferLegFrom = "debit"
+	TransferLegTo   = "credit"
+)
+
+// TransferLegParams is the wallet one leg of a transfer is recorded in and
+// the amount it moves, in the wallet's currency.
+type TransferLegParams struct {
+	WalletID int32  `json:"wallet_id"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type CreateTransferTxParams struct {
+	LedgerID    int32             `json:"ledger_id"`
+	CreatedBy   sql.NullInt32     `json:"created_by"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Date        time.Time         `json:"date"`
+	From        TransferLegParams `json:"from"`
+	To          TransferLegParams `json:"to"`
+}
+
+type TransferTxResult struct {
+	Transfer Transfer `json:"transfer"`
+	From     Account  `json:"from"`
+	To       Account  `json:"to"`
+}
+
+// CreateTransferTx records a transfer, both of its legs and its journal
+// entry, or nothing.
+func (store *SQLStore) CreateTransferTx(ctx context.Context, arg CreateTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			LedgerID:  arg.LedgerID,
+			CreatedBy: arg.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		leg := func(side TransferLegParams, legType string) CreateTransferLegParams {
+			return CreateTransferLegParams{
+				LedgerID:    arg.LedgerID,
+				CreatedBy:   arg.CreatedBy,
+				TransferID:  sql.NullInt32{Int32: result.Transfer.ID, Valid: true},
+				WalletID:    side.WalletID,
+				Title:       arg.Title,
+				Type:        legType,
+				Description: arg.Description,
+				Amount:      side.Amount,
+				Currency:    side.Currency,
+				Date:        arg.Date,
+			}
+		}
+
+		result.From, err = q.CreateTransferLeg(ctx, leg(arg.From, TransferLegFrom))
+		if err != nil {
+			return err
+		}
+
+		result.To, err = q.CreateTransferLeg(ctx, leg(arg.To, TransferLegTo))
+		if err != nil {
+			return err
+		}
+
+		return journalTransfer(ctx, q, result)
+	})
+	return result, err
+}
+
+// GetTransferTx returns a transfer of the ledger with both of its legs.
+func (store *SQLStore) GetTransferTx(ctx context.Context, arg GetTransferParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = getTransferWithLegs(ctx, q, arg)
+		return err
+	})
+	return result, err
+}
+
+type UpdateTransferTxParams struct {
+	ID          int32     `json:"id"`
+	LedgerID    int32     `json:"ledger_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+	FromAmount  int64     `json:"from_amount"`
+	ToAmount    int64     `json:"to_amount"`
+}
+
+// UpdateTransferTx changes both legs of a transfer together and replaces
+// its journal entry. The legs stay in their wallets and currencies.
+func (store *SQLStore) UpdateTransferTx(ctx context.Context, arg UpdateTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = getTransferWithLegs(ctx, q, GetTransferParams{ID: arg.ID, LedgerID: arg.LedgerID})
+		if err != nil {
+			return err
+		}
+
+		leg := func(id int32, amount int64) UpdateTransferLegParams {
+			return UpdateTransferLegParams{
+				ID:          id,
+				Title:       arg.Title,
+				Description: arg.Description,
+				Amount:      amount,
+				Date:        arg.Date,
+				TransferID:  sql.NullInt32{Int32: arg.ID, Valid: true},
+			}
+		}
+
+		result.From, err = q.UpdateTransferLeg(ctx, leg(result.From.ID, arg.FromAmount))
+		if err != nil {
+			return err
+		}
+
+		result.To, err = q.UpdateTransferLeg(ctx, leg(result.To.ID, arg.ToAmount))
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteTransferJournalEntry(ctx, sql.NullInt32{Int32: arg.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		return journalTransfer(ctx, q, result)
+	})
+	return result, err
+}
+
+func getTransferWithLegs(ctx context.Context, q *Queries, arg GetTransferParams) (TransferTxResult, error) {
+	transfer, err := q.GetTransfer(ctx, arg)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	legs, err := q.ListTransferLegs(ctx, sql.NullInt32{Int32: transfer.ID, Valid: true})
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	if len(legs) != 2 || legs[0].Type != TransferLegFrom || legs[1].Type != TransferLegTo {
+		return TransferTxResult{}, fmt.Errorf("transfer %d does not have a debit and a credit leg", transfer.ID)
+	}
+	return TransferTxResult{Transfer: transfer, From: legs[0], To: legs[1]}, nil
+}
+
+func journalTransfer(ctx context.Context, q *Queries, result TransferTxResult) error {
+	return postJournalEntry(ctx, q, CreateJournalEntryParams{
+		LedgerID:   result.Transfer.LedgerID,
+		TransferID: sql.NullInt32{Int32: result.Transfer.ID, Valid: true},
+		Date:       result.From.Date,
+	}, transferPostings(result.From, result.To))
+}
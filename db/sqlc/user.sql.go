@@ -7,8 +7,29 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const confirmUserEmailChange = `-- name: ConfirmUserEmailChange :execrows
+UPDATE users
+SET email = pending_email, pending_email = NULL, verified_at = now()
+WHERE id = $1 AND pending_email = $2::varchar
+`
+
+type ConfirmUserEmailChangeParams struct {
+	ID    int32  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) ConfirmUserEmailChange(ctx context.Context, arg ConfirmUserEmailChangeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, confirmUserEmailChange, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   username,
@@ -16,7 +37,7 @@ INSERT INTO users (
   email
 ) VALUES (
   $1, $2, $3
-) RETURNING id, username, password, email, created_at
+) RETURNING id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency
 `
 
 type CreateUserParams struct {
@@ -34,12 +55,76 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Password,
 		&i.Email,
 		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+RETURNING id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency
+`
+
+func (q *Queries) DisableUser(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, disableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
+
+const enableUser = `-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL
+WHERE id = $1
+RETURNING id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, password, email, created_at FROM users
+SELECT id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -52,12 +137,46 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Password,
 		&i.Email,
 		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, username, password, email, created_at FROM users
+SELECT id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -70,6 +189,280 @@ func (q *Queries) GetUserById(ctx context.Context, id int32) (User, error) {
 		&i.Password,
 		&i.Email,
 		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
 	)
 	return i, err
 }
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency FROM users
+WHERE
+  LOWER(username) LIKE CONCAT('%', LOWER($1::text), '%')
+OR
+  LOWER(email) LIKE CONCAT('%', LOWER($1::text), '%')
+ORDER BY id
+LIMIT $2 OFFSET $3
+`
+
+type ListUsersParams struct {
+	Search     string `json:"search"`
+	PageLimit  int32  `json:"page_limit"`
+	PageOffset int32  `json:"page_offset"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Search, arg.PageLimit, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Password,
+			&i.Email,
+			&i.CreatedAt,
+			&i.VerifiedAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.PasswordResetRequired,
+			&i.DisplayName,
+			&i.PendingEmail,
+			&i.DeletedAt,
+			&i.BaseCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < $1::timestamptz
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUsers, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :execrows
+UPDATE users
+SET password = $1
+WHERE id = $2 AND password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewPassword string `json:"new_password"`
+	ID          int32  `json:"id"`
+	OldPassword string `json:"old_password"`
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rehashUserPassword, arg.NewPassword, arg.ID, arg.OldPassword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const requireUserPasswordReset = `-- name: RequireUserPasswordReset :one
+UPDATE users
+SET password_reset_required = true
+WHERE id = $1
+RETURNING id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency
+`
+
+func (q *Queries) RequireUserPasswordReset(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, requireUserPasswordReset, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
+
+const setUserPendingEmail = `-- name: SetUserPendingEmail :exec
+UPDATE users
+SET pending_email = $2
+WHERE id = $1
+`
+
+type SetUserPendingEmailParams struct {
+	ID           int32          `json:"id"`
+	PendingEmail sql.NullString `json:"pending_email"`
+}
+
+func (q *Queries) SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPendingEmail, arg.ID, arg.PendingEmail)
+	return err
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :one
+UPDATE users
+SET deleted_at = COALESCE(deleted_at, now()), pending_email = NULL
+WHERE id = $1
+RETURNING id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency
+`
+
+func (q *Queries) SoftDeleteUser(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2, password_reset_required = false
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID       int32  `json:"id"`
+	Password string `json:"password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.Password)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET username = $2, display_name = $3, base_currency = $4
+WHERE id = $1
+RETURNING id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency
+`
+
+type UpdateUserProfileParams struct {
+	ID           int32  `json:"id"`
+	Username     string `json:"username"`
+	DisplayName  string `json:"display_name"`
+	BaseCurrency string `json:"base_currency"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.ID,
+		arg.Username,
+		arg.DisplayName,
+		arg.BaseCurrency,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING id, username, password, email, created_at, verified_at, role, disabled_at, password_reset_required, display_name, pending_email, deleted_at, base_currency
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32  `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.PendingEmail,
+		&i.DeletedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :execrows
+UPDATE users
+SET verified_at = now()
+WHERE id = $1 AND email = $2 AND verified_at IS NULL
+`
+
+type VerifyUserEmailParams struct {
+	ID    int32  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -2,7 +2,10 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/wil-ckaew/gofinance-backend/util"
@@ -22,6 +25,9 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.Username, user.Username)
 	require.Equal(t, arg.Password, user.Password)
 	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, "user", user.Role)
+	require.False(t, user.DisabledAt.Valid)
+	require.Equal(t, "BRL", user.BaseCurrency)
 	require.NotEmpty(t, user.CreatedAt)
 
 	return user
@@ -31,6 +37,17 @@ func TestCreateUser(t *testing.T) {
 	createRandomUser(t)
 }
 
+func TestCreateUserUsernameTaken(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.CreateUser(context.Background(), CreateUserParams{
+		Username: user.Username,
+		Password: util.RandomString(12),
+		Email:    util.RandomEmail(8),
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
+
 func TestGetUser(t *testing.T) {
 	user1 := createRandomUser(t)
 	user2, err := testQueries.GetUser(context.Background(), user1.Username)
@@ -54,3 +71,272 @@ func TestGetUserById(t *testing.T) {
 	require.Equal(t, user1.Email, user2.Email)
 	require.NotEmpty(t, user2.CreatedAt)
 }
+
+func TestGetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.NotEmpty(t, user2)
+
+	require.Equal(t, user1.ID, user2.ID)
+	require.Equal(t, user1.Username, user2.Username)
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	arg := UpdateUserPasswordParams{
+		ID:       user1.ID,
+		Password: util.RandomString(12),
+	}
+	err := testQueries.UpdateUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+
+	user2, err := testQueries.GetUserById(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Password, user2.Password)
+}
+
+func TestVerifyUserEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.False(t, user1.VerifiedAt.Valid)
+
+	rows, err := testQueries.VerifyUserEmail(context.Background(), VerifyUserEmailParams{
+		ID:    user1.ID,
+		Email: util.RandomEmail(8),
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	arg := VerifyUserEmailParams{ID: user1.ID, Email: user1.Email}
+	rows, err = testQueries.VerifyUserEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	user2, err := testQueries.GetUserById(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.True(t, user2.VerifiedAt.Valid)
+
+	rows, err = testQueries.VerifyUserEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestListUsers(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomUser(t)
+
+	users, err := testQueries.ListUsers(context.Background(), ListUsersParams{
+		Search:     strings.ToUpper(user.Email),
+		PageLimit:  5,
+		PageOffset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user, users[0])
+
+	users, err = testQueries.ListUsers(context.Background(), ListUsersParams{PageLimit: 2})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+}
+
+func TestUpdateUserRoleTx(t *testing.T) {
+	session := createRandomSession(t)
+
+	admin, err := testStore.UpdateUserRoleTx(context.Background(), UpdateUserRoleParams{ID: session.UserID, Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, "admin", admin.Role)
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.RevokedAt.Valid)
+
+	_, err = testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{ID: session.UserID, Role: "owner"})
+	require.Error(t, err)
+}
+
+func TestDisableUserTx(t *testing.T) {
+	session := createRandomSession(t)
+
+	disabled, err := testStore.DisableUserTx(context.Background(), session.UserID)
+	require.NoError(t, err)
+	require.True(t, disabled.DisabledAt.Valid)
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.RevokedAt.Valid)
+
+	again, err := testStore.DisableUserTx(context.Background(), session.UserID)
+	require.NoError(t, err)
+	require.Equal(t, disabled.DisabledAt, again.DisabledAt)
+
+	enabled, err := testQueries.EnableUser(context.Background(), session.UserID)
+	require.NoError(t, err)
+	require.False(t, enabled.DisabledAt.Valid)
+}
+
+func TestForcePasswordResetTx(t *testing.T) {
+	user := createRandomUser(t)
+	pat := createRandomPersonalAccessToken(t, user)
+
+	forced, err := testStore.ForcePasswordResetTx(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.True(t, forced.PasswordResetRequired)
+
+	pat, err = testQueries.GetPersonalAccessToken(context.Background(), pat.ID)
+	require.NoError(t, err)
+	require.True(t, pat.RevokedAt.Valid)
+
+	err = testQueries.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		ID:       user.ID,
+		Password: util.RandomString(12),
+	})
+	require.NoError(t, err)
+
+	user, err = testQueries.GetUserById(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, user.PasswordResetRequired)
+}
+
+func TestGetSystemStats(t *testing.T) {
+	createRandomUser(t)
+
+	stats, err := testQueries.GetSystemStats(context.Background())
+	require.NoError(t, err)
+	require.Positive(t, stats.Users)
+	require.LessOrEqual(t, stats.VerifiedUsers, stats.Users)
+	require.LessOrEqual(t, stats.Admins, stats.Users)
+}
+
+func TestRehashUserPassword(t *testing.T) {
+	user := createRandomUser(t)
+	arg := RehashUserPasswordParams{
+		NewPassword: util.RandomString(12),
+		ID:          user.ID,
+		OldPassword: util.RandomString(12),
+	}
+
+	rows, err := testQueries.RehashUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	arg.OldPassword = user.Password
+	rows, err = testQueries.RehashUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	user, err = testQueries.GetUserById(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.NewPassword, user.Password)
+}
+
+func TestChangePasswordTx(t *testing.T) {
+	current := createRandomSession(t)
+	other, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+		UserID:           current.UserID,
+		RefreshTokenHash: util.RandomString(64),
+		ExpiresAt:        current.ExpiresAt,
+	})
+	require.NoError(t, err)
+
+	arg := ChangePasswordTxParams{
+		UserID:    current.UserID,
+		Password:  util.RandomString(12),
+		SessionID: current.ID,
+	}
+	require.NoError(t, testStore.ChangePasswordTx(context.Background(), arg))
+
+	user, err := testQueries.GetUserById(context.Background(), arg.UserID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Password, user.Password)
+
+	current, err = testQueries.GetSession(context.Background(), current.ID)
+	require.NoError(t, err)
+	require.False(t, current.RevokedAt.Valid)
+	other, err = testQueries.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.True(t, other.RevokedAt.Valid)
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	user1 := createRandomUser(t)
+	arg := UpdateUserProfileParams{
+		ID:           user1.ID,
+		Username:     util.RandomString(8),
+		DisplayName:  util.RandomString(10),
+		BaseCurrency: "EUR",
+	}
+
+	user2, err := testQueries.UpdateUserProfile(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user2.Username)
+	require.Equal(t, arg.DisplayName, user2.DisplayName)
+	require.Equal(t, arg.BaseCurrency, user2.BaseCurrency)
+	require.Equal(t, user1.Email, user2.Email)
+}
+
+func TestConfirmUserEmailChange(t *testing.T) {
+	user := createRandomUser(t)
+	newEmail := util.RandomEmail(8)
+
+	rows, err := testQueries.ConfirmUserEmailChange(context.Background(), ConfirmUserEmailChangeParams{ID: user.ID, Email: newEmail})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	err = testQueries.SetUserPendingEmail(context.Background(), SetUserPendingEmailParams{
+		ID:           user.ID,
+		PendingEmail: sql.NullString{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+
+	rows, err = testQueries.ConfirmUserEmailChange(context.Background(), ConfirmUserEmailChangeParams{ID: user.ID, Email: newEmail})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	user, err = testQueries.GetUserById(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, newEmail, user.Email)
+	require.False(t, user.PendingEmail.Valid)
+	require.True(t, user.VerifiedAt.Valid)
+}
+
+func TestDeleteUserTx(t *testing.T) {
+	session := createRandomSession(t)
+
+	user, err := testStore.DeleteUserTx(context.Background(), session.UserID)
+	require.NoError(t, err)
+	require.True(t, user.DeletedAt.Valid)
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.RevokedAt.Valid)
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	account := createRandomAccount(t)
+	kept := createRandomUser(t)
+
+	_, err := testStore.DeleteUserTx(context.Background(), account.CreatedBy.Int32)
+	require.NoError(t, err)
+
+	// Still within the grace period.
+	_, err = testQueries.PurgeDeletedUsers(context.Background(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = testQueries.GetUserById(context.Background(), account.CreatedBy.Int32)
+	require.NoError(t, err)
+
+	rows, err := testQueries.PurgeDeletedUsers(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, rows, int64(1))
+
+	// Ledgers the user owns go with them, categories and accounts included.
+	_, err = testQueries.GetUserById(context.Background(), account.CreatedBy.Int32)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.GetAccount(context.Background(), GetAccountParams{ID: account.ID, LedgerID: account.LedgerID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.GetCategory(context.Background(), GetCategoryParams{ID: account.CategoryID.Int32, LedgerID: account.LedgerID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.GetUserById(context.Background(), kept.ID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func createRandomWallet(t *testing.T, ledgerID int32, currency string) Wallet {
+	arg := CreateWalletParams{
+		LedgerID:       ledgerID,
+		Name:           util.RandomString(10),
+		Type:           "checking",
+		Currency:       currency,
+		OpeningBalance: 10000,
+	}
+
+	wallet, err := testQueries.CreateWallet(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, wallet.ID)
+	require.Equal(t, arg.LedgerID, wallet.LedgerID)
+	require.Equal(t, arg.Name, wallet.Name)
+	require.Equal(t, arg.Type, wallet.Type)
+	require.Equal(t, arg.Currency, wallet.Currency)
+	require.Equal(t, arg.OpeningBalance, wallet.OpeningBalance)
+	require.NotZero(t, wallet.CreatedAt)
+
+	return wallet
+}
+
+// createWalletTransaction records amount as a transaction of type in the
+// wallet on date.
+func createWalletTransaction(t *testing.T, wallet Wallet, accountType string, amount int64, date time.Time) Account {
+	category, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		LedgerID:    wallet.LedgerID,
+		Title:       util.RandomString(12),
+		Type:        accountType,
+		Description: util.RandomString(20),
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+		LedgerID:    wallet.LedgerID,
+		CategoryID:  sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:       util.RandomString(12),
+		Type:        accountType,
+		Description: util.RandomString(20),
+		Amount:      amount,
+		Currency:    wallet.Currency,
+		Date:        date,
+		WalletID:    wallet.ID,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestCreateWalletRejectsUnknownType(t *testing.T) {
+	ledger := createRandomLedger(t)
+	_, err := testQueries.CreateWallet(context.Background(), CreateWalletParams{
+		LedgerID: ledger.ID,
+		Name:     util.RandomString(10),
+		Type:     "mattress",
+		Currency: "BRL",
+	})
+	require.Error(t, err)
+}
+
+func TestGetWallet(t *testing.T) {
+	wallet1 := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+
+	wallet2, err := testQueries.GetWallet(context.Background(), GetWalletParams{
+		ID:       wallet1.ID,
+		LedgerID: wallet1.LedgerID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, wallet1, wallet2)
+
+	_, err = testQueries.GetWallet(context.Background(), GetWalletParams{
+		ID:       wallet1.ID,
+		LedgerID: createRandomLedger(t).ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateWallet(t *testing.T) {
+	wallet1 := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+
+	arg := UpdateWalletParams{
+		ID:             wallet1.ID,
+		Name:           util.RandomString(10),
+		Type:           "savings",
+		OpeningBalance: -500,
+		LedgerID:       wallet1.LedgerID,
+	}
+	wallet2, err := testQueries.UpdateWallet(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Name, wallet2.Name)
+	require.Equal(t, arg.Type, wallet2.Type)
+	require.Equal(t, arg.OpeningBalance, wallet2.OpeningBalance)
+	require.Equal(t, wallet1.Currency, wallet2.Currency)
+}
+
+func TestWalletBalances(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	createWalletTransaction(t, wallet, "credit", 5000, day)
+	createWalletTransaction(t, wallet, "debit", 1200, day)
+	debit := createWalletTransaction(t, wallet, "debit", 300, day.AddDate(0, 0, 2))
+
+	total, err := testQueries.GetWalletTransactionsTotal(context.Background(), GetWalletTransactionsTotalParams{
+		WalletID: wallet.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3500), total)
+
+	total, err = testQueries.GetWalletTransactionsTotal(context.Background(), GetWalletTransactionsTotalParams{
+		WalletID: wallet.ID,
+		Date:     sql.NullTime{Time: day.AddDate(0, 0, 1), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3800), total)
+
+	days, err := testQueries.ListWalletDailyTotals(context.Background(), ListWalletDailyTotalsParams{
+		WalletID: wallet.ID,
+		FromDate: day,
+		ToDate:   day.AddDate(0, 0, 2),
+	})
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	require.Equal(t, int64(3800), days[0].Total)
+	require.Equal(t, int64(-300), days[1].Total)
+
+	wallets, err := testQueries.ListWallets(context.Background(), wallet.LedgerID)
+	require.NoError(t, err)
+	require.Len(t, wallets, 1)
+	require.Equal(t, wallet.OpeningBalance+3500, wallets[0].Balance)
+
+	// Balances follow edits and deletions of transactions.
+	_, err = testQueries.DeleteAccount(context.Background(), DeleteAccountParams{ID: debit.ID, LedgerID: debit.LedgerID})
+	require.NoError(t, err)
+	wallets, err = testQueries.ListWallets(context.Background(), wallet.LedgerID)
+	require.NoError(t, err)
+	require.Equal(t, wallet.OpeningBalance+3800, wallets[0].Balance)
+}
+
+func TestDeleteWallet(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "BRL")
+	account := createWalletTransaction(t, wallet, "debit", 100, time.Now())
+
+	rows, err := testQueries.DeleteWallet(context.Background(), DeleteWalletParams{ID: wallet.ID, LedgerID: wallet.LedgerID})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	_, err = testQueries.DeleteAccount(context.Background(), DeleteAccountParams{ID: account.ID, LedgerID: account.LedgerID})
+	require.NoError(t, err)
+
+	// Neither are wallets recurring transactions are booked to.
+	recurring := createRandomRecurringTransaction(t, wallet, "FREQ=MONTHLY", time.Now())
+	rows, err = testQueries.DeleteWallet(context.Background(), DeleteWalletParams{ID: wallet.ID, LedgerID: wallet.LedgerID})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	_, err = testQueries.DeleteRecurringTransaction(context.Background(), DeleteRecurringTransactionParams{ID: recurring.ID, LedgerID: recurring.LedgerID})
+	require.NoError(t, err)
+
+	rows, err = testQueries.DeleteWallet(context.Background(), DeleteWalletParams{ID: wallet.ID, LedgerID: createRandomLedger(t).ID})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.DeleteWallet(context.Background(), DeleteWalletParams{ID: wallet.ID, LedgerID: wallet.LedgerID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestAccountWalletMustMatchCurrency(t *testing.T) {
+	wallet := createRandomWallet(t, createRandomLedger(t).ID, "USD")
+	category := createRandomCategory(t)
+
+	_, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+		LedgerID:    wallet.LedgerID,
+		CategoryID:  sql.NullInt32{Int32: category.ID, Valid: true},
+		Title:       util.RandomString(12),
+		Type:        "debit",
+		Description: util.RandomString(20),
+		Amount:      100,
+		Currency:    "BRL",
+		Date:        time.Now(),
+		WalletID:    wallet.ID,
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func TestLogMailer(t *testing.T) {
+	var out bytes.Buffer
+	mailer := NewLogMailer(&out, "no-reply@gofinance.local")
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "alice@email.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	require.Contains(t, out.String(), "From: no-reply@gofinance.local\n")
+	require.Contains(t, out.String(), "To: alice@email.com\n")
+	require.Contains(t, out.String(), "Subject: Hello\n")
+	require.Contains(t, out.String(), "line one\nline two")
+}
+
+func TestNewMailer(t *testing.T) {
+	mailer, err := NewMailer(util.Config{MailDriver: "smtp", SMTPHost: "localhost", SMTPPort: "25"})
+	require.NoError(t, err)
+	require.IsType(t, &SMTPMailer{}, mailer)
+
+	mailer, err = NewMailer(util.Config{MailDriver: "log"})
+	require.NoError(t, err)
+	require.IsType(t, &LogMailer{}, mailer)
+
+	_, err = NewMailer(util.Config{MailDriver: "pigeon"})
+	require.Error(t, err)
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	mailer := NewSMTPMailer("localhost", "25", "", "", "no-reply@gofinance.local")
+	err := mailer.Send(context.Background(), Message{To: "alice@email.com\r\nBcc: eve@email.com", Subject: "Hi"})
+	require.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional emails such as password reset links.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer builds the mailer selected by MAIL_DRIVER. The "log" driver
+// writes messages to MAIL_LOG_FILE, or to stdout when it is empty.
+func NewMailer(config util.Config) (Mailer, error) {
+	switch config.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom), nil
+	case "log", "":
+		if config.MailLogFile == "" {
+			return NewLogMailer(os.Stdout, config.MailFrom), nil
+		}
+		file, err := os.OpenFile(config.MailLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return NewLogMailer(file, config.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", config.MailDriver)
+	}
+}
@@ -1,34 +1,47 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
-	"os"
 
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/wil-ckaew/gofinance-backend/api"
 	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/mail"
+	"github.com/wil-ckaew/gofinance-backend/token"
+	"github.com/wil-ckaew/gofinance-backend/util"
+	"github.com/wil-ckaew/gofinance-backend/worker"
 )
 
 func main() {
-	err := godotenv.Load()
+	config, err := util.LoadConfig(".env")
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Fatal("cannot load config: ", err)
 	}
 
-	dbDriver := os.Getenv("DB_DRIVER")
-	dbSource := os.Getenv("DB_SOURCE")
-	serverAddress := os.Getenv("SERVER_ADDRESS")
-	conn, err := sql.Open(dbDriver, dbSource)
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
 	if err != nil {
 		log.Fatal("cannot connect to db: ", err)
 	}
 
+	keyRing, err := token.LoadKeyRing(config.TokenKeys, config.TokenActiveKeyID)
+	if err != nil {
+		log.Fatal("cannot load token keys: ", err)
+	}
+
+	mailer, err := mail.NewMailer(config)
+	if err != nil {
+		log.Fatal("cannot create mailer: ", err)
+	}
+
 	store := db.NewStore(conn)
-	server := api.NewServer(store)
+	go worker.NewAccountPurger(store, config.AccountDeletionGracePeriod, config.AccountPurgeInterval).Run(context.Background())
+	go worker.NewRecurringGenerator(store, config.RecurringInterval).Run(context.Background())
+
+	server := api.NewServer(config, store, token.NewService(keyRing, config.AccessTokenDuration), mailer)
 
-	err = server.Start(serverAddress)
+	err = server.Start(config.ServerAddress)
 	if err != nil {
 		log.Fatal("cannot start api: ", err)
 	}
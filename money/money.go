@@ -0,0 +1,190 @@
+// Package money represents amounts as integer minor units of an ISO 4217
+// currency, so they add up exactly. In JSON a Money is a decimal string and
+// a currency code: {"amount":"123.45","currency":"BRL"}.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrOverflow         = errors.New("amount out of range")
+)
+
+type Money struct {
+	// Amount is in minor units of Currency, cents for BRL.
+	Amount   int64
+	Currency string
+}
+
+// New returns amount minor units of currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Parse reads a decimal amount such as "123.45" or "-7" in currency. It
+// rejects more decimal places than the currency has.
+func Parse(amount, currency string) (Money, error) {
+	digits, err := MinorUnits(currency)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q", err, currency)
+	}
+
+	invalid := fmt.Errorf("%w %q for %s", ErrInvalidAmount, amount, currency)
+	units, fraction, found := strings.Cut(amount, ".")
+	sign := ""
+	if strings.HasPrefix(units, "-") {
+		sign, units = "-", units[1:]
+	}
+	if !isDigits(units) || found && (!isDigits(fraction) || len(fraction) > digits) {
+		return Money{}, invalid
+	}
+
+	minor, err := strconv.ParseInt(sign+units+fraction+strings.Repeat("0", digits-len(fraction)), 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return Money{}, ErrOverflow
+		}
+		return Money{}, invalid
+	}
+	return New(minor, currency), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in major units with the currency's decimal
+// places, "123.45" for 12345 BRL.
+func (m Money) Decimal() string {
+	digits, ok := minorUnits[m.Currency]
+	if !ok {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign, abs = "-", -abs
+	}
+	if digits == 0 {
+		return sign + strconv.FormatUint(abs, 10)
+	}
+	scale := uint64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/scale, digits, abs%scale)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns m + other. Both must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return New(sum, m.Currency), nil
+}
+
+// Sub returns m - other. Both must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(New(-other.Amount, other.Currency))
+}
+
+// Mul returns m times n.
+func (m Money) Mul(n int64) (Money, error) {
+	product := m.Amount * n
+	if m.Amount != 0 && (product/m.Amount != n || (m.Amount == -1 && n == math.MinInt64)) {
+		return Money{}, ErrOverflow
+	}
+	return New(product, m.Currency), nil
+}
+
+// Split divides m into n parts that add up to it exactly. Every part is
+// m/n rounded toward zero, except one that also takes the remainder: the
+// first when remainderFirst is set, the last otherwise. n must be
+// positive.
+func (m Money) Split(n int, remainderFirst bool) []Money {
+	share := m.Amount / int64(n)
+	parts := make([]Money, n)
+	for i := range parts {
+		parts[i] = New(share, m.Currency)
+	}
+
+	remainder := m.Amount - share*int64(n)
+	if remainderFirst {
+		parts[0].Amount += remainder
+	} else {
+		parts[n-1].Amount += remainder
+	}
+	return parts
+}
+
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, err := json.Marshal(m.Decimal())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonMoney{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts the amount as a string or as a JSON number, which
+// is read digit by digit and never goes through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var v jsonMoney
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	amount := string(v.Amount)
+	if strings.HasPrefix(amount, `"`) {
+		err = json.Unmarshal(v.Amount, &amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,172 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		currency string
+		want     int64
+		decimal  string
+	}{
+		{"123.45", "BRL", 12345, "123.45"},
+		{"123.4", "BRL", 12340, "123.40"},
+		{"123", "BRL", 12300, "123.00"},
+		{"-0.05", "BRL", -5, "-0.05"},
+		{"1500", "JPY", 1500, "1500"},
+		{"1.234", "KWD", 1234, "1.234"},
+		{"92233720368547758.07", "USD", math.MaxInt64, "92233720368547758.07"},
+	}
+
+	for _, tc := range testCases {
+		m, err := Parse(tc.amount, tc.currency)
+		require.NoError(t, err, tc.amount)
+		require.Equal(t, New(tc.want, tc.currency), m)
+		require.Equal(t, tc.decimal, m.Decimal())
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		currency string
+		err      error
+	}{
+		{"1.234", "BRL", ErrInvalidAmount},
+		{"1.5", "JPY", ErrInvalidAmount},
+		{"", "BRL", ErrInvalidAmount},
+		{".5", "BRL", ErrInvalidAmount},
+		{"1.", "BRL", ErrInvalidAmount},
+		{"1,50", "BRL", ErrInvalidAmount},
+		{"+1", "BRL", ErrInvalidAmount},
+		{"1e3", "BRL", ErrInvalidAmount},
+		{"1", "brl", ErrUnknownCurrency},
+		{"1", "XYZ", ErrUnknownCurrency},
+		{"92233720368547758.08", "USD", ErrOverflow},
+	}
+
+	for _, tc := range testCases {
+		_, err := Parse(tc.amount, tc.currency)
+		require.ErrorIs(t, err, tc.err, tc.amount)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	require.Equal(t, "123.45", New(12345, "BRL").Decimal())
+	require.Equal(t, "0.05", New(5, "BRL").Decimal())
+	require.Equal(t, "-0.05", New(-5, "BRL").Decimal())
+	require.Equal(t, "1500", New(1500, "JPY").Decimal())
+	require.Equal(t, "1.000", New(1000, "BHD").Decimal())
+	require.Equal(t, "-92233720368547758.08", New(math.MinInt64, "USD").Decimal())
+	require.Equal(t, "12.34 EUR", New(1234, "EUR").String())
+}
+
+func TestArithmetic(t *testing.T) {
+	a := New(1050, "BRL")
+	b := New(250, "BRL")
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	require.Equal(t, New(1300, "BRL"), sum)
+
+	diff, err := b.Sub(a)
+	require.NoError(t, err)
+	require.Equal(t, New(-800, "BRL"), diff)
+
+	product, err := a.Mul(3)
+	require.NoError(t, err)
+	require.Equal(t, New(3150, "BRL"), product)
+
+	_, err = a.Add(New(1, "USD"))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = New(math.MaxInt64, "BRL").Add(New(1, "BRL"))
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MinInt64, "BRL").Sub(New(1, "BRL"))
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MaxInt64/2+1, "BRL").Mul(2)
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MinInt64, "BRL").Mul(-1)
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestSplit(t *testing.T) {
+	total := New(10000, "BRL")
+	require.Equal(t, []Money{New(3334, "BRL"), New(3333, "BRL"), New(3333, "BRL")}, total.Split(3, true))
+	require.Equal(t, []Money{New(3333, "BRL"), New(3333, "BRL"), New(3334, "BRL")}, total.Split(3, false))
+	require.Equal(t, []Money{New(5000, "BRL"), New(5000, "BRL")}, total.Split(2, true))
+	require.Equal(t, []Money{New(-3, "BRL"), New(-3, "BRL"), New(-4, "BRL")}, New(-10, "BRL").Split(3, false))
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(12345, "BRL"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"123.45","currency":"BRL"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal(data, &m))
+	require.Equal(t, New(12345, "BRL"), m)
+
+	// Numbers are read exactly, without going through a float.
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":0.1,"currency":"USD"}`), &m))
+	require.Equal(t, New(10, "USD"), m)
+
+	require.Error(t, json.Unmarshal([]byte(`{"amount":"1.005","currency":"USD"}`), &m))
+	require.Error(t, json.Unmarshal([]byte(`{"amount":"1"}`), &m))
+	require.Error(t, json.Unmarshal([]byte(`{"currency":"USD"}`), &m))
+}
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("5.1234")
+	require.NoError(t, err)
+	require.Equal(t, "5.1234", FormatRate(rate))
+
+	rate, err = ParseRate("0.0000000001")
+	require.NoError(t, err)
+	require.Equal(t, "0.0000000001", FormatRate(rate))
+
+	for _, s := range []string{"0", "0.0", "-1", "1.", ".5", "1e3", "1/3", "0.00000000001", ""} {
+		_, err = ParseRate(s)
+		require.ErrorIs(t, err, ErrInvalidRate, s)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		from Money
+		to   string
+		rate string
+		want Money
+	}{
+		{New(10000, "USD"), "BRL", "5.1234", New(51234, "BRL")},
+		// 0.01 USD is 0.051234 BRL, rounded to 0.05.
+		{New(1, "USD"), "BRL", "5.1234", New(5, "BRL")},
+		// Halves round away from zero.
+		{New(1, "USD"), "BRL", "0.5", New(1, "BRL")},
+		{New(-1, "USD"), "BRL", "0.5", New(-1, "BRL")},
+		{New(1000, "JPY"), "BRL", "0.0345", New(3450, "BRL")},
+		{New(12345, "BRL"), "JPY", "28.99", New(3579, "JPY")},
+		{New(1000, "BRL"), "KWD", "0.055", New(550, "KWD")},
+	}
+
+	for _, tc := range testCases {
+		rate, err := ParseRate(tc.rate)
+		require.NoError(t, err)
+		got, err := tc.from.Convert(tc.to, rate)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got, tc.from.String())
+	}
+
+	rate, err := ParseRate("1000")
+	require.NoError(t, err)
+	_, err = New(math.MaxInt64, "USD").Convert("BRL", rate)
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(1, "USD").Convert("XYZ", rate)
+	require.ErrorIs(t, err, ErrUnknownCurrency)
+}
@@ -0,0 +1,68 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RateDigits is the number of decimal places exchange rates are kept with.
+const RateDigits = 10
+
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// ParseRate reads a positive decimal exchange rate such as "5.1234".
+func ParseRate(s string) (*big.Rat, error) {
+	units, fraction, found := strings.Cut(s, ".")
+	if !isDigits(units) || found && (!isDigits(fraction) || len(fraction) > RateDigits) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidRate, s)
+	}
+
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w %q", ErrInvalidRate, s)
+	}
+	return rate, nil
+}
+
+// FormatRate formats rate with up to RateDigits decimal places and no
+// trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(RateDigits)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Convert returns m in currency to, where one unit of m's currency is
+// worth rate units of to. The result is rounded half away from zero to the
+// minor unit of to.
+func (m Money) Convert(to string, rate *big.Rat) (Money, error) {
+	fromDigits, err := MinorUnits(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	toDigits, err := MinorUnits(to)
+	if err != nil {
+		return Money{}, err
+	}
+
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, rate)
+	for digits := fromDigits; digits < toDigits; digits++ {
+		value.Mul(value, big.NewRat(10, 1))
+	}
+	for digits := toDigits; digits < fromDigits; digits++ {
+		value.Mul(value, big.NewRat(1, 10))
+	}
+
+	quo, rem := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	rem.Abs(rem).Lsh(rem, 1)
+	if rem.Cmp(value.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(value.Sign())))
+	}
+	if !quo.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return New(quo.Int64(), to), nil
+}
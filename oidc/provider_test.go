@@ -0,0 +1,90 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/oidc"
+	"github.com/wil-ckaew/gofinance-backend/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:3000/oidc/callback"
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	mock, err := oidctest.NewProvider("gofinance", "secret")
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+	return mock, oidc.NewProvider(mock.Config("mock", redirectURL), mock.Server.Client())
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	mock, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, mock.Issuer()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, oidc.CodeChallenge(verifier), u.Query().Get("code_challenge"))
+	require.Equal(t, "openid email profile", u.Query().Get("scope"))
+
+	identity := oidctest.Identity{Subject: "user-1", Email: "alice@email.com", EmailVerified: true}
+	code, state, err := mock.Authorize(authURL, identity)
+	require.NoError(t, err)
+	require.Equal(t, "state-1", state)
+
+	// A wrong verifier is refused by the provider.
+	_, err = provider.Exchange(ctx, code, "wrong")
+	require.Error(t, err)
+
+	code, _, err = mock.Authorize(authURL, identity)
+	require.NoError(t, err)
+	rawIDToken, err := provider.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	idToken, err := provider.VerifyIDToken(ctx, rawIDToken, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-1", idToken.Subject)
+	require.Equal(t, "alice@email.com", idToken.Email)
+	require.True(t, idToken.EmailVerified)
+
+	_, err = provider.VerifyIDToken(ctx, rawIDToken, "other-nonce")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	mock, provider := newTestProvider(t)
+	other, _ := newTestProvider(t)
+	ctx := context.Background()
+	identity := oidctest.Identity{Subject: "user-1"}
+
+	expired, err := mock.IDToken(identity, mock.ClientID, "n", -time.Minute)
+	require.NoError(t, err)
+	wrongAudience, err := mock.IDToken(identity, "someone-else", "n", time.Minute)
+	require.NoError(t, err)
+	wrongIssuer, err := other.IDToken(identity, mock.ClientID, "n", time.Minute)
+	require.NoError(t, err)
+	noSubject, err := mock.IDToken(oidctest.Identity{}, mock.ClientID, "n", time.Minute)
+	require.NoError(t, err)
+
+	for _, rawIDToken := range []string{expired, wrongAudience, wrongIssuer, noSubject, "garbage"} {
+		_, err := provider.VerifyIDToken(ctx, rawIDToken, "n")
+		require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	mock, _ := newTestProvider(t)
+	config := mock.Config("mock", redirectURL)
+	config.Issuer += "/"
+
+	_, err := oidc.NewProvider(config, mock.Server.Client()).AuthCodeURL(context.Background(), "s", "n", "v")
+	require.ErrorIs(t, err, oidc.ErrIssuerMismatch)
+}
@@ -0,0 +1,376 @@
+// Package schedule expands recurrence rules, written in a subset of the
+// iCalendar RRULE syntax of RFC 5545, into the days they fall on:
+// "FREQ=MONTHLY;BYMONTHDAY=5" is the fifth of every month,
+// "FREQ=WEEKLY;INTERVAL=2" every other week and
+// "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1" the last business day of
+// the month. Days are dates at midnight UTC.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+var weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Rule is a parsed recurrence rule. Every Interval periods of Freq it
+// falls on the days of the period matching ByDay and ByMonthDay, or on the
+// day of the start when both are empty; BySetPos, when set, keeps only
+// the nth of those days, counting from the end when negative.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	// ByMonthDay counts from the end of the month when negative, -1
+	// being the last day.
+	ByMonthDay []int
+	BySetPos   int
+}
+
+// Parse reads a rule such as "FREQ=MONTHLY;BYMONTHDAY=-1". It supports
+// FREQ, INTERVAL, BYDAY (without ordinals), BYMONTHDAY for monthly rules
+// and BYSETPOS; the end of a schedule is kept apart from its rule.
+func Parse(rule string) (Rule, error) {
+	r := Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+		name, value, found := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !found || value == "" {
+			return Rule{}, fmt.Errorf("%w: %q is not NAME=VALUE", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return Rule{}, fmt.Errorf("%w: %s given twice", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			switch r.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unknown frequency %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseInt(value, 1, 1000)
+		case "BYDAY":
+			r.ByDay, err = parseWeekdays(value)
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				var n int
+				n, err = parseInt(day, -31, 31)
+				if err == nil && n == 0 {
+					err = errors.New("month day 0")
+				}
+				if err != nil {
+					break
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "BYSETPOS":
+			r.BySetPos, err = parseInt(value, -366, 366)
+			if err == nil && r.BySetPos == 0 {
+				err = errors.New("BYSETPOS 0")
+			}
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	case len(r.ByMonthDay) > 0 && r.Freq != Monthly:
+		return Rule{}, fmt.Errorf("%w: BYMONTHDAY needs FREQ=MONTHLY", ErrInvalidRule)
+	case len(r.ByDay) > 0 && r.Freq == Yearly:
+		return Rule{}, fmt.Errorf("%w: BYDAY is not supported with FREQ=YEARLY", ErrInvalidRule)
+	case r.BySetPos != 0 && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0:
+		return Rule{}, fmt.Errorf("%w: BYSETPOS needs BYDAY or BYMONTHDAY", ErrInvalidRule)
+	}
+	return r, nil
+}
+
+func parseInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%q is not a number from %d to %d", value, min, max)
+	}
+	return n, nil
+}
+
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, code := range strings.Split(strings.ToUpper(value), ",") {
+		day := -1
+		for i, weekday := range weekdays {
+			if code == weekday {
+				day = i
+			}
+		}
+		if day < 0 {
+			return nil, fmt.Errorf("unknown weekday %q", code)
+		}
+		days = append(days, time.Weekday(day))
+	}
+	return days, nil
+}
+
+// String writes the rule back in the form Parse reads.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = weekdays[day]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.BySetPos != 0 {
+		parts = append(parts, "BYSETPOS="+strconv.Itoa(r.BySetPos))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Day returns the calendar day of t as midnight UTC.
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// periodStart returns the first day of the nth period of the rule, the
+// first being the one holding start. Weeks start on Monday.
+func (r Rule) periodStart(start time.Time, n int) time.Time {
+	step := n * r.Interval
+	switch r.Freq {
+	case Weekly:
+		monday := start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		return monday.AddDate(0, 0, 7*step)
+	case Monthly:
+		return time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+	case Yearly:
+		return time.Date(start.Year()+step, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return start.AddDate(0, 0, step)
+}
+
+// periodOf returns the number of the period holding day, counting from
+// the one holding start, for day on or after start.
+func (r Rule) periodOf(start, day time.Time) int {
+	var n int
+	switch r.Freq {
+	case Daily:
+		n = int((day.Unix() - start.Unix()) / (24 * 60 * 60))
+	case Weekly:
+		monday := start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		n = int((day.Unix()-monday.Unix())/(24*60*60)) / 7
+	case Monthly:
+		n = (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+	case Yearly:
+		n = day.Year() - start.Year()
+	}
+	return n / r.Interval
+}
+
+// cycle returns after how many periods the days the rule falls on repeat:
+// weekdays repeat every 7 days and the Gregorian calendar every 400
+// years.
+func (r Rule) cycle() int {
+	switch r.Freq {
+	case Daily:
+		return 7 / gcd(7, r.Interval)
+	case Monthly:
+		return 4800 / gcd(4800, r.Interval)
+	case Yearly:
+		return 400 / gcd(400, r.Interval)
+	}
+	return 1
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// countDays counts the days the rule falls on in count periods from the
+// nth on, n > 0, without going through every one of them.
+func (r Rule) countDays(start time.Time, n, count int) int {
+	total := 0
+	if cycle := r.cycle(); count > cycle {
+		perCycle := r.countDays(start, n, cycle)
+		total = count / cycle * perCycle
+		count %= cycle
+	}
+	for i := 0; i < count; i++ {
+		total += len(r.days(r.periodStart(start, n+i), start))
+	}
+	return total
+}
+
+// days lists in order the days of the period beginning on first that the
+// rule falls on.
+func (r Rule) days(first, start time.Time) []time.Time {
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		if len(r.ByDay) == 0 || hasWeekday(r.ByDay, first.Weekday()) {
+			days = append(days, first)
+		}
+	case Weekly:
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{start.Weekday()}
+		}
+		for i := 0; i < 7; i++ {
+			day := first.AddDate(0, 0, i)
+			if hasWeekday(byDay, day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		byMonthDay := r.ByMonthDay
+		if len(byMonthDay) == 0 && len(r.ByDay) == 0 {
+			byMonthDay = []int{start.Day()}
+		}
+		length := first.AddDate(0, 1, -1).Day()
+		for d := 1; d <= length; d++ {
+			day := first.AddDate(0, 0, d-1)
+			if len(byMonthDay) > 0 && !hasMonthDay(byMonthDay, d, length) {
+				continue
+			}
+			if len(r.ByDay) > 0 && !hasWeekday(r.ByDay, day.Weekday()) {
+				continue
+			}
+			days = append(days, day)
+		}
+	case Yearly:
+		// Like RFC 5545, a yearly rule started on February 29 skips the
+		// years without one.
+		day := time.Date(first.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Month() == start.Month() {
+			days = append(days, day)
+		}
+	}
+
+	if r.BySetPos != 0 {
+		i := r.BySetPos - 1
+		if r.BySetPos < 0 {
+			i = len(days) + r.BySetPos
+		}
+		if i < 0 || i >= len(days) {
+			return nil
+		}
+		return days[i : i+1]
+	}
+	return days
+}
+
+func hasWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMonthDay(days []int, day, length int) bool {
+	for _, d := range days {
+		if d == day || d == day-length-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Schedule is a rule starting on a day. It ends after Until unless that
+// is zero, and after Count occurrences when Count is positive. Like in
+// RFC 5545, days before the start in the first period are not
+// occurrences, and days the rule names that a month lacks, such as the
+// 31st, are skipped.
+type Schedule struct {
+	Rule  Rule
+	Start time.Time
+	Until time.Time
+	Count int
+}
+
+type Occurrence struct {
+	// Index numbers the occurrences of a schedule from 1.
+	Index int
+	Date  time.Time
+}
+
+// Between returns in order the occurrences falling on the days from from
+// to to, both included. It counts the occurrences before from a cycle of
+// periods at a time, so its cost does not grow with how far from is past
+// the start.
+func (s Schedule) Between(from, to time.Time) []Occurrence {
+	start, from, to := Day(s.Start), Day(from), Day(to)
+	if !s.Until.IsZero() && Day(s.Until).Before(to) {
+		to = Day(s.Until)
+	}
+
+	occurrences := []Occurrence{}
+	index, n := 0, 0
+	if skip := s.Rule.periodOf(start, from) - 1; skip > 0 {
+		// Every period before the one holding from ends before it: only
+		// the number of their occurrences matters.
+		for _, day := range s.Rule.days(s.Rule.periodStart(start, 0), start) {
+			if !day.Before(start) {
+				index++
+			}
+		}
+		index += s.Rule.countDays(start, 1, skip)
+		n = skip + 1
+	}
+	for ; !s.Rule.periodStart(start, n).After(to); n++ {
+		for _, day := range s.Rule.days(s.Rule.periodStart(start, n), start) {
+			if day.Before(start) {
+				continue
+			}
+			index++
+			if day.After(to) || s.Count > 0 && index > s.Count {
+				return occurrences
+			}
+			if !day.Before(from) {
+				occurrences = append(occurrences, Occurrence{Index: index, Date: day})
+			}
+		}
+	}
+	return occurrences
+}
+
+// Includes reports whether the schedule falls on day.
+func (s Schedule) Includes(day time.Time) bool {
+	return len(s.Between(day, day)) > 0
+}
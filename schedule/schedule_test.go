@@ -0,0 +1,186 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func dates(occurrences []Occurrence) []string {
+	days := make([]string, len(occurrences))
+	for i, occurrence := range occurrences {
+		days[i] = occurrence.Date.Format("2006-01-02")
+	}
+	return days
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=MONTHLY;BYMONTHDAY=5", "FREQ=MONTHLY;BYMONTHDAY=5"},
+		{"RRULE:freq=weekly;interval=2", "FREQ=WEEKLY;INTERVAL=2"},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1"},
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+		{"FREQ=YEARLY", "FREQ=YEARLY"},
+	}
+
+	for _, tc := range testCases {
+		rule, err := Parse(tc.rule)
+		require.NoError(t, err, tc.rule)
+		require.Equal(t, tc.want, rule.String())
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"BYMONTHDAY=5",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;FREQ=WEEKLY",
+		"FREQ=MONTHLY;INTERVAL=0",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=5",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=MONTHLY;COUNT=3",
+		"FREQ=MONTHLY;UNTIL=20250101",
+		"FREQ=MONTHLY;BYMONTHDAY",
+	} {
+		_, err := Parse(rule)
+		require.ErrorIs(t, err, ErrInvalidRule, rule)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule Schedule
+		from, to string
+		want     []string
+	}{
+		{
+			name:     "monthly on the start day",
+			schedule: Schedule{Rule: Rule{Freq: Monthly, Interval: 1}, Start: day("2024-01-10")},
+			from:     "2024-01-01", to: "2024-04-30",
+			want: []string{"2024-01-10", "2024-02-10", "2024-03-10", "2024-04-10"},
+		},
+		{
+			name:     "monthly on day 31 skips shorter months",
+			schedule: Schedule{Rule: Rule{Freq: Monthly, Interval: 1, ByMonthDay: []int{31}}, Start: day("2024-01-01")},
+			from:     "2024-01-01", to: "2024-05-31",
+			want: []string{"2024-01-31", "2024-03-31", "2024-05-31"},
+		},
+		{
+			name:     "last day of the month",
+			schedule: Schedule{Rule: Rule{Freq: Monthly, Interval: 1, ByMonthDay: []int{-1}}, Start: day("2024-01-01")},
+			from:     "2024-01-01", to: "2024-03-31",
+			want: []string{"2024-01-31", "2024-02-29", "2024-03-31"},
+		},
+		{
+			name: "last business day",
+			schedule: Schedule{Rule: Rule{
+				Freq:     Monthly,
+				Interval: 1,
+				ByDay:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+				BySetPos: -1,
+			}, Start: day("2024-01-01")},
+			from: "2024-01-01", to: "2024-06-30",
+			want: []string{"2024-01-31", "2024-02-29", "2024-03-29", "2024-04-30", "2024-05-31", "2024-06-28"},
+		},
+		{
+			name:     "every two weeks",
+			schedule: Schedule{Rule: Rule{Freq: Weekly, Interval: 2}, Start: day("2024-03-01")},
+			from:     "2024-03-01", to: "2024-04-15",
+			want: []string{"2024-03-01", "2024-03-15", "2024-03-29", "2024-04-12"},
+		},
+		{
+			name:     "weekdays from a Wednesday",
+			schedule: Schedule{Rule: Rule{Freq: Weekly, Interval: 1, ByDay: []time.Weekday{time.Monday, time.Friday}}, Start: day("2024-03-06")},
+			from:     "2024-03-01", to: "2024-03-12",
+			want: []string{"2024-03-08", "2024-03-11"},
+		},
+		{
+			name:     "yearly on February 29",
+			schedule: Schedule{Rule: Rule{Freq: Yearly, Interval: 1}, Start: day("2024-02-29")},
+			from:     "2024-01-01", to: "2032-12-31",
+			want: []string{"2024-02-29", "2028-02-29", "2032-02-29"},
+		},
+		{
+			name:     "occurrence count",
+			schedule: Schedule{Rule: Rule{Freq: Monthly, Interval: 1}, Start: day("2024-01-05"), Count: 3},
+			from:     "2024-02-01", to: "2024-12-31",
+			want: []string{"2024-02-05", "2024-03-05"},
+		},
+		{
+			name:     "end date",
+			schedule: Schedule{Rule: Rule{Freq: Daily, Interval: 3}, Start: day("2024-01-01"), Until: day("2024-01-10")},
+			from:     "2024-01-01", to: "2024-12-31",
+			want: []string{"2024-01-01", "2024-01-04", "2024-01-07", "2024-01-10"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, dates(tc.schedule.Between(day(tc.from), day(tc.to))))
+		})
+	}
+}
+
+func TestBetweenNumbersOccurrencesFromTheStart(t *testing.T) {
+	s := Schedule{Rule: Rule{Freq: Monthly, Interval: 1}, Start: day("2024-01-05")}
+
+	occurrences := s.Between(day("2024-03-01"), day("2024-04-30"))
+	require.Equal(t, []Occurrence{{Index: 3, Date: day("2024-03-05")}, {Index: 4, Date: day("2024-04-05")}}, occurrences)
+	require.True(t, s.Includes(day("2024-04-05")))
+	require.False(t, s.Includes(day("2024-04-06")))
+	require.False(t, s.Includes(day("2023-12-05")))
+}
+
+func TestBetweenFarFromTheStart(t *testing.T) {
+	from, to := day("2474-02-20"), day("2474-05-10")
+	for _, rule := range []string{
+		"FREQ=DAILY",
+		"FREQ=DAILY;INTERVAL=3;BYDAY=MO,WE",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+		"FREQ=MONTHLY;BYMONTHDAY=31",
+		"FREQ=MONTHLY;INTERVAL=7;BYMONTHDAY=-1,15",
+		"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+		"FREQ=YEARLY",
+	} {
+		r, err := Parse(rule)
+		require.NoError(t, err)
+		for _, count := range []int{0, 1000, 1000000} {
+			s := Schedule{Rule: r, Start: day("2024-02-29"), Count: count}
+
+			// Listing everything from the start is slow but goes through
+			// every period.
+			want := []Occurrence{}
+			for _, occurrence := range s.Between(s.Start, to) {
+				if !occurrence.Date.Before(from) {
+					want = append(want, occurrence)
+				}
+			}
+			require.Equal(t, want, s.Between(from, to), "%s, count %d", rule, count)
+		}
+	}
+
+	// A preview thousands of years ahead does not list every day before it.
+	s := Schedule{Rule: Rule{Freq: Daily, Interval: 1}, Start: day("2024-01-01")}
+	occurrences := s.Between(day("9999-12-31"), day("9999-12-31"))
+	require.Len(t, occurrences, 1)
+	require.Equal(t, int((day("9999-12-31").Unix()-s.Start.Unix())/(24*60*60))+1, occurrences[0].Index)
+}
@@ -0,0 +1,168 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrNoActiveKey      = errors.New("active signing key is not configured")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrMissingSignerKey = errors.New("signing key has no private part")
+)
+
+// Key is one entry of the key ring. Verify-only keys (a retired public key,
+// for example) have a nil signKey.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey returns an HS256 key using secret for both signing and verifying.
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewRSAKey returns an RS256 key. A nil private key makes it verify-only.
+func NewRSAKey(id string, private *rsa.PrivateKey, public *rsa.PublicKey) Key {
+	key := Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: public}
+	if private != nil {
+		key.signKey = private
+		key.verifyKey = &private.PublicKey
+	}
+	return key
+}
+
+// NewEdDSAKey returns an EdDSA (Ed25519) key. A nil private key makes it
+// verify-only.
+func NewEdDSAKey(id string, private ed25519.PrivateKey, public ed25519.PublicKey) Key {
+	key := Key{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: public}
+	if private != nil {
+		key.signKey = private
+		key.verifyKey = private.Public()
+	}
+	return key
+}
+
+// KeyRing holds every key that may verify a token, indexed by kid, and the
+// kid of the key used to sign new tokens.
+type KeyRing struct {
+	activeID string
+	keys     map[string]Key
+	order    []string
+}
+
+func NewKeyRing(activeID string, keys ...Key) (*KeyRing, error) {
+	ring := &KeyRing{activeID: activeID, keys: map[string]Key{}}
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+		ring.order = append(ring.order, key.ID)
+	}
+
+	active, ok := ring.keys[activeID]
+	if !ok {
+		return nil, ErrNoActiveKey
+	}
+	if active.signKey == nil {
+		return nil, ErrMissingSignerKey
+	}
+	return ring, nil
+}
+
+// LoadKeyRing builds a key ring from a comma separated list of
+// "kid:alg:path" entries. HS256 paths hold the raw secret, RS256 and EdDSA
+// paths hold a PEM encoded private key, or a public key for verify-only
+// entries kept around during a rotation.
+func LoadKeyRing(specs string, activeID string) (*KeyRing, error) {
+	var keys []Key
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		fields := strings.SplitN(spec, ":", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid key spec %q, expected kid:alg:path", spec)
+		}
+
+		data, err := os.ReadFile(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("cannot read key %q: %w", fields[0], err)
+		}
+
+		key, err := parseKey(fields[0], fields[1], data)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse key %q: %w", fields[0], err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeyRing(activeID, keys...)
+}
+
+func parseKey(id string, alg string, data []byte) (Key, error) {
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < 32 {
+			return Key{}, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		return NewHMACKey(id, secret), nil
+	case jwt.SigningMethodRS256.Alg():
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			return NewRSAKey(id, private, nil), nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return Key{}, err
+		}
+		return NewRSAKey(id, nil, public), nil
+	case jwt.SigningMethodEdDSA.Alg():
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			return NewEdDSAKey(id, private.(ed25519.PrivateKey), nil), nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return Key{}, err
+		}
+		return NewEdDSAKey(id, nil, public.(ed25519.PublicKey)), nil
+	default:
+		return Key{}, ErrUnsupportedAlg
+	}
+}
+
+func (ring *KeyRing) active() Key {
+	return ring.keys[ring.activeID]
+}
+
+func (ring *KeyRing) lookup(id string) (Key, error) {
+	key, ok := ring.keys[id]
+	if !ok {
+		return Key{}, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// publicKeys returns the asymmetric verification keys in configuration order.
+func (ring *KeyRing) publicKeys() []Key {
+	var keys []Key
+	for _, id := range ring.order {
+		if key := ring.keys[id]; key.Method != jwt.SigningMethodHS256 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,26 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken returns a random URL-safe token together with the hash
+// that should be stored in place of it.
+func NewOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	opaque := base64.RawURLEncoding.EncodeToString(buf)
+	return opaque, HashOpaqueToken(opaque), nil
+}
+
+// HashOpaqueToken hashes a high-entropy token for storage. A fast hash is
+// enough because the token itself cannot be guessed.
+func HashOpaqueToken(opaque string) string {
+	sum := sha256.Sum256([]byte(opaque))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,141 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var ErrInvalidToken = errors.New("token is invalid")
+
+const (
+	PurposeAccess            = "access"
+	PurposeEmailVerification = "email_verification"
+	PurposeMfaChallenge      = "mfa_challenge"
+)
+
+// Subject describes the user a token is issued to.
+type Subject struct {
+	UserID        int32
+	Username      string
+	Role          string
+	EmailVerified bool
+}
+
+type Claims struct {
+	UserID        int32  `json:"user_id"`
+	Username      string `json:"username"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	SessionID     int64  `json:"sid"`
+	Purpose       string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// PurposeClaims are carried by short-lived single-purpose tokens such as
+// email verification links. They are never accepted as access tokens.
+type PurposeClaims struct {
+	UserID  int32  `json:"user_id"`
+	Email   string `json:"email,omitempty"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// Service issues and verifies the access tokens of the API.
+type Service struct {
+	keys     *KeyRing
+	duration time.Duration
+}
+
+func NewService(keys *KeyRing, duration time.Duration) *Service {
+	return &Service{keys: keys, duration: duration}
+}
+
+func registeredClaims(duration time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+	}
+}
+
+// CreateToken signs an access token for the subject's session with the
+// active key.
+func (service *Service) CreateToken(subject Subject, sessionID int64) (string, *Claims, error) {
+	claims := &Claims{
+		UserID:           subject.UserID,
+		Username:         subject.Username,
+		Role:             subject.Role,
+		EmailVerified:    subject.EmailVerified,
+		SessionID:        sessionID,
+		Purpose:          PurposeAccess,
+		RegisteredClaims: registeredClaims(service.duration),
+	}
+
+	signed, err := service.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// CreatePurposeToken signs a token that is only valid for purpose.
+func (service *Service) CreatePurposeToken(purpose string, userID int32, email string, duration time.Duration) (string, error) {
+	return service.sign(&PurposeClaims{
+		UserID:           userID,
+		Email:            email,
+		Purpose:          purpose,
+		RegisteredClaims: registeredClaims(duration),
+	})
+}
+
+func (service *Service) sign(claims jwt.Claims) (string, error) {
+	key := service.keys.active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// VerifyToken checks the signature against the key named by the kid
+// header and returns the claims of a valid access token.
+func (service *Service) VerifyToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	if err := service.verify(token, claims); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeAccess {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// VerifyPurposeToken returns the claims of a valid token issued for purpose.
+func (service *Service) VerifyPurposeToken(purpose string, token string) (*PurposeClaims, error) {
+	claims := &PurposeClaims{}
+	if err := service.verify(token, claims); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (service *Service) verify(token string, claims jwt.Claims) error {
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := service.keys.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, ErrUnsupportedAlg
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil || !parsed.Valid {
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/wil-ckaew/gofinance-backend/util"
+)
+
+func newRSAKey(t *testing.T, id string) Key {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return NewRSAKey(id, private, nil)
+}
+
+func newEdDSAKey(t *testing.T, id string) Key {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return NewEdDSAKey(id, private, nil)
+}
+
+func TestCreateAndVerifyToken(t *testing.T) {
+	keys := map[string]Key{
+		"HS256": NewHMACKey("hmac", []byte(util.RandomString(32))),
+		"RS256": newRSAKey(t, "rsa"),
+		"EdDSA": newEdDSAKey(t, "ed"),
+	}
+
+	for alg, key := range keys {
+		t.Run(alg, func(t *testing.T) {
+			ring, err := NewKeyRing(key.ID, key)
+			require.NoError(t, err)
+			service := NewService(ring, time.Minute)
+
+			signed, issued, err := service.CreateToken(Subject{UserID: 42, Username: "alice", Role: "admin", EmailVerified: true}, 7)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(signed, &Claims{})
+			require.NoError(t, err)
+			require.Equal(t, alg, parsed.Method.Alg())
+			require.Equal(t, key.ID, parsed.Header["kid"])
+
+			claims, err := service.VerifyToken(signed)
+			require.NoError(t, err)
+			require.Equal(t, int32(42), claims.UserID)
+			require.Equal(t, "alice", claims.Username)
+			require.Equal(t, "admin", claims.Role)
+			require.Equal(t, int64(7), claims.SessionID)
+			require.True(t, claims.EmailVerified)
+			require.WithinDuration(t, issued.ExpiresAt.Time, claims.ExpiresAt.Time, time.Second)
+		})
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	ring, err := NewKeyRing("hmac", NewHMACKey("hmac", []byte(util.RandomString(32))))
+	require.NoError(t, err)
+
+	signed, _, err := NewService(ring, -time.Minute).CreateToken(Subject{UserID: 1, Username: "alice"}, 1)
+	require.NoError(t, err)
+
+	_, err = NewService(ring, time.Minute).VerifyToken(signed)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestPurposeTokens(t *testing.T) {
+	ring, err := NewKeyRing("hmac", NewHMACKey("hmac", []byte(util.RandomString(32))))
+	require.NoError(t, err)
+	service := NewService(ring, time.Minute)
+
+	signed, err := service.CreatePurposeToken(PurposeEmailVerification, 3, "alice@email.com", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := service.VerifyPurposeToken(PurposeEmailVerification, signed)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), claims.UserID)
+	require.Equal(t, "alice@email.com", claims.Email)
+
+	_, err = service.VerifyPurposeToken("password_reset", signed)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.VerifyToken(signed)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	accessToken, _, err := service.CreateToken(Subject{UserID: 3}, 1)
+	require.NoError(t, err)
+	_, err = service.VerifyPurposeToken(PurposeEmailVerification, accessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := service.CreatePurposeToken(PurposeEmailVerification, 3, "alice@email.com", -time.Minute)
+	require.NoError(t, err)
+	_, err = service.VerifyPurposeToken(PurposeEmailVerification, expired)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "2023-11")
+	newKey := newEdDSAKey(t, "2024-02")
+
+	oldRing, err := NewKeyRing(oldKey.ID, oldKey)
+	require.NoError(t, err)
+	oldToken, _, err := NewService(oldRing, time.Minute).CreateToken(Subject{UserID: 1, Username: "alice"}, 1)
+	require.NoError(t, err)
+
+	rotated, err := NewKeyRing(newKey.ID, newKey, oldKey)
+	require.NoError(t, err)
+	service := NewService(rotated, time.Minute)
+
+	claims, err := service.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), claims.UserID)
+
+	newToken, _, err := service.CreateToken(Subject{UserID: 2, Username: "bob"}, 2)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	require.Equal(t, newKey.ID, parsed.Header["kid"])
+
+	retired, err := NewKeyRing(newKey.ID, newKey)
+	require.NoError(t, err)
+	_, err = NewService(retired, time.Minute).VerifyToken(oldToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	ring, err := NewKeyRing(rsaKey.ID, rsaKey)
+	require.NoError(t, err)
+	service := NewService(ring, time.Minute)
+
+	// An attacker signs an HS256 token using the public RSA key as secret.
+	claims := &Claims{UserID: 1, Purpose: PurposeAccess, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = rsaKey.ID
+	publicDER, err := x509.MarshalPKIXPublicKey(rsaKey.verifyKey)
+	require.NoError(t, err)
+	signed, err := forged.SignedString(publicDER)
+	require.NoError(t, err)
+
+	_, err = service.VerifyToken(signed)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNewKeyRingRequiresSigningKey(t *testing.T) {
+	_, err := NewKeyRing("missing", NewHMACKey("hmac", []byte(util.RandomString(32))))
+	require.ErrorIs(t, err, ErrNoActiveKey)
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = NewKeyRing("public", NewRSAKey("public", nil, &private.PublicKey))
+	require.ErrorIs(t, err, ErrMissingSignerKey)
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	secretPath := filepath.Join(dir, "hmac.secret")
+	require.NoError(t, os.WriteFile(secretPath, []byte(util.RandomString(40)+"\n"), 0600))
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPath := filepath.Join(dir, "rsa.pem")
+	require.NoError(t, os.WriteFile(rsaPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaPrivate),
+	}), 0600))
+
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+	edPath := filepath.Join(dir, "ed.pub.pem")
+	require.NoError(t, os.WriteFile(edPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: edDER,
+	}), 0600))
+
+	specs := fmt.Sprintf("rsa:RS256:%s, hmac:HS256:%s, ed:EdDSA:%s", rsaPath, secretPath, edPath)
+	ring, err := LoadKeyRing(specs, "rsa")
+	require.NoError(t, err)
+	require.Len(t, ring.keys, 3)
+	require.Nil(t, ring.keys["ed"].signKey)
+
+	_, err = LoadKeyRing(specs, "ed")
+	require.ErrorIs(t, err, ErrMissingSignerKey)
+
+	_, err = LoadKeyRing("broken", "broken")
+	require.Error(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	edKey := newEdDSAKey(t, "ed")
+	ring, err := NewKeyRing(rsaKey.ID, rsaKey, NewHMACKey("hmac", []byte(util.RandomString(32))), edKey)
+	require.NoError(t, err)
+
+	set := NewService(ring, time.Minute).JWKS()
+	require.Len(t, set.Keys, 2)
+
+	require.Equal(t, "RSA", set.Keys[0].Kty)
+	require.Equal(t, "rsa", set.Keys[0].Kid)
+	require.Equal(t, "RS256", set.Keys[0].Alg)
+	require.Equal(t, "AQAB", set.Keys[0].E)
+	require.NotEmpty(t, set.Keys[0].N)
+
+	require.Equal(t, "OKP", set.Keys[1].Kty)
+	require.Equal(t, "Ed25519", set.Keys[1].Crv)
+	require.Equal(t, "EdDSA", set.Keys[1].Alg)
+	require.NotEmpty(t, set.Keys[1].X)
+}
+
+func TestJSONWebKeyPublicKey(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	edKey := newEdDSAKey(t, "ed")
+	ring, err := NewKeyRing(rsaKey.ID, rsaKey, edKey)
+	require.NoError(t, err)
+
+	set := NewService(ring, time.Minute).JWKS()
+	require.Len(t, set.Keys, 2)
+
+	public, err := set.Keys[0].PublicKey()
+	require.NoError(t, err)
+	require.True(t, rsaKey.verifyKey.(*rsa.PublicKey).Equal(public))
+
+	public, err = set.Keys[1].PublicKey()
+	require.NoError(t, err)
+	require.True(t, edKey.verifyKey.(ed25519.PublicKey).Equal(public))
+
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	public, err = JSONWebKey{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(ecPrivate.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(ecPrivate.Y.Bytes()),
+	}.PublicKey()
+	require.NoError(t, err)
+	require.True(t, ecPrivate.PublicKey.Equal(public))
+
+	_, err = JSONWebKey{Kty: "oct"}.PublicKey()
+	require.Error(t, err)
+}
@@ -1,51 +0,0 @@
-package util
-
-import (
-	"net/http"
-	"strings"
-
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4" // instalar na raiz jwt corrigir erro >>  go get -u github.com/golang-jwt/jwt/v4
-)
-
-type Claims struct {
-	Username string `json:"username"`
-	jwt.RegisteredClaims
-}
-
-func ValidateToken(ctx *gin.Context, token string) error {
-	claims := &Claims{}
-	var jwtSignedKey = []byte("secret_key")
-	tokenParse, err := jwt.ParseWithClaims(token, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			return jwtSignedKey, nil
-		})
-
-	if err != nil {
-		if err == jwt.ErrSignatureInvalid {
-			ctx.JSON(http.StatusUnauthorized, err)
-			return err
-		}
-		ctx.JSON(http.StatusBadRequest, err)
-		return err
-	}
-
-	if !tokenParse.Valid {
-		ctx.JSON(http.StatusUnauthorized, "Token is invalid")
-		return nil
-	}
-
-	ctx.Next()
-	return nil
-}
-
-func GetTokenInHeaderAndVerify(ctx *gin.Context) error {
-	authorizationHeaderKey := ctx.GetHeader("authorization")
-	fields := strings.Fields(authorizationHeaderKey)
-	tokenToValidate := fields[1]
-	errOnValiteToken := ValidateToken(ctx, tokenToValidate)
-	if errOnValiteToken != nil {
-		return errOnValiteToken
-	}
-	return nil
-}
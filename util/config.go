@@ -0,0 +1,279 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	DBDriver             string
+	DBSource             string
+	ServerAddress        string
+	TokenKeys            string
+	TokenActiveKeyID     string
+	AccessTokenDuration  time.Duration
+	RefreshTokenDuration time.Duration
+	AppURL               string
+	MailDriver           string
+	MailFrom             string
+	MailLogFile          string
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUsername         string
+	SMTPPassword         string
+	PasswordParams       PasswordParams
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+	LedgerInvitationTTL  time.Duration
+	// UnverifiedLoginPolicy is one of UnverifiedLoginAllow,
+	// UnverifiedLoginReadOnly or UnverifiedLoginDeny.
+	UnverifiedLoginPolicy string
+	MfaIssuer             string
+	MfaChallengeTTL       time.Duration
+	// A username or client IP is locked out for LoginLockoutDuration after
+	// LoginMaxUserFailures or LoginMaxIPFailures failed logins within
+	// LoginFailureWindow. Before that, each failure doubles the wait before
+	// the next attempt, starting at LoginBackoffBase up to LoginBackoffMax.
+	LoginMaxUserFailures int
+	LoginMaxIPFailures   int
+	LoginFailureWindow   time.Duration
+	LoginLockoutDuration time.Duration
+	LoginBackoffBase     time.Duration
+	LoginBackoffMax      time.Duration
+	OIDCProviders        []OIDCProviderConfig
+	// OIDCRedirectURL is the page of the app the identity providers send
+	// the user back to; it posts the code and state to /oidc/callback.
+	OIDCRedirectURL string
+	// OIDCAutoCreateUsers is one of OIDCAutoCreateOff,
+	// OIDCAutoCreateVerifiedEmail or OIDCAutoCreateAlways.
+	OIDCAutoCreateUsers string
+	OIDCAuthRequestTTL  time.Duration
+	// Deleted accounts are purged AccountDeletionGracePeriod after the
+	// user deleted them; the purge job runs every AccountPurgeInterval.
+	AccountDeletionGracePeriod time.Duration
+	AccountPurgeInterval       time.Duration
+	// Recurring transactions are generated every RecurringInterval.
+	RecurringInterval time.Duration
+}
+
+// OIDCProviderConfig is read from OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and the optional OIDC_<NAME>_SCOPES for every
+// name listed in OIDC_PROVIDERS.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+const (
+	UnverifiedLoginAllow    = "allow"
+	UnverifiedLoginReadOnly = "readonly"
+	UnverifiedLoginDeny     = "deny"
+)
+
+// Policies for creating a user the first time someone signs in with an
+// identity provider.
+const (
+	OIDCAutoCreateOff           = "off"
+	OIDCAutoCreateVerifiedEmail = "verified_email"
+	OIDCAutoCreateAlways        = "always"
+)
+
+// LoadConfig reads the configuration from the environment, after loading
+// the given .env files when they exist.
+func LoadConfig(filenames ...string) (config Config, err error) {
+	for _, filename := range filenames {
+		if _, statErr := os.Stat(filename); statErr == nil {
+			if err = godotenv.Load(filename); err != nil {
+				return
+			}
+		}
+	}
+
+	config.DBDriver = os.Getenv("DB_DRIVER")
+	config.DBSource = os.Getenv("DB_SOURCE")
+	config.ServerAddress = os.Getenv("SERVER_ADDRESS")
+	config.TokenKeys = os.Getenv("TOKEN_KEYS")
+	config.TokenActiveKeyID = os.Getenv("TOKEN_ACTIVE_KEY_ID")
+	config.AppURL = os.Getenv("APP_URL")
+	config.MailDriver = os.Getenv("MAIL_DRIVER")
+	config.MailFrom = os.Getenv("MAIL_FROM")
+	config.MailLogFile = os.Getenv("MAIL_LOG_FILE")
+	config.SMTPHost = os.Getenv("SMTP_HOST")
+	config.SMTPPort = os.Getenv("SMTP_PORT")
+	config.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	config.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	config.MfaIssuer = os.Getenv("MFA_ISSUER")
+	if config.MfaIssuer == "" {
+		config.MfaIssuer = "GoFinance"
+	}
+
+	config.UnverifiedLoginPolicy = os.Getenv("UNVERIFIED_LOGIN_POLICY")
+	switch config.UnverifiedLoginPolicy {
+	case "":
+		config.UnverifiedLoginPolicy = UnverifiedLoginReadOnly
+	case UnverifiedLoginAllow, UnverifiedLoginReadOnly, UnverifiedLoginDeny:
+	default:
+		err = fmt.Errorf("unknown UNVERIFIED_LOGIN_POLICY %q", config.UnverifiedLoginPolicy)
+		return
+	}
+
+	config.PasswordParams, err = passwordParamsEnv()
+	if err != nil {
+		return
+	}
+
+	config.AccessTokenDuration, err = durationEnv("ACCESS_TOKEN_DURATION", 15*time.Minute)
+	if err != nil {
+		return
+	}
+	config.RefreshTokenDuration, err = durationEnv("REFRESH_TOKEN_DURATION", 30*24*time.Hour)
+	if err != nil {
+		return
+	}
+	config.PasswordResetTTL, err = durationEnv("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		return
+	}
+	config.EmailVerificationTTL, err = durationEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	if err != nil {
+		return
+	}
+	config.LedgerInvitationTTL, err = durationEnv("LEDGER_INVITATION_TTL", 7*24*time.Hour)
+	if err != nil {
+		return
+	}
+	config.MfaChallengeTTL, err = durationEnv("MFA_CHALLENGE_TTL", 5*time.Minute)
+	if err != nil {
+		return
+	}
+
+	config.LoginMaxUserFailures, err = intEnv("LOGIN_MAX_USER_FAILURES", 5)
+	if err != nil {
+		return
+	}
+	config.LoginMaxIPFailures, err = intEnv("LOGIN_MAX_IP_FAILURES", 20)
+	if err != nil {
+		return
+	}
+	config.LoginFailureWindow, err = durationEnv("LOGIN_FAILURE_WINDOW", time.Hour)
+	if err != nil {
+		return
+	}
+	config.LoginLockoutDuration, err = durationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return
+	}
+	config.LoginBackoffBase, err = durationEnv("LOGIN_BACKOFF_BASE", time.Second)
+	if err != nil {
+		return
+	}
+	config.LoginBackoffMax, err = durationEnv("LOGIN_BACKOFF_MAX", 30*time.Second)
+	if err != nil {
+		return
+	}
+
+	config.OIDCRedirectURL = os.Getenv("OIDC_REDIRECT_URL")
+	if config.OIDCRedirectURL == "" {
+		config.OIDCRedirectURL = strings.TrimSuffix(config.AppURL, "/") + "/oidc/callback"
+	}
+	config.OIDCAutoCreateUsers = os.Getenv("OIDC_AUTO_CREATE_USERS")
+	switch config.OIDCAutoCreateUsers {
+	case "":
+		config.OIDCAutoCreateUsers = OIDCAutoCreateVerifiedEmail
+	case OIDCAutoCreateOff, OIDCAutoCreateVerifiedEmail, OIDCAutoCreateAlways:
+	default:
+		err = fmt.Errorf("unknown OIDC_AUTO_CREATE_USERS %q", config.OIDCAutoCreateUsers)
+		return
+	}
+	config.OIDCAuthRequestTTL, err = durationEnv("OIDC_AUTH_REQUEST_TTL", 10*time.Minute)
+	if err != nil {
+		return
+	}
+	config.AccountDeletionGracePeriod, err = durationEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	if err != nil {
+		return
+	}
+	config.AccountPurgeInterval, err = durationEnv("ACCOUNT_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return
+	}
+	config.RecurringInterval, err = durationEnv("RECURRING_INTERVAL", time.Hour)
+	if err != nil {
+		return
+	}
+	config.OIDCProviders, err = oidcProvidersEnv()
+	return
+}
+
+func oidcProvidersEnv() ([]OIDCProviderConfig, error) {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID are required", prefix, prefix)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// passwordParamsEnv reads the argon2id parameters from
+// PASSWORD_ARGON2_MEMORY (KiB), PASSWORD_ARGON2_ITERATIONS and
+// PASSWORD_ARGON2_PARALLELISM. Changing them rehashes passwords as users
+// sign in.
+func passwordParamsEnv() (PasswordParams, error) {
+	memory, err := intEnv("PASSWORD_ARGON2_MEMORY", int(DefaultPasswordParams.Memory))
+	if err != nil {
+		return PasswordParams{}, err
+	}
+	iterations, err := intEnv("PASSWORD_ARGON2_ITERATIONS", int(DefaultPasswordParams.Iterations))
+	if err != nil {
+		return PasswordParams{}, err
+	}
+	parallelism, err := intEnv("PASSWORD_ARGON2_PARALLELISM", int(DefaultPasswordParams.Parallelism))
+	if err != nil {
+		return PasswordParams{}, err
+	}
+
+	if iterations < 1 || parallelism < 1 || parallelism > 255 || memory < 8*parallelism {
+		return PasswordParams{}, fmt.Errorf("invalid argon2 parameters m=%d,t=%d,p=%d", memory, iterations, parallelism)
+	}
+	return PasswordParams{Memory: uint32(memory), Iterations: uint32(iterations), Parallelism: uint8(parallelism)}, nil
+}
+
+func durationEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func intEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashes are versioned by the scheme prefix of the stored string:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>  argon2id, the current scheme
+//	$2a$10$...                                    legacy bcrypt of a SHA-512/256 pre-hash
+//
+// Legacy hashes are still verified; PasswordNeedsRehash tells the caller to
+// replace them, and argon2id hashes with outdated parameters, after the next
+// successful login.
+
+var (
+	ErrPasswordMismatch    = errors.New("password does not match")
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+// PasswordParams are the argon2id cost parameters. Memory is in KiB.
+type PasswordParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultPasswordParams follow the OWASP recommendation for argon2id.
+var DefaultPasswordParams = PasswordParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 2}
+
+const (
+	argon2idPrefix     = "$argon2id$"
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+)
+
+// HashPassword returns the argon2id hash of password in PHC string format.
+func HashPassword(password string, params PasswordParams) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, passwordKeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches the stored hash, in any
+// supported scheme. It returns ErrPasswordMismatch when it does not.
+func CheckPassword(password string, hashedPassword string) error {
+	if strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		params, salt, key, err := decodeArgon2id(hashedPassword)
+		if err != nil {
+			return err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	}
+
+	if isLegacyPasswordHash(hashedPassword) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), prepareLegacyPassword(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+
+	return ErrUnknownPasswordHash
+}
+
+// PasswordNeedsRehash reports whether a hash that just verified should be
+// replaced by a new one: it uses the legacy scheme or other parameters.
+func PasswordNeedsRehash(hashedPassword string, params PasswordParams) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return true
+	}
+	current, _, key, err := decodeArgon2id(hashedPassword)
+	return err != nil || current != params || len(key) != passwordKeyLength
+}
+
+func decodeArgon2id(hashedPassword string) (PasswordParams, []byte, []byte, error) {
+	var params PasswordParams
+	var version int
+
+	fields := strings.Split(hashedPassword, "$")
+	if len(fields) != 6 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	return params, salt, key, nil
+}
+
+func isLegacyPasswordHash(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") || strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+// prepareLegacyPassword reproduces the pre-hash of the legacy scheme,
+// including the trimming of null bytes, so existing hashes still verify.
+func prepareLegacyPassword(password string) []byte {
+	hashedInput := sha512.Sum512_256([]byte(password))
+	return bytes.Trim(hashedInput[:], "\x00")
+}
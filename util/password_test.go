@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testPasswordParams = PasswordParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestPassword(t *testing.T) {
+	password := RandomString(12)
+
+	hashedPassword1, err := HashPassword(password, testPasswordParams)
+	require.NoError(t, err)
+	require.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[^$]+\$[^$]+$`, hashedPassword1)
+
+	require.NoError(t, CheckPassword(password, hashedPassword1))
+	require.ErrorIs(t, CheckPassword(RandomString(12), hashedPassword1), ErrPasswordMismatch)
+
+	hashedPassword2, err := HashPassword(password, testPasswordParams)
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword1, hashedPassword2)
+}
+
+func TestLegacyPassword(t *testing.T) {
+	password := RandomString(12)
+	legacy, err := bcrypt.GenerateFromPassword(prepareLegacyPassword(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	require.NoError(t, CheckPassword(password, string(legacy)))
+	require.ErrorIs(t, CheckPassword(RandomString(12), string(legacy)), ErrPasswordMismatch)
+	require.True(t, PasswordNeedsRehash(string(legacy), testPasswordParams))
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	hashedPassword, err := HashPassword(RandomString(12), testPasswordParams)
+	require.NoError(t, err)
+
+	require.False(t, PasswordNeedsRehash(hashedPassword, testPasswordParams))
+	stronger := testPasswordParams
+	stronger.Iterations++
+	require.True(t, PasswordNeedsRehash(hashedPassword, stronger))
+}
+
+func TestCheckPasswordUnknownHash(t *testing.T) {
+	for _, hashedPassword := range []string{"", "plain", "$argon2id$v=19$m=1024$x$y", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		require.ErrorIs(t, CheckPassword("secret", hashedPassword), ErrUnknownPasswordHash, hashedPassword)
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+)
+
+// AccountPurger permanently removes accounts whose deletion grace period
+// has passed. Their categories, accounts and sessions go with them through
+// the ON DELETE CASCADE foreign keys.
+type AccountPurger struct {
+	store       db.Querier
+	gracePeriod time.Duration
+	interval    time.Duration
+}
+
+func NewAccountPurger(store db.Querier, gracePeriod, interval time.Duration) *AccountPurger {
+	return &AccountPurger{store: store, gracePeriod: gracePeriod, interval: interval}
+}
+
+// PurgeOnce removes the accounts deleted more than the grace period before
+// now and returns how many there were.
+func (purger *AccountPurger) PurgeOnce(ctx context.Context, now time.Time) (int64, error) {
+	return purger.store.PurgeDeletedUsers(ctx, now.Add(-purger.gracePeriod))
+}
+
+// Run purges once right away and then every interval until ctx is done.
+// Failures are logged and retried on the next tick.
+func (purger *AccountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := purger.PurgeOnce(ctx, time.Now())
+		if err != nil {
+			log.Printf("cannot purge deleted accounts: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d deleted accounts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+)
+
+type purgeStore struct {
+	db.Querier
+	calls chan time.Time
+}
+
+func (s *purgeStore) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	s.calls <- deletedBefore
+	return 1, nil
+}
+
+func TestPurgeOnceAppliesGracePeriod(t *testing.T) {
+	store := &purgeStore{calls: make(chan time.Time, 1)}
+	purger := NewAccountPurger(store, 30*24*time.Hour, time.Hour)
+
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	purged, err := purger.PurgeOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+	require.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), <-store.calls)
+}
+
+func TestRunStopsWithContext(t *testing.T) {
+	store := &purgeStore{calls: make(chan time.Time, 10)}
+	purger := NewAccountPurger(store, time.Hour, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		purger.Run(ctx)
+		close(done)
+	}()
+
+	<-store.calls
+	<-store.calls
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
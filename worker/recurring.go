@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+	"github.com/wil-ckaew/gofinance-backend/schedule"
+)
+
+// RecurringGenerator records the occurrences of recurring transactions as
+// transactions once their day has come. Each occurrence is generated at
+// most once, so runs may overlap or repeat after a failure.
+type RecurringGenerator struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewRecurringGenerator(store db.Store, interval time.Duration) *RecurringGenerator {
+	return &RecurringGenerator{store: store, interval: interval}
+}
+
+// GenerateOnce generates every occurrence due up to and including the day
+// of now and returns how many transactions it recorded. A template that
+// fails is logged and retried on the next run without holding up the
+// others.
+func (generator *RecurringGenerator) GenerateOnce(ctx context.Context, now time.Time) (int, error) {
+	today := schedule.Day(now)
+	templates, err := generator.store.ListDueRecurringTransactions(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, recurring := range templates {
+		n, err := generator.generate(ctx, recurring, today)
+		generated += n
+		if err != nil {
+			log.Printf("cannot generate recurring transaction %d: %v", recurring.ID, err)
+		}
+	}
+	return generated, nil
+}
+
+func (generator *RecurringGenerator) generate(ctx context.Context, recurring db.RecurringTransaction, today time.Time) (int, error) {
+	s, err := recurring.Schedule()
+	if err != nil {
+		return 0, err
+	}
+
+	from := recurring.StartsOn
+	if recurring.GeneratedThrough.Valid {
+		from = recurring.GeneratedThrough.Time.AddDate(0, 0, 1)
+	}
+
+	generated := 0
+	for _, occurrence := range s.Between(from, today) {
+		_, err = generator.store.GenerateRecurringTx(ctx, db.GenerateRecurringTxParams{
+			Recurring: recurring,
+			Date:      occurrence.Date,
+		})
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return generated, err
+		}
+		generated++
+	}
+
+	err = generator.store.SetRecurringGeneratedThrough(ctx, db.SetRecurringGeneratedThroughParams{
+		ID:               recurring.ID,
+		GeneratedThrough: sql.NullTime{Time: today, Valid: true},
+	})
+	return generated, err
+}
+
+// Run generates once right away and then every interval until ctx is
+// done.
+func (generator *RecurringGenerator) Run(ctx context.Context) {
+	ticker := time.NewTicker(generator.interval)
+	defer ticker.Stop()
+
+	for {
+		generated, err := generator.GenerateOnce(ctx, time.Now())
+		if err != nil {
+			log.Printf("cannot generate recurring transactions: %v", err)
+		} else if generated > 0 {
+			log.Printf("generated %d recurring transactions", generated)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
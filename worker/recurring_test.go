@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/wil-ckaew/gofinance-backend/db/sqlc"
+)
+
+type recurringStore struct {
+	db.Store
+	templates []db.RecurringTransaction
+	generated map[int32][]time.Time
+	through   map[int32]time.Time
+}
+
+func newRecurringStore(templates ...db.RecurringTransaction) *recurringStore {
+	return &recurringStore{
+		templates: templates,
+		generated: map[int32][]time.Time{},
+		through:   map[int32]time.Time{},
+	}
+}
+
+func (s *recurringStore) ListDueRecurringTransactions(ctx context.Context, today time.Time) ([]db.RecurringTransaction, error) {
+	return s.templates, nil
+}
+
+func (s *recurringStore) GenerateRecurringTx(ctx context.Context, arg db.GenerateRecurringTxParams) (db.Account, error) {
+	for _, date := range s.generated[arg.Recurring.ID] {
+		if date.Equal(arg.Date) {
+			return db.Account{}, sql.ErrNoRows
+		}
+	}
+	s.generated[arg.Recurring.ID] = append(s.generated[arg.Recurring.ID], arg.Date)
+	return db.Account{Date: arg.Date}, nil
+}
+
+func (s *recurringStore) SetRecurringGeneratedThrough(ctx context.Context, arg db.SetRecurringGeneratedThroughParams) error {
+	s.through[arg.ID] = arg.GeneratedThrough.Time
+	return nil
+}
+
+func TestGenerateOnceRecordsDueOccurrences(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	rent := db.RecurringTransaction{ID: 1, Rule: "FREQ=MONTHLY;BYMONTHDAY=5", StartsOn: day(1, 1)}
+	salary := db.RecurringTransaction{
+		ID:               2,
+		Rule:             "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+		StartsOn:         day(1, 1),
+		GeneratedThrough: sql.NullTime{Time: day(2, 29), Valid: true},
+	}
+	broken := db.RecurringTransaction{ID: 3, Rule: "FREQ=HOURLY", StartsOn: day(1, 1)}
+	store := newRecurringStore(rent, salary, broken)
+	generator := NewRecurringGenerator(store, time.Hour)
+
+	now := time.Date(2024, 3, 29, 18, 30, 0, 0, time.UTC)
+	generated, err := generator.GenerateOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 4, generated)
+	require.Equal(t, []time.Time{day(1, 5), day(2, 5), day(3, 5)}, store.generated[rent.ID])
+	require.Equal(t, []time.Time{day(3, 29)}, store.generated[salary.ID])
+	require.Equal(t, day(3, 29), store.through[rent.ID])
+	require.Equal(t, day(3, 29), store.through[salary.ID])
+	require.NotContains(t, store.through, broken.ID)
+
+	// Running again, even before the templates are marked, records nothing.
+	generated, err = generator.GenerateOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Zero(t, generated)
+	require.Len(t, store.generated[rent.ID], 3)
+}